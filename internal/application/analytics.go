@@ -0,0 +1,53 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/persistence"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
+)
+
+// TimeAnalytics computes the stats of the hands matching filter by hour of
+// day, day of week and session phase.
+func (s *Service) TimeAnalytics(ctx context.Context, filter persistence.HandFilter) (*stats.TimeAnalytics, error) {
+	hands, localSeat, err := s.chronologicalHands(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("time analytics: %w", err)
+	}
+	calc := stats.NewTimeAnalyticsCalculator(localSeat)
+	for _, h := range hands {
+		calc.Feed(h)
+	}
+	return calc.Compute(), nil
+}
+
+// MetricTrend computes a registry metric over a rolling window of the last
+// window hands matching filter, with at most maxPoints points.
+func (s *Service) MetricTrend(ctx context.Context, filter persistence.HandFilter, id stats.MetricID, window, maxPoints int) ([]stats.TrendPoint, error) {
+	hands, localSeat, err := s.chronologicalHands(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("metric trend: %w", err)
+	}
+	return stats.MetricTrend(hands, localSeat, id, window, maxPoints), nil
+}
+
+// chronologicalHands lists the complete hands matching filter in start time
+// order, with the local seat to compute their stats for.
+func (s *Service) chronologicalHands(ctx context.Context, filter persistence.HandFilter) ([]*parser.Hand, int, error) {
+	s.mu.RLock()
+	localSeat := s.localSeat
+	s.mu.RUnlock()
+
+	filter.OnlyComplete = true
+	hands, err := s.repo.ListHands(ctx, filter)
+	if err != nil {
+		return nil, localSeat, err
+	}
+	// Sessions and rolling windows need chronological order, which ListHands
+	// does not promise for every repository.
+	sort.SliceStable(hands, func(i, j int) bool { return hands[i].StartTime.Before(hands[j].StartTime) })
+	return hands, localSeat, nil
+}
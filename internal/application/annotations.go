@@ -0,0 +1,87 @@
+package application
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/persistence"
+)
+
+// HandAnnotation returns the user's note, tags and star flag on a hand, or nil if it has none.
+func (s *Service) HandAnnotation(ctx context.Context, uid string) (*persistence.HandAnnotation, error) {
+	return s.repo.GetHandAnnotation(ctx, uid)
+}
+
+// SaveHandAnnotation replaces the annotation of a.HandUID. An empty annotation removes it.
+func (s *Service) SaveHandAnnotation(ctx context.Context, a persistence.HandAnnotation) error {
+	if a.HandUID == "" {
+		return fmt.Errorf("save hand annotation: missing hand uid")
+	}
+	if err := s.repo.SaveHandAnnotation(ctx, a); err != nil {
+		return fmt.Errorf("save hand annotation: %w", err)
+	}
+	return nil
+}
+
+// ListHandTags returns every tag in use, most used first.
+func (s *Service) ListHandTags(ctx context.Context) ([]persistence.TagCount, error) {
+	return s.repo.ListHandTags(ctx)
+}
+
+// handExportHeader is the column order of ExportHandsCSV.
+var handExportHeader = []string{
+	"hand_uid", "start_time", "position", "hole_cards", "board", "players",
+	"pot", "net_chips", "won", "starred", "tags", "note",
+}
+
+// ExportHandsCSV writes the hands matching f, newest first, as CSV including
+// their notes, tags and star flags. It returns the number of hands written.
+func (s *Service) ExportHandsCSV(ctx context.Context, f persistence.HandFilter, w io.Writer) (int, error) {
+	f.Limit, f.Offset = 0, 0
+	summaries, _, err := s.repo.ListHandSummaries(ctx, f)
+	if err != nil {
+		return 0, fmt.Errorf("list hands for export: %w", err)
+	}
+	annotations, err := s.repo.ListHandAnnotations(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list annotations for export: %w", err)
+	}
+	notes := make(map[string]persistence.HandAnnotation, len(annotations))
+	for _, a := range annotations {
+		notes[a.HandUID] = a
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(handExportHeader); err != nil {
+		return 0, err
+	}
+	for _, hs := range summaries {
+		a := notes[hs.HandUID]
+		if err := cw.Write([]string{
+			hs.HandUID,
+			hs.StartTime.Format(time.RFC3339),
+			hs.Position,
+			strings.TrimSpace(hs.HoleCard0 + " " + hs.HoleCard1),
+			hs.CommunityCards,
+			strconv.Itoa(hs.NumPlayers),
+			strconv.Itoa(hs.TotalPot),
+			strconv.Itoa(hs.NetChips),
+			strconv.FormatBool(hs.Won),
+			strconv.FormatBool(a.Starred),
+			strings.Join(a.Tags, ", "),
+			a.Note,
+		}); err != nil {
+			return 0, err
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return 0, err
+	}
+	return len(summaries), nil
+}
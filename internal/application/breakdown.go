@@ -0,0 +1,37 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/persistence"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
+)
+
+// StatsBreakdown computes the stats of the hands matching filter separately
+// for each value of breakdown. For BreakdownOwner it also returns the known
+// display names of owning users keyed by user UID; group owners have none.
+func (s *Service) StatsBreakdown(ctx context.Context, filter persistence.HandFilter, breakdown stats.Breakdown) ([]stats.StatsGroup, map[string]string, error) {
+	s.mu.RLock()
+	localSeat := s.localSeat
+	s.mu.RUnlock()
+
+	filter.OnlyComplete = true
+	hands, err := s.repo.ListHands(ctx, filter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("stats breakdown: %w", err)
+	}
+	calc := stats.NewGroupedCalculator(localSeat, breakdown)
+	for _, h := range hands {
+		calc.Feed(h)
+	}
+
+	var names map[string]string
+	if breakdown == stats.BreakdownOwner {
+		names, err = s.repo.UserDisplayNames(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("stats breakdown: %w", err)
+		}
+	}
+	return calc.Compute(), names, nil
+}
@@ -0,0 +1,23 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/persistence"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
+)
+
+// CompareFilters computes the stats of the hands matching a and b and
+// compares b against a.
+func (s *Service) CompareFilters(ctx context.Context, a, b persistence.HandFilter) (*stats.Comparison, error) {
+	sa, _, err := s.Stats(ctx, a)
+	if err != nil {
+		return nil, fmt.Errorf("compare filters: %w", err)
+	}
+	sb, _, err := s.Stats(ctx, b)
+	if err != nil {
+		return nil, fmt.Errorf("compare filters: %w", err)
+	}
+	return stats.Compare(sa, sb), nil
+}
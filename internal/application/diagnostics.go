@@ -0,0 +1,92 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/persistence"
+)
+
+// DiagnosticsReport summarizes unrecognized poker log lines and hand anomaly
+// rates for every log file parsed during this session. Files skipped because
+// they were already fully imported are not included.
+type DiagnosticsReport struct {
+	GeneratedAt time.Time                  `json:"generated_at"`
+	Totals      parser.DiagnosticsSnapshot `json:"totals"`
+	Files       []LogFileDiagnostics       `json:"files"`
+}
+
+// LogFileDiagnostics is the diagnostics snapshot for a single log file.
+type LogFileDiagnostics struct {
+	SourcePath string `json:"source_path"`
+	parser.DiagnosticsSnapshot
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r DiagnosticsReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(r); err != nil {
+		return fmt.Errorf("encode diagnostics report: %w", err)
+	}
+	return nil
+}
+
+// DiagnosticsReport returns the parser diagnostics collected so far, ordered by path.
+func (s *Service) DiagnosticsReport(ctx context.Context) (DiagnosticsReport, error) {
+	if err := ctx.Err(); err != nil {
+		return DiagnosticsReport{}, err
+	}
+
+	s.diagMu.Lock()
+	paths := make([]string, 0, len(s.diagnostics))
+	for path := range s.diagnostics {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	totals := parser.NewDiagnostics()
+	files := make([]LogFileDiagnostics, 0, len(paths))
+	for _, path := range paths {
+		d := s.diagnostics[path]
+		totals.Merge(d)
+		files = append(files, LogFileDiagnostics{SourcePath: path, DiagnosticsSnapshot: d.Snapshot()})
+	}
+	s.diagMu.Unlock()
+
+	return DiagnosticsReport{
+		GeneratedAt: time.Now(),
+		Totals:      totals.Snapshot(),
+		Files:       files,
+	}, nil
+}
+
+// recordDiagnostics stores d for path. When replace is false (a resumed or
+// incremental parse) the counters are added to the existing entry instead.
+func (s *Service) recordDiagnostics(path string, d *parser.Diagnostics, replace bool) {
+	if path == "" || d == nil {
+		return
+	}
+	s.diagMu.Lock()
+	defer s.diagMu.Unlock()
+	if s.diagnostics == nil {
+		s.diagnostics = make(map[string]*parser.Diagnostics)
+	}
+	existing, ok := s.diagnostics[path]
+	if replace || !ok {
+		s.diagnostics[path] = d
+		return
+	}
+	existing.Merge(d)
+}
+
+func observeHands(d *parser.Diagnostics, rows []persistence.PersistedHand) {
+	for _, row := range rows {
+		d.ObserveHand(row.Hand)
+	}
+}
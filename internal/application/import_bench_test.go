@@ -0,0 +1,131 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/persistence"
+)
+
+// benchLogsEnv names a directory of logs to benchmark imports with, such as
+// the output of tools/gen_testlog (see the bench-import mise task). Without
+// it small synthetic logs are generated.
+const benchLogsEnv = "VRPOKER_BENCH_LOGS"
+
+// benchmarkLogs returns the benchmark logs newest first and their total size.
+func benchmarkLogs(b *testing.B) ([]string, int64) {
+	b.Helper()
+	var paths []string
+	if dir := os.Getenv(benchLogsEnv); dir != "" {
+		matches, err := filepath.Glob(filepath.Join(dir, "output_log_*.txt"))
+		if err != nil || len(matches) == 0 {
+			b.Fatalf("no output_log_*.txt in %s=%q", benchLogsEnv, dir)
+		}
+		paths = matches
+	} else {
+		paths = writeSyntheticLogs(b, 8, 500)
+	}
+	// The names sort by time; the locator lists newest first.
+	slices.Sort(paths)
+	slices.Reverse(paths)
+
+	var size int64
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			b.Fatalf("stat: %v", err)
+		}
+		size += info.Size()
+	}
+	return paths, size
+}
+
+// writeSyntheticLogs writes files logs of hands folded hands each.
+func writeSyntheticLogs(b *testing.B, files, hands int) []string {
+	b.Helper()
+	dir := b.TempDir()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	paths := make([]string, files)
+	for i := range paths {
+		var sb strings.Builder
+		t := start.Add(time.Duration(i) * 24 * time.Hour)
+		for range hands {
+			for j, msg := range []string{
+				"[Table]: Preparing for New Game: ",
+				"[Seat]: Player 0 SB BET IN = 10",
+				"[Seat]: Player 1 BB BET IN = 20",
+				"[PotManager]: All players folded, player 0 won 30",
+			} {
+				fmt.Fprintf(&sb, "%s Debug      -  %s\n", t.Add(time.Duration(j)*time.Second).Format("2006.01.02 15:04:05"), msg)
+			}
+			t = t.Add(time.Minute)
+		}
+		fmt.Fprintf(&sb, "%s Debug      -  [Table]: Preparing for New Game: \n", t.Format("2006.01.02 15:04:05"))
+		paths[i] = filepath.Join(dir, "output_log_"+start.Add(time.Duration(i)*24*time.Hour).Format("2006-01-02_15-04-05")+".txt")
+		if err := os.WriteFile(paths[i], []byte(sb.String()), 0o600); err != nil {
+			b.Fatalf("write log: %v", err)
+		}
+	}
+	return paths
+}
+
+// BenchmarkParseWorker measures parsing alone, without database writes.
+func BenchmarkParseWorker(b *testing.B) {
+	paths, size := benchmarkLogs(b)
+	ctx := context.Background()
+	out := make(chan parseResult, 1)
+	b.SetBytes(size)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, p := range paths {
+			parseWorker(ctx, p, false, nil, out)
+			if res := <-out; res.err != nil {
+				b.Fatalf("parse %s: %v", p, res.err)
+			}
+		}
+	}
+}
+
+// BenchmarkBootstrapImport measures a first import into an empty SQLite
+// database at several worker counts.
+func BenchmarkBootstrapImport(b *testing.B) {
+	paths, size := benchmarkLogs(b)
+	ctx := context.Background()
+	locator := func([]string) ([]string, error) { return paths, nil }
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			b.SetBytes(size)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				repo, err := persistence.NewSQLiteRepository(filepath.Join(b.TempDir(), "stats.db"))
+				if err != nil {
+					b.Fatalf("new sqlite repo: %v", err)
+				}
+				svc := NewService(repo, locator)
+				settings := DefaultAppSettings()
+				settings.KeepRawLogs = false
+				settings.ImportWorkers = workers
+				if err := svc.SaveSettings(ctx, settings); err != nil {
+					b.Fatalf("save settings: %v", err)
+				}
+				b.StartTimer()
+
+				if _, err := svc.BootstrapImportAllLogs(ctx); err != nil {
+					b.Fatalf("bootstrap: %v", err)
+				}
+
+				b.StopTimer()
+				_ = repo.Close()
+				b.StartTimer()
+			}
+		})
+	}
+}
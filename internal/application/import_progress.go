@@ -0,0 +1,320 @@
+package application
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/logsource"
+)
+
+const (
+	// progressInterval is how often byte progress is reported while logs
+	// are parsed.
+	progressInterval = 250 * time.Millisecond
+	// pauseCheckLines is how many lines a parser reads between checks for
+	// a pause.
+	pauseCheckLines = 1024
+	// maxAutoImportWorkers bounds the parse workers when the worker count
+	// is automatic.
+	maxAutoImportWorkers = 4
+)
+
+// FileState is the import state of one log in BootstrapProgress.
+type FileState int
+
+const (
+	FileQueued FileState = iota
+	FileParsing
+	FileSaving
+	FileDone
+	FileFailed
+)
+
+// FileProgress is the progress of one log of an import.
+type FileProgress struct {
+	Path  string
+	State FileState
+	// Live is set for logs that are still being written and stay watched.
+	Live bool
+	// BytesDone and Size count the bytes parsed and to parse in this run.
+	// Size is 0 until an archived log is opened.
+	BytesDone int64
+	Size      int64
+	// Hands is the number of new hands found in the log.
+	Hands int
+}
+
+// BootstrapOptions configure BootstrapImport.
+type BootstrapOptions struct {
+	// OnProgress is called after each historical log is saved, before each
+	// live log is imported and periodically while logs are parsed. It may
+	// be nil.
+	OnProgress func(BootstrapProgress)
+	// Control pauses and resumes the import. It may be nil.
+	Control *ImportControl
+}
+
+// ImportControl pauses and resumes a running import. Cancel the import's
+// context to stop it. The zero value is running.
+type ImportControl struct {
+	mu sync.Mutex
+	// resumed is closed on Resume; it is nil while running.
+	resumed   chan struct{}
+	pausedAt  time.Time
+	pausedFor time.Duration
+}
+
+// Pause makes the import stop at the next line or file until Resume.
+func (c *ImportControl) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resumed != nil {
+		return
+	}
+	c.resumed = make(chan struct{})
+	c.pausedAt = time.Now()
+}
+
+// Resume continues a paused import.
+func (c *ImportControl) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resumed == nil {
+		return
+	}
+	close(c.resumed)
+	c.resumed = nil
+	c.pausedFor += time.Since(c.pausedAt)
+}
+
+// Paused reports whether the import waits for Resume.
+func (c *ImportControl) Paused() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.resumed != nil
+}
+
+// pausedDuration returns how long the import has been paused in total.
+func (c *ImportControl) pausedDuration() time.Duration {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	d := c.pausedFor
+	if c.resumed != nil {
+		d += time.Since(c.pausedAt)
+	}
+	return d
+}
+
+// wait blocks while the import is paused.
+func (c *ImportControl) wait(ctx context.Context) error {
+	if c == nil {
+		return ctx.Err()
+	}
+	c.mu.Lock()
+	resumed := c.resumed
+	c.mu.Unlock()
+	if resumed == nil {
+		return ctx.Err()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-resumed:
+		return nil
+	}
+}
+
+// importWorkerCount returns the number of parse workers for the worker
+// count setting, where 0 picks one per CPU up to maxAutoImportWorkers.
+func importWorkerCount(setting int) int {
+	if setting > 0 {
+		return setting
+	}
+	return max(1, min(runtime.GOMAXPROCS(0), maxAutoImportWorkers))
+}
+
+// fileCounter tracks the parse of one log for progress reports. A nil
+// counter ignores updates and never pauses.
+type fileCounter struct {
+	control *ImportControl
+	size    atomic.Int64
+	parsed  atomic.Int64
+	hands   atomic.Int64
+}
+
+// begin sets the number of bytes left to parse.
+func (c *fileCounter) begin(size int64) {
+	if c != nil {
+		c.size.Store(size)
+	}
+}
+
+// advance records parsed bytes after line lineNo and waits while the import
+// is paused.
+func (c *fileCounter) advance(ctx context.Context, parsed, lineNo int64) error {
+	if c == nil {
+		return nil
+	}
+	c.parsed.Store(parsed)
+	if lineNo%pauseCheckLines != 0 {
+		return nil
+	}
+	return c.control.wait(ctx)
+}
+
+func (c *fileCounter) addHands(n int) {
+	if c != nil {
+		c.hands.Add(int64(n))
+	}
+}
+
+// progressTracker assembles BootstrapProgress from the counters of every log
+// of an import. Reports are serialized.
+type progressTracker struct {
+	onProgress func(BootstrapProgress)
+	control    *ImportControl
+	started    time.Time
+	counters   []*fileCounter
+
+	mu            sync.Mutex
+	prog          BootstrapProgress
+	reportedBytes int64
+
+	// reportMu keeps reports in order.
+	reportMu sync.Mutex
+}
+
+// newProgressTracker tracks historical logs followed by live ones.
+func newProgressTracker(historical, live []string, skipped int, opts BootstrapOptions) *progressTracker {
+	n := len(historical) + len(live)
+	t := &progressTracker{
+		onProgress: opts.OnProgress,
+		control:    opts.Control,
+		started:    time.Now(),
+		counters:   make([]*fileCounter, n),
+		prog: BootstrapProgress{
+			Total:   n,
+			Skipped: skipped,
+			Files:   make([]FileProgress, n),
+		},
+	}
+	for i := range n {
+		path := ""
+		if i < len(historical) {
+			path = historical[i]
+		} else {
+			path = live[i-len(historical)]
+			t.prog.Files[i].Live = true
+		}
+		t.prog.Files[i].Path = path
+		c := &fileCounter{control: opts.Control}
+		if !logsource.IsMemberPath(path) {
+			if info, err := os.Stat(path); err == nil {
+				c.size.Store(info.Size())
+			}
+		}
+		t.counters[i] = c
+	}
+	return t
+}
+
+func (t *progressTracker) counter(i int) *fileCounter {
+	return t.counters[i]
+}
+
+func (t *progressTracker) setState(i int, state FileState) {
+	t.mu.Lock()
+	t.prog.Files[i].State = state
+	t.mu.Unlock()
+}
+
+// start counts log i as the current file, in the state given, and reports.
+func (t *progressTracker) start(i int, state FileState) {
+	t.mu.Lock()
+	t.prog.Current++
+	t.prog.Path = t.prog.Files[i].Path
+	t.prog.Files[i].State = state
+	t.mu.Unlock()
+	t.report(true)
+}
+
+// report calls onProgress with the current progress. Unless forced it only
+// does so when bytes were parsed since the last report.
+func (t *progressTracker) report(force bool) {
+	if t.onProgress == nil {
+		return
+	}
+	t.reportMu.Lock()
+	defer t.reportMu.Unlock()
+	p, changed := t.snapshot()
+	if force || changed {
+		t.onProgress(p)
+	}
+}
+
+func (t *progressTracker) snapshot() (BootstrapProgress, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p := t.prog
+	p.Files = make([]FileProgress, len(t.prog.Files))
+	p.BytesDone, p.BytesTotal, p.Hands = 0, 0, 0
+	for i, f := range t.prog.Files {
+		c := t.counters[i]
+		f.BytesDone = c.parsed.Load()
+		f.Size = max(c.size.Load(), f.BytesDone)
+		f.Hands = int(c.hands.Load())
+		if f.State == FileDone {
+			f.BytesDone = f.Size
+		}
+		p.Files[i] = f
+		p.BytesDone += f.BytesDone
+		p.BytesTotal += f.Size
+		p.Hands += f.Hands
+	}
+	p.Paused = t.control.Paused()
+	if p.BytesDone > 0 && p.BytesTotal > p.BytesDone {
+		running := time.Since(t.started) - t.control.pausedDuration()
+		p.ETA = time.Duration(float64(running) * float64(p.BytesTotal-p.BytesDone) / float64(p.BytesDone))
+	}
+	changed := p.BytesDone != t.reportedBytes
+	t.reportedBytes = p.BytesDone
+	return p, changed
+}
+
+// run reports progress every progressInterval until the returned function
+// is called.
+func (t *progressTracker) run() (stop func()) {
+	if t.onProgress == nil {
+		return func() {}
+	}
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				t.report(false)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
@@ -0,0 +1,184 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/logsource"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/persistence"
+)
+
+// ErrIntegrityUnsupported is returned by CheckDatabase and RepairDatabase
+// when the repository cannot be checked.
+var ErrIntegrityUnsupported = errors.New("the database does not support integrity checks")
+
+// DatabaseCheck is the result of CheckDatabase.
+type DatabaseCheck struct {
+	Issues []persistence.IntegrityIssue
+}
+
+// Repairable reports whether RepairDatabase can fix any of the issues.
+func (c DatabaseCheck) Repairable() bool {
+	for _, issue := range c.Issues {
+		if issue.Repairable() {
+			return true
+		}
+	}
+	return false
+}
+
+// DatabaseRepair is the result of RepairDatabase.
+type DatabaseRepair struct {
+	Repaired []persistence.IntegrityIssue
+	// Remaining are the issues a check still finds after the repair.
+	Remaining []persistence.IntegrityIssue
+}
+
+// WriteIntegrityIssues writes a plain-text line per issue, or a single
+// line saying none were found.
+func WriteIntegrityIssues(w io.Writer, issues []persistence.IntegrityIssue) error {
+	var b strings.Builder
+	if len(issues) == 0 {
+		b.WriteString("no problems found\n")
+	}
+	for _, issue := range issues {
+		fmt.Fprintf(&b, "%s: %s (%d)", issue.Problem, issue.Subject, issue.Count)
+		if issue.Detail != "" {
+			fmt.Fprintf(&b, ": %s", issue.Detail)
+		}
+		b.WriteByte('\n')
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("write integrity issues: %w", err)
+	}
+	return nil
+}
+
+// CheckDatabase checks the stored data for damage left by crashes and old
+// migrations, and the import cursors for logs that shrank. It changes
+// nothing.
+func (s *Service) CheckDatabase(ctx context.Context) (DatabaseCheck, error) {
+	repo, ok := s.repo.(persistence.IntegrityRepository)
+	if !ok {
+		return DatabaseCheck{}, ErrIntegrityUnsupported
+	}
+	issues, err := repo.CheckIntegrity(ctx)
+	if err != nil {
+		return DatabaseCheck{}, fmt.Errorf("check database: %w", err)
+	}
+	cursorIssues, err := cursorsBeyondFile(ctx, repo)
+	if err != nil {
+		return DatabaseCheck{}, err
+	}
+	return DatabaseCheck{Issues: append(issues, cursorIssues...)}, nil
+}
+
+// RepairDatabase repairs what CheckDatabase finds. Cursors past the end of
+// their log are deleted, so the log is imported again from its start; its
+// hands already stored are updated in place. Corruption found by SQLite is
+// left for the user to handle by restoring a backup or resetting the
+// database.
+func (s *Service) RepairDatabase(ctx context.Context) (DatabaseRepair, error) {
+	repo, ok := s.repo.(persistence.IntegrityRepository)
+	if !ok {
+		return DatabaseRepair{}, ErrIntegrityUnsupported
+	}
+	repaired, err := repo.RepairIntegrity(ctx)
+	if err != nil {
+		return DatabaseRepair{}, err
+	}
+	cursorIssues, err := cursorsBeyondFile(ctx, repo)
+	if err != nil {
+		return DatabaseRepair{}, err
+	}
+	for _, issue := range cursorIssues {
+		if err := repo.DeleteCursor(ctx, issue.Subject); err != nil {
+			return DatabaseRepair{}, fmt.Errorf("repair cursor %s: %w", issue.Subject, err)
+		}
+		repaired = append(repaired, issue)
+	}
+
+	if len(repaired) > 0 {
+		// Deleted hands may be in the all-time calculator.
+		s.incMu.Lock()
+		s.incCalc = nil
+		s.watermark = time.Time{}
+		s.incMu.Unlock()
+		s.invalidateStatsCache()
+		if err := s.rebuildRepairedRollups(ctx); err != nil {
+			return DatabaseRepair{Repaired: repaired}, err
+		}
+	}
+
+	check, err := s.CheckDatabase(ctx)
+	if err != nil {
+		return DatabaseRepair{Repaired: repaired}, err
+	}
+	return DatabaseRepair{Repaired: repaired, Remaining: check.Issues}, nil
+}
+
+// rebuildRepairedRollups rebuilds the daily rollups of the days whose hands
+// a repair deleted, which the repository marked dirty. Without a local seat,
+// as when repairing from the command line, rebuilding would replace the
+// rollups of every day, so the dirty days are left to the next period query.
+func (s *Service) rebuildRepairedRollups(ctx context.Context) error {
+	repo, ok := s.repo.(persistence.StatsRollupRepository)
+	s.mu.RLock()
+	localSeat := s.localSeat
+	s.mu.RUnlock()
+	if !ok || localSeat < 0 {
+		return nil
+	}
+	if _, err := s.refreshStatsRollups(ctx, repo, localSeat); err != nil {
+		return fmt.Errorf("rebuild stats rollups: %w", err)
+	}
+	return nil
+}
+
+// cursorsBeyondFile returns an issue for every cursor past the end of its
+// log. Cursors of logs that are gone are fine: VRChat deletes old logs and
+// their cursors stay to recognize copies of them.
+func cursorsBeyondFile(ctx context.Context, repo persistence.IntegrityRepository) ([]persistence.IntegrityIssue, error) {
+	cursors, err := repo.ListCursors(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list cursors: %w", err)
+	}
+	var out []persistence.IntegrityIssue
+	for _, c := range cursors {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		size, err := logSize(c.SourcePath)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				slog.Warn("check cursor", "path", c.SourcePath, "error", err)
+			}
+			continue
+		}
+		if c.NextByteOffset > size {
+			out = append(out, persistence.IntegrityIssue{
+				Problem: persistence.ProblemCursorBeyondFile,
+				Subject: c.SourcePath,
+				Count:   1,
+				Detail:  fmt.Sprintf("cursor at byte %d, log has %d bytes", c.NextByteOffset, size),
+			})
+		}
+	}
+	return out, nil
+}
+
+// logSize returns the size of a plain or archived log.
+func logSize(path string) (int64, error) {
+	f, err := logsource.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return f.Seek(0, io.SeekEnd)
+}
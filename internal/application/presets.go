@@ -0,0 +1,262 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/handquery"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/persistence"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
+)
+
+// settingFilterPresets is the settings key holding the saved presets as JSON.
+const settingFilterPresets = "filter_presets"
+
+// filterPresetFileVersion is the version written to exported preset files.
+// Files with a newer version are rejected on import.
+const filterPresetFileVersion = 1
+
+// Preset period modes.
+const (
+	PresetPeriodAll        = "all"
+	PresetPeriodLastDays   = "last_days"
+	PresetPeriodLastMonths = "last_months"
+	PresetPeriodCustom     = "custom"
+)
+
+// presetDateLayout is the date format of custom preset periods.
+const presetDateLayout = "2006-01-02"
+
+// PresetPeriod is the time window of a preset. N is used by the last_days and
+// last_months modes; From and To (YYYY-MM-DD, inclusive) by custom.
+type PresetPeriod struct {
+	Mode string `json:"mode"`
+	N    int    `json:"n,omitempty"`
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+// FilterPreset is a named, shareable slice of hands. Empty lists mean no
+// restriction. Values use the search query vocabulary: positions like "BTN",
+// pocket category codes like "premium", hand classes like "two_pair", table
+// size codes from stats.TableSize.Code and instance types like "public".
+// Query is an optional extra search expression.
+type FilterPreset struct {
+	Name             string       `json:"name"`
+	Period           PresetPeriod `json:"period"`
+	Positions        []string     `json:"positions,omitempty"`
+	PocketCategories []string     `json:"pocket_categories,omitempty"`
+	FinalClasses     []string     `json:"final_classes,omitempty"`
+	TableSizes       []string     `json:"table_sizes,omitempty"`
+	InstanceTypes    []string     `json:"instance_types,omitempty"`
+	Query            string       `json:"query,omitempty"`
+}
+
+// QueryText returns the search expression equivalent to the preset's
+// position, hand, instance and query conditions.
+func (p FilterPreset) QueryText() string {
+	var terms []string
+	add := func(field string, values []string) {
+		if len(values) > 0 {
+			terms = append(terms, field+":"+strings.Join(values, ","))
+		}
+	}
+	add("pos", p.Positions)
+	add("pocket", p.PocketCategories)
+	add("made", p.FinalClasses)
+	add("instance", p.InstanceTypes)
+	if q := strings.TrimSpace(p.Query); q != "" {
+		terms = append(terms, "("+q+")")
+	}
+	return strings.Join(terms, " ")
+}
+
+// HandFilter converts the preset into a hand filter. Relative periods are
+// resolved against now rounded down to the minute: the start time is part of
+// the Stats cache key, so calls within the same minute share a cache entry.
+func (p FilterPreset) HandFilter(now time.Time) (persistence.HandFilter, error) {
+	var f persistence.HandFilter
+	switch p.Period.Mode {
+	case PresetPeriodAll, "":
+	case PresetPeriodLastDays, PresetPeriodLastMonths:
+		if p.Period.N <= 0 {
+			return f, fmt.Errorf("preset %q: period length must be positive", p.Name)
+		}
+		now = now.Truncate(time.Minute)
+		from := now.AddDate(0, 0, -p.Period.N)
+		if p.Period.Mode == PresetPeriodLastMonths {
+			from = now.AddDate(0, -p.Period.N, 0)
+		}
+		f.FromTime = &from
+	case PresetPeriodCustom:
+		if p.Period.From != "" {
+			from, err := time.ParseInLocation(presetDateLayout, p.Period.From, now.Location())
+			if err != nil {
+				return f, fmt.Errorf("preset %q: invalid from date: %w", p.Name, err)
+			}
+			f.FromTime = &from
+		}
+		if p.Period.To != "" {
+			to, err := time.ParseInLocation(presetDateLayout, p.Period.To, now.Location())
+			if err != nil {
+				return f, fmt.Errorf("preset %q: invalid to date: %w", p.Name, err)
+			}
+			to = to.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			f.ToTime = &to
+		}
+	default:
+		return f, fmt.Errorf("preset %q: unknown period mode %q", p.Name, p.Period.Mode)
+	}
+
+	for _, code := range p.TableSizes {
+		size, ok := stats.TableSizeByCode(code)
+		if !ok {
+			return f, fmt.Errorf("preset %q: unknown table size %q", p.Name, code)
+		}
+		f.TableSizes = append(f.TableSizes, size)
+	}
+	q, err := handquery.Parse(p.QueryText())
+	if err != nil {
+		return f, fmt.Errorf("preset %q: %w", p.Name, err)
+	}
+	f.Query = q
+	return f, nil
+}
+
+// Validate reports whether the preset has a name and converts to a filter.
+func (p FilterPreset) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("preset name is empty")
+	}
+	_, err := p.HandFilter(time.Now())
+	return err
+}
+
+type filterPresetFile struct {
+	Version int            `json:"version"`
+	Presets []FilterPreset `json:"presets"`
+}
+
+// WriteFilterPresets writes presets as an indented JSON preset file.
+func WriteFilterPresets(w io.Writer, presets []FilterPreset) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(filterPresetFile{Version: filterPresetFileVersion, Presets: presets}); err != nil {
+		return fmt.Errorf("write filter presets: %w", err)
+	}
+	return nil
+}
+
+// ReadFilterPresets reads a preset file written by WriteFilterPresets and
+// validates every preset in it.
+func ReadFilterPresets(r io.Reader) ([]FilterPreset, error) {
+	var file filterPresetFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("read filter presets: %w", err)
+	}
+	if file.Version < 1 || file.Version > filterPresetFileVersion {
+		return nil, fmt.Errorf("read filter presets: unsupported version %d", file.Version)
+	}
+	for i := range file.Presets {
+		file.Presets[i].Name = strings.TrimSpace(file.Presets[i].Name)
+		if err := file.Presets[i].Validate(); err != nil {
+			return nil, fmt.Errorf("read filter presets: %w", err)
+		}
+	}
+	return file.Presets, nil
+}
+
+// MergeFilterPresets adds presets to existing, replacing presets with the
+// same name, and returns the result sorted by name.
+func MergeFilterPresets(existing, presets []FilterPreset) []FilterPreset {
+	byName := make(map[string]FilterPreset, len(existing)+len(presets))
+	for _, p := range existing {
+		byName[p.Name] = p
+	}
+	for _, p := range presets {
+		byName[p.Name] = p
+	}
+	out := make([]FilterPreset, 0, len(byName))
+	for _, p := range byName {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// FilterPresets returns the saved presets sorted by name. A stored value that
+// cannot be decoded yields no presets rather than an error.
+func (s *Service) FilterPresets(ctx context.Context) ([]FilterPreset, error) {
+	values, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load filter presets: %w", err)
+	}
+	var presets []FilterPreset
+	if v, ok := values[settingFilterPresets]; ok {
+		if err := json.Unmarshal([]byte(v), &presets); err != nil {
+			slog.Warn("ignoring unreadable filter presets", "error", err)
+			return nil, nil
+		}
+	}
+	return MergeFilterPresets(nil, presets), nil
+}
+
+// SaveFilterPresets replaces the saved presets. Every preset must be valid
+// and names must be unique.
+func (s *Service) SaveFilterPresets(ctx context.Context, presets []FilterPreset) error {
+	seen := make(map[string]bool, len(presets))
+	for _, p := range presets {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("save filter presets: %w", err)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("save filter presets: duplicate name %q", p.Name)
+		}
+		seen[p.Name] = true
+	}
+	data, err := json.Marshal(MergeFilterPresets(nil, presets))
+	if err != nil {
+		return fmt.Errorf("save filter presets: %w", err)
+	}
+	if err := s.repo.SaveSettings(ctx, map[string]string{settingFilterPresets: string(data)}); err != nil {
+		return fmt.Errorf("save filter presets: %w", err)
+	}
+	return nil
+}
+
+// ExportFilterPresets writes the saved presets as a JSON preset file and
+// returns how many were written.
+func (s *Service) ExportFilterPresets(ctx context.Context, w io.Writer) (int, error) {
+	presets, err := s.FilterPresets(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if presets == nil {
+		presets = []FilterPreset{}
+	}
+	return len(presets), WriteFilterPresets(w, presets)
+}
+
+// ImportFilterPresets merges the presets of a JSON preset file into the saved
+// presets, replacing presets with the same name, and returns how many were
+// imported.
+func (s *Service) ImportFilterPresets(ctx context.Context, r io.Reader) (int, error) {
+	imported, err := ReadFilterPresets(r)
+	if err != nil {
+		return 0, err
+	}
+	existing, err := s.FilterPresets(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.SaveFilterPresets(ctx, MergeFilterPresets(existing, imported)); err != nil {
+		return 0, err
+	}
+	return len(imported), nil
+}
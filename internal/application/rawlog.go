@@ -0,0 +1,67 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/logsource"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/persistence"
+)
+
+// maxRawLogSnippet bounds the size of a stored raw log snippet. A hand span this
+// large means the hand boundaries were lost, and the text is not worth keeping.
+const maxRawLogSnippet = 256 * 1024
+
+// HandRawLog returns the raw log lines stored for a hand, or nil if none were kept.
+func (s *Service) HandRawLog(ctx context.Context, uid string) (*persistence.RawLogSnippet, error) {
+	return s.repo.GetHandRawLog(ctx, uid)
+}
+
+// attachRawLogs reads each row's source span from r and stores it in
+// row.RawLog. The snippet starts one line before the span so the hand's
+// "New Game" line is included (see reparseSpans). Rows whose span cannot be
+// read are left without a snippet; raw logs are best effort.
+func attachRawLogs(r io.ReaderAt, rows []persistence.PersistedHand) {
+	for i := range rows {
+		src := rows[i].Source
+		if rows[i].Hand == nil || src.EndByte <= src.StartByte {
+			continue
+		}
+		start, err := precedingLineStart(r, src.StartByte)
+		if err != nil {
+			slog.Debug("raw log snippet start not found", "path", src.SourcePath, "error", err)
+			continue
+		}
+		if src.EndByte-start > maxRawLogSnippet {
+			continue
+		}
+		buf := make([]byte, src.EndByte-start)
+		n, err := r.ReadAt(buf, start)
+		if err != nil && !errors.Is(err, io.EOF) {
+			slog.Debug("raw log snippet read failed", "path", src.SourcePath, "error", err)
+			continue
+		}
+		rows[i].RawLog = &persistence.RawLogSnippet{
+			SourcePath: src.SourcePath,
+			StartByte:  start,
+			EndByte:    start + int64(n),
+			Data:       buf[:n],
+		}
+	}
+}
+
+// attachRawLogsFromPath is attachRawLogs for callers without an open file.
+func attachRawLogsFromPath(path string, rows []persistence.PersistedHand) {
+	if len(rows) == 0 {
+		return
+	}
+	f, err := logsource.Open(path)
+	if err != nil {
+		slog.Debug("raw log source unavailable", "path", path, "error", err)
+		return
+	}
+	defer f.Close()
+	attachRawLogs(f, rows)
+}
@@ -0,0 +1,473 @@
+package application
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/logsource"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/persistence"
+)
+
+// reprocessBatchSize is the number of hands written per UpsertHands call.
+const reprocessBatchSize = 200
+
+// reprocessLookback bounds how far before a span ReprocessHands searches for
+// the start of the preceding line.
+const reprocessLookback = 64 * 1024
+
+// ReprocessOptions controls ReprocessHands.
+type ReprocessOptions struct {
+	// DryRun re-parses and diffs the outdated hands without writing anything.
+	DryRun bool
+}
+
+// ReprocessProgress is reported before each source file and after each batch.
+type ReprocessProgress struct {
+	// FileIndex is the 1-based index of the file being processed.
+	FileIndex int
+	FileCount int
+	Path      string
+	// Processed is the number of outdated hands handled so far across all files.
+	Processed int
+	Total     int
+}
+
+// HandDiff lists the fields of a stored hand that change when it is re-parsed.
+type HandDiff struct {
+	HandUID    string   `json:"hand_uid"`
+	SourcePath string   `json:"source_path"`
+	StartByte  int64    `json:"start_byte"`
+	EndByte    int64    `json:"end_byte"`
+	Changes    []string `json:"changes"`
+}
+
+// ReprocessResult summarizes a ReprocessHands run.
+type ReprocessResult struct {
+	DryRun        bool
+	ParserVersion int
+	// Outdated is the number of stored hands produced by an older parser.
+	Outdated int
+	// Reparsed is the number of outdated hands found again in their source log.
+	Reparsed int
+	// Changed is the number of re-parsed hands whose data differs from storage.
+	Changed int
+	// Updated is the number of hands written back; always 0 for a dry run.
+	Updated int
+	// Unmatched is the number of spans whose log no longer yields a hand there,
+	// for example because the file was replaced.
+	Unmatched int
+	// MissingFiles lists source logs that no longer exist. Their hands are
+	// re-parsed from stored raw log snippets when available.
+	MissingFiles []string
+	// FromRawLogs is the number of hands re-parsed from raw log snippets.
+	FromRawLogs int
+	Diffs       []HandDiff
+}
+
+// WriteDiff writes a plain-text report of the run, one block per changed hand.
+func (r ReprocessResult) WriteDiff(w io.Writer) error {
+	var b strings.Builder
+	mode := "apply"
+	if r.DryRun {
+		mode = "dry run"
+	}
+	fmt.Fprintf(&b, "# reprocess (%s), parser version %d\n", mode, r.ParserVersion)
+	fmt.Fprintf(&b, "# outdated=%d reparsed=%d changed=%d updated=%d unmatched=%d missing_files=%d from_raw_logs=%d\n",
+		r.Outdated, r.Reparsed, r.Changed, r.Updated, r.Unmatched, len(r.MissingFiles), r.FromRawLogs)
+	for _, path := range r.MissingFiles {
+		fmt.Fprintf(&b, "missing: %s\n", path)
+	}
+	for _, d := range r.Diffs {
+		fmt.Fprintf(&b, "\n--- %s %s:%d-%d\n", d.HandUID, d.SourcePath, d.StartByte, d.EndByte)
+		for _, c := range d.Changes {
+			fmt.Fprintf(&b, "  %s\n", c)
+		}
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("write reprocess diff: %w", err)
+	}
+	return nil
+}
+
+// reparsedHand pairs a freshly parsed hand with the stored span it replaces.
+type reparsedHand struct {
+	source persistence.HandSourceRef
+	hand   *parser.Hand
+}
+
+// ReprocessHands re-parses every stored hand produced by an older parser
+// version from its original log span and upserts the result under the same
+// hand UID. When a log file is gone its hands are re-parsed from their stored
+// raw log snippets; hands without one are left untouched. onProgress may be nil.
+func (s *Service) ReprocessHands(ctx context.Context, opts ReprocessOptions, onProgress func(ReprocessProgress)) (ReprocessResult, error) {
+	if !s.reprocessMu.TryLock() {
+		return ReprocessResult{}, fmt.Errorf("reprocess is already running")
+	}
+	defer s.reprocessMu.Unlock()
+
+	res := ReprocessResult{DryRun: opts.DryRun, ParserVersion: parser.Version}
+	sources, err := s.repo.ListOutdatedHandSources(ctx, parser.Version)
+	if err != nil {
+		return res, fmt.Errorf("list outdated hands: %w", err)
+	}
+	paths, byPath := groupSourcesByPath(sources)
+	for _, spans := range byPath {
+		res.Outdated += len(spans)
+	}
+
+	report := func(fileIndex int, path string, processed int) {
+		if onProgress != nil {
+			onProgress(ReprocessProgress{FileIndex: fileIndex, FileCount: len(paths), Path: path, Processed: processed, Total: res.Outdated})
+		}
+	}
+
+	processed := 0
+	for i, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		spans := byPath[path]
+		report(i+1, path, processed)
+
+		reparsed, unmatched, err := s.reparseSpans(ctx, path, spans)
+		fileExists := !errors.Is(err, fs.ErrNotExist)
+		if !fileExists {
+			res.MissingFiles = append(res.MissingFiles, path)
+			var missing int
+			reparsed, unmatched, missing, err = s.reparseFromRawLogs(ctx, spans)
+			res.FromRawLogs += len(reparsed)
+			processed += missing
+		}
+		if err != nil {
+			return res, fmt.Errorf("reparse %s: %w", path, err)
+		}
+		res.Reparsed += len(reparsed)
+		res.Unmatched += unmatched
+		processed += unmatched
+
+		rows := make([]persistence.PersistedHand, 0, reprocessBatchSize)
+		storedRows := make([]*parser.Hand, 0, reprocessBatchSize)
+		flush := func() error {
+			if len(rows) == 0 {
+				return nil
+			}
+			if !opts.DryRun {
+				// Backfill raw log snippets for hands imported before they were kept.
+				if fileExists && s.currentSettings().KeepRawLogs {
+					attachRawLogsFromPath(path, rows)
+				}
+				if err := s.writeHands(ctx, rows, storedRows, func() error {
+					_, err := s.repo.UpsertHands(ctx, rows)
+					return err
+				}); err != nil {
+					return fmt.Errorf("upsert reprocessed hands: %w", err)
+				}
+				res.Updated += len(rows)
+			}
+			processed += len(rows)
+			rows = rows[:0]
+			storedRows = storedRows[:0]
+			report(i+1, path, processed)
+			return nil
+		}
+		for _, rh := range reparsed {
+			stored, err := s.repo.GetHandByUID(ctx, rh.source.HandUID)
+			if err != nil {
+				return res, fmt.Errorf("load stored hand %s: %w", rh.source.HandUID, err)
+			}
+			if changes := diffHands(stored, rh.hand); len(changes) > 0 {
+				res.Changed++
+				res.Diffs = append(res.Diffs, HandDiff{
+					HandUID:    rh.source.HandUID,
+					SourcePath: rh.source.SourcePath,
+					StartByte:  rh.source.StartByte,
+					EndByte:    rh.source.EndByte,
+					Changes:    changes,
+				})
+			}
+			rows = append(rows, persistence.PersistedHand{Hand: rh.hand, Source: rh.source})
+			storedRows = append(storedRows, stored)
+			if len(rows) >= reprocessBatchSize {
+				if err := flush(); err != nil {
+					return res, err
+				}
+			}
+		}
+		if err := flush(); err != nil {
+			return res, err
+		}
+	}
+
+	if res.Updated > 0 {
+		s.invalidateStatsCache()
+	}
+	return res, nil
+}
+
+// reparseSpans re-reads path from the first span to the last and returns the
+// hands that match a stored span (see reparseReader), plus the number of spans
+// that were not found again.
+//
+// Import spans start right after the line that finalized the previous hand,
+// which is normally this hand's "New Game" line, so parsing starts one line
+// before the first span.
+func (s *Service) reparseSpans(ctx context.Context, path string, spans []persistence.HandSourceRef) ([]reparsedHand, int, error) {
+	f, err := logsource.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	start, err := precedingLineStart(f, spans[0].StartByte)
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, err := f.Seek(start, io.SeekStart); err != nil {
+		return nil, 0, err
+	}
+	return s.reparseReader(ctx, f, start, spans)
+}
+
+// reparseFromRawLogs re-parses spans from their stored raw log snippets. It
+// returns the re-parsed hands, the number of snippets that did not yield their
+// hand, and the number of spans without a usable snippet.
+func (s *Service) reparseFromRawLogs(ctx context.Context, spans []persistence.HandSourceRef) ([]reparsedHand, int, int, error) {
+	out := make([]reparsedHand, 0, len(spans))
+	unmatched, missing := 0, 0
+	for _, sp := range spans {
+		raw, err := s.repo.GetHandRawLog(ctx, sp.HandUID)
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("load raw log %s: %w", sp.HandUID, err)
+		}
+		if raw == nil || raw.EndByte != sp.EndByte || raw.StartByte > sp.StartByte {
+			missing++
+			continue
+		}
+		hands, n, err := s.reparseReader(ctx, bytes.NewReader(raw.Data), raw.StartByte, []persistence.HandSourceRef{sp})
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		out = append(out, hands...)
+		unmatched += n
+	}
+	return out, unmatched, missing, nil
+}
+
+// reparseReader parses r, whose first byte is at offset start in the source
+// log, until every span is found again. World context and local seat are
+// restored from the first stored hand because their log lines usually
+// precede the span.
+//
+// A re-parsed hand matches the span it ends at, or else the stored hand
+// with its start time: hands imported before offsets counted carriage
+// returns have spans that fall short of the real offsets in CRLF logs.
+func (s *Service) reparseReader(ctx context.Context, r io.Reader, start int64, spans []persistence.HandSourceRef) ([]reparsedHand, int, error) {
+	byEnd := make(map[int64]persistence.HandSourceRef, len(spans))
+	byStart := make(map[time.Time]persistence.HandSourceRef, len(spans))
+	maxEnd := int64(0)
+	var maxStart time.Time
+	var first *parser.Hand
+	for i, sp := range spans {
+		byEnd[sp.EndByte] = sp
+		maxEnd = max(maxEnd, sp.EndByte)
+		stored, err := s.repo.GetHandByUID(ctx, sp.HandUID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("load stored hand %s: %w", sp.HandUID, err)
+		}
+		if i == 0 {
+			first = stored
+		}
+		if stored != nil && !stored.StartTime.IsZero() {
+			byStart[stored.StartTime.UTC()] = sp
+			if stored.StartTime.After(maxStart) {
+				maxStart = stored.StartTime
+			}
+		}
+	}
+	matched := make(map[string]bool, len(spans))
+	match := func(h *parser.Hand, end int64) (persistence.HandSourceRef, bool) {
+		src, ok := byEnd[end]
+		if !ok || matched[src.HandUID] {
+			src, ok = byStart[h.StartTime.UTC()]
+		}
+		if !ok || matched[src.HandUID] {
+			return persistence.HandSourceRef{}, false
+		}
+		matched[src.HandUID] = true
+		return src, true
+	}
+
+	p := parser.NewParser()
+	if first != nil {
+		p.RestoreWorldContext(parser.WorldContext{
+			WorldID:          first.WorldID,
+			WorldDisplayName: first.WorldDisplayName,
+			InstanceUID:      first.InstanceUID,
+			InstanceType:     first.InstanceType,
+			InstanceOwner:    first.InstanceOwner,
+			InstanceRegion:   first.InstanceRegion,
+			InPokerWorld:     true,
+			WorldDetected:    first.WorldID != "",
+		})
+		p.RestoreLocalSeat(first.LocalPlayerSeat)
+	}
+
+	out := make([]reparsedHand, 0, len(spans))
+	parsed := 0
+	pastLast := false
+	byteOffset := start
+	br := bufio.NewReaderSize(r, 1024*1024)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		line, n, _, err := logsource.ReadLine(br, maxLogLineBytes)
+		last := errors.Is(err, io.EOF)
+		if last && n == 0 {
+			break
+		}
+		if err != nil && !last {
+			return nil, 0, err
+		}
+		byteOffset += n
+		_ = p.ParseLine(line)
+		if p.HandCount() > parsed {
+			for _, h := range p.GetHands()[parsed:] {
+				if src, ok := match(h, byteOffset); ok {
+					out = append(out, reparsedHand{source: src, hand: h})
+				}
+				pastLast = pastLast || h.StartTime.After(maxStart)
+			}
+			parsed = p.HandCount()
+		}
+		// Spans may end short of the real offsets, so reading goes on
+		// past the last one until its hand or a later one was parsed.
+		if last || len(matched) == len(spans) || (byteOffset >= maxEnd && pastLast) {
+			break
+		}
+	}
+	return out, len(spans) - len(matched), nil
+}
+
+// precedingLineStart returns the offset of the line that ends at offset.
+func precedingLineStart(r io.ReaderAt, offset int64) (int64, error) {
+	if offset <= 0 {
+		return 0, nil
+	}
+	from := offset - reprocessLookback
+	if from < 0 {
+		from = 0
+	}
+	buf := make([]byte, offset-from)
+	n, err := r.ReadAt(buf, from)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+	buf = bytes.TrimSuffix(buf[:n], []byte("\n"))
+	if i := bytes.LastIndexByte(buf, '\n'); i >= 0 {
+		return from + int64(i) + 1, nil
+	}
+	return from, nil
+}
+
+// groupSourcesByPath groups spans by source path, keeping one span per hand,
+// and returns the paths in sorted order.
+func groupSourcesByPath(sources []persistence.HandSourceRef) ([]string, map[string][]persistence.HandSourceRef) {
+	seen := make(map[string]bool, len(sources))
+	byPath := make(map[string][]persistence.HandSourceRef)
+	for _, src := range sources {
+		if src.SourcePath == "" || seen[src.HandUID] {
+			continue
+		}
+		seen[src.HandUID] = true
+		byPath[src.SourcePath] = append(byPath[src.SourcePath], src)
+	}
+	paths := make([]string, 0, len(byPath))
+	for path, spans := range byPath {
+		sort.Slice(spans, func(i, j int) bool { return spans[i].StartByte < spans[j].StartByte })
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths, byPath
+}
+
+// diffHands describes the differences between a stored hand and its re-parsed
+// version. A nil stored hand yields a single "missing" entry.
+func diffHands(stored, cur *parser.Hand) []string {
+	if stored == nil {
+		return []string{"stored hand: missing"}
+	}
+	var out []string
+	add := func(field string, before, after any) {
+		if before != after {
+			out = append(out, fmt.Sprintf("%s: %v -> %v", field, before, after))
+		}
+	}
+	add("start_time", stored.StartTime.UTC().Format(time.RFC3339Nano), cur.StartTime.UTC().Format(time.RFC3339Nano))
+	add("end_time", stored.EndTime.UTC().Format(time.RFC3339Nano), cur.EndTime.UTC().Format(time.RFC3339Nano))
+	add("is_complete", stored.IsComplete, cur.IsComplete)
+	add("stats_eligible", stored.IsStatsEligible(), cur.IsStatsEligible())
+	add("anomalies", anomalyCodes(stored), anomalyCodes(cur))
+	add("local_seat", stored.LocalPlayerSeat, cur.LocalPlayerSeat)
+	add("sb_seat", stored.SBSeat, cur.SBSeat)
+	add("bb_seat", stored.BBSeat, cur.BBSeat)
+	add("num_players", stored.NumPlayers, cur.NumPlayers)
+	add("total_pot", stored.TotalPot, cur.TotalPot)
+	add("winner_seat", stored.WinnerSeat, cur.WinnerSeat)
+	add("win_type", stored.WinType, cur.WinType)
+	add("board", cardsString(stored.CommunityCards), cardsString(cur.CommunityCards))
+
+	seats := make(map[int]struct{}, len(stored.Players)+len(cur.Players))
+	for seat := range stored.Players {
+		seats[seat] = struct{}{}
+	}
+	for seat := range cur.Players {
+		seats[seat] = struct{}{}
+	}
+	ordered := make([]int, 0, len(seats))
+	for seat := range seats {
+		ordered = append(ordered, seat)
+	}
+	sort.Ints(ordered)
+	for _, seat := range ordered {
+		add(fmt.Sprintf("seat %d", seat), playerSummary(stored.Players[seat]), playerSummary(cur.Players[seat]))
+	}
+	return out
+}
+
+func anomalyCodes(h *parser.Hand) string {
+	codes := make([]string, 0, len(h.Anomalies))
+	for _, a := range h.Anomalies {
+		codes = append(codes, a.Code)
+	}
+	return strings.Join(codes, ",")
+}
+
+func cardsString(cards []parser.Card) string {
+	parts := make([]string, 0, len(cards))
+	for _, c := range cards {
+		parts = append(parts, c.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+func playerSummary(pi *parser.PlayerHandInfo) string {
+	if pi == nil {
+		return "-"
+	}
+	actions := make([]string, 0, len(pi.Actions))
+	for _, act := range pi.Actions {
+		actions = append(actions, fmt.Sprintf("%s:%s:%d", act.Street, act.Action, act.Amount))
+	}
+	return fmt.Sprintf("pos=%s cards=[%s] won=%d vpip=%t pfr=%t 3bet=%t actions=[%s]",
+		pi.Position, cardsString(pi.HoleCards), pi.PotWon, pi.VPIP, pi.PFR, pi.ThreeBet, strings.Join(actions, " "))
+}
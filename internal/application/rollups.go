@@ -0,0 +1,169 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/persistence"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
+)
+
+// rollupRefreshAttempts bounds how often a query rebuilds dirty days before
+// it gives up on rollups and scans the hands, when imports keep writing to
+// the days being rebuilt.
+const rollupRefreshAttempts = 3
+
+// refreshStatsRollups rebuilds the rollups of every dirty day and reports
+// whether all rollups are up to date afterwards.
+func (s *Service) refreshStatsRollups(ctx context.Context, repo persistence.StatsRollupRepository, localSeat int) (bool, error) {
+	s.rollupMu.Lock()
+	defer s.rollupMu.Unlock()
+
+	for attempt := 0; attempt < rollupRefreshAttempts; attempt++ {
+		days, err := repo.DirtyRollupDays(ctx, localSeat, stats.RollupVersion)
+		if err != nil {
+			return false, fmt.Errorf("list dirty rollup days: %w", err)
+		}
+		if len(days) == 0 {
+			return true, nil
+		}
+		for _, d := range days {
+			if err := s.rebuildRollupDay(ctx, repo, d, localSeat); err != nil {
+				return false, err
+			}
+		}
+	}
+	return false, nil
+}
+
+// rebuildRollupDay recomputes the per-table-size rollups of one UTC day.
+func (s *Service) rebuildRollupDay(ctx context.Context, repo persistence.StatsRollupRepository, d persistence.RollupDay, localSeat int) error {
+	from, err := time.ParseInLocation(persistence.StatsRollupDayLayout, d.Day, time.UTC)
+	if err != nil {
+		// Hands without a parsable start time never fall inside a period
+		// filter; saving no rollups just clears the day.
+		return repo.SaveStatsRollups(ctx, d, localSeat, stats.RollupVersion, nil)
+	}
+	to := from.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	hands, err := s.repo.ListHands(ctx, persistence.HandFilter{FromTime: &from, ToTime: &to, OnlyComplete: true})
+	if err != nil {
+		return fmt.Errorf("list hands of %s: %w", d.Day, err)
+	}
+
+	bySize := make(map[stats.TableSize]*stats.IncrementalCalculator)
+	for _, h := range hands {
+		size := stats.TableSizeForPlayers(h.NumPlayers)
+		calc := bySize[size]
+		if calc == nil {
+			calc = stats.NewIncrementalCalculator(localSeat)
+			bySize[size] = calc
+		}
+		calc.Feed(h)
+	}
+	rollups := make([]persistence.StatsRollup, 0, len(bySize))
+	for size, calc := range bySize {
+		r := calc.Rollup()
+		if r.TotalHands == 0 {
+			continue
+		}
+		rollups = append(rollups, persistence.StatsRollup{Day: d.Day, TableSize: size, HandCount: r.TotalHands, State: r})
+	}
+	if err := repo.SaveStatsRollups(ctx, d, localSeat, stats.RollupVersion, rollups); err != nil {
+		return fmt.Errorf("save rollups of %s: %w", d.Day, err)
+	}
+	return nil
+}
+
+// rollupFilterSupported reports whether stats for f can be built from daily
+// rollups: only the time range and table sizes may be set.
+func rollupFilterSupported(f persistence.HandFilter) bool {
+	return f.Query == nil &&
+		f.LocalSeat == nil &&
+		len(f.PocketCategoryIDs) == 0 &&
+		len(f.FinalClassIDs) == 0 &&
+		!f.OnlyStatsExcluded &&
+		!f.OnlyStarred &&
+		len(f.Tags) == 0 &&
+		f.AnnotationSearch == ""
+}
+
+// statsFromRollups computes period stats by summing the rollups of the UTC
+// days fully inside the filter's range and scanning only the partial days at
+// its edges. It reports false when rollups cannot serve the filter, and the
+// caller must scan instead. The result carries no tilt report, which needs
+// every hand in order.
+func (s *Service) statsFromRollups(ctx context.Context, filter persistence.HandFilter, localSeat int) (*stats.Stats, bool, error) {
+	repo, ok := s.repo.(persistence.StatsRollupRepository)
+	if !ok || !rollupFilterSupported(filter) {
+		return nil, false, nil
+	}
+
+	// Full days are [firstDay, endDay). ToTime is inclusive.
+	var firstDay, endDay time.Time
+	if filter.FromTime != nil {
+		firstDay = filter.FromTime.UTC().Truncate(24 * time.Hour)
+		if firstDay.Before(filter.FromTime.UTC()) {
+			firstDay = firstDay.AddDate(0, 0, 1)
+		}
+	}
+	if filter.ToTime != nil {
+		endDay = filter.ToTime.UTC().Add(time.Nanosecond).Truncate(24 * time.Hour)
+	}
+	if filter.FromTime != nil && filter.ToTime != nil && !firstDay.Before(endDay) {
+		return nil, false, nil
+	}
+
+	upToDate, err := s.refreshStatsRollups(ctx, repo, localSeat)
+	if err != nil || !upToDate {
+		return nil, false, err
+	}
+
+	calc := stats.NewIncrementalCalculator(localSeat)
+	edge := filter
+	edge.OnlyComplete = true
+	if filter.FromTime != nil && filter.FromTime.Before(firstDay) {
+		to := firstDay.Add(-time.Nanosecond)
+		edge.FromTime, edge.ToTime = filter.FromTime, &to
+		if err := s.feedHands(ctx, calc, edge); err != nil {
+			return nil, false, err
+		}
+	}
+	if filter.ToTime != nil && !filter.ToTime.Before(endDay) {
+		edge.FromTime, edge.ToTime = &endDay, filter.ToTime
+		if err := s.feedHands(ctx, calc, edge); err != nil {
+			return nil, false, err
+		}
+	}
+
+	fromDay, toDay := "", "9999-12-31"
+	if filter.FromTime != nil {
+		fromDay = firstDay.Format(persistence.StatsRollupDayLayout)
+	}
+	if filter.ToTime != nil {
+		toDay = endDay.AddDate(0, 0, -1).Format(persistence.StatsRollupDayLayout)
+	}
+	rollups, err := repo.ListStatsRollups(ctx, fromDay, toDay)
+	if err != nil {
+		return nil, false, fmt.Errorf("list rollups: %w", err)
+	}
+	for _, r := range rollups {
+		if len(filter.TableSizes) > 0 && !slices.Contains(filter.TableSizes, r.TableSize) {
+			continue
+		}
+		calc.AddRollup(r.TableSize, r.State)
+	}
+	return calc.Compute(), true, nil
+}
+
+func (s *Service) feedHands(ctx context.Context, calc *stats.IncrementalCalculator, f persistence.HandFilter) error {
+	hands, err := s.repo.ListHands(ctx, f)
+	if err != nil {
+		return err
+	}
+	for _, h := range hands {
+		calc.Feed(h)
+	}
+	return nil
+}
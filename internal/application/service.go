@@ -3,52 +3,107 @@ package application
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
-	"runtime"
+	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/logsource"
 	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
 	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/persistence"
 	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
 )
 
+// maxLogLineBytes bounds a single log line; longer lines are truncated.
+const maxLogLineBytes = 4 * 1024 * 1024
+
 // AppService is the interface that the UI layer depends on for log import and stats queries.
 // application.Service satisfies this interface.
 type AppService interface {
 	BootstrapImportAllLogs(ctx context.Context) (string, error)
 	BootstrapImportAllLogsWithProgress(ctx context.Context, onProgress func(BootstrapProgress)) (string, error)
+	// BootstrapImport is BootstrapImportAllLogsWithProgress with a pause
+	// control and byte-level progress reports.
+	BootstrapImport(ctx context.Context, opts BootstrapOptions) (string, error)
+	// ImportArchivedLogs imports logs from archives that were not imported yet.
+	ImportArchivedLogs(ctx context.Context, onProgress func(BootstrapProgress)) (int, error)
 	ChangeLogFile(ctx context.Context, path string) error
+	// FollowLogFile imports a log from its cursor and keeps it live next to
+	// the current one, so ImportLines accepts lines appended to it.
+	FollowLogFile(ctx context.Context, path string) error
+	// UnfollowLogFile stops accepting lines for a log that is no longer watched.
+	UnfollowLogFile(path string)
+	// LiveLogFiles returns the logs ImportLines accepts, the current one first.
+	LiveLogFiles() []string
 	ImportLines(ctx context.Context, sourcePath string, lines []string, startOffset int64, endOffset int64) error
 	Snapshot(ctx context.Context) (*stats.Stats, []*parser.Hand, int, error)
 	Stats(ctx context.Context, filter persistence.HandFilter) (*stats.Stats, int, error)
+	// TiltReport scans the hands matching filter for loss events; Stats
+	// leaves Tilt nil when it summed daily rollups.
+	TiltReport(ctx context.Context, filter persistence.HandFilter) (*stats.TiltReport, error)
+	// StatsBreakdown returns stats grouped by instance type, region or owner,
+	// plus owner display names for BreakdownOwner.
+	StatsBreakdown(ctx context.Context, filter persistence.HandFilter, breakdown stats.Breakdown) ([]stats.StatsGroup, map[string]string, error)
+	// TimeAnalytics returns stats by hour of day, day of week and session phase.
+	TimeAnalytics(ctx context.Context, filter persistence.HandFilter) (*stats.TimeAnalytics, error)
+	// MetricTrend returns a metric over a rolling window of hands, oldest point first.
+	MetricTrend(ctx context.Context, filter persistence.HandFilter, id stats.MetricID, window, maxPoints int) ([]stats.TrendPoint, error)
+	// CompareFilters returns the differences of the hands matching b from those matching a.
+	CompareFilters(ctx context.Context, a, b persistence.HandFilter) (*stats.Comparison, error)
 	ListHandSummaries(ctx context.Context, f persistence.HandFilter) ([]persistence.HandSummary, int, error)
 	// GetHandByUID returns the full hand data for a single hand UID (for detail view).
 	// Returns nil, nil if not found.
 	GetHandByUID(ctx context.Context, uid string) (*parser.Hand, error)
 	NextOffset(ctx context.Context, path string) (int64, error)
 	MarkLogFullyImported(ctx context.Context, path string)
+	// DiagnosticsReport returns unrecognized-line and anomaly counts per log file.
+	DiagnosticsReport(ctx context.Context) (DiagnosticsReport, error)
+	// ListStatsExcludedHands returns complete hands whose anomalies exclude them
+	// from stats, newest first.
+	ListStatsExcludedHands(ctx context.Context, f persistence.HandFilter) ([]*parser.Hand, error)
+	// ReprocessHands re-parses hands stored by an older parser version from their source logs.
+	ReprocessHands(ctx context.Context, opts ReprocessOptions, onProgress func(ReprocessProgress)) (ReprocessResult, error)
+	// CheckDatabase checks the stored data for damage; RepairDatabase repairs it.
+	CheckDatabase(ctx context.Context) (DatabaseCheck, error)
+	RepairDatabase(ctx context.Context) (DatabaseRepair, error)
+	// HandRawLog returns the stored raw log lines of a hand, or nil if none were kept.
+	HandRawLog(ctx context.Context, uid string) (*persistence.RawLogSnippet, error)
+	// HandAnnotation returns the user's note, tags and star flag on a hand, or nil if it has none.
+	HandAnnotation(ctx context.Context, uid string) (*persistence.HandAnnotation, error)
+	SaveHandAnnotation(ctx context.Context, a persistence.HandAnnotation) error
+	ListHandTags(ctx context.Context) ([]persistence.TagCount, error)
+	// ExportHandsCSV writes the hands matching f with their annotations as CSV.
+	ExportHandsCSV(ctx context.Context, f persistence.HandFilter, w io.Writer) (int, error)
+	// FilterPresets returns the saved filter presets sorted by name.
+	FilterPresets(ctx context.Context) ([]FilterPreset, error)
+	SaveFilterPresets(ctx context.Context, presets []FilterPreset) error
+	// ExportFilterPresets and ImportFilterPresets write and merge JSON preset files.
+	ExportFilterPresets(ctx context.Context, w io.Writer) (int, error)
+	ImportFilterPresets(ctx context.Context, r io.Reader) (int, error)
+	Settings(ctx context.Context) (AppSettings, error)
+	SaveSettings(ctx context.Context, settings AppSettings) error
 	Close() error
 }
 
-type LogFileLocator func() ([]string, error)
+// LogFileLocator returns the VRChat log files and log archives found in the
+// default log locations and in extraDirs, newest first.
+type LogFileLocator func(extraDirs []string) ([]string, error)
 
 type Service struct {
-	mu             sync.RWMutex
-	repo           persistence.ImportRepository
-	parser         *parser.Parser
+	mu   sync.RWMutex
+	repo persistence.ImportRepository
+	// logPath is the current log, whose parser decides the local seat.
 	logPath        string
 	localSeat      int
 	detectLogFiles LogFileLocator
 
-	parsedHands          int
-	currentLineNumber    int64
-	currentHandStartLn   int64
-	currentByteOffset    int64
-	currentHandStartByte int64
+	// live holds the parser state of every log being watched, keyed by path.
+	live map[string]*liveLog
 
 	// Incremental AllTime calculator
 	incMu        sync.Mutex
@@ -59,32 +114,63 @@ type Service struct {
 	// Period-filter cache (keyed by filter + localSeat + handCount)
 	cacheMu    sync.Mutex
 	statsCache map[statsCacheKey]*stats.Stats
+	tiltCache  map[statsCacheKey]*stats.TiltReport
+
+	// rollupMu serializes rebuilds of the daily stats rollups.
+	rollupMu sync.Mutex
+
+	// Parser diagnostics keyed by source path
+	diagMu      sync.Mutex
+	diagnostics map[string]*parser.Diagnostics
+
+	// reprocessMu ensures only one ReprocessHands run at a time.
+	reprocessMu sync.Mutex
+
+	settingsMu sync.RWMutex
+	settings   AppSettings
+}
+
+// liveLog is the parser state at the end of a log that is still being
+// written, so appended lines continue where the import stopped.
+type liveLog struct {
+	parser        *parser.Parser
+	parsedHands   int
+	lineNumber    int64
+	handStartLn   int64
+	byteOffset    int64
+	handStartByte int64
+	// fingerprint covers less than logsource.FingerprintSize bytes, or is
+	// zero, until the log is long enough.
+	fingerprint logsource.Fingerprint
 }
 
 type statsCacheKey struct {
-	fromTime  time.Time
-	toTime    time.Time
-	localSeat int
-	handCount int
+	fromTime   time.Time
+	toTime     time.Time
+	localSeat  int
+	handCount  int
+	tableSizes string // table sizes of the filter, if any
+	query      string // canonical text of the filter query, if any
+	conditions string // the filter's other conditions, if any
 }
 
 func NewService(repo persistence.ImportRepository, locator LogFileLocator) *Service {
 	if locator == nil {
-		locator = func() ([]string, error) {
+		locator = func([]string) ([]string, error) {
 			return nil, fmt.Errorf("log file locator is not configured")
 		}
 	}
 
 	return &Service{
-		repo:               repo,
-		parser:             parser.NewParser(),
-		localSeat:          -1,
-		currentHandStartLn: 0,
-		detectLogFiles:     locator,
+		repo:           repo,
+		localSeat:      -1,
+		live:           make(map[string]*liveLog),
+		detectLogFiles: locator,
+		settings:       loadSettings(repo),
 	}
 }
 
-// BootstrapProgress carries per-file progress information during bootstrap import.
+// BootstrapProgress carries progress information during bootstrap import.
 type BootstrapProgress struct {
 	// Current is the 1-based index of the file currently being imported.
 	Current int
@@ -94,6 +180,19 @@ type BootstrapProgress struct {
 	Path string
 	// Skipped is the number of files skipped (already fully imported).
 	Skipped int
+	// BytesDone and BytesTotal count the log bytes parsed and to parse.
+	BytesDone  int64
+	BytesTotal int64
+	// Hands is the number of new hands found so far.
+	Hands int
+	// ETA estimates the time left from the parse rate so far; it is 0 when
+	// unknown.
+	ETA time.Duration
+	// Paused reports whether the import waits for ImportControl.Resume.
+	Paused bool
+	// Files holds the state of every file to import, historical logs first
+	// in import order, then the live logs.
+	Files []FileProgress
 }
 
 func (s *Service) BootstrapImportAllLogs(ctx context.Context) (string, error) {
@@ -105,63 +204,100 @@ type parseResult struct {
 	path          string
 	hands         []persistence.PersistedHand
 	parser        *parser.Parser
+	diagnostics   *parser.Diagnostics
 	byteOffset    int64
 	lineNumber    int64
 	handStartLn   int64
 	handStartByte int64
 	parsedHands   int
+	fingerprint   logsource.Fingerprint
 	err           error
 }
 
 // parseWorker parses a single log file and sends the result on out.
-// It does not touch the database.
-func parseWorker(ctx context.Context, path string, out chan<- parseResult) {
+// It does not touch the database. When keepRawLogs is set each hand carries
+// its raw log snippet. counter may be nil.
+func parseWorker(ctx context.Context, path string, keepRawLogs bool, counter *fileCounter, out chan<- parseResult) {
 	result := parseResult{path: path}
 
-	f, err := os.Open(path)
+	f, err := logsource.Open(path)
 	if err != nil {
 		result.err = err
 		out <- result
 		return
 	}
 	defer f.Close()
+	if size, err := f.Seek(0, io.SeekEnd); err == nil {
+		counter.begin(size)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		result.err = err
+		out <- result
+		return
+	}
 
 	p := parser.NewParser()
+	diag := parser.NewDiagnostics()
 	lineNo := int64(0)
 	handStartLn := int64(0)
 	byteOffset := int64(0)
 	handStartByte := int64(0)
 	parsedHands := 0
 
-	scanner := bufio.NewScanner(f)
-	scanner.Buffer(make([]byte, 1024*1024), 4*1024*1024)
-	for scanner.Scan() {
+	// The file is finished, so a last line without a newline is parsed
+	// like any other; its real length keeps the cursor at the end of the
+	// file.
+	r := bufio.NewReaderSize(f, 1024*1024)
+	for {
 		if ctx.Err() != nil {
 			result.err = ctx.Err()
 			out <- result
 			return
 		}
-		line := scanner.Text()
+		line, n, truncated, err := logsource.ReadLine(r, maxLogLineBytes)
+		last := errors.Is(err, io.EOF)
+		if last && n == 0 {
+			break
+		}
+		if err != nil && !last {
+			result.err = err
+			out <- result
+			return
+		}
+		if truncated {
+			slog.Warn("log line too long, truncated", "path", path, "offset", byteOffset, "bytes", n, "kept", len(line))
+		}
 		lineStartByte := byteOffset
 		lineNo++
-		byteOffset += int64(len(line)) + 1
+		byteOffset += n
 
 		markHandStart(line, lineNo, lineStartByte, &handStartLn, &handStartByte)
 
+		diag.ObserveLine(lineNo, line)
 		_ = p.ParseLine(line)
 		if p.HandCount() > parsedHands {
 			hands := p.GetHands()
 			newRows := collectNewPersistedHands(path, hands, &parsedHands, lineNo, &handStartLn, &handStartByte, lineStartByte, byteOffset)
+			observeHands(diag, newRows)
+			if keepRawLogs {
+				attachRawLogs(f, newRows)
+			}
+			counter.addHands(len(newRows))
 			result.hands = append(result.hands, newRows...)
 		}
-	}
-	if err := scanner.Err(); err != nil {
-		result.err = err
-		out <- result
-		return
+		if err := counter.advance(ctx, byteOffset, lineNo); err != nil {
+			result.err = err
+			out <- result
+			return
+		}
+		if last {
+			break
+		}
 	}
 
+	result.fingerprint = logFingerprint(path, f)
 	result.parser = p
+	result.diagnostics = diag
 	result.byteOffset = byteOffset
 	result.lineNumber = lineNo
 	result.handStartLn = handStartLn
@@ -173,21 +309,33 @@ func parseWorker(ctx context.Context, path string, out chan<- parseResult) {
 // BootstrapImportAllLogsWithProgress imports all log files, calling onProgress after
 // each file is saved to the database. Files whose is_fully_imported flag is set are
 // skipped. Non-active files are parsed concurrently using a worker pool; the DB
-// writes are serialized. onProgress may be nil.
+// writes are serialized. Logs inside archives are imported as historical files
+// with one cursor per member. onProgress may be nil.
+//
+// The returned path is the newest plain log file, which should be watched. It
+// is empty when only archived logs were found.
 func (s *Service) BootstrapImportAllLogsWithProgress(ctx context.Context, onProgress func(BootstrapProgress)) (string, error) {
+	return s.BootstrapImport(ctx, BootstrapOptions{OnProgress: onProgress})
+}
+
+// BootstrapImport imports all log files like BootstrapImportAllLogsWithProgress.
+// opts.Control pauses the import between lines; cancelling ctx stops it, and
+// the logs saved until then stay imported.
+func (s *Service) BootstrapImport(ctx context.Context, opts BootstrapOptions) (string, error) {
 	if err := ctx.Err(); err != nil {
 		return "", err
 	}
 
-	paths, err := s.detectLogFiles()
+	paths, err := s.detectLogFiles(s.currentSettings().ExtraLogDirs)
 	if err != nil {
 		return "", err
 	}
 	if len(paths) == 0 {
 		return "", fmt.Errorf("no log files found")
 	}
+	paths, archives := splitArchivePaths(paths)
 
-	slog.Info("bootstrapping log import", "files", len(paths))
+	slog.Info("bootstrapping log import", "files", len(paths), "archives", len(archives))
 
 	// DetectAllLogFiles returns newest first. Import oldest -> newest.
 	reversed := make([]string, len(paths))
@@ -195,143 +343,327 @@ func (s *Service) BootstrapImportAllLogsWithProgress(ctx context.Context, onProg
 		reversed[i] = paths[len(paths)-1-i]
 	}
 
+	// The newest log of every directory may still be written to, by this or
+	// another VRChat install, and is kept live. The newest of them becomes
+	// the current log.
+	active := newestLogPerDir(paths)
+	activeFiles := make([]string, 0, len(active))
+	activeFile := ""
+	if len(reversed) > 0 {
+		activeFile = reversed[len(reversed)-1]
+	}
+
 	// Pre-check which non-active files can be skipped (is_fully_imported=1).
 	skipped := 0
-	toImport := make([]string, 0, len(reversed))
-	for i, p := range reversed {
-		isLast := i == len(reversed)-1
-		if !isLast {
-			cursor, cerr := s.repo.GetCursor(ctx, p)
-			if cerr == nil && cursor != nil && cursor.IsFullyImported {
-				slog.Debug("skipping fully-imported file", "path", p)
-				skipped++
-				continue
+	historicalFiles := make([]string, 0, len(reversed))
+	var movedCursors []persistence.ImportCursor
+	for _, p := range reversed {
+		if active[p] {
+			activeFiles = append(activeFiles, p)
+			continue
+		}
+		cursor, moved, cerr := s.resumeCursor(ctx, p, nil)
+		if cerr == nil && cursor != nil && cursor.IsFullyImported {
+			slog.Debug("skipping fully-imported file", "path", p)
+			if moved {
+				movedCursors = append(movedCursors, *cursor)
 			}
+			skipped++
+			continue
 		}
-		toImport = append(toImport, p)
+		historicalFiles = append(historicalFiles, p)
 	}
 
-	// Separate the last (active) file from historical files.
-	activeFile := reversed[len(reversed)-1]
-	historicalFiles := toImport
-	if len(historicalFiles) > 0 && historicalFiles[len(historicalFiles)-1] == activeFile {
-		historicalFiles = historicalFiles[:len(historicalFiles)-1]
+	// Archived logs predate the plain files, so they are imported first.
+	scan := s.pendingArchiveMembers(ctx, archives)
+	skipped += scan.skipped
+	movedCursors = append(movedCursors, scan.moved...)
+	historicalFiles = append(scan.members, historicalFiles...)
+
+	tracker := newProgressTracker(historicalFiles, activeFiles, skipped, opts)
+	stopReports := tracker.run()
+	defer stopReports()
+
+	if err := s.importHistoricalFiles(ctx, historicalFiles, tracker); err != nil {
+		return "", err
 	}
+	s.saveMovedCursors(ctx, movedCursors)
+	s.markArchivesImported(ctx, scan.expanded)
 
-	prog := BootstrapProgress{Total: len(toImport), Skipped: skipped}
+	if activeFile == "" {
+		slog.Info("bootstrap import complete without a live log", "archives", len(archives), "skipped", skipped)
+		return "", nil
+	}
 
-	// --- Parallel parse of historical files ---
-	if len(historicalFiles) > 0 {
-		workers := runtime.GOMAXPROCS(0)
-		if workers > 4 {
-			workers = 4
+	// --- Import the active files serially to activate parser state ---
+	for j, p := range activeFiles {
+		if err := opts.Control.wait(ctx); err != nil {
+			return "", err
 		}
-		if workers < 1 {
-			workers = 1
+		i := len(historicalFiles) + j
+		tracker.start(i, FileParsing)
+		if err := s.followLogFile(ctx, p, tracker.counter(i)); err != nil {
+			tracker.setState(i, FileFailed)
+			if p == activeFile || ctx.Err() != nil {
+				return "", fmt.Errorf("import active file %q: %w", p, err)
+			}
+			// Another install's log must not keep the current one from
+			// loading.
+			slog.Warn("failed to import active log", "path", p, "error", err)
+			continue
 		}
-		slog.Debug("parallel parse", "files", len(historicalFiles), "workers", workers)
+		tracker.setState(i, FileDone)
+	}
+	s.setCurrentLog(activeFile)
 
-		jobCh := make(chan string, len(historicalFiles))
-		resultCh := make(chan parseResult, workers*2)
+	slog.Info("bootstrap import complete", "files", len(paths), "skipped", skipped, "live", len(activeFiles))
+	return activeFile, nil
+}
 
-		// Launch workers.
-		var wg sync.WaitGroup
-		for range workers {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				for path := range jobCh {
-					if ctx.Err() != nil {
-						return
-					}
-					parseWorker(ctx, path, resultCh)
-				}
-			}()
+// newestLogPerDir returns the first path of every directory in paths, which
+// are sorted newest first.
+func newestLogPerDir(paths []string) map[string]bool {
+	dirs := make(map[string]bool)
+	newest := make(map[string]bool)
+	for _, p := range paths {
+		dir := filepath.Dir(p)
+		if dirs[dir] {
+			continue
 		}
+		dirs[dir] = true
+		newest[p] = true
+	}
+	return newest
+}
 
-		// Feed jobs.
-		for _, p := range historicalFiles {
-			jobCh <- p
+// ImportArchivedLogs imports logs from archives in the log directories that
+// have not been imported yet. It returns the number of archived logs imported.
+// onProgress may be nil.
+func (s *Service) ImportArchivedLogs(ctx context.Context, onProgress func(BootstrapProgress)) (int, error) {
+	paths, err := s.detectLogFiles(s.currentSettings().ExtraLogDirs)
+	if err != nil {
+		return 0, err
+	}
+	_, archives := splitArchivePaths(paths)
+	scan := s.pendingArchiveMembers(ctx, archives)
+	tracker := newProgressTracker(scan.members, nil, scan.skipped, BootstrapOptions{OnProgress: onProgress})
+	stopReports := tracker.run()
+	defer stopReports()
+	if err := s.importHistoricalFiles(ctx, scan.members, tracker); err != nil {
+		p, _ := tracker.snapshot()
+		return p.Current, err
+	}
+	s.saveMovedCursors(ctx, scan.moved)
+	s.markArchivesImported(ctx, scan.expanded)
+	return len(scan.members), nil
+}
+
+// importHistoricalFiles parses files concurrently and saves them in order,
+// marking each fully imported. files may be plain logs or archive members;
+// they are the first logs of tracker.
+//
+// Results are saved as soon as every earlier file is saved. Workers parse at
+// most a window of files ahead of the oldest unsaved one, so memory stays
+// bounded by the window rather than by the number of files.
+func (s *Service) importHistoricalFiles(ctx context.Context, files []string, tracker *progressTracker) error {
+	if len(files) == 0 {
+		return nil
+	}
+	settings := s.currentSettings()
+	workers := min(importWorkerCount(settings.ImportWorkers), len(files))
+	window := 2 * workers
+	slog.Debug("parallel parse", "files", len(files), "workers", workers)
+
+	ctx, cancel := context.WithCancel(ctx)
+	pathIdx := make(map[string]int, len(files))
+	for i, p := range files {
+		pathIdx[p] = i
+	}
+	// A file takes a slot from the time it is queued until it is saved.
+	slots := make(chan struct{}, window)
+	jobCh := make(chan int)
+	resultCh := make(chan parseResult, window)
+
+	go func() {
+		defer close(jobCh)
+		for i := range files {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobCh <- i:
+			case <-ctx.Done():
+				return
+			}
 		}
-		close(jobCh)
+	}()
 
-		// Wait for all workers then close resultCh.
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
 		go func() {
-			wg.Wait()
-			close(resultCh)
+			defer wg.Done()
+			for i := range jobCh {
+				if ctx.Err() != nil {
+					return
+				}
+				tracker.setState(i, FileParsing)
+				parseWorker(ctx, files[i], settings.KeepRawLogs, tracker.counter(i), resultCh)
+			}
 		}()
+	}
+	go func() {
+		wg.Wait()
+		close(resultCh)
+	}()
+	// Stop the workers and wait for them when returning early.
+	defer func() {
+		cancel()
+		for range resultCh {
+		}
+	}()
+
+	pending := make(map[int]parseResult, window)
+	next := 0
+	for next < len(files) {
+		res, ok := <-resultCh
+		if !ok {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return fmt.Errorf("parse workers stopped after %d of %d files", next, len(files))
+		}
+		pending[pathIdx[res.path]] = res
 
-		// Collect results and write to DB serially.
-		// We collect all results first to report progress in order; results arrive
-		// out-of-order because workers run in parallel.
-		type indexedResult struct {
-			idx int
-			res parseResult
-		}
-		// Build a path-to-index map for progress ordering.
-		pathIdx := make(map[string]int, len(historicalFiles))
-		for i, p := range historicalFiles {
-			pathIdx[p] = i
-		}
-		collected := make([]parseResult, len(historicalFiles))
-		seen := 0
-		for res := range resultCh {
-			idx := pathIdx[res.path]
-			collected[idx] = res
-			seen++
-		}
-
-		// Now write to DB in order (oldest → newest).
-		for i, res := range collected {
-			if ctx.Err() != nil {
-				return "", ctx.Err()
+		// Write to DB in order (oldest → newest).
+		for {
+			res, ok := pending[next]
+			if !ok {
+				break
 			}
+			delete(pending, next)
 			if res.err != nil {
-				return "", fmt.Errorf("parse %q: %w", res.path, res.err)
+				tracker.setState(next, FileFailed)
+				return fmt.Errorf("parse %q: %w", res.path, res.err)
+			}
+			if err := tracker.control.wait(ctx); err != nil {
+				return err
 			}
 
+			tracker.setState(next, FileSaving)
 			cursor := buildImportCursorWithContext(res.path, res.byteOffset, res.lineNumber, res.parser)
 			cursor.IsFullyImported = true
+			cursor.Fingerprint, cursor.FingerprintSize = res.fingerprint.Hash, res.fingerprint.Size
 			if err := s.saveImportBatch(ctx, res.hands, cursor); err != nil {
-				return "", fmt.Errorf("save %q: %w", res.path, err)
-			}
-			if len(res.hands) > 0 {
-				if earliest, ok := earliestStartTime(res.hands); ok {
-					s.resetIncrementalIfNeeded(earliest)
-				}
+				tracker.setState(next, FileFailed)
+				return fmt.Errorf("save %q: %w", res.path, err)
 			}
+			s.recordDiagnostics(res.path, res.diagnostics, true)
+			<-slots
 
-			prog.Current++
-			prog.Path = res.path
-			prog.Skipped = skipped + i
-			if onProgress != nil {
-				onProgress(prog)
-			}
+			tracker.start(next, FileDone)
 			slog.Debug("historical file imported", "path", res.path, "hands", len(res.hands))
+			next++
 		}
 	}
+	return nil
+}
 
-	// --- Import the active (latest) file serially to activate parser state ---
-	if ctx.Err() != nil {
-		return "", ctx.Err()
-	}
-	prog.Current++
-	prog.Path = activeFile
-	if onProgress != nil {
-		onProgress(prog)
+// splitArchivePaths separates log archives from plain log files, keeping the
+// order of both.
+func splitArchivePaths(paths []string) (logs, archives []string) {
+	for _, p := range paths {
+		if logsource.IsArchive(p) {
+			archives = append(archives, p)
+		} else {
+			logs = append(logs, p)
+		}
 	}
-	// Attempt to resume from existing cursor (world context + byte offset).
-	activeCursor, cerr := s.repo.GetCursor(ctx, activeFile)
-	if cerr != nil {
-		slog.Warn("failed to load cursor for active file, scanning from start", "path", activeFile, "error", cerr)
-		activeCursor = nil
+	return logs, archives
+}
+
+// expandedArchive is a multi-member archive whose members were queued for
+// import. Its own cursor records the archive size once all members are saved
+// so an unchanged archive is not re-read on the next startup.
+type expandedArchive struct {
+	path string
+	size int64
+}
+
+// archiveScan is the pending work found in log archives.
+type archiveScan struct {
+	// members are the archived logs to import, oldest first.
+	members  []string
+	expanded []expandedArchive
+	// moved are the cursors taken over by archived logs that were imported
+	// under another path; they are saved once the import finished.
+	moved   []persistence.ImportCursor
+	skipped int
+}
+
+// pendingArchiveMembers lists the archived logs that are not fully imported
+// yet. It reads every archive once and writes nothing. Unreadable archives
+// are logged and skipped.
+func (s *Service) pendingArchiveMembers(ctx context.Context, archives []string) archiveScan {
+	var (
+		scan    archiveScan
+		members []logsource.Member
+	)
+	for _, a := range archives {
+		info, err := os.Stat(a)
+		if err != nil {
+			slog.Warn("log archive unavailable", "path", a, "error", err)
+			continue
+		}
+		// A .gz file holds a single log keyed by the archive path itself, so
+		// its member cursor already covers it.
+		multi := !logsource.IsMemberPath(a)
+		if multi {
+			cursor, cerr := s.repo.GetCursor(ctx, a)
+			if cerr == nil && cursor != nil && cursor.IsFullyImported && cursor.NextByteOffset == info.Size() {
+				slog.Debug("skipping fully-imported archive", "path", a)
+				continue
+			}
+		}
+		list, err := logsource.ListMembers(a)
+		if err != nil {
+			slog.Warn("failed to read log archive", "path", a, "error", err)
+			continue
+		}
+		for _, m := range list {
+			cursor, moved, cerr := s.resumeCursor(ctx, m.Path, m.Head)
+			if cerr == nil && cursor != nil && cursor.IsFullyImported {
+				if moved {
+					scan.moved = append(scan.moved, *cursor)
+				}
+				scan.skipped++
+				continue
+			}
+			members = append(members, m)
+		}
+		if multi {
+			scan.expanded = append(scan.expanded, expandedArchive{path: a, size: info.Size()})
+		}
 	}
-	if err := s.importFileFrom(ctx, activeFile, true, activeCursor); err != nil {
-		return "", fmt.Errorf("import active file %q: %w", activeFile, err)
+	slices.SortStableFunc(members, func(a, b logsource.Member) int {
+		return a.ModTime.Compare(b.ModTime)
+	})
+	scan.members = make([]string, len(members))
+	for i, m := range members {
+		scan.members[i] = m.Path
 	}
+	return scan
+}
 
-	slog.Info("bootstrap import complete", "files", len(paths), "skipped", skipped)
-	return paths[0], nil
+func (s *Service) markArchivesImported(ctx context.Context, archives []expandedArchive) {
+	for _, a := range archives {
+		cursor := persistence.ImportCursor{SourcePath: a.path, NextByteOffset: a.size, IsFullyImported: true}
+		if err := s.repo.SaveCursor(ctx, cursor); err != nil {
+			slog.Warn("failed to mark log archive as imported", "path", a.path, "error", err)
+		}
+	}
 }
 
 // MarkLogFullyImported marks the given log file path as fully imported in the
@@ -346,19 +678,155 @@ func (s *Service) MarkLogFullyImported(ctx context.Context, path string) {
 	}
 }
 
+// ChangeLogFile imports path and makes it the current log. The previous
+// current log is no longer live.
 func (s *Service) ChangeLogFile(ctx context.Context, path string) error {
-	return s.importFile(ctx, path, true)
+	if err := s.importFileFrom(ctx, path, true, nil, nil); err != nil {
+		return err
+	}
+	s.setCurrentLog(path)
+	return nil
+}
+
+// FollowLogFile imports path from its cursor and keeps it live. It becomes
+// the current log when there is none.
+func (s *Service) FollowLogFile(ctx context.Context, path string) error {
+	if err := s.followLogFile(ctx, path, nil); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	current := s.logPath
+	s.mu.Unlock()
+	if current == "" {
+		s.setCurrentLog(path)
+	}
+	return nil
+}
+
+func (s *Service) followLogFile(ctx context.Context, path string, counter *fileCounter) error {
+	// Attempt to resume from existing cursor (world context + byte offset).
+	cursor, _, err := s.resumeCursor(ctx, path, nil)
+	if err != nil {
+		slog.Warn("failed to load cursor for active file, scanning from start", "path", path, "error", err)
+		cursor = nil
+	}
+	return s.importFileFrom(ctx, path, true, cursor, counter)
+}
+
+// resumeCursor returns the cursor of path. A log without one whose content
+// matches a known log, because it was moved, renamed, archived or copied from
+// another machine, takes over that log's cursor, so it is not parsed again
+// from the start; moved reports it. The taken-over cursor is keyed by path
+// but not saved; the import of path saves it. head is the start of the log,
+// or nil to read it from path.
+func (s *Service) resumeCursor(ctx context.Context, path string, head logsource.Head) (cursor *persistence.ImportCursor, moved bool, err error) {
+	cursor, err = s.repo.GetCursor(ctx, path)
+	if err != nil || cursor != nil {
+		return cursor, false, err
+	}
+	if head == nil {
+		if head, err = logsource.ReadHeadPath(path); err != nil {
+			slog.Debug("failed to fingerprint log", "path", path, "error", err)
+			return nil, false, nil
+		}
+	}
+	sizes, err := s.repo.FingerprintSizes(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	// A known log may have been shorter than FingerprintSize when it was
+	// fingerprinted, so the head is compared at the size of each stored
+	// fingerprint.
+	for _, size := range sizes {
+		fp := head.Prefix(size)
+		if fp.IsZero() {
+			continue
+		}
+		known, err := s.repo.FindCursorByFingerprint(ctx, fp.Hash, fp.Size)
+		if err != nil {
+			return nil, false, err
+		}
+		if known == nil {
+			continue
+		}
+		slog.Info("log recognized by content, resuming from its previous cursor", "path", path, "previous", known.SourcePath, "offset", known.NextByteOffset)
+		taken := *known
+		taken.SourcePath = path
+		taken.UpdatedAt = time.Time{}
+		return &taken, true, nil
+	}
+	return nil, false, nil
+}
+
+// saveMovedCursors saves the cursors that logs skipped as already imported
+// took over from their previous path.
+func (s *Service) saveMovedCursors(ctx context.Context, cursors []persistence.ImportCursor) {
+	for _, c := range cursors {
+		if err := s.repo.SaveCursor(ctx, c); err != nil {
+			slog.Warn("failed to save cursor of moved log", "path", c.SourcePath, "error", err)
+		}
+	}
+}
+
+// logFingerprint returns the content fingerprint of an open log, or the zero
+// Fingerprint when it has none or cannot be read.
+func logFingerprint(path string, r io.ReaderAt) logsource.Fingerprint {
+	fingerprint, err := logsource.ReadFingerprint(r)
+	if err != nil {
+		slog.Debug("failed to fingerprint log", "path", path, "error", err)
+	}
+	return fingerprint
+}
+
+// UnfollowLogFile drops the live state of path.
+func (s *Service) UnfollowLogFile(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.live, path)
+	if path == s.logPath {
+		s.logPath = ""
+	}
+}
+
+// LiveLogFiles returns the live logs, the current log first and the others
+// sorted by path.
+func (s *Service) LiveLogFiles() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]string, 0, len(s.live))
+	for p := range s.live {
+		if p != s.logPath {
+			out = append(out, p)
+		}
+	}
+	slices.Sort(out)
+	if _, ok := s.live[s.logPath]; ok {
+		out = slices.Insert(out, 0, s.logPath)
+	}
+	return out
 }
 
-func (s *Service) importFile(ctx context.Context, path string, activate bool) error {
-	return s.importFileFrom(ctx, path, activate, nil)
+// setCurrentLog makes the live log path the current one, dropping the live
+// state of the previous current log.
+func (s *Service) setCurrentLog(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.logPath != path {
+		delete(s.live, s.logPath)
+	}
+	s.logPath = path
+	if live := s.live[path]; live != nil {
+		s.localSeat = live.parser.GetLocalSeat()
+	}
 }
 
 // importFileFrom imports path, optionally resuming from cursor's byte offset.
 // If cursor is non-nil and has WorldCtx, the parser is restored from context
 // and parsing begins at cursor.NextByteOffset (skipping already-processed bytes).
-func (s *Service) importFileFrom(ctx context.Context, path string, activate bool, cursor *persistence.ImportCursor) error {
-	slog.Debug("importing file", "path", path, "activate", activate)
+// With live set the final parser state is kept for ImportLines. counter may
+// be nil.
+func (s *Service) importFileFrom(ctx context.Context, path string, live bool, cursor *persistence.ImportCursor, counter *fileCounter) error {
+	slog.Debug("importing file", "path", path, "live", live)
 	f, err := os.Open(path)
 	if err != nil {
 		return err
@@ -383,70 +851,87 @@ func (s *Service) importFileFrom(ctx context.Context, path string, activate bool
 		}
 	}
 
+	if info, err := f.Stat(); err == nil {
+		counter.begin(max(info.Size()-startByte, 0))
+	}
+
 	lineNo := int64(0)
 	handStartLn := int64(0)
 	byteOffset := startByte
 	handStartByte := startByte
 	parsedHands := p.HandCount() // already-restored hands don't count as new
-	var earliestNewStart time.Time
-	hasNewStart := false
-
-	scanner := bufio.NewScanner(f)
-	scanner.Buffer(make([]byte, 1024*1024), 4*1024*1024)
-	for scanner.Scan() {
+	diag := parser.NewDiagnostics()
+	keepRawLogs := s.currentSettings().KeepRawLogs
+
+	// The file may be written while it is read: a last line without a
+	// newline is held back, leaving the cursor at the end of the last
+	// complete line so the watcher resumes there once the line is finished.
+	r := bufio.NewReaderSize(f, 1024*1024)
+	for {
 		if err := ctx.Err(); err != nil {
 			return err
 		}
 
-		line := scanner.Text()
+		line, n, truncated, err := logsource.ReadLine(r, maxLogLineBytes)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if truncated {
+			slog.Warn("log line too long, truncated", "path", path, "offset", byteOffset, "bytes", n, "kept", len(line))
+		}
 		lineStartByte := byteOffset
 		lineNo++
-		byteOffset += int64(len(line)) + 1
+		byteOffset += n
 
 		markHandStart(line, lineNo, lineStartByte, &handStartLn, &handStartByte)
 
+		diag.ObserveLine(lineNo, line)
 		_ = p.ParseLine(line)
 		if p.HandCount() > parsedHands {
 			hands := p.GetHands()
 			newRows := collectNewPersistedHands(path, hands, &parsedHands, lineNo, &handStartLn, &handStartByte, lineStartByte, byteOffset)
-			for _, row := range newRows {
-				if row.Hand == nil || row.Hand.StartTime.IsZero() {
-					continue
-				}
-				if !hasNewStart || row.Hand.StartTime.Before(earliestNewStart) {
-					earliestNewStart = row.Hand.StartTime
-					hasNewStart = true
-				}
+			observeHands(diag, newRows)
+			if keepRawLogs {
+				attachRawLogs(f, newRows)
 			}
 			cursor := buildImportCursorWithContext(path, byteOffset, lineNo, p)
 			if err := s.saveImportBatch(ctx, newRows, cursor); err != nil {
 				return fmt.Errorf("save imported hands: %w", err)
 			}
+			counter.addHands(len(newRows))
+		}
+		if err := counter.advance(ctx, byteOffset-startByte, lineNo); err != nil {
+			return err
 		}
-	}
-	if err := scanner.Err(); err != nil {
-		return err
 	}
 
-	if err := s.saveImportBatch(ctx, nil, buildImportCursorWithContext(path, byteOffset, lineNo, p)); err != nil {
+	fingerprint := logFingerprint(path, f)
+	final := buildImportCursorWithContext(path, byteOffset, lineNo, p)
+	final.Fingerprint, final.FingerprintSize = fingerprint.Hash, fingerprint.Size
+	if err := s.saveImportBatch(ctx, nil, final); err != nil {
 		return err
 	}
-	if hasNewStart {
-		s.resetIncrementalIfNeeded(earliestNewStart)
-	}
+	s.recordDiagnostics(path, diag, startByte == 0)
 
 	s.invalidateStatsCache()
 
-	if activate {
+	if live {
 		s.mu.Lock()
-		s.parser = p
-		s.logPath = path
-		s.localSeat = p.GetLocalSeat()
-		s.parsedHands = parsedHands
-		s.currentLineNumber = lineNo
-		s.currentHandStartLn = maxInt64(handStartLn, 1)
-		s.currentByteOffset = byteOffset
-		s.currentHandStartByte = maxInt64(handStartByte, 0)
+		s.live[path] = &liveLog{
+			parser:        p,
+			parsedHands:   parsedHands,
+			lineNumber:    lineNo,
+			handStartLn:   maxInt64(handStartLn, 1),
+			byteOffset:    byteOffset,
+			handStartByte: maxInt64(handStartByte, 0),
+			fingerprint:   fingerprint,
+		}
+		if path == s.logPath {
+			s.localSeat = p.GetLocalSeat()
+		}
 		s.mu.Unlock()
 	}
 
@@ -463,17 +948,19 @@ func (s *Service) ImportLines(ctx context.Context, sourcePath string, lines []st
 	if sourcePath == "" {
 		sourcePath = s.logPath
 	}
-	if sourcePath == "" || sourcePath != s.logPath {
+	live := s.live[sourcePath]
+	if live == nil {
 		s.mu.RUnlock()
 		return nil
 	}
 
-	workingParser := s.parser.Clone()
-	parsedHands := s.parsedHands
-	lineNo := s.currentLineNumber
-	handStartLn := s.currentHandStartLn
-	byteOffset := s.currentByteOffset
-	handStartByte := s.currentHandStartByte
+	workingParser := live.parser.Clone()
+	parsedHands := live.parsedHands
+	lineNo := live.lineNumber
+	handStartLn := live.handStartLn
+	byteOffset := live.byteOffset
+	handStartByte := live.handStartByte
+	fingerprint := live.fingerprint
 	s.mu.RUnlock()
 
 	if startOffset > 0 {
@@ -484,6 +971,7 @@ func (s *Service) ImportLines(ctx context.Context, sourcePath string, lines []st
 	}
 
 	newRows := make([]persistence.PersistedHand, 0)
+	diag := parser.NewDiagnostics()
 	for i, line := range lines {
 		if err := ctx.Err(); err != nil {
 			return err
@@ -501,6 +989,7 @@ func (s *Service) ImportLines(ctx context.Context, sourcePath string, lines []st
 
 		markHandStart(line, lineNo, lineStartByte, &handStartLn, &handStartByte)
 
+		diag.ObserveLine(lineNo, line)
 		_ = workingParser.ParseLine(line)
 		if workingParser.HandCount() > parsedHands {
 			hands := workingParser.GetHands()
@@ -512,68 +1001,48 @@ func (s *Service) ImportLines(ctx context.Context, sourcePath string, lines []st
 		byteOffset = endOffset
 	}
 
+	if s.currentSettings().KeepRawLogs {
+		attachRawLogsFromPath(sourcePath, newRows)
+	}
+	// A short log's fingerprint grows with it up to FingerprintSize.
+	if fingerprint.Size < logsource.FingerprintSize && byteOffset > int64(fingerprint.Size) {
+		if head, err := logsource.ReadHeadPath(sourcePath); err == nil {
+			fingerprint = head.Fingerprint()
+		} else {
+			slog.Debug("failed to fingerprint log", "path", sourcePath, "error", err)
+		}
+	}
 	cursor := buildImportCursorWithContext(sourcePath, byteOffset, lineNo, workingParser)
+	cursor.Fingerprint, cursor.FingerprintSize = fingerprint.Hash, fingerprint.Size
 	if err := s.saveImportBatch(ctx, newRows, cursor); err != nil {
 		return err
 	}
-	if len(newRows) > 0 {
-		earliestNewStart, hasNewStart := earliestStartTime(newRows)
-		if hasNewStart {
-			s.resetIncrementalIfNeeded(earliestNewStart)
-		}
-	}
+	observeHands(diag, newRows)
+	s.recordDiagnostics(sourcePath, diag, false)
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if sourcePath != s.logPath {
+	if s.live[sourcePath] != live {
+		// Unfollowed or re-imported meanwhile.
 		return nil
 	}
-	s.parser = workingParser
-	s.localSeat = workingParser.GetLocalSeat()
-	s.parsedHands = parsedHands
-	s.currentLineNumber = lineNo
-	s.currentHandStartLn = maxInt64(handStartLn, 1)
-	s.currentByteOffset = byteOffset
-	s.currentHandStartByte = maxInt64(handStartByte, 0)
+	s.live[sourcePath] = &liveLog{
+		parser:        workingParser,
+		parsedHands:   parsedHands,
+		lineNumber:    lineNo,
+		handStartLn:   maxInt64(handStartLn, 1),
+		byteOffset:    byteOffset,
+		handStartByte: maxInt64(handStartByte, 0),
+		fingerprint:   fingerprint,
+	}
+	if sourcePath == s.logPath {
+		s.localSeat = workingParser.GetLocalSeat()
+	}
 
 	s.invalidateStatsCache()
 	return nil
 }
 
-func earliestStartTime(rows []persistence.PersistedHand) (time.Time, bool) {
-	var earliest time.Time
-	has := false
-	for _, row := range rows {
-		if row.Hand == nil || row.Hand.StartTime.IsZero() {
-			continue
-		}
-		if !has || row.Hand.StartTime.Before(earliest) {
-			earliest = row.Hand.StartTime
-			has = true
-		}
-	}
-	return earliest, has
-}
-
-func (s *Service) resetIncrementalIfNeeded(earliestNewStart time.Time) {
-	if earliestNewStart.IsZero() {
-		return
-	}
-	s.incMu.Lock()
-	defer s.incMu.Unlock()
-	if s.incCalc == nil {
-		return
-	}
-	if s.watermark.IsZero() {
-		return
-	}
-	if earliestNewStart.After(s.watermark) {
-		return
-	}
-	s.incCalc = nil
-	s.watermark = time.Time{}
-}
-
 func (s *Service) LogPath() string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -624,25 +1093,41 @@ func (s *Service) ListHandSummaries(ctx context.Context, f persistence.HandFilte
 	return s.repo.ListHandSummaries(ctx, f)
 }
 
+// ListStatsExcludedHands returns complete hands excluded from stats by their
+// anomalies, ordered by start_time DESC. f.Limit caps the result when set.
+func (s *Service) ListStatsExcludedHands(ctx context.Context, f persistence.HandFilter) ([]*parser.Hand, error) {
+	f.OnlyComplete = true
+	f.OnlyStatsExcluded = true
+	f.NewestFirst = true
+	hands, err := s.repo.ListHands(ctx, f)
+	if err != nil {
+		return nil, fmt.Errorf("list stats-excluded hands: %w", err)
+	}
+	return hands, nil
+}
+
 // Stats returns aggregated stats for the given filter.
-// When no time range is set (AllTime mode) it uses an IncrementalCalculator with a
-// watermark so only new hands are re-processed on each call.
-// For period-filter modes a small LRU-style cache (keyed by filter + hand count)
-// avoids redundant full-scan calculations.
+// When the filter sets nothing but at most one table size (AllTime mode) it
+// uses an IncrementalCalculator with a watermark so only new hands are
+// re-processed on each call. The calculator is persisted with its watermark,
+// so a restart resumes where it left off.
+// For other filters the stats are summed from persisted daily rollups where
+// possible, leaving Tilt nil (see TiltReport), and a small LRU-style cache
+// (keyed by filter + hand count) avoids redundant calculations.
 func (s *Service) Stats(ctx context.Context, filter persistence.HandFilter) (*stats.Stats, int, error) {
 	s.mu.RLock()
 	localSeat := s.localSeat
 	s.mu.RUnlock()
 
-	if filter.FromTime == nil && filter.ToTime == nil {
-		// AllTime mode — use IncrementalCalculator.
+	if filter.FromTime == nil && filter.ToTime == nil && rollupFilterSupported(filter) && len(filter.TableSizes) <= 1 {
+		// AllTime mode — use IncrementalCalculator, which also splits by
+		// table size.
 		s.incMu.Lock()
 		defer s.incMu.Unlock()
 
 		if s.incCalc == nil || s.incLocalSeat != localSeat {
-			s.incCalc = stats.NewIncrementalCalculator(localSeat)
+			s.incCalc, s.watermark = s.loadIncrementalCalculator(ctx, localSeat)
 			s.incLocalSeat = localSeat
-			s.watermark = time.Time{}
 		}
 
 		newHands, err := s.repo.ListHandsAfter(ctx, s.watermark, localSeat)
@@ -656,31 +1141,24 @@ func (s *Service) Stats(ctx context.Context, filter persistence.HandFilter) (*st
 				s.watermark = h.StartTime
 			}
 		}
+		if len(newHands) > 0 {
+			s.saveIncrementalCalculator(ctx)
+		}
 
-		return s.incCalc.Compute(), localSeat, nil
+		out := s.incCalc.Compute()
+		if len(filter.TableSizes) == 1 {
+			out = out.ForTableSize(filter.TableSizes[0])
+		}
+		return out, localSeat, nil
 	}
 
-	// Period-filter mode — use cache keyed by (fromTime, toTime, localSeat, handCount).
-	count, err := s.repo.CountHands(ctx, filter)
+	// Period-filter mode — use cache keyed by the filter, localSeat and the
+	// hand count.
+	key, err := s.statsCacheKey(ctx, filter, localSeat)
 	if err != nil {
 		return nil, localSeat, err
 	}
 
-	fromTime := time.Time{}
-	if filter.FromTime != nil {
-		fromTime = *filter.FromTime
-	}
-	toTime := time.Time{}
-	if filter.ToTime != nil {
-		toTime = *filter.ToTime
-	}
-	key := statsCacheKey{
-		fromTime:  fromTime,
-		toTime:    toTime,
-		localSeat: localSeat,
-		handCount: count,
-	}
-
 	s.cacheMu.Lock()
 	defer s.cacheMu.Unlock()
 
@@ -688,15 +1166,20 @@ func (s *Service) Stats(ctx context.Context, filter persistence.HandFilter) (*st
 		return cached, localSeat, nil
 	}
 
-	// Cache miss: full compute.
+	// Cache miss: sum the daily rollups when the filter allows it, or fall
+	// back to a full compute.
 	filter.OnlyComplete = true
-	hands, err := s.repo.ListHands(ctx, filter)
+	result, ok, err := s.statsFromRollups(ctx, filter, localSeat)
 	if err != nil {
-		return nil, localSeat, err
+		slog.Warn("stats rollups unavailable, scanning hands", "error", err)
+	}
+	if !ok || err != nil {
+		hands, err := s.repo.ListHands(ctx, filter)
+		if err != nil {
+			return nil, localSeat, err
+		}
+		result = stats.NewCalculator().Calculate(hands, localSeat)
 	}
-
-	calc := stats.NewCalculator()
-	result := calc.Calculate(hands, localSeat)
 
 	if s.statsCache == nil {
 		s.statsCache = make(map[statsCacheKey]*stats.Stats)
@@ -709,12 +1192,72 @@ func (s *Service) Stats(ctx context.Context, filter persistence.HandFilter) (*st
 	return result, localSeat, nil
 }
 
+// statsCacheKey returns the cache key of the stats of filter.
+func (s *Service) statsCacheKey(ctx context.Context, filter persistence.HandFilter, localSeat int) (statsCacheKey, error) {
+	count, err := s.repo.CountHands(ctx, filter)
+	if err != nil {
+		return statsCacheKey{}, err
+	}
+	key := statsCacheKey{localSeat: localSeat, handCount: count}
+	if filter.FromTime != nil {
+		key.fromTime = *filter.FromTime
+	}
+	if filter.ToTime != nil {
+		key.toTime = *filter.ToTime
+	}
+	if len(filter.TableSizes) > 0 {
+		key.tableSizes = fmt.Sprint(filter.TableSizes)
+	}
+	if filter.Query != nil {
+		key.query = filter.Query.String()
+	}
+	seat := -1
+	if filter.LocalSeat != nil {
+		seat = *filter.LocalSeat
+	}
+	key.conditions = fmt.Sprintf("%d %v %v %t %t %q %q", seat, filter.PocketCategoryIDs, filter.FinalClassIDs,
+		filter.OnlyStatsExcluded, filter.OnlyStarred, filter.Tags, filter.AnnotationSearch)
+	return key, nil
+}
+
+// TiltReport returns the tilt report of the hands matching filter. Stats
+// summed from daily rollups carry none, since loss events depend on every
+// hand in order; callers that need it use this scan instead.
+func (s *Service) TiltReport(ctx context.Context, filter persistence.HandFilter) (*stats.TiltReport, error) {
+	s.mu.RLock()
+	localSeat := s.localSeat
+	s.mu.RUnlock()
+
+	key, err := s.statsCacheKey(ctx, filter, localSeat)
+	if err != nil {
+		return nil, err
+	}
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	if cached, ok := s.tiltCache[key]; ok {
+		return cached, nil
+	}
+
+	filter.OnlyComplete = true
+	calc := stats.NewIncrementalCalculator(localSeat)
+	if err := s.feedHands(ctx, calc, filter); err != nil {
+		return nil, fmt.Errorf("list hands for tilt: %w", err)
+	}
+	report := calc.Compute().Tilt
+	if s.tiltCache == nil || len(s.tiltCache) >= 8 {
+		s.tiltCache = make(map[statsCacheKey]*stats.TiltReport)
+	}
+	s.tiltCache[key] = report
+	return report, nil
+}
+
 // invalidateStatsCache clears the period-filter stats cache.
 // The AllTime incremental calculator is NOT reset — it picks up new hands via
 // ListHandsAfter(watermark) on the next Stats() call.
 func (s *Service) invalidateStatsCache() {
 	s.cacheMu.Lock()
 	s.statsCache = nil
+	s.tiltCache = nil
 	s.cacheMu.Unlock()
 }
 
@@ -726,17 +1269,19 @@ func (s *Service) Close() error {
 }
 
 func (s *Service) saveImportBatch(ctx context.Context, hands []persistence.PersistedHand, cursor persistence.ImportCursor) error {
-	if repo, ok := s.repo.(persistence.ImportBatchRepository); ok {
-		_, err := repo.SaveImportBatch(ctx, hands, cursor)
-		return err
-	}
-
-	if len(hands) > 0 {
-		if _, err := s.repo.UpsertHands(ctx, hands); err != nil {
+	return s.writeHands(ctx, hands, nil, func() error {
+		if repo, ok := s.repo.(persistence.ImportBatchRepository); ok {
+			_, err := repo.SaveImportBatch(ctx, hands, cursor)
 			return err
 		}
-	}
-	return s.repo.SaveCursor(ctx, cursor)
+
+		if len(hands) > 0 {
+			if _, err := s.repo.UpsertHands(ctx, hands); err != nil {
+				return err
+			}
+		}
+		return s.repo.SaveCursor(ctx, cursor)
+	})
 }
 
 func maxInt64(a, b int64) int64 {
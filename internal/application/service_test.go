@@ -1,14 +1,28 @@
 package application
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/logsource"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
 	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/persistence"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/watcher"
 )
 
 func TestBootstrapImportAllLogsImportsEachFileOnce(t *testing.T) {
@@ -24,7 +38,7 @@ func TestBootstrapImportAllLogsImportsEachFileOnce(t *testing.T) {
 		t.Fatalf("write new log: %v", err)
 	}
 
-	svc := NewService(persistence.NewMemoryRepository(), func() ([]string, error) {
+	svc := NewService(persistence.NewMemoryRepository(), func([]string) ([]string, error) {
 		return []string{newPath, oldPath}, nil
 	})
 
@@ -45,6 +59,233 @@ func TestBootstrapImportAllLogsImportsEachFileOnce(t *testing.T) {
 	}
 }
 
+func TestBootstrapFollowsNewestLogOfEveryDirectory(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dirA, dirB := t.TempDir(), t.TempDir()
+	oldA := filepath.Join(dirA, "old.log")
+	newA := filepath.Join(dirA, "new.log")
+	pathB := filepath.Join(dirB, "b.log")
+	for path, minute := range map[string]string{oldA: "05:00", newA: "05:10", pathB: "05:20"} {
+		if err := os.WriteFile(path, []byte(testHandLog(minute)), 0o600); err != nil {
+			t.Fatalf("write log: %v", err)
+		}
+	}
+
+	repo := persistence.NewMemoryRepository()
+	svc := NewService(repo, func([]string) ([]string, error) {
+		return []string{pathB, newA, oldA}, nil
+	})
+	latest, err := svc.BootstrapImportAllLogs(ctx)
+	if err != nil {
+		t.Fatalf("bootstrap import: %v", err)
+	}
+	if latest != pathB {
+		t.Fatalf("latest path = %q, want %q", latest, pathB)
+	}
+	if got := svc.LiveLogFiles(); !reflect.DeepEqual(got, []string{pathB, newA}) {
+		t.Fatalf("live logs = %q, want %q", got, []string{pathB, newA})
+	}
+	if cursor, err := repo.GetCursor(ctx, newA); err != nil || cursor == nil || cursor.IsFullyImported {
+		t.Fatalf("cursor of the other install's log = %+v, %v; want not fully imported", cursor, err)
+	}
+
+	countHands := func() int {
+		t.Helper()
+		hands, err := repo.ListHands(ctx, persistence.HandFilter{OnlyComplete: true})
+		if err != nil {
+			t.Fatalf("list hands: %v", err)
+		}
+		return len(hands)
+	}
+	appendHand := func(minute string) {
+		t.Helper()
+		offset, err := svc.NextOffset(ctx, newA)
+		if err != nil {
+			t.Fatalf("next offset: %v", err)
+		}
+		log := testHandLog(minute)
+		lines := strings.Split(strings.TrimSuffix(log, "\n"), "\n")
+		if err := svc.ImportLines(ctx, newA, lines, offset, offset+int64(len(log))); err != nil {
+			t.Fatalf("import lines: %v", err)
+		}
+	}
+
+	// Lines appended to the other install's log are imported.
+	before := countHands()
+	appendHand("05:30")
+	if got := countHands(); got <= before {
+		t.Fatalf("hand count = %d after appending to a followed log, want more than %d", got, before)
+	}
+
+	// Once unfollowed they are not, until the log is followed again.
+	svc.UnfollowLogFile(newA)
+	before = countHands()
+	appendHand("05:40")
+	if got := countHands(); got != before {
+		t.Fatalf("hand count = %d after appending to an unfollowed log, want %d", got, before)
+	}
+	if err := svc.FollowLogFile(ctx, newA); err != nil {
+		t.Fatalf("follow: %v", err)
+	}
+	if got := svc.LiveLogFiles(); !reflect.DeepEqual(got, []string{pathB, newA}) {
+		t.Fatalf("live logs after follow = %q", got)
+	}
+	before = countHands()
+	appendHand("05:50")
+	if got := countHands(); got <= before {
+		t.Fatalf("hand count = %d after following again, want more than %d", got, before)
+	}
+}
+
+// batchCountingRepo counts the hands written through SaveImportBatch.
+type batchCountingRepo struct {
+	*persistence.MemoryRepository
+	hands int
+}
+
+func (r *batchCountingRepo) SaveImportBatch(ctx context.Context, hands []persistence.PersistedHand, c persistence.ImportCursor) (persistence.UpsertResult, error) {
+	r.hands += len(hands)
+	return r.MemoryRepository.SaveImportBatch(ctx, hands, c)
+}
+
+func TestMovedLogsResumeFromTheirPreviousCursor(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	// Logs long enough to have a content fingerprint.
+	longLog := func(minute string) string {
+		header := "2026.02.21 " + minute + ":00 Debug      -  VRChat log started\n"
+		return header + strings.Repeat("2026.02.21 "+minute+":00 Debug      -  filler\n", 500) + testHandLog(minute)
+	}
+	dirA, dirB := t.TempDir(), t.TempDir()
+	logs := map[string]string{"old.log": longLog("06:00"), "new.log": longLog("06:10")}
+	for name, content := range logs {
+		if err := os.WriteFile(filepath.Join(dirA, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("write log: %v", err)
+		}
+	}
+	locatorIn := func(dir string) LogFileLocator {
+		return func([]string) ([]string, error) {
+			return []string{filepath.Join(dir, "new.log"), filepath.Join(dir, "old.log")}, nil
+		}
+	}
+
+	repo := &batchCountingRepo{MemoryRepository: persistence.NewMemoryRepository()}
+	if _, err := NewService(repo, locatorIn(dirA)).BootstrapImportAllLogs(ctx); err != nil {
+		t.Fatalf("first bootstrap: %v", err)
+	}
+	if repo.hands == 0 {
+		t.Fatal("first bootstrap saved no hands")
+	}
+
+	// Both logs move to another directory, as when a prefix is relocated.
+	for name := range logs {
+		if err := os.Rename(filepath.Join(dirA, name), filepath.Join(dirB, name)); err != nil {
+			t.Fatalf("move log: %v", err)
+		}
+	}
+	repo.hands = 0
+	var progress []BootstrapProgress
+	if _, err := NewService(repo, locatorIn(dirB)).BootstrapImportAllLogsWithProgress(ctx, func(p BootstrapProgress) {
+		progress = append(progress, p)
+	}); err != nil {
+		t.Fatalf("second bootstrap: %v", err)
+	}
+	if len(progress) != 1 || progress[0].Path != filepath.Join(dirB, "new.log") || progress[0].Skipped != 1 {
+		t.Fatalf("progress = %+v, want only the live log with the old one skipped", progress)
+	}
+	if repo.hands != 0 {
+		t.Fatalf("moved logs were parsed again: %d hands saved", repo.hands)
+	}
+	for name, content := range logs {
+		cursor, err := repo.GetCursor(ctx, filepath.Join(dirB, name))
+		if err != nil || cursor == nil || cursor.NextByteOffset != int64(len(content)) || cursor.Fingerprint == "" {
+			t.Fatalf("cursor of moved %s = %+v, %v", name, cursor, err)
+		}
+	}
+}
+
+func TestShortLogsAreRecognizedWhenArchivedOrGrown(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dirA, dirB := t.TempDir(), t.TempDir()
+	oldLog, newLog := testHandLog("07:00"), testHandLog("07:10")
+	if len(oldLog) >= logsource.FingerprintSize {
+		t.Fatalf("test log of %d bytes is not short", len(oldLog))
+	}
+	for name, content := range map[string]string{"old.log": oldLog, "new.log": newLog} {
+		if err := os.WriteFile(filepath.Join(dirA, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("write log: %v", err)
+		}
+	}
+	repo := &batchCountingRepo{MemoryRepository: persistence.NewMemoryRepository()}
+	locatorA := func([]string) ([]string, error) {
+		return []string{filepath.Join(dirA, "new.log"), filepath.Join(dirA, "old.log")}, nil
+	}
+	if _, err := NewService(repo, locatorA).BootstrapImportAllLogs(ctx); err != nil {
+		t.Fatalf("first bootstrap: %v", err)
+	}
+
+	// The old log is archived next to a log never seen before; the live
+	// log grows by a hand and moves.
+	archive := filepath.Join(dirB, "logs.tar.gz")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatalf("create archive: %v", err)
+	}
+	zw := gzip.NewWriter(f)
+	tw := tar.NewWriter(zw)
+	for name, content := range map[string]string{"output_log_old.txt": oldLog, "output_log_other.txt": testHandLog("06:00")} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("tar header: %v", err)
+		}
+		if _, err := io.WriteString(tw, content); err != nil {
+			t.Fatalf("tar write: %v", err)
+		}
+	}
+	for _, c := range []io.Closer{tw, zw, f} {
+		if err := c.Close(); err != nil {
+			t.Fatalf("close archive: %v", err)
+		}
+	}
+	grown := newLog + testHandLog("07:20")
+	if err := os.WriteFile(filepath.Join(dirB, "new.log"), []byte(grown), 0o600); err != nil {
+		t.Fatalf("write grown log: %v", err)
+	}
+
+	locatorB := func([]string) ([]string, error) { return []string{filepath.Join(dirB, "new.log"), archive}, nil }
+	svc := NewService(repo, locatorB)
+	oldMember := logsource.MemberPath(archive, "output_log_old.txt")
+	scan := svc.pendingArchiveMembers(ctx, []string{archive})
+	if len(scan.members) != 1 || scan.members[0] != logsource.MemberPath(archive, "output_log_other.txt") || len(scan.moved) != 1 || scan.skipped != 1 {
+		t.Fatalf("scan = %+v, want only the unseen member pending", scan)
+	}
+	if c, err := repo.GetCursor(ctx, oldMember); err != nil || c != nil {
+		t.Fatalf("listing saved cursor %+v, %v", c, err)
+	}
+
+	repo.hands = 0
+	if _, err := svc.BootstrapImportAllLogs(ctx); err != nil {
+		t.Fatalf("second bootstrap: %v", err)
+	}
+	// Only the unseen member and the hand added to the live log are parsed.
+	if repo.hands != 2 {
+		t.Fatalf("second bootstrap saved %d hands, want 2", repo.hands)
+	}
+	for path, want := range map[string]int{oldMember: len(oldLog), filepath.Join(dirB, "new.log"): len(grown)} {
+		c, err := repo.GetCursor(ctx, path)
+		if err != nil || c == nil || c.NextByteOffset != int64(want) || c.Fingerprint == "" {
+			t.Fatalf("cursor of %s = %+v, %v; want one at %d", path, c, err, want)
+		}
+	}
+	if c, _ := repo.GetCursor(ctx, filepath.Join(dirB, "new.log")); c.FingerprintSize != len(grown) {
+		t.Fatalf("grown log fingerprint covers %d bytes, want %d", c.FingerprintSize, len(grown))
+	}
+}
+
 func TestImportLinesSkipsStaleSource(t *testing.T) {
 	t.Parallel()
 
@@ -101,7 +342,7 @@ func TestBootstrapSkipsFullyImportedFiles(t *testing.T) {
 	}
 
 	repo := persistence.NewMemoryRepository()
-	locator := func() ([]string, error) { return []string{newPath, oldPath}, nil }
+	locator := func([]string) ([]string, error) { return []string{newPath, oldPath}, nil }
 
 	// First bootstrap: both files imported, old file marked fully-imported.
 	svc1 := NewService(repo, locator)
@@ -162,7 +403,7 @@ func TestBootstrapParallelImport(t *testing.T) {
 	}
 
 	repo := persistence.NewMemoryRepository()
-	svc := NewService(repo, func() ([]string, error) { return reversed, nil })
+	svc := NewService(repo, func([]string) ([]string, error) { return reversed, nil })
 
 	if _, err := svc.BootstrapImportAllLogs(context.Background()); err != nil {
 		t.Fatalf("bootstrap: %v", err)
@@ -178,6 +419,185 @@ func TestBootstrapParallelImport(t *testing.T) {
 	}
 }
 
+// writeTestLogs writes n logs of one hand each and returns a locator that
+// lists them newest first, like the real one.
+func writeTestLogs(t *testing.T, n int) ([]string, LogFileLocator) {
+	t.Helper()
+	tmp := t.TempDir()
+	paths := make([]string, n)
+	for i := range paths {
+		paths[i] = filepath.Join(tmp, fmt.Sprintf("log_%02d.log", i))
+		if err := os.WriteFile(paths[i], []byte(testHandLog(fmt.Sprintf("00:%02d", i))), 0o600); err != nil {
+			t.Fatalf("write log %d: %v", i, err)
+		}
+	}
+	newestFirst := slices.Clone(paths)
+	slices.Reverse(newestFirst)
+	return paths, func([]string) ([]string, error) { return newestFirst, nil }
+}
+
+func TestBootstrapImportReportsBytesAndPauses(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	paths, locator := writeTestLogs(t, 6)
+	var totalBytes int64
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("stat: %v", err)
+		}
+		totalBytes += info.Size()
+	}
+	svc := NewService(persistence.NewMemoryRepository(), locator)
+	settings := DefaultAppSettings()
+	settings.ImportWorkers = 2
+	if err := svc.SaveSettings(ctx, settings); err != nil {
+		t.Fatalf("save settings: %v", err)
+	}
+
+	control := &ImportControl{}
+	control.Pause()
+	var mu sync.Mutex
+	var progress []BootstrapProgress
+	done := make(chan error, 1)
+	go func() {
+		_, err := svc.BootstrapImport(ctx, BootstrapOptions{
+			Control: control,
+			OnProgress: func(p BootstrapProgress) {
+				mu.Lock()
+				progress = append(progress, p)
+				mu.Unlock()
+			},
+		})
+		done <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	if _, hands, _, err := svc.Snapshot(ctx); err != nil || len(hands) != 0 {
+		t.Fatalf("hands saved while paused = %d, %v", len(hands), err)
+	}
+	control.Resume()
+	if err := <-done; err != nil {
+		t.Fatalf("bootstrap: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var saved []string
+	for _, p := range progress {
+		if p.Path != paths[len(paths)-1] {
+			saved = append(saved, p.Path)
+		}
+	}
+	if !reflect.DeepEqual(slices.Compact(saved), paths[:len(paths)-1]) {
+		t.Fatalf("historical logs saved in order %v, want %v", slices.Compact(saved), paths[:len(paths)-1])
+	}
+	last := progress[len(progress)-1]
+	if last.Current != len(paths) || last.Total != len(paths) || len(last.Files) != len(paths) {
+		t.Fatalf("last progress = %+v", last)
+	}
+	if !last.Files[len(paths)-1].Live || last.Files[0].Live {
+		t.Fatalf("live flags = %+v", last.Files)
+	}
+	if last.Files[0].State != FileDone || last.Files[0].BytesDone != last.Files[0].Size {
+		t.Fatalf("first file = %+v", last.Files[0])
+	}
+	// The live log may be reported before or after it is parsed.
+	if last.BytesTotal != totalBytes || last.Hands < len(paths)-1 {
+		t.Fatalf("bytes %d/%d, hands %d; want total %d, %d hands", last.BytesDone, last.BytesTotal, last.Hands, totalBytes, len(paths)-1)
+	}
+}
+
+func TestBootstrapImportCancelWhilePaused(t *testing.T) {
+	t.Parallel()
+
+	_, locator := writeTestLogs(t, 4)
+	repo := persistence.NewMemoryRepository()
+	svc := NewService(repo, locator)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	control := &ImportControl{}
+	control.Pause()
+	done := make(chan error, 1)
+	go func() {
+		_, err := svc.BootstrapImport(ctx, BootstrapOptions{Control: control})
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("bootstrap error = %v, want context.Canceled", err)
+	}
+	if _, hands, _, err := NewService(repo, locator).Snapshot(context.Background()); err != nil || len(hands) != 0 {
+		t.Fatalf("hands after cancel = %d, %v", len(hands), err)
+	}
+}
+
+func TestBootstrapImportsArchivedLogsOncePerMember(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	archivePath := filepath.Join(tmp, "old-logs.zip")
+	activePath := filepath.Join(tmp, "output_log_2026-02-21_05-00-00.txt")
+	if err := os.WriteFile(activePath, []byte(testHandLog("05:00")), 0o600); err != nil {
+		t.Fatalf("write active log: %v", err)
+	}
+	f, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("create archive: %v", err)
+	}
+	zw := zip.NewWriter(f)
+	for i, minute := range []string{"04:00", "04:10"} {
+		w, err := zw.Create(fmt.Sprintf("logs/output_log_2026-02-21_04-%d0-00.txt", i))
+		if err != nil {
+			t.Fatalf("create member: %v", err)
+		}
+		if _, err := w.Write([]byte(testHandLog(minute))); err != nil {
+			t.Fatalf("write member: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("close archive: %v", err)
+	}
+
+	repo := persistence.NewMemoryRepository()
+	locator := func([]string) ([]string, error) { return []string{activePath, archivePath}, nil }
+	latest, err := NewService(repo, locator).BootstrapImportAllLogs(context.Background())
+	if err != nil {
+		t.Fatalf("first bootstrap: %v", err)
+	}
+	if latest != activePath {
+		t.Fatalf("latest path = %q, want %q", latest, activePath)
+	}
+	memberPath := archivePath + "!/logs/output_log_2026-02-21_04-00-00.txt"
+	cursor, err := repo.GetCursor(context.Background(), memberPath)
+	if err != nil || cursor == nil || !cursor.IsFullyImported {
+		t.Fatalf("member cursor = %+v, %v; want fully imported", cursor, err)
+	}
+
+	svc := NewService(repo, locator)
+	var progress []BootstrapProgress
+	if _, err := svc.BootstrapImportAllLogsWithProgress(context.Background(), func(p BootstrapProgress) {
+		progress = append(progress, p)
+	}); err != nil {
+		t.Fatalf("second bootstrap: %v", err)
+	}
+	if len(progress) != 1 || progress[0].Path != activePath {
+		t.Fatalf("second bootstrap progress = %+v, want only the active file", progress)
+	}
+	_, hands, _, err := svc.Snapshot(context.Background())
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if len(hands) != 3 {
+		t.Fatalf("hand count = %d, want 3", len(hands))
+	}
+}
+
 func testHandLog(minute string) string {
 	return strings.Join([]string{
 		"2026.02.21 " + minute + ":00 Debug      -  [Table]: Preparing for New Game: ",
@@ -187,3 +607,1072 @@ func testHandLog(minute string) string {
 		"2026.02.21 " + minute + ":04 Debug      -  [Table]: Preparing for New Game: ",
 	}, "\n") + "\n"
 }
+
+func TestDiagnosticsReportTracksUnrecognizedLinesPerFile(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	path := filepath.Join(tmp, "drift.log")
+	log := testHandLog("03:00") + "2026.02.21 03:00:05 Debug      -  [Seat]: Player 0 Start Turn\n"
+	if err := os.WriteFile(path, []byte(log), 0o600); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+
+	svc := NewService(persistence.NewMemoryRepository(), nil)
+	if err := svc.ChangeLogFile(context.Background(), path); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	extra := []string{"2026.02.21 03:00:06 Debug      -  [Seat]: Player 1 Start Turn"}
+	if err := svc.ImportLines(context.Background(), path, extra, int64(len(log)), int64(len(log)+len(extra[0])+1)); err != nil {
+		t.Fatalf("import lines: %v", err)
+	}
+
+	report, err := svc.DiagnosticsReport(context.Background())
+	if err != nil {
+		t.Fatalf("diagnostics report: %v", err)
+	}
+	if len(report.Files) != 1 || report.Files[0].SourcePath != path {
+		t.Fatalf("unexpected files: %+v", report.Files)
+	}
+	file := report.Files[0]
+	if file.UnrecognizedLines != 2 || len(file.Patterns) != 1 || file.Patterns[0].Count != 2 {
+		t.Fatalf("unexpected diagnostics: %+v", file.DiagnosticsSnapshot)
+	}
+	if file.Hands != 1 || report.Totals.UnrecognizedLines != 2 {
+		t.Fatalf("hands=%d totals=%d, want 1/2", file.Hands, report.Totals.UnrecognizedLines)
+	}
+
+	var buf strings.Builder
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("write json: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"pattern": "[Seat]: Player <n> Start Turn"`) {
+		t.Fatalf("json export missing pattern: %s", buf.String())
+	}
+}
+
+func TestReprocessHandsUpdatesOutdatedHands(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	path := filepath.Join(tmp, "reprocess.log")
+	log := testHandLog("04:00")
+	if err := os.WriteFile(path, []byte(log), 0o600); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+
+	repo := persistence.NewMemoryRepository()
+	svc := NewService(repo, nil)
+	if err := svc.ChangeLogFile(context.Background(), path); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	hands, err := repo.ListHands(context.Background(), persistence.HandFilter{})
+	if err != nil || len(hands) != 1 {
+		t.Fatalf("list hands = %d, %v", len(hands), err)
+	}
+
+	// Simulate a hand stored by an older parser with a different pot.
+	stale := hands[0]
+	uid := stale.HandUID
+	stale.TotalPot = 999
+	stale.ParserVersion = 0
+	src := persistence.HandSourceRef{SourcePath: path, StartByte: 0, EndByte: int64(len(log)), StartLine: 1, EndLine: 5, HandUID: uid}
+	if _, err := repo.UpsertHands(context.Background(), []persistence.PersistedHand{{Hand: stale, Source: src}}); err != nil {
+		t.Fatalf("upsert stale hand: %v", err)
+	}
+
+	dry, err := svc.ReprocessHands(context.Background(), ReprocessOptions{DryRun: true}, nil)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if dry.Outdated != 1 || dry.Reparsed != 1 || dry.Changed != 1 || dry.Updated != 0 {
+		t.Fatalf("unexpected dry run result: %+v", dry)
+	}
+	if len(dry.Diffs) != 1 || !strings.Contains(strings.Join(dry.Diffs[0].Changes, "\n"), "total_pot: 999 -> 30") {
+		t.Fatalf("unexpected diff: %+v", dry.Diffs)
+	}
+	if h, _ := repo.GetHandByUID(context.Background(), uid); h.TotalPot != 999 {
+		t.Fatalf("dry run modified stored hand: pot=%d", h.TotalPot)
+	}
+
+	var progress []ReprocessProgress
+	res, err := svc.ReprocessHands(context.Background(), ReprocessOptions{}, func(p ReprocessProgress) {
+		progress = append(progress, p)
+	})
+	if err != nil {
+		t.Fatalf("reprocess: %v", err)
+	}
+	if res.Updated != 1 || len(progress) == 0 || progress[len(progress)-1].Processed != 1 {
+		t.Fatalf("unexpected result: %+v progress=%+v", res, progress)
+	}
+	h, err := repo.GetHandByUID(context.Background(), uid)
+	if err != nil || h == nil {
+		t.Fatalf("get hand: %v", err)
+	}
+	if h.TotalPot != 30 || h.ParserVersion != parser.Version {
+		t.Fatalf("hand not reprocessed: pot=%d version=%d", h.TotalPot, h.ParserVersion)
+	}
+
+	again, err := svc.ReprocessHands(context.Background(), ReprocessOptions{DryRun: true}, nil)
+	if err != nil || again.Outdated != 0 {
+		t.Fatalf("second run outdated = %d, %v", again.Outdated, err)
+	}
+}
+
+func TestReprocessHandsFindsCRLFHandsImportedWithoutCarriageReturns(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "crlf.log")
+	log := testHandLog("04:10") + testHandLog("04:20") + testHandLog("04:30")
+	if err := os.WriteFile(path, []byte(log), 0o600); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+	repo := persistence.NewMemoryRepository()
+	svc := NewService(repo, nil)
+	if err := svc.ChangeLogFile(ctx, path); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	// Older imports did not count carriage returns, so the stored spans of
+	// a CRLF log are those of the same log with plain newlines.
+	sources, err := repo.ListOutdatedHandSources(ctx, parser.Version+1)
+	if err != nil || len(sources) < 3 {
+		t.Fatalf("sources = %+v, %v", sources, err)
+	}
+	pots := make(map[string]int, len(sources))
+	for _, src := range sources {
+		stale, err := repo.GetHandByUID(ctx, src.HandUID)
+		if err != nil || stale == nil {
+			t.Fatalf("get hand: %+v, %v", stale, err)
+		}
+		pots[src.HandUID] = stale.TotalPot
+		stale.TotalPot, stale.ParserVersion = 999, 0
+		if _, err := repo.UpsertHands(ctx, []persistence.PersistedHand{{Hand: stale, Source: src}}); err != nil {
+			t.Fatalf("upsert stale hand: %v", err)
+		}
+	}
+	if err := os.WriteFile(path, []byte(strings.ReplaceAll(log, "\n", "\r\n")), 0o600); err != nil {
+		t.Fatalf("write crlf log: %v", err)
+	}
+
+	res, err := svc.ReprocessHands(ctx, ReprocessOptions{}, nil)
+	if err != nil {
+		t.Fatalf("reprocess: %v", err)
+	}
+	if res.Reparsed != len(sources) || res.Unmatched != 0 || res.Updated != len(sources) {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	for _, src := range sources {
+		if h, _ := repo.GetHandByUID(ctx, src.HandUID); h == nil || h.TotalPot != pots[src.HandUID] || h.ParserVersion != parser.Version {
+			t.Fatalf("hand %s not reprocessed: %+v", src.HandUID, h)
+		}
+	}
+}
+
+func TestReprocessHandsReportsMissingFiles(t *testing.T) {
+	t.Parallel()
+
+	repo := persistence.NewMemoryRepository()
+	h := &parser.Hand{ID: 1, StartTime: time.Date(2026, 2, 21, 5, 0, 0, 0, time.UTC), Players: map[int]*parser.PlayerHandInfo{}, IsComplete: true}
+	src := persistence.HandSourceRef{SourcePath: filepath.Join(t.TempDir(), "gone.log"), StartByte: 0, EndByte: 100}
+	src.HandUID = persistence.GenerateHandUID(h, src)
+	if _, err := repo.UpsertHands(context.Background(), []persistence.PersistedHand{{Hand: h, Source: src}}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	res, err := NewService(repo, nil).ReprocessHands(context.Background(), ReprocessOptions{}, nil)
+	if err != nil {
+		t.Fatalf("reprocess: %v", err)
+	}
+	if res.Outdated != 1 || len(res.MissingFiles) != 1 || res.Updated != 0 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+}
+
+func TestRawLogsAreKeptAndUsedWhenLogIsGone(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	tmp := t.TempDir()
+	path := filepath.Join(tmp, "rotated.log")
+	log := testHandLog("06:00")
+	if err := os.WriteFile(path, []byte(log), 0o600); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+
+	repo := persistence.NewMemoryRepository()
+	svc := NewService(repo, nil)
+	if err := svc.ChangeLogFile(ctx, path); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	hands, err := repo.ListHands(ctx, persistence.HandFilter{})
+	if err != nil || len(hands) != 1 {
+		t.Fatalf("list hands = %d, %v", len(hands), err)
+	}
+	uid := hands[0].HandUID
+	raw, err := svc.HandRawLog(ctx, uid)
+	if err != nil || raw == nil {
+		t.Fatalf("raw log = %+v, %v", raw, err)
+	}
+	if string(raw.Data) != log {
+		t.Fatalf("raw log data = %q, want %q", raw.Data, log)
+	}
+
+	// Mark the hand outdated and delete the log; reprocess must use the snippet.
+	stale := hands[0]
+	stale.ParserVersion = 0
+	stale.TotalPot = 1
+	src := persistence.HandSourceRef{SourcePath: path, StartByte: 0, EndByte: int64(len(log)), StartLine: 1, EndLine: 5, HandUID: uid}
+	if _, err := repo.UpsertHands(ctx, []persistence.PersistedHand{{Hand: stale, Source: src}}); err != nil {
+		t.Fatalf("upsert stale hand: %v", err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("remove log: %v", err)
+	}
+	res, err := svc.ReprocessHands(ctx, ReprocessOptions{}, nil)
+	if err != nil {
+		t.Fatalf("reprocess: %v", err)
+	}
+	if len(res.MissingFiles) != 1 || res.FromRawLogs != 1 || res.Updated != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if h, _ := repo.GetHandByUID(ctx, uid); h == nil || h.TotalPot != 30 {
+		t.Fatalf("hand not restored from raw log: %+v", h)
+	}
+}
+
+func TestRawLogsCanBeDisabled(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "nolog.log")
+	if err := os.WriteFile(path, []byte(testHandLog("07:00")), 0o600); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+
+	repo := persistence.NewMemoryRepository()
+	svc := NewService(repo, nil)
+	settings := DefaultAppSettings()
+	settings.KeepRawLogs = false
+	if err := svc.SaveSettings(ctx, settings); err != nil {
+		t.Fatalf("save settings: %v", err)
+	}
+	if got, _ := NewService(repo, nil).Settings(ctx); got.KeepRawLogs {
+		t.Fatal("setting was not persisted")
+	}
+	if err := svc.ChangeLogFile(ctx, path); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	hands, err := repo.ListHands(ctx, persistence.HandFilter{})
+	if err != nil || len(hands) != 1 {
+		t.Fatalf("list hands = %d, %v", len(hands), err)
+	}
+	if raw, _ := svc.HandRawLog(ctx, hands[0].HandUID); raw != nil {
+		t.Fatalf("raw log stored while disabled: %+v", raw)
+	}
+}
+
+func TestExportHandsCSVIncludesAnnotations(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repo := persistence.NewMemoryRepository()
+	base := time.Date(2026, 2, 21, 6, 0, 0, 0, time.UTC)
+	rows := make([]persistence.PersistedHand, 0, 2)
+	for i := range 2 {
+		h := &parser.Hand{
+			ID:              i + 1,
+			StartTime:       base.Add(time.Duration(i) * time.Minute),
+			LocalPlayerSeat: 0,
+			Players:         map[int]*parser.PlayerHandInfo{0: {SeatID: 0, PotWon: 30}},
+			NumPlayers:      2,
+			TotalPot:        30,
+			IsComplete:      true,
+			StatsEligible:   true,
+		}
+		src := persistence.HandSourceRef{SourcePath: "test.log", StartByte: int64(i * 100), EndByte: int64(i*100 + 99)}
+		src.HandUID = persistence.GenerateHandUID(h, src)
+		rows = append(rows, persistence.PersistedHand{Hand: h, Source: src})
+	}
+	if _, err := repo.UpsertHands(ctx, rows); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	svc := NewService(repo, nil)
+	uid := rows[1].Source.HandUID
+	if err := svc.SaveHandAnnotation(ctx, persistence.HandAnnotation{
+		HandUID: uid,
+		Note:    "check-raise, \"too thin\"",
+		Tags:    []string{"review with coach"},
+		Starred: true,
+	}); err != nil {
+		t.Fatalf("save annotation: %v", err)
+	}
+
+	var buf strings.Builder
+	n, err := svc.ExportHandsCSV(ctx, persistence.HandFilter{}, &buf)
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("exported %d hands, want 2", n)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "hand_uid,") {
+		t.Fatalf("unexpected csv:\n%s", buf.String())
+	}
+	if want := uid + ","; !strings.HasPrefix(lines[1], want) {
+		t.Fatalf("newest hand should come first:\n%s", buf.String())
+	}
+	if !strings.HasSuffix(lines[1], `,true,review with coach,"check-raise, ""too thin"""`) {
+		t.Fatalf("annotation columns missing:\n%s", lines[1])
+	}
+
+	buf.Reset()
+	if n, err := svc.ExportHandsCSV(ctx, persistence.HandFilter{OnlyStarred: true}, &buf); err != nil || n != 1 {
+		t.Fatalf("starred export = %d, %v; want 1 hand", n, err)
+	}
+}
+
+func TestFilterPresetsRoundTripAndFilterStats(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repo := persistence.NewMemoryRepository()
+	base := time.Date(2026, 3, 1, 6, 0, 0, 0, time.Local)
+	seats := []struct {
+		pos     parser.Position
+		players int
+	}{
+		{pos: parser.PosBTN, players: 2},
+		{pos: parser.PosBTN, players: 6},
+		{pos: parser.PosCO, players: 2},
+	}
+	rows := make([]persistence.PersistedHand, 0, len(seats))
+	for i, s := range seats {
+		h := &parser.Hand{
+			ID:              i + 1,
+			StartTime:       base.Add(time.Duration(i) * time.Minute),
+			LocalPlayerSeat: 0,
+			Players:         map[int]*parser.PlayerHandInfo{0: {SeatID: 0, Position: s.pos}},
+			NumPlayers:      s.players,
+			IsComplete:      true,
+			StatsEligible:   true,
+		}
+		src := persistence.HandSourceRef{SourcePath: "test.log", StartByte: int64(i * 100), EndByte: int64(i*100 + 99)}
+		src.HandUID = persistence.GenerateHandUID(h, src)
+		rows = append(rows, persistence.PersistedHand{Hand: h, Source: src})
+	}
+	if _, err := repo.UpsertHands(ctx, rows); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	svc := NewService(repo, nil)
+	if err := svc.SaveFilterPresets(ctx, []FilterPreset{{Name: "bad", Positions: []string{"XX"}}}); err == nil {
+		t.Fatalf("invalid preset was saved")
+	}
+	preset := FilterPreset{
+		Name:       "HU button",
+		Period:     PresetPeriod{Mode: PresetPeriodCustom, From: "2026-03-01", To: "2026-03-01"},
+		Positions:  []string{"BTN"},
+		TableSizes: []string{"hu"},
+	}
+	if err := svc.SaveFilterPresets(ctx, []FilterPreset{preset}); err != nil {
+		t.Fatalf("save presets: %v", err)
+	}
+
+	var buf strings.Builder
+	if n, err := svc.ExportFilterPresets(ctx, &buf); err != nil || n != 1 {
+		t.Fatalf("export = %d, %v", n, err)
+	}
+
+	other := NewService(persistence.NewMemoryRepository(), nil)
+	if err := other.SaveFilterPresets(ctx, []FilterPreset{{Name: "HU button"}, {Name: "all"}}); err != nil {
+		t.Fatalf("save other presets: %v", err)
+	}
+	if n, err := other.ImportFilterPresets(ctx, strings.NewReader(buf.String())); err != nil || n != 1 {
+		t.Fatalf("import = %d, %v", n, err)
+	}
+	got, err := other.FilterPresets(ctx)
+	if err != nil {
+		t.Fatalf("list presets: %v", err)
+	}
+	if len(got) != 2 || got[0].Name != "HU button" || got[0].Period != preset.Period || got[1].Name != "all" {
+		t.Fatalf("presets after import = %+v", got)
+	}
+	if _, err := other.ImportFilterPresets(ctx, strings.NewReader(`{"version": 99, "presets": []}`)); err == nil {
+		t.Fatalf("future preset file version was accepted")
+	}
+
+	filter, err := got[0].HandFilter(time.Now())
+	if err != nil {
+		t.Fatalf("preset filter: %v", err)
+	}
+	s, _, err := svc.Stats(ctx, filter)
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	if s.TotalHands != 1 {
+		t.Fatalf("preset stats hands = %d, want 1", s.TotalHands)
+	}
+	all, _, err := svc.Stats(ctx, persistence.HandFilter{})
+	if err != nil {
+		t.Fatalf("all-time stats: %v", err)
+	}
+	if all.TotalHands != 3 {
+		t.Fatalf("all-time stats hands = %d, want 3", all.TotalHands)
+	}
+	hu, _, err := svc.Stats(ctx, persistence.HandFilter{TableSizes: []stats.TableSize{stats.TableSizeHeadsUp}})
+	if err != nil {
+		t.Fatalf("heads-up stats: %v", err)
+	}
+	if hu.TotalHands != 2 {
+		t.Fatalf("heads-up stats hands = %d, want 2", hu.TotalHands)
+	}
+	if got := all.ForTableSize(stats.TableSizeSixMax).TotalHands; got != 1 {
+		t.Fatalf("six-max split hands = %d, want 1", got)
+	}
+}
+
+func TestStatsBreakdownGroupsByOwner(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repo := persistence.NewMemoryRepository()
+	base := time.Date(2026, 3, 2, 6, 0, 0, 0, time.Local)
+	owners := []string{"usr_practice", "usr_practice", "", "grp_club"}
+	rows := make([]persistence.PersistedHand, 0, len(owners))
+	for i, owner := range owners {
+		h := &parser.Hand{
+			ID:              i + 1,
+			StartTime:       base.Add(time.Duration(i) * time.Minute),
+			LocalPlayerSeat: 0,
+			Players:         map[int]*parser.PlayerHandInfo{0: {SeatID: 0, Position: parser.PosBTN}},
+			NumPlayers:      2,
+			IsComplete:      true,
+			StatsEligible:   true,
+			InstanceUID:     "wrld_poker:" + owner,
+			InstanceOwner:   owner,
+			InstanceUsers:   []parser.InstanceUser{{UserUID: "usr_practice", DisplayName: "Coach"}},
+		}
+		src := persistence.HandSourceRef{SourcePath: "test.log", StartByte: int64(i * 100), EndByte: int64(i*100 + 99)}
+		src.HandUID = persistence.GenerateHandUID(h, src)
+		rows = append(rows, persistence.PersistedHand{Hand: h, Source: src})
+	}
+	if _, err := repo.UpsertHands(ctx, rows); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	svc := NewService(repo, nil)
+	groups, names, err := svc.StatsBreakdown(ctx, persistence.HandFilter{}, stats.BreakdownOwner)
+	if err != nil {
+		t.Fatalf("breakdown: %v", err)
+	}
+	if len(groups) != 3 || groups[0].Key != "usr_practice" || groups[0].Stats.TotalHands != 2 {
+		t.Fatalf("groups = %+v", groups)
+	}
+	if names["usr_practice"] != "Coach" {
+		t.Fatalf("owner names = %v", names)
+	}
+}
+
+func TestCompareFiltersSplitsPeriods(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repo := persistence.NewMemoryRepository()
+	base := time.Date(2026, 3, 2, 6, 0, 0, 0, time.Local)
+	rows := make([]persistence.PersistedHand, 0, 6)
+	for i := 0; i < 6; i++ {
+		h := &parser.Hand{
+			ID:              i + 1,
+			StartTime:       base.Add(time.Duration(i) * 24 * time.Hour),
+			LocalPlayerSeat: 0,
+			Players:         map[int]*parser.PlayerHandInfo{0: {SeatID: 0, Position: parser.PosBTN, VPIP: i >= 3}},
+			NumPlayers:      2,
+			IsComplete:      true,
+			StatsEligible:   true,
+		}
+		src := persistence.HandSourceRef{SourcePath: "test.log", StartByte: int64(i * 100), EndByte: int64(i*100 + 99)}
+		src.HandUID = persistence.GenerateHandUID(h, src)
+		rows = append(rows, persistence.PersistedHand{Hand: h, Source: src})
+	}
+	if _, err := repo.UpsertHands(ctx, rows); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	split := base.Add(3*24*time.Hour - time.Minute)
+	svc := NewService(repo, nil)
+	cmp, err := svc.CompareFilters(ctx, persistence.HandFilter{ToTime: &split}, persistence.HandFilter{FromTime: &split})
+	if err != nil {
+		t.Fatalf("compare: %v", err)
+	}
+	if cmp.A.TotalHands != 3 || cmp.B.TotalHands != 3 {
+		t.Fatalf("hands = %d / %d, want 3 / 3", cmp.A.TotalHands, cmp.B.TotalHands)
+	}
+	if btn := cmp.Positions[parser.PosBTN]; btn.VPIP.A != 0 || btn.VPIP.B != 100 {
+		t.Fatalf("BTN VPIP = %+v, want 0%% -> 100%%", btn.VPIP)
+	}
+}
+
+func TestPeriodStatsFromRollupsMatchScan(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repo, err := persistence.NewSQLiteRepository(filepath.Join(t.TempDir(), "stats.db"))
+	if err != nil {
+		t.Fatalf("new sqlite repo: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = repo.Close()
+	})
+
+	base := time.Date(2026, 3, 2, 5, 0, 0, 0, time.UTC)
+	hand := func(i int) persistence.PersistedHand {
+		h := &parser.Hand{
+			ID:              i + 1,
+			StartTime:       base.Add(time.Duration(i) * 7 * time.Hour),
+			LocalPlayerSeat: 0,
+			Players: map[int]*parser.PlayerHandInfo{0: {
+				SeatID:   0,
+				Position: parser.Position(i % 6),
+				VPIP:     i%2 == 0,
+				PFR:      i%4 == 0,
+				Won:      i%3 == 0,
+				PotWon:   30 * (i % 3),
+			}},
+			NumPlayers:    2 + i%5,
+			IsComplete:    true,
+			StatsEligible: true,
+		}
+		src := persistence.HandSourceRef{SourcePath: "test.log", StartByte: int64(i * 100), EndByte: int64(i*100 + 99)}
+		src.HandUID = persistence.GenerateHandUID(h, src)
+		return persistence.PersistedHand{Hand: h, Source: src}
+	}
+	rows := make([]persistence.PersistedHand, 0, 20)
+	for i := 0; i < 20; i++ {
+		rows = append(rows, hand(i))
+	}
+	if _, err := repo.UpsertHands(ctx, rows); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	for _, i := range []int{1, 8} {
+		if err := repo.SaveHandAnnotation(ctx, persistence.HandAnnotation{HandUID: rows[i].Source.HandUID, Starred: true, Tags: []string{"review"}}); err != nil {
+			t.Fatalf("save annotation: %v", err)
+		}
+	}
+
+	at := func(day, hour int) *time.Time {
+		v := time.Date(2026, 3, day, hour, 0, 0, 0, time.UTC)
+		return &v
+	}
+	endOf := func(day int) *time.Time {
+		v := time.Date(2026, 3, day+1, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond)
+		return &v
+	}
+	filters := map[string]persistence.HandFilter{
+		"partial edges": {FromTime: at(2, 12), ToTime: at(6, 9)},
+		"whole days":    {FromTime: at(3, 0), ToTime: endOf(5)},
+		"from only":     {FromTime: at(3, 3)},
+		"to only":       {ToTime: at(5, 20)},
+		"one day":       {FromTime: at(4, 1), ToTime: at(4, 22)},
+		"table sizes":   {FromTime: at(2, 12), ToTime: at(6, 9), TableSizes: []stats.TableSize{stats.TableSizeHeadsUp, stats.TableSizeSixMax}},
+		// Without a time range, but not all hands either.
+		"starred": {OnlyStarred: true},
+		"tagged":  {Tags: []string{"review"}, TableSizes: []stats.TableSize{stats.TableSizeShort}},
+	}
+
+	check := func(t *testing.T) {
+		t.Helper()
+		// A fresh service has no cached results, so every query goes
+		// through the rollups.
+		svc := NewService(repo, nil)
+		for name, f := range filters {
+			got, seat, err := svc.Stats(ctx, f)
+			if err != nil {
+				t.Fatalf("%s: stats: %v", name, err)
+			}
+			scanFilter := f
+			scanFilter.OnlyComplete = true
+			hands, err := repo.ListHands(ctx, scanFilter)
+			if err != nil {
+				t.Fatalf("%s: list hands: %v", name, err)
+			}
+			want := stats.NewCalculator().Calculate(hands, seat)
+			gotCopy, wantCopy := *got, *want
+			gotCopy.Tilt, wantCopy.Tilt = nil, nil
+			if !reflect.DeepEqual(gotCopy, wantCopy) {
+				t.Errorf("%s: stats differ from scan: got %d hands, want %d", name, got.TotalHands, want.TotalHands)
+			}
+			tilt, err := svc.TiltReport(ctx, f)
+			if err != nil {
+				t.Fatalf("%s: tilt: %v", name, err)
+			}
+			if !reflect.DeepEqual(tilt, want.Tilt) {
+				t.Errorf("%s: tilt report = %+v, want %+v", name, tilt, want.Tilt)
+			}
+		}
+	}
+	check(t)
+
+	// A hand written into a summed day must show up in the next query.
+	if _, err := repo.UpsertHands(ctx, []persistence.PersistedHand{hand(40)}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	rows[0].Hand.Players[0].VPIP = false
+	if _, err := repo.UpsertHands(ctx, rows[:1]); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	check(t)
+}
+
+// afterRecordingRepo records the watermark of every ListHandsAfter call.
+type afterRecordingRepo struct {
+	*persistence.SQLiteRepository
+	after []time.Time
+}
+
+func (r *afterRecordingRepo) ListHandsAfter(ctx context.Context, after time.Time, localSeat int) ([]*parser.Hand, error) {
+	r.after = append(r.after, after)
+	return r.SQLiteRepository.ListHandsAfter(ctx, after, localSeat)
+}
+
+func TestAllTimeStatsResumeFromPersistedCalculator(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	sqliteRepo, err := persistence.NewSQLiteRepository(filepath.Join(t.TempDir(), "stats.db"))
+	if err != nil {
+		t.Fatalf("new sqlite repo: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = sqliteRepo.Close()
+	})
+	repo := &afterRecordingRepo{SQLiteRepository: sqliteRepo}
+
+	base := time.Date(2026, 3, 2, 5, 0, 0, 0, time.UTC)
+	upsert := func(i int) {
+		t.Helper()
+		h := &parser.Hand{
+			ID:              i + 1,
+			StartTime:       base.Add(time.Duration(i) * time.Hour),
+			LocalPlayerSeat: 0,
+			Players:         map[int]*parser.PlayerHandInfo{0: {SeatID: 0, Position: parser.PosBTN, VPIP: i%2 == 0}},
+			NumPlayers:      2,
+			IsComplete:      true,
+			StatsEligible:   true,
+		}
+		src := persistence.HandSourceRef{SourcePath: "test.log", StartByte: int64(i * 100), EndByte: int64(i*100 + 99)}
+		src.HandUID = persistence.GenerateHandUID(h, src)
+		if _, err := repo.UpsertHands(ctx, []persistence.PersistedHand{{Hand: h, Source: src}}); err != nil {
+			t.Fatalf("upsert: %v", err)
+		}
+	}
+	// allTime runs an all-time query on a fresh service, like a restart, and
+	// returns the watermark it resumed from.
+	allTime := func(wantHands int) time.Time {
+		t.Helper()
+		repo.after = nil
+		got, _, err := NewService(repo, nil).Stats(ctx, persistence.HandFilter{})
+		if err != nil {
+			t.Fatalf("stats: %v", err)
+		}
+		if got.TotalHands != wantHands {
+			t.Fatalf("hands = %d, want %d", got.TotalHands, wantHands)
+		}
+		if len(repo.after) != 1 {
+			t.Fatalf("ListHandsAfter calls = %d, want 1", len(repo.after))
+		}
+		return repo.after[0]
+	}
+
+	for i := 2; i < 6; i++ {
+		upsert(i)
+	}
+	if after := allTime(4); !after.IsZero() {
+		t.Fatalf("first query resumed from %v", after)
+	}
+	if after := allTime(4); !after.Equal(base.Add(5 * time.Hour)) {
+		t.Fatalf("restart resumed from %v, want the last hand", after)
+	}
+
+	upsert(6)
+	if after := allTime(5); !after.Equal(base.Add(5 * time.Hour)) {
+		t.Fatalf("resumed from %v after a newer hand", after)
+	}
+
+	// A hand older than the watermark discards the persisted state.
+	upsert(0)
+	if after := allTime(6); !after.IsZero() {
+		t.Fatalf("resumed from %v after an older hand", after)
+	}
+}
+
+func TestBackfilledHandsUpdateAllTimeStatsWithoutRebuild(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	sqliteRepo, err := persistence.NewSQLiteRepository(filepath.Join(t.TempDir(), "stats.db"))
+	if err != nil {
+		t.Fatalf("new sqlite repo: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = sqliteRepo.Close()
+	})
+	repo := &afterRecordingRepo{SQLiteRepository: sqliteRepo}
+
+	base := time.Date(2026, 3, 2, 5, 0, 0, 0, time.UTC)
+	row := func(i int, vpip bool) persistence.PersistedHand {
+		h := &parser.Hand{
+			ID:              i + 1,
+			StartTime:       base.Add(time.Duration(i) * time.Hour),
+			LocalPlayerSeat: 0,
+			Players:         map[int]*parser.PlayerHandInfo{0: {SeatID: 0, Position: parser.Position(i % 4), VPIP: vpip}},
+			NumPlayers:      2 + i%5,
+			IsComplete:      true,
+			StatsEligible:   true,
+		}
+		return persistence.PersistedHand{Hand: h, Source: persistence.HandSourceRef{
+			SourcePath: fmt.Sprintf("log%d.txt", i/10),
+			StartByte:  int64(i * 100),
+			EndByte:    int64(i*100 + 99),
+		}}
+	}
+	batch := func(from, to int, vpip bool) []persistence.PersistedHand {
+		out := make([]persistence.PersistedHand, 0, to-from)
+		for i := from; i < to; i++ {
+			out = append(out, row(i, vpip))
+		}
+		return out
+	}
+
+	svc := NewService(repo, nil)
+	if err := svc.saveImportBatch(ctx, batch(20, 30, true), persistence.ImportCursor{SourcePath: "log2.txt"}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if _, _, err := svc.Stats(ctx, persistence.HandFilter{}); err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+
+	// An older log imported late, and a hand of it imported again with
+	// other contents.
+	if err := svc.saveImportBatch(ctx, batch(0, 10, false), persistence.ImportCursor{SourcePath: "log0.txt"}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if err := svc.saveImportBatch(ctx, batch(5, 6, true), persistence.ImportCursor{SourcePath: "log0.txt"}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	check := func(svc *Service) {
+		t.Helper()
+		repo.after = nil
+		got, seat, err := svc.Stats(ctx, persistence.HandFilter{})
+		if err != nil {
+			t.Fatalf("stats: %v", err)
+		}
+		if len(repo.after) != 1 || !repo.after[0].Equal(base.Add(29*time.Hour)) {
+			t.Fatalf("stats resumed from %v, want the newest hand", repo.after)
+		}
+		hands, err := repo.ListHands(ctx, persistence.HandFilter{OnlyComplete: true})
+		if err != nil {
+			t.Fatalf("list hands: %v", err)
+		}
+		want := stats.NewCalculator().Calculate(hands, seat)
+		gotCopy, wantCopy := *got, *want
+		gotCopy.Tilt, wantCopy.Tilt = nil, nil
+		if !reflect.DeepEqual(gotCopy, wantCopy) {
+			t.Errorf("stats differ from a rebuild: %d hands, %d VPIP; want %d, %d",
+				got.TotalHands, got.VPIPHands, want.TotalHands, want.VPIPHands)
+		}
+	}
+	check(svc)
+	// The updated calculator was persisted, so a restart resumes too.
+	check(NewService(repo, nil))
+}
+
+func TestRepairDatabaseDeletesCursorsBeyondTheirLog(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	tmp := t.TempDir()
+	shrunk := filepath.Join(tmp, "shrunk.log")
+	intact := filepath.Join(tmp, "intact.log")
+	for _, path := range []string{shrunk, intact} {
+		if err := os.WriteFile(path, []byte("0123456789"), 0o600); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+	repo := persistence.NewMemoryRepository()
+	cursors := []persistence.ImportCursor{
+		{SourcePath: shrunk, NextByteOffset: 64},
+		{SourcePath: intact, NextByteOffset: 10},
+		// Logs that are gone keep their cursors.
+		{SourcePath: filepath.Join(tmp, "deleted.log"), NextByteOffset: 64},
+	}
+	for _, c := range cursors {
+		if _, err := repo.SaveImportBatch(ctx, nil, c); err != nil {
+			t.Fatalf("save cursor: %v", err)
+		}
+	}
+	svc := NewService(repo, nil)
+
+	check, err := svc.CheckDatabase(ctx)
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if len(check.Issues) != 1 || check.Issues[0].Problem != persistence.ProblemCursorBeyondFile || check.Issues[0].Subject != shrunk {
+		t.Fatalf("check issues = %+v", check.Issues)
+	}
+
+	res, err := svc.RepairDatabase(ctx)
+	if err != nil {
+		t.Fatalf("repair: %v", err)
+	}
+	if len(res.Repaired) != 1 || len(res.Remaining) != 0 {
+		t.Fatalf("repair = %+v", res)
+	}
+	if c, err := repo.GetCursor(ctx, shrunk); err != nil || c != nil {
+		t.Fatalf("shrunk cursor after repair = %+v, %v", c, err)
+	}
+	if c, err := repo.GetCursor(ctx, intact); err != nil || c == nil {
+		t.Fatalf("intact cursor after repair = %+v, %v", c, err)
+	}
+}
+
+func TestRepairDatabaseRebuildsRollupsOfDeletedHands(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "stats.db")
+	repo, err := persistence.NewSQLiteRepository(dbPath)
+	if err != nil {
+		t.Fatalf("new sqlite repo: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = repo.Close()
+	})
+
+	rows := make([]persistence.PersistedHand, 0, 2)
+	for i := range 2 {
+		h := &parser.Hand{
+			ID:              i + 1,
+			StartTime:       time.Date(2026, 3, 2+i, 12, 0, 0, 0, time.UTC),
+			LocalPlayerSeat: 0,
+			Players:         map[int]*parser.PlayerHandInfo{0: {SeatID: 0, VPIP: true}},
+			NumPlayers:      2,
+			IsComplete:      true,
+			StatsEligible:   true,
+		}
+		src := persistence.HandSourceRef{SourcePath: "test.log", StartByte: int64(i * 100), EndByte: int64(i*100 + 99)}
+		src.HandUID = persistence.GenerateHandUID(h, src)
+		rows = append(rows, persistence.PersistedHand{Hand: h, Source: src})
+	}
+	if _, err := repo.UpsertHands(ctx, rows); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	svc := NewService(repo, nil)
+	svc.localSeat = 0
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	if s, _, err := svc.Stats(ctx, persistence.HandFilter{FromTime: &from}); err != nil || s.TotalHands != 2 {
+		t.Fatalf("stats before the repair = %+v, %v", s, err)
+	}
+
+	// A crash left the first hand without its source, so the repair
+	// deletes it.
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	_, err = db.Exec(`DELETE FROM hand_occurrences WHERE hand_uid = ?`, rows[0].Source.HandUID)
+	_ = db.Close()
+	if err != nil {
+		t.Fatalf("drop hand source: %v", err)
+	}
+	res, err := svc.RepairDatabase(ctx)
+	if err != nil || len(res.Repaired) == 0 || len(res.Remaining) != 0 {
+		t.Fatalf("repair = %+v, %v", res, err)
+	}
+
+	// The rollups were rebuilt by the repair, not left for the next query.
+	if days, err := repo.DirtyRollupDays(ctx, 0, stats.RollupVersion); err != nil || len(days) != 0 {
+		t.Fatalf("dirty rollup days after the repair = %+v, %v", days, err)
+	}
+	rollups, err := repo.ListStatsRollups(ctx, "2026-03-01", "2026-03-31")
+	if err != nil || len(rollups) != 1 || rollups[0].Day != "2026-03-03" {
+		t.Fatalf("rollups after the repair = %+v, %v", rollups, err)
+	}
+}
+
+func TestLogWithoutTrailingNewlineKeepsCursorInsideFile(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	tmp := t.TempDir()
+	oldPath := filepath.Join(tmp, "old.log")
+	newPath := filepath.Join(tmp, "new.log")
+	oldLog := strings.TrimSuffix(testHandLog("06:00"), "\n")
+	newLog := strings.TrimSuffix(testHandLog("06:10"), "\n")
+	if err := os.WriteFile(oldPath, []byte(oldLog), 0o600); err != nil {
+		t.Fatalf("write old log: %v", err)
+	}
+	if err := os.WriteFile(newPath, []byte(newLog), 0o600); err != nil {
+		t.Fatalf("write new log: %v", err)
+	}
+
+	repo := persistence.NewMemoryRepository()
+	svc := NewService(repo, func([]string) ([]string, error) {
+		return []string{newPath, oldPath}, nil
+	})
+	if _, err := svc.BootstrapImportAllLogs(ctx); err != nil {
+		t.Fatalf("bootstrap import: %v", err)
+	}
+
+	// A finished log is read to its end; the last line of the followed log
+	// may still be written and is left for the watcher.
+	wantOffsets := map[string]int64{
+		oldPath: int64(len(oldLog)),
+		newPath: int64(strings.LastIndex(newLog, "\n") + 1),
+	}
+	for path, want := range wantOffsets {
+		if offset, err := svc.NextOffset(ctx, path); err != nil || offset != want {
+			t.Fatalf("next offset of %s = %d, %v; want %d", filepath.Base(path), offset, err, want)
+		}
+	}
+	hands, err := repo.ListHands(ctx, persistence.HandFilter{})
+	if err != nil || len(hands) != 1 {
+		t.Fatalf("hands = %d, %v; want the hand of the finished log", len(hands), err)
+	}
+
+	check, err := svc.CheckDatabase(ctx)
+	if err != nil || len(check.Issues) != 0 {
+		t.Fatalf("check = %+v, %v", check.Issues, err)
+	}
+	res, err := svc.RepairDatabase(ctx)
+	if err != nil || len(res.Repaired) != 0 || len(res.Remaining) != 0 {
+		t.Fatalf("repair = %+v, %v", res, err)
+	}
+}
+
+func TestRelativePresetPeriodHitsStatsCache(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	svc := NewService(persistence.NewMemoryRepository(), nil)
+	preset := FilterPreset{Name: "last week", Period: PresetPeriod{Mode: PresetPeriodLastDays, N: 7}}
+	minute := time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	statsAt := func(now time.Time) *stats.Stats {
+		t.Helper()
+		f, err := preset.HandFilter(now)
+		if err != nil {
+			t.Fatalf("preset filter: %v", err)
+		}
+		s, _, err := svc.Stats(ctx, f)
+		if err != nil {
+			t.Fatalf("stats: %v", err)
+		}
+		return s
+	}
+	first := statsAt(minute.Add(10 * time.Second))
+	if again := statsAt(minute.Add(50 * time.Second)); again != first {
+		t.Fatalf("stats within the same minute were recomputed")
+	}
+	if next := statsAt(minute.Add(70 * time.Second)); next == first {
+		t.Fatalf("stats of the next minute came from the cache")
+	}
+}
+
+func TestLiveImportHoldsBackHalfWrittenLineForWatcher(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	tmp := t.TempDir()
+	full := testHandLog("05:00") + testHandLog("05:01") + testHandLog("05:02")
+	refPath := filepath.Join(tmp, "reference.log")
+	if err := os.WriteFile(refPath, []byte(full), 0o600); err != nil {
+		t.Fatalf("write reference log: %v", err)
+	}
+	refRepo := persistence.NewMemoryRepository()
+	if err := NewService(refRepo, nil).ChangeLogFile(ctx, refPath); err != nil {
+		t.Fatalf("import reference: %v", err)
+	}
+
+	// The app starts while the game is in the middle of writing a line.
+	path := filepath.Join(tmp, "output_log.txt")
+	cut := strings.Index(full, "05:01:02") + 12
+	if err := os.WriteFile(path, []byte(full[:cut]), 0o600); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+	repo := persistence.NewMemoryRepository()
+	svc := NewService(repo, nil)
+	if err := svc.ChangeLogFile(ctx, path); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	offset, err := svc.NextOffset(ctx, path)
+	if want := int64(strings.LastIndex(full[:cut], "\n") + 1); err != nil || offset != want {
+		t.Fatalf("next offset = %d, %v; want %d (end of the last complete line)", offset, err, want)
+	}
+
+	// Follow the log from the cursor as the app does while the game writes
+	// the rest of it in small fragments.
+	w, err := watcher.NewLogWatcher(path, watcher.WatcherConfig{
+		Backend:         watcher.BackendPolling,
+		PollInterval:    time.Millisecond,
+		MaxPollInterval: 5 * time.Millisecond,
+		OnNewData: func(lines []string, start, end int64) {
+			if err := svc.ImportLines(ctx, path, lines, start, end); err != nil {
+				t.Errorf("import lines: %v", err)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("new watcher: %v", err)
+	}
+	w.SetOffset(offset)
+	if err := w.Start(); err != nil {
+		t.Fatalf("start watcher: %v", err)
+	}
+	defer w.Stop()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatalf("open log: %v", err)
+	}
+	defer f.Close()
+	for rest := full[cut:]; rest != ""; {
+		n := min(7, len(rest))
+		if _, err := f.WriteString(rest[:n]); err != nil {
+			t.Fatalf("write log: %v", err)
+		}
+		rest = rest[n:]
+		time.Sleep(time.Millisecond)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		offset, err := svc.NextOffset(ctx, path)
+		if err == nil && offset == int64(len(full)) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("next offset = %d, %v; want %d", offset, err, len(full))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	w.Stop()
+
+	want, err := refRepo.ListHands(ctx, persistence.HandFilter{})
+	if err != nil || len(want) == 0 {
+		t.Fatalf("reference hands = %d, %v", len(want), err)
+	}
+	got, err := repo.ListHands(ctx, persistence.HandFilter{})
+	if err != nil || len(got) != len(want) {
+		t.Fatalf("live hands = %d, %v; want %d", len(got), err, len(want))
+	}
+	for i := range want {
+		if got[i].StartTime != want[i].StartTime || got[i].TotalPot != want[i].TotalPot || !reflect.DeepEqual(got[i].Players, want[i].Players) {
+			t.Fatalf("hand %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/persistence"
+)
+
+// Setting keys in the app_settings table.
+const (
+	settingKeepRawLogs    = "keep_raw_logs"
+	settingExtraLogDirs   = "extra_log_dirs"
+	settingTiltWarning    = "tilt_warning"
+	settingWatcherBackend = "watcher_backend"
+	settingImportWorkers  = "import_workers"
+)
+
+// AppSettings are the user settings stored in the database.
+type AppSettings struct {
+	// KeepRawLogs stores a compressed copy of each new hand's raw log lines.
+	KeepRawLogs bool
+	// ExtraLogDirs are scanned for VRChat logs and log archives in addition
+	// to the platform's VRChat log directory.
+	ExtraLogDirs []string
+	// TiltWarning shows a warning when play drifts after a big loss in the
+	// current session.
+	TiltWarning bool
+	// WatcherBackend is how the live log is watched: "hybrid", "fsnotify"
+	// or "polling".
+	WatcherBackend string
+	// ImportWorkers is the number of logs parsed at once when importing
+	// past logs; 0 picks one per CPU, up to four.
+	ImportWorkers int
+}
+
+// DefaultAppSettings returns the settings used when nothing is stored yet.
+func DefaultAppSettings() AppSettings {
+	return AppSettings{KeepRawLogs: true, TiltWarning: true, WatcherBackend: "hybrid"}
+}
+
+func (a AppSettings) values() map[string]string {
+	dirs, _ := json.Marshal(a.ExtraLogDirs)
+	return map[string]string{
+		settingKeepRawLogs:    strconv.FormatBool(a.KeepRawLogs),
+		settingExtraLogDirs:   string(dirs),
+		settingTiltWarning:    strconv.FormatBool(a.TiltWarning),
+		settingWatcherBackend: a.WatcherBackend,
+		settingImportWorkers:  strconv.Itoa(a.ImportWorkers),
+	}
+}
+
+// appSettingsFromValues applies stored values on top of the defaults. Invalid
+// values are ignored so a bad row cannot prevent the app from starting.
+func appSettingsFromValues(values map[string]string) AppSettings {
+	out := DefaultAppSettings()
+	if v, ok := values[settingKeepRawLogs]; ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			out.KeepRawLogs = b
+		}
+	}
+	if v, ok := values[settingTiltWarning]; ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			out.TiltWarning = b
+		}
+	}
+	if v, ok := values[settingWatcherBackend]; ok && v != "" {
+		out.WatcherBackend = v
+	}
+	if v, ok := values[settingImportWorkers]; ok {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			out.ImportWorkers = n
+		}
+	}
+	if v, ok := values[settingExtraLogDirs]; ok {
+		var dirs []string
+		if err := json.Unmarshal([]byte(v), &dirs); err == nil {
+			out.ExtraLogDirs = dirs
+		}
+	}
+	return out
+}
+
+// Settings returns the current settings.
+func (s *Service) Settings(ctx context.Context) (AppSettings, error) {
+	if err := ctx.Err(); err != nil {
+		return AppSettings{}, err
+	}
+	s.settingsMu.RLock()
+	defer s.settingsMu.RUnlock()
+	return s.settings, nil
+}
+
+// SaveSettings stores settings and applies them to subsequent imports.
+func (s *Service) SaveSettings(ctx context.Context, settings AppSettings) error {
+	if err := s.repo.SaveSettings(ctx, settings.values()); err != nil {
+		return fmt.Errorf("save settings: %w", err)
+	}
+	settings.ExtraLogDirs = slices.Clone(settings.ExtraLogDirs)
+	s.settingsMu.Lock()
+	s.settings = settings
+	s.settingsMu.Unlock()
+	return nil
+}
+
+func (s *Service) currentSettings() AppSettings {
+	s.settingsMu.RLock()
+	defer s.settingsMu.RUnlock()
+	return s.settings
+}
+
+// loadSettings reads the stored settings, falling back to the defaults when
+// the repository cannot be read.
+func loadSettings(repo persistence.SettingsRepository) AppSettings {
+	values, err := repo.GetSettings(context.Background())
+	if err != nil {
+		slog.Warn("failed to load settings, using defaults", "error", err)
+		return DefaultAppSettings()
+	}
+	return appSettingsFromValues(values)
+}
@@ -0,0 +1,139 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/persistence"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
+)
+
+// loadIncrementalCalculator returns the persisted all-time calculator and
+// its watermark when the repository keeps one for localSeat, or an empty
+// calculator and the zero watermark.
+func (s *Service) loadIncrementalCalculator(ctx context.Context, localSeat int) (*stats.IncrementalCalculator, time.Time) {
+	repo, ok := s.repo.(persistence.CalculatorStateRepository)
+	if !ok {
+		return stats.NewIncrementalCalculator(localSeat), time.Time{}
+	}
+	snap, err := repo.LoadCalculatorState(ctx)
+	if err != nil {
+		slog.Warn("load stats calculator state", "error", err)
+	}
+	if snap == nil || snap.State == nil || snap.State.LocalSeat != localSeat {
+		return stats.NewIncrementalCalculator(localSeat), time.Time{}
+	}
+	ic, err := stats.RestoreIncrementalCalculator(snap.State)
+	if err != nil {
+		slog.Debug("discarding stats calculator state", "error", err)
+		return stats.NewIncrementalCalculator(localSeat), time.Time{}
+	}
+	return ic, snap.Watermark
+}
+
+// saveIncrementalCalculator persists the all-time calculator with its
+// watermark. The caller holds incMu.
+func (s *Service) saveIncrementalCalculator(ctx context.Context) {
+	repo, ok := s.repo.(persistence.CalculatorStateRepository)
+	if !ok || s.incCalc == nil || s.watermark.IsZero() {
+		return
+	}
+	snap := persistence.CalculatorSnapshot{Watermark: s.watermark, State: s.incCalc.State()}
+	if err := repo.SaveCalculatorState(ctx, snap); err != nil {
+		slog.Warn("save stats calculator state", "error", err)
+	}
+}
+
+// writeHands runs write, which stores rows, and keeps the all-time calculator
+// in step with it. Rows after the watermark are left to the next Stats call.
+// Rows at or before it are folded in directly: the stored versions they
+// replace are retracted and the written versions inserted, so backfilling an
+// older log costs only its own hands. stored holds the versions rows replace
+// when the caller already loaded them; when nil they are looked up.
+func (s *Service) writeHands(ctx context.Context, rows []persistence.PersistedHand, stored []*parser.Hand, write func() error) error {
+	s.incMu.Lock()
+	defer s.incMu.Unlock()
+
+	if s.incCalc == nil {
+		// The repository discards a persisted calculator that a write
+		// overtakes, so load it first and update it instead.
+		s.mu.RLock()
+		localSeat := s.localSeat
+		s.mu.RUnlock()
+		if ic, wm := s.loadIncrementalCalculator(ctx, localSeat); !wm.IsZero() {
+			s.incCalc, s.watermark, s.incLocalSeat = ic, wm, localSeat
+		}
+	}
+	if s.incCalc == nil || s.watermark.IsZero() {
+		return write()
+	}
+
+	behind := make([]persistence.PersistedHand, 0, len(rows))
+	var old []*parser.Hand
+	for i, row := range rows {
+		var prev *parser.Hand
+		if stored != nil {
+			prev = stored[i]
+		}
+		rowBehind := row.Hand != nil && !row.Hand.StartTime.After(s.watermark)
+		if rowBehind {
+			behind = append(behind, row)
+		}
+		if prev != nil && !prev.StartTime.After(s.watermark) {
+			old = append(old, prev)
+		}
+	}
+	if stored == nil && len(behind) > 0 {
+		prev, err := s.repo.StoredHands(ctx, behind)
+		if err != nil {
+			return fmt.Errorf("load stored hands: %w", err)
+		}
+		for _, h := range prev {
+			if h != nil && !h.StartTime.After(s.watermark) {
+				old = append(old, h)
+			}
+		}
+	}
+
+	if err := write(); err != nil {
+		return err
+	}
+	if len(behind) == 0 && len(old) == 0 {
+		return nil
+	}
+
+	// Insert the versions as stored, which is what a rebuild would feed.
+	written, err := s.repo.StoredHands(ctx, behind)
+	if err != nil {
+		slog.Warn("reload written hands, rebuilding stats", "error", err)
+		s.incCalc = nil
+		s.watermark = time.Time{}
+		s.dropIncrementalCalculatorState(ctx)
+		return nil
+	}
+	for _, h := range old {
+		s.incCalc.Retract(h)
+	}
+	for _, h := range written {
+		if h != nil {
+			s.incCalc.Insert(h)
+		}
+	}
+	s.saveIncrementalCalculator(ctx)
+	return nil
+}
+
+// dropIncrementalCalculatorState deletes the persisted all-time calculator.
+// The caller holds incMu.
+func (s *Service) dropIncrementalCalculatorState(ctx context.Context) {
+	repo, ok := s.repo.(persistence.CalculatorStateRepository)
+	if !ok {
+		return
+	}
+	if err := repo.DeleteCalculatorState(ctx); err != nil {
+		slog.Warn("delete stats calculator state", "error", err)
+	}
+}
@@ -0,0 +1,382 @@
+// Package handquery parses hand search expressions such as
+//
+//	pos:BTN hole:AKs board:flush pot>40bb action:flop:checkraise result:lost players<=4
+//
+// into a typed AST. Terms separated by spaces must all match; OR, parentheses
+// and a leading "-" (or NOT) combine them further. Terms about the hero (pos,
+// hole, pocket, made, net, action, result) refer to the local player. Words
+// without a field search hand notes and tags.
+//
+// The AST is evaluated by the persistence layer, which compiles it to SQL for
+// the SQLite repository and matches it directly for the in-memory one.
+package handquery
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
+)
+
+// Expr is a node of a parsed query. String returns the canonical query text
+// of the node.
+type Expr interface {
+	String() string
+}
+
+// And matches when every term matches.
+type And struct {
+	Terms []Expr
+}
+
+// Or matches when at least one term matches.
+type Or struct {
+	Terms []Expr
+}
+
+// Not matches when Term does not.
+type Not struct {
+	Term Expr
+}
+
+// Position matches hands where the hero sat in Position.
+type Position struct {
+	Position parser.Position
+}
+
+// Suitedness restricts a two-card hole pattern.
+type Suitedness int
+
+const (
+	AnySuit Suitedness = iota
+	Suited
+	Offsuit
+)
+
+// CardPattern matches a single card. An empty Rank or Suit matches any.
+// Ranks use the parser's notation ("10" for ten).
+type CardPattern struct {
+	Rank string
+	Suit string
+}
+
+// Matches reports whether c matches the pattern.
+func (p CardPattern) Matches(c parser.Card) bool {
+	return (p.Rank == "" || p.Rank == c.Rank) && (p.Suit == "" || p.Suit == c.Suit)
+}
+
+func (p CardPattern) String() string {
+	rank := p.Rank
+	if rank == "10" {
+		rank = "T"
+	}
+	return rank + p.Suit
+}
+
+// Hole matches the hero's hole cards. With one card the hero must hold a
+// matching card; with two cards both must match, in either order.
+type Hole struct {
+	Cards      []CardPattern
+	Suitedness Suitedness
+}
+
+// BoardTexture is a property of the community cards.
+type BoardTexture int
+
+const (
+	// BoardCard means the board holds a card matching Board.Card.
+	BoardCard BoardTexture = iota
+	// BoardFlush means three or more board cards share a suit.
+	BoardFlush
+	// BoardPaired means two board cards share a rank.
+	BoardPaired
+	// BoardMonotone means at least three board cards, all of one suit.
+	BoardMonotone
+	// BoardRainbow means the flop has three different suits.
+	BoardRainbow
+)
+
+// Board matches the community cards.
+type Board struct {
+	Texture BoardTexture
+	Card    CardPattern
+}
+
+// Pocket matches the hero's pocket category. As in the hand history filter,
+// a hand counts only toward the highest priority category it belongs to.
+type Pocket struct {
+	Category stats.PocketCategory
+}
+
+// Instance matches the type of the instance the hand was played in.
+type Instance struct {
+	Type parser.InstanceType
+}
+
+// MadeHand matches the hero's final hand class on a complete board. Class is
+// one of stats.AllMadeHandClasses.
+type MadeHand struct {
+	Class string
+}
+
+// Field is a numeric hand value used by Compare.
+type Field int
+
+const (
+	// FieldPot is the total pot.
+	FieldPot Field = iota
+	// FieldNet is the hero's net chips (pot won minus invested).
+	FieldNet
+	// FieldPlayers is the number of players dealt in.
+	FieldPlayers
+)
+
+// Op is a comparison operator.
+type Op int
+
+const (
+	OpEq Op = iota
+	OpNe
+	OpLt
+	OpLe
+	OpGt
+	OpGe
+)
+
+// Compare matches a numeric field against Value. When InBB is set, Value is
+// in big blinds and is scaled by the hand's big blind.
+type Compare struct {
+	Field Field
+	Op    Op
+	Value float64
+	InBB  bool
+}
+
+// Holds reports whether v op c.Value holds, for a value already in the
+// unit of c.Value.
+func (c Compare) Holds(v float64) bool {
+	switch c.Op {
+	case OpNe:
+		return v != c.Value
+	case OpLt:
+		return v < c.Value
+	case OpLe:
+		return v <= c.Value
+	case OpGt:
+		return v > c.Value
+	case OpGe:
+		return v >= c.Value
+	default:
+		return v == c.Value
+	}
+}
+
+// ActionKind is an action searched for with Action.
+type ActionKind int
+
+const (
+	ActionFold ActionKind = iota
+	ActionCheck
+	ActionCall
+	ActionBet
+	ActionRaise
+	ActionAllIn
+	// ActionCheckRaise is a check followed by a raise on the same street.
+	ActionCheckRaise
+)
+
+// Action matches hands where the hero took Kind, on Street unless AnyStreet.
+type Action struct {
+	Kind      ActionKind
+	Street    parser.Street
+	AnyStreet bool
+}
+
+// Outcome is a hand result searched for with Result.
+type Outcome int
+
+const (
+	// OutcomeWon means the hero won (part of) the pot.
+	OutcomeWon Outcome = iota
+	// OutcomeLost means the hero finished with negative net chips.
+	OutcomeLost
+	// OutcomeShowdown means the hero showed down.
+	OutcomeShowdown
+)
+
+// Result matches the hero's result in the hand.
+type Result struct {
+	Outcome Outcome
+}
+
+// Starred matches hands the user starred.
+type Starred struct{}
+
+// Tag matches hands carrying Tag (normalized like persistence.NormalizeTag).
+type Tag struct {
+	Tag string
+}
+
+// Text matches hands whose note or one of whose tags contains Text, ignoring
+// case. Text is lower-cased.
+type Text struct {
+	Text string
+}
+
+func (e And) String() string { return joinExprs(e.Terms, " ") }
+func (e Or) String() string  { return "(" + joinExprs(e.Terms, " OR ") + ")" }
+
+// String parenthesizes an And under Not: "-a b" would negate only a. An Or
+// prints its own parentheses.
+func (e Not) String() string {
+	if _, ok := e.Term.(And); ok {
+		return "-(" + e.Term.String() + ")"
+	}
+	return "-" + e.Term.String()
+}
+
+func (e Position) String() string {
+	return "pos:" + strings.ReplaceAll(e.Position.String(), "+", "")
+}
+
+func (e Hole) String() string {
+	var b strings.Builder
+	b.WriteString("hole:")
+	for _, c := range e.Cards {
+		b.WriteString(c.String())
+	}
+	switch e.Suitedness {
+	case Suited:
+		b.WriteString("s")
+	case Offsuit:
+		b.WriteString("o")
+	}
+	return b.String()
+}
+
+func (e Board) String() string {
+	if e.Texture == BoardCard {
+		return "board:" + e.Card.String()
+	}
+	return "board:" + boardTextureNames[e.Texture]
+}
+
+func (e Pocket) String() string {
+	for code, c := range pocketCodes {
+		if c == e.Category {
+			return "pocket:" + code
+		}
+	}
+	return "pocket:?"
+}
+
+func (e Instance) String() string { return "instance:" + string(e.Type) }
+
+func (e MadeHand) String() string {
+	return "made:" + strings.ToLower(strings.ReplaceAll(e.Class, " ", "_"))
+}
+
+func (e Compare) String() string {
+	s := fieldNames[e.Field] + opNames[e.Op] + strconv.FormatFloat(e.Value, 'f', -1, 64)
+	if e.InBB {
+		s += "bb"
+	}
+	return s
+}
+
+func (e Action) String() string {
+	if e.AnyStreet {
+		return "action:" + actionKindNames[e.Kind]
+	}
+	return "action:" + streetNames[e.Street] + ":" + actionKindNames[e.Kind]
+}
+
+func (e Result) String() string { return "result:" + outcomeNames[e.Outcome] }
+func (Starred) String() string  { return "is:starred" }
+func (e Tag) String() string    { return "tag:" + quoteIfNeeded(e.Tag) }
+func (e Text) String() string   { return quoteIfNeeded(e.Text) }
+
+func joinExprs(exprs []Expr, sep string) string {
+	parts := make([]string, len(exprs))
+	for i, e := range exprs {
+		parts[i] = e.String()
+	}
+	return strings.Join(parts, sep)
+}
+
+// quoteIfNeeded quotes s when Parse would not read it back as one plain
+// word: when it has whitespace, parentheses, a field separator or a leading
+// '-'.
+func quoteIfNeeded(s string) string {
+	if strings.HasPrefix(s, "-") || strings.ContainsAny(s, " \t\r\n()\":<>=!") {
+		return `"` + strings.ReplaceAll(s, `"`, "") + `"`
+	}
+	return s
+}
+
+var (
+	// pocketCodes are the codes of the pocket_categories table.
+	pocketCodes = map[string]stats.PocketCategory{
+		"premium":           stats.PocketPremium,
+		"second_premium":    stats.PocketSecondPremium,
+		"pair":              stats.PocketPair,
+		"suited_connector":  stats.PocketSuitedConnector,
+		"suited_one_gapper": stats.PocketSuitedOneGapper,
+		"suited":            stats.PocketSuited,
+		"ax":                stats.PocketAx,
+		"kx":                stats.PocketKx,
+		"broadway_offsuit":  stats.PocketBroadwayOffsuit,
+		"connector":         stats.PocketConnector,
+	}
+	instanceTypes = []parser.InstanceType{
+		parser.InstanceTypePublic,
+		parser.InstanceTypeFriends,
+		parser.InstanceTypeFriendsPlus,
+		parser.InstanceTypeInvite,
+		parser.InstanceTypeInvitePlus,
+		parser.InstanceTypeGroup,
+		parser.InstanceTypeGroupPlus,
+		parser.InstanceTypeGroupPublic,
+		parser.InstanceTypeUnknown,
+	}
+	boardTextureNames = map[BoardTexture]string{
+		BoardFlush:    "flush",
+		BoardPaired:   "paired",
+		BoardMonotone: "monotone",
+		BoardRainbow:  "rainbow",
+	}
+	fieldNames = map[Field]string{
+		FieldPot:     "pot",
+		FieldNet:     "net",
+		FieldPlayers: "players",
+	}
+	opNames = map[Op]string{
+		OpEq: "=",
+		OpNe: "!=",
+		OpLt: "<",
+		OpLe: "<=",
+		OpGt: ">",
+		OpGe: ">=",
+	}
+	actionKindNames = map[ActionKind]string{
+		ActionFold:       "fold",
+		ActionCheck:      "check",
+		ActionCall:       "call",
+		ActionBet:        "bet",
+		ActionRaise:      "raise",
+		ActionAllIn:      "allin",
+		ActionCheckRaise: "checkraise",
+	}
+	streetNames = map[parser.Street]string{
+		parser.StreetPreFlop: "preflop",
+		parser.StreetFlop:    "flop",
+		parser.StreetTurn:    "turn",
+		parser.StreetRiver:   "river",
+	}
+	outcomeNames = map[Outcome]string{
+		OutcomeWon:      "won",
+		OutcomeLost:     "lost",
+		OutcomeShowdown: "showdown",
+	}
+)
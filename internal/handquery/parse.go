@@ -0,0 +1,526 @@
+package handquery
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
+)
+
+// ParseError describes invalid query text. Pos is the byte offset of the
+// offending token.
+type ParseError struct {
+	Pos int
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Pos+1, e.Msg)
+}
+
+// Parse parses query text. Blank text yields a nil Expr and no error.
+// Errors are *ParseError.
+func Parse(text string) (Expr, error) {
+	toks, err := lex(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) == 1 {
+		return nil, nil
+	}
+	p := &queryParser{toks: toks}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, &ParseError{Pos: t.pos, Msg: "unexpected \")\""}
+	}
+	return e, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokLParen
+	tokRParen
+	tokOr
+	tokAnd
+	tokNot
+)
+
+type token struct {
+	kind tokenKind
+	pos  int
+	// text is the word with quotes removed.
+	text string
+	// sep is the index in text of the first unquoted field separator
+	// (':', '<', '>', '=' or '!'), or -1.
+	sep int
+}
+
+func isSeparator(c byte) bool {
+	return c == ':' || c == '<' || c == '>' || c == '=' || c == '!'
+}
+
+func lex(s string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			toks = append(toks, token{kind: tokLParen, pos: i})
+			i++
+		case c == ')':
+			toks = append(toks, token{kind: tokRParen, pos: i})
+			i++
+		case c == '-' && i+1 < len(s) && s[i+1] != ' ' && s[i+1] != '\t':
+			// Words are read whole, so a '-' seen here starts a token.
+			toks = append(toks, token{kind: tokNot, pos: i})
+			i++
+		default:
+			start := i
+			var b strings.Builder
+			sep := -1
+			quoted := false
+			for i < len(s) {
+				c := s[i]
+				if c == '"' {
+					quoted = !quoted
+					i++
+					continue
+				}
+				if !quoted && (c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '(' || c == ')') {
+					break
+				}
+				if !quoted && sep < 0 && isSeparator(c) {
+					sep = b.Len()
+				}
+				b.WriteByte(c)
+				i++
+			}
+			if quoted {
+				return nil, &ParseError{Pos: start, Msg: "unterminated quote"}
+			}
+			word := b.String()
+			kind := tokWord
+			if !strings.Contains(s[start:i], `"`) {
+				switch word {
+				case "OR":
+					kind = tokOr
+				case "AND":
+					kind = tokAnd
+				case "NOT":
+					kind = tokNot
+				}
+			}
+			toks = append(toks, token{kind: kind, pos: start, text: word, sep: sep})
+		}
+	}
+	return append(toks, token{kind: tokEOF, pos: len(s)}), nil
+}
+
+type queryParser struct {
+	toks []token
+	i    int
+}
+
+func (p *queryParser) peek() token { return p.toks[p.i] }
+
+func (p *queryParser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+// parseOr parses: and (OR and)*
+func (p *queryParser) parseOr() (Expr, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	terms := []Expr{first}
+	for p.peek().kind == tokOr {
+		p.next()
+		e, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, e)
+	}
+	if len(terms) == 1 {
+		return first, nil
+	}
+	return Or{Terms: terms}, nil
+}
+
+// parseAnd parses: unary ([AND] unary)*
+func (p *queryParser) parseAnd() (Expr, error) {
+	var terms []Expr
+	for {
+		t := p.peek()
+		if t.kind == tokEOF || t.kind == tokRParen || t.kind == tokOr {
+			break
+		}
+		if t.kind == tokAnd {
+			p.next()
+			continue
+		}
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, e)
+	}
+	switch len(terms) {
+	case 0:
+		t := p.peek()
+		return nil, &ParseError{Pos: t.pos, Msg: "expected a search term"}
+	case 1:
+		return terms[0], nil
+	default:
+		return And{Terms: terms}, nil
+	}
+}
+
+// parseUnary parses: (- | NOT) unary | "(" or ")" | term
+func (p *queryParser) parseUnary() (Expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNot:
+		if k := p.peek().kind; k == tokEOF || k == tokRParen || k == tokOr || k == tokAnd {
+			return nil, &ParseError{Pos: t.pos, Msg: "expected a search term after NOT"}
+		}
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Term: e}, nil
+	case tokLParen:
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if r := p.next(); r.kind != tokRParen {
+			return nil, &ParseError{Pos: t.pos, Msg: "missing \")\""}
+		}
+		return e, nil
+	case tokWord:
+		return parseTerm(t)
+	default:
+		return nil, &ParseError{Pos: t.pos, Msg: "expected a search term"}
+	}
+}
+
+// parseTerm parses a single word. Comma separated values expand to an Or.
+func parseTerm(t token) (Expr, error) {
+	if t.sep < 0 {
+		text := strings.ToLower(strings.Join(strings.Fields(t.text), " "))
+		if text == "" {
+			return nil, &ParseError{Pos: t.pos, Msg: "empty search term"}
+		}
+		return Text{Text: text}, nil
+	}
+
+	key := strings.ToLower(t.text[:t.sep])
+	rest := t.text[t.sep:]
+	op, value := OpEq, ""
+	if rest[0] == ':' {
+		value = rest[1:]
+	} else {
+		var ok bool
+		op, value, ok = cutOp(rest)
+		if !ok {
+			return nil, &ParseError{Pos: t.pos, Msg: fmt.Sprintf("invalid operator in %q", t.text)}
+		}
+		if _, numeric := fieldByName[key]; !numeric {
+			return nil, &ParseError{Pos: t.pos, Msg: fmt.Sprintf("%q cannot be compared with %q", key, opNames[op])}
+		}
+	}
+
+	parse, ok := termParsers[key]
+	if !ok {
+		if field, numeric := fieldByName[key]; numeric {
+			parse = func(v string) (Expr, error) { return parseCompare(field, op, v) }
+		} else {
+			return nil, &ParseError{Pos: t.pos, Msg: fmt.Sprintf("unknown field %q", key)}
+		}
+	}
+
+	var terms []Expr
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return nil, &ParseError{Pos: t.pos, Msg: fmt.Sprintf("missing value for %q", key)}
+		}
+		e, err := parse(v)
+		if err != nil {
+			return nil, &ParseError{Pos: t.pos, Msg: err.Error()}
+		}
+		terms = append(terms, e)
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return Or{Terms: terms}, nil
+}
+
+func cutOp(s string) (Op, string, bool) {
+	for _, op := range []Op{OpLe, OpGe, OpNe, OpLt, OpGt, OpEq} {
+		if v, ok := strings.CutPrefix(s, opNames[op]); ok {
+			return op, v, true
+		}
+	}
+	return 0, "", false
+}
+
+var fieldByName = map[string]Field{
+	"pot":     FieldPot,
+	"net":     FieldNet,
+	"players": FieldPlayers,
+}
+
+var termParsers = map[string]func(string) (Expr, error){
+	"pos":      parsePosition,
+	"position": parsePosition,
+	"hole":     parseHole,
+	"board":    parseBoard,
+	"pocket":   parsePocket,
+	"instance": parseInstance,
+	"made":     parseMadeHand,
+	"action":   parseAction,
+	"result":   parseResult,
+	"is":       parseIs,
+	"tag": func(v string) (Expr, error) {
+		return Tag{Tag: strings.ToLower(strings.Join(strings.Fields(v), " "))}, nil
+	},
+}
+
+func parsePosition(v string) (Expr, error) {
+	positions := map[string]parser.Position{
+		"SB":   parser.PosSB,
+		"BB":   parser.PosBB,
+		"UTG":  parser.PosUTG,
+		"UTG1": parser.PosUTG1,
+		"MP":   parser.PosMP,
+		"HJ":   parser.PosHJ,
+		"MP1":  parser.PosMP1,
+		"CO":   parser.PosCO,
+		"BTN":  parser.PosBTN,
+		"BU":   parser.PosBTN,
+	}
+	pos, ok := positions[strings.ReplaceAll(strings.ToUpper(v), "+", "")]
+	if !ok {
+		return nil, fmt.Errorf("unknown position %q", v)
+	}
+	return Position{Position: pos}, nil
+}
+
+// cutRank parses a leading rank ("A".."2", "T" or "10").
+func cutRank(s string) (string, string, bool) {
+	if strings.HasPrefix(s, "10") {
+		return "10", s[2:], true
+	}
+	if s == "" {
+		return "", s, false
+	}
+	r := strings.ToUpper(s[:1])
+	if r == "T" {
+		return "10", s[1:], true
+	}
+	if strings.Contains("AKQJ98765432", r) {
+		return r, s[1:], true
+	}
+	return "", s, false
+}
+
+// cutSuit parses a leading suit letter.
+func cutSuit(s string) (string, string) {
+	if s != "" && strings.Contains("hdcs", strings.ToLower(s[:1])) {
+		return strings.ToLower(s[:1]), s[1:]
+	}
+	return "", s
+}
+
+func parseCard(v string) (CardPattern, error) {
+	rank, rest, ok := cutRank(v)
+	if !ok {
+		return CardPattern{}, fmt.Errorf("invalid card %q", v)
+	}
+	suit, rest := cutSuit(rest)
+	if rest != "" {
+		return CardPattern{}, fmt.Errorf("invalid card %q", v)
+	}
+	return CardPattern{Rank: rank, Suit: suit}, nil
+}
+
+// parseHole parses "A", "Ah", "QQ", "AK", "AKs", "AKo" or "AhKd". A trailing
+// "s" after two ranks without suits means suited, not the spade suit.
+func parseHole(v string) (Expr, error) {
+	var h Hole
+	rest := v
+	for len(h.Cards) < 2 && rest != "" {
+		rank, r, ok := cutRank(rest)
+		if !ok {
+			break
+		}
+		suit, r := cutSuit(r)
+		h.Cards = append(h.Cards, CardPattern{Rank: rank, Suit: suit})
+		rest = r
+	}
+	if len(h.Cards) == 2 && h.Cards[0].Suit == "" && h.Cards[1].Suit == "s" && rest == "" {
+		h.Cards[1].Suit = ""
+		rest = "s"
+	}
+	switch strings.ToLower(rest) {
+	case "":
+	case "s", "o":
+		if len(h.Cards) != 2 || h.Cards[0].Suit != "" || h.Cards[1].Suit != "" {
+			return nil, fmt.Errorf("invalid hole cards %q", v)
+		}
+		h.Suitedness = Suited
+		if strings.ToLower(rest) == "o" {
+			h.Suitedness = Offsuit
+		}
+		if h.Suitedness == Suited && h.Cards[0].Rank == h.Cards[1].Rank {
+			return nil, fmt.Errorf("a pair cannot be suited: %q", v)
+		}
+	default:
+		return nil, fmt.Errorf("invalid hole cards %q", v)
+	}
+	if len(h.Cards) == 0 {
+		return nil, fmt.Errorf("invalid hole cards %q", v)
+	}
+	if len(h.Cards) == 2 && h.Cards[0] == h.Cards[1] && h.Cards[0].Suit != "" {
+		return nil, fmt.Errorf("duplicate card in %q", v)
+	}
+	return h, nil
+}
+
+func parseBoard(v string) (Expr, error) {
+	for texture, name := range boardTextureNames {
+		if strings.EqualFold(v, name) {
+			return Board{Texture: texture}, nil
+		}
+	}
+	card, err := parseCard(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid board %q: use a card or flush, paired, monotone, rainbow", v)
+	}
+	return Board{Texture: BoardCard, Card: card}, nil
+}
+
+func parsePocket(v string) (Expr, error) {
+	c, ok := pocketCodes[strings.ToLower(strings.ReplaceAll(v, "-", "_"))]
+	if !ok {
+		return nil, fmt.Errorf("unknown pocket category %q", v)
+	}
+	return Pocket{Category: c}, nil
+}
+
+func parseInstance(v string) (Expr, error) {
+	name := strings.ReplaceAll(strings.ToLower(v), "+", "_plus")
+	for _, t := range instanceTypes {
+		if string(t) == name {
+			return Instance{Type: t}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown instance type %q", v)
+}
+
+func parseMadeHand(v string) (Expr, error) {
+	name := strings.ToLower(strings.NewReplacer("_", " ", "-", " ").Replace(v))
+	switch name {
+	case "pair":
+		name = "one pair"
+	case "set":
+		name = "trips"
+	}
+	for _, cls := range stats.AllMadeHandClasses() {
+		if strings.ToLower(cls) == name {
+			return MadeHand{Class: cls}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown hand class %q", v)
+}
+
+func parseCompare(field Field, op Op, v string) (Expr, error) {
+	c := Compare{Field: field, Op: op}
+	num := v
+	if field != FieldPlayers {
+		if n, ok := strings.CutSuffix(strings.ToLower(v), "bb"); ok {
+			num, c.InBB = n, true
+		}
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %q", v)
+	}
+	c.Value = f
+	return c, nil
+}
+
+func parseAction(v string) (Expr, error) {
+	streets := map[string]parser.Street{
+		"preflop": parser.StreetPreFlop,
+		"pf":      parser.StreetPreFlop,
+		"flop":    parser.StreetFlop,
+		"turn":    parser.StreetTurn,
+		"river":   parser.StreetRiver,
+	}
+	kinds := map[string]ActionKind{
+		"fold":        ActionFold,
+		"check":       ActionCheck,
+		"call":        ActionCall,
+		"bet":         ActionBet,
+		"raise":       ActionRaise,
+		"allin":       ActionAllIn,
+		"all-in":      ActionAllIn,
+		"checkraise":  ActionCheckRaise,
+		"check-raise": ActionCheckRaise,
+		"xr":          ActionCheckRaise,
+	}
+	a := Action{AnyStreet: true}
+	kindText := strings.ToLower(v)
+	if street, k, ok := strings.Cut(kindText, ":"); ok {
+		s, known := streets[street]
+		if !known {
+			return nil, fmt.Errorf("unknown street %q", street)
+		}
+		a.Street, a.AnyStreet, kindText = s, false, k
+	}
+	kind, ok := kinds[kindText]
+	if !ok {
+		return nil, fmt.Errorf("unknown action %q", kindText)
+	}
+	a.Kind = kind
+	return a, nil
+}
+
+func parseResult(v string) (Expr, error) {
+	switch strings.ToLower(v) {
+	case "won", "win":
+		return Result{Outcome: OutcomeWon}, nil
+	case "lost", "loss":
+		return Result{Outcome: OutcomeLost}, nil
+	case "showdown", "sd":
+		return Result{Outcome: OutcomeShowdown}, nil
+	}
+	return nil, fmt.Errorf("unknown result %q", v)
+}
+
+func parseIs(v string) (Expr, error) {
+	if strings.EqualFold(v, "starred") {
+		return Starred{}, nil
+	}
+	return nil, fmt.Errorf("unknown flag %q", v)
+}
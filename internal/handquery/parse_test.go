@@ -0,0 +1,153 @@
+package handquery
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
+)
+
+func TestParseCanonicalForm(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "", want: ""},
+		{in: "pos:BTN hole:AKs board:flush pot>40bb action:flop:checkraise result:lost players<=4",
+			want: "pos:BTN hole:AKs board:flush pot>40bb action:flop:checkraise result:lost players<=4"},
+		{in: "pos:utg+1,hj", want: "(pos:UTG1 OR pos:HJ)"},
+		{in: "hole:QQ", want: "hole:QQ"},
+		{in: "hole:AKo", want: "hole:AKo"},
+		{in: "hole:AhKd", want: "hole:AhKd"},
+		{in: "hole:T9s", want: "hole:T9s"},
+		{in: "hole:10h", want: "hole:Th"},
+		{in: "board:Ah board:PAIRED", want: "board:Ah board:paired"},
+		{in: "made:two_pair made:set", want: "made:two_pair made:trips"},
+		{in: "pocket:Suited-Connector instance:friends+", want: "pocket:suited_connector instance:friends_plus"},
+		{in: "net<-20bb net:0 pot!=100", want: "net<-20bb net=0 pot!=100"},
+		{in: "action:raise action:pf:3bet", want: ""},
+		{in: "-result:won NOT is:starred", want: "-result:won -is:starred"},
+		{in: "(pos:BTN OR pos:CO) AND result:won", want: "(pos:BTN OR pos:CO) result:won"},
+		{in: `tag:"Review  With Coach" "Hero Call"`, want: `tag:"review with coach" "hero call"`},
+		{in: "3bet", want: "3bet"},
+	}
+	for _, tc := range tests {
+		got, err := Parse(tc.in)
+		if tc.want == "" && tc.in != "" {
+			if err == nil {
+				t.Fatalf("Parse(%q) = %v, want error", tc.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tc.in, err)
+		}
+		s := ""
+		if got != nil {
+			s = got.String()
+		}
+		if s != tc.want {
+			t.Fatalf("Parse(%q) = %q, want %q", tc.in, s, tc.want)
+		}
+	}
+}
+
+// TestParseStringRoundTrip checks that String is a faithful canonical form:
+// the Stats cache keys on it, so two different expressions must not print
+// the same.
+func TestParseStringRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	btn := Position{Position: parser.PosBTN}
+	won := Result{Outcome: OutcomeWon}
+	starred := Starred{}
+	tests := []Expr{
+		Not{Term: And{Terms: []Expr{btn, won}}},
+		And{Terms: []Expr{Not{Term: And{Terms: []Expr{btn, won}}}, starred}},
+		Not{Term: Or{Terms: []Expr{btn, won}}},
+		Not{Term: Not{Term: btn}},
+		Or{Terms: []Expr{And{Terms: []Expr{btn, won}}, Not{Term: starred}}},
+		Text{Text: "pos:btn"},
+		Text{Text: "net<0"},
+		Text{Text: "a=b!"},
+		Text{Text: "-tilt"},
+		Text{Text: "hero call"},
+		Text{Text: "(sic)"},
+		And{Terms: []Expr{Text{Text: "-tilt"}, Not{Term: Text{Text: "note:x"}}}},
+		Tag{Tag: "to:review"},
+		Tag{Tag: "-x"},
+		Tag{Tag: "with coach"},
+	}
+	for _, want := range tests {
+		s := want.String()
+		got, err := Parse(s)
+		if err != nil {
+			t.Fatalf("Parse(%q) of %#v: %v", s, want, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Parse(%q) = %#v, want %#v", s, got, want)
+		}
+	}
+}
+
+func TestParseBuildsTypedNodes(t *testing.T) {
+	t.Parallel()
+
+	got, err := Parse("hole:AKs action:turn:bet")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := And{Terms: []Expr{
+		Hole{Cards: []CardPattern{{Rank: "A"}, {Rank: "K"}}, Suitedness: Suited},
+		Action{Kind: ActionBet, Street: parser.StreetTurn},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Parse = %#v, want %#v", got, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in  string
+		pos int
+	}{
+		{in: "pos:XX", pos: 0},
+		{in: "result:won foo:bar", pos: 11},
+		{in: "hole:QQs", pos: 0},
+		{in: "hole:AhAh", pos: 0},
+		{in: "pos>3", pos: 0},
+		{in: "players>4bb", pos: 0},
+		{in: "(pos:BTN", pos: 0},
+		{in: "pos:BTN)", pos: 7},
+		{in: `tag:"open`, pos: 0},
+		{in: "pos:BTN OR", pos: 10},
+		{in: "NOT", pos: 0},
+	}
+	for _, tc := range tests {
+		_, err := Parse(tc.in)
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Fatalf("Parse(%q) error = %v, want *ParseError", tc.in, err)
+		}
+		if pe.Pos != tc.pos {
+			t.Fatalf("Parse(%q) error pos = %d (%v), want %d", tc.in, pe.Pos, err, tc.pos)
+		}
+	}
+}
+
+func TestCardPatternMatches(t *testing.T) {
+	t.Parallel()
+
+	ten := parser.Card{Rank: "10", Suit: "h"}
+	if !(CardPattern{Rank: "10"}).Matches(ten) || !(CardPattern{Suit: "h"}).Matches(ten) {
+		t.Fatalf("pattern should match %v", ten)
+	}
+	if (CardPattern{Rank: "10", Suit: "d"}).Matches(ten) {
+		t.Fatalf("pattern should not match %v", ten)
+	}
+}
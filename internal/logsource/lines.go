@@ -0,0 +1,37 @@
+package logsource
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+)
+
+// ReadLine reads one line terminated by '\n' and returns it without the line
+// ending, together with the number of bytes consumed. Lines longer than limit
+// are cut to limit bytes and reported as truncated; the rest of the line is
+// still consumed. It returns io.EOF with the partial line read so far when r
+// ends before a newline; a reader following a log that is still written must
+// read that line again once it is complete.
+func ReadLine(r *bufio.Reader, limit int) (line string, n int64, truncated bool, err error) {
+	var buf []byte
+	for {
+		frag, err := r.ReadSlice('\n')
+		n += int64(len(frag))
+		if room := limit - len(buf); len(frag) > room {
+			buf = append(buf, frag[:max(room, 0)]...)
+			truncated = true
+		} else {
+			buf = append(buf, frag...)
+		}
+		switch {
+		case err == nil:
+			buf = bytes.TrimSuffix(buf, []byte("\n"))
+			buf = bytes.TrimSuffix(buf, []byte("\r"))
+			return string(buf), n, truncated, nil
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		default:
+			return string(bytes.TrimSuffix(buf, []byte("\r"))), n, truncated, err
+		}
+	}
+}
@@ -0,0 +1,388 @@
+// Package logsource opens VRChat log files, including logs kept inside .gz,
+// .zip and .tar.gz archives.
+//
+// A log stored in an archive is addressed by a member path of the form
+// "<archive>!/<member>", which is used wherever a plain log path would be
+// (import cursors, hand sources). A .gz file holds a single log and is
+// addressed by its own path.
+package logsource
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// memberSep separates an archive path from the member name in a member path.
+const memberSep = "!/"
+
+// maxMemberSize bounds the decompressed size of a single archived log.
+const maxMemberSize = 1 << 30
+
+// File is an opened log. Plain logs are *os.File; archived logs are read into
+// memory.
+type File interface {
+	io.Reader
+	io.ReaderAt
+	io.Seeker
+	io.Closer
+}
+
+// Member is a VRChat log found inside an archive.
+type Member struct {
+	// Path is the member path used to open the log and key its cursor.
+	Path    string
+	Name    string
+	ModTime time.Time
+	// Head is the start of the log, read while listing the archive so the
+	// log can be recognized by content without decompressing it again.
+	Head Head
+}
+
+type archiveKind int
+
+const (
+	kindNone archiveKind = iota
+	kindGzip
+	kindZip
+	kindTarGzip
+)
+
+func archiveKindOf(p string) archiveKind {
+	lower := strings.ToLower(p)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return kindTarGzip
+	case strings.HasSuffix(lower, ".gz"):
+		return kindGzip
+	case strings.HasSuffix(lower, ".zip"):
+		return kindZip
+	default:
+		return kindNone
+	}
+}
+
+// IsArchive reports whether p names a supported archive (.gz, .zip, .tar.gz or .tgz).
+func IsArchive(p string) bool {
+	return archiveKindOf(p) != kindNone
+}
+
+// IsLogName reports whether the base name of p is a VRChat log file name.
+// Both slash and OS separators are accepted so archive member names match.
+func IsLogName(p string) bool {
+	name := path.Base(filepath.ToSlash(p))
+	matched, err := path.Match("output_log_*.txt", name)
+	return err == nil && matched
+}
+
+// MemberPath returns the path that addresses member inside archive.
+func MemberPath(archive, member string) string {
+	if archiveKindOf(archive) == kindGzip {
+		return archive
+	}
+	return archive + memberSep + member
+}
+
+// SplitMemberPath splits a member path into its archive and member name. ok is
+// false for plain log paths.
+func SplitMemberPath(p string) (archive, member string, ok bool) {
+	if i := strings.LastIndex(p, memberSep); i > 0 {
+		if k := archiveKindOf(p[:i]); k == kindZip || k == kindTarGzip {
+			return p[:i], p[i+len(memberSep):], true
+		}
+	}
+	if archiveKindOf(p) == kindGzip {
+		return p, gzipMemberName(p, ""), true
+	}
+	return "", "", false
+}
+
+// IsMemberPath reports whether p addresses a log inside an archive.
+func IsMemberPath(p string) bool {
+	_, _, ok := SplitMemberPath(p)
+	return ok
+}
+
+// gzipMemberName prefers the archive name without ".gz" and falls back to the
+// name recorded in the gzip header.
+func gzipMemberName(archive, headerName string) string {
+	name := filepath.Base(archive)
+	name = name[:len(name)-len(".gz")]
+	if !IsLogName(name) && IsLogName(headerName) {
+		return path.Base(filepath.ToSlash(headerName))
+	}
+	return name
+}
+
+// ListMembers returns the VRChat logs stored in archive.
+func ListMembers(archive string) ([]Member, error) {
+	switch archiveKindOf(archive) {
+	case kindZip:
+		r, err := zip.OpenReader(archive)
+		if err != nil {
+			return nil, fmt.Errorf("open zip %s: %w", archive, err)
+		}
+		defer r.Close()
+		var out []Member
+		for _, f := range r.File {
+			if f.FileInfo().IsDir() || !IsLogName(f.Name) {
+				continue
+			}
+			head, err := readZipHead(f)
+			if err != nil {
+				return nil, fmt.Errorf("read %s in %s: %w", f.Name, archive, err)
+			}
+			out = append(out, Member{Path: MemberPath(archive, f.Name), Name: f.Name, ModTime: f.Modified, Head: head})
+		}
+		return out, nil
+	case kindTarGzip:
+		var out []Member
+		err := walkTarGzip(archive, func(hdr *tar.Header, r io.Reader) (bool, error) {
+			if hdr.Typeflag != tar.TypeReg || !IsLogName(hdr.Name) {
+				return false, nil
+			}
+			head, err := ReadHead(r)
+			if err != nil {
+				return false, fmt.Errorf("read %s in %s: %w", hdr.Name, archive, err)
+			}
+			out = append(out, Member{Path: MemberPath(archive, hdr.Name), Name: hdr.Name, ModTime: hdr.ModTime, Head: head})
+			return false, nil
+		})
+		return out, err
+	case kindGzip:
+		f, err := os.Open(archive)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("open gzip %s: %w", archive, err)
+		}
+		defer zr.Close()
+		name := gzipMemberName(archive, zr.Name)
+		if !IsLogName(name) {
+			return nil, nil
+		}
+		mod := zr.ModTime
+		if mod.IsZero() {
+			if info, err := f.Stat(); err == nil {
+				mod = info.ModTime()
+			}
+		}
+		head, err := ReadHead(zr)
+		if err != nil {
+			return nil, fmt.Errorf("read gzip %s: %w", archive, err)
+		}
+		return []Member{{Path: archive, Name: name, ModTime: mod, Head: head}}, nil
+	default:
+		return nil, fmt.Errorf("%s is not a supported archive", archive)
+	}
+}
+
+func readZipHead(f *zip.File) (Head, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ReadHead(rc)
+}
+
+// Open opens a plain log path or an archive member path. A missing archive or
+// member yields an error wrapping fs.ErrNotExist.
+func Open(p string) (File, error) {
+	archive, member, ok := SplitMemberPath(p)
+	if !ok {
+		return os.Open(p)
+	}
+	data, err := readMember(archive, member)
+	if err != nil {
+		return nil, err
+	}
+	return memberFile{bytes.NewReader(data)}, nil
+}
+
+type memberFile struct {
+	*bytes.Reader
+}
+
+func (memberFile) Close() error { return nil }
+
+func readMember(archive, member string) ([]byte, error) {
+	switch archiveKindOf(archive) {
+	case kindZip:
+		r, err := zip.OpenReader(archive)
+		if err != nil {
+			return nil, fmt.Errorf("open zip %s: %w", archive, err)
+		}
+		defer r.Close()
+		for _, f := range r.File {
+			if f.Name != member {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("open %s in %s: %w", member, archive, err)
+			}
+			defer rc.Close()
+			return readLimited(rc, archive, member)
+		}
+	case kindTarGzip:
+		var data []byte
+		err := walkTarGzip(archive, func(hdr *tar.Header, r io.Reader) (bool, error) {
+			if hdr.Typeflag != tar.TypeReg || hdr.Name != member {
+				return false, nil
+			}
+			var err error
+			data, err = readLimited(r, archive, member)
+			return true, err
+		})
+		if err != nil || data != nil {
+			return data, err
+		}
+	case kindGzip:
+		f, err := os.Open(archive)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("open gzip %s: %w", archive, err)
+		}
+		defer zr.Close()
+		return readLimited(zr, archive, member)
+	}
+	return nil, fmt.Errorf("%s in %s: %w", member, archive, fs.ErrNotExist)
+}
+
+func readLimited(r io.Reader, archive, member string) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxMemberSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read %s in %s: %w", member, archive, err)
+	}
+	if len(data) > maxMemberSize {
+		return nil, fmt.Errorf("%s in %s exceeds %d bytes", member, archive, maxMemberSize)
+	}
+	return data, nil
+}
+
+// walkTarGzip calls fn for each entry until fn returns done or an error.
+func walkTarGzip(archive string, fn func(hdr *tar.Header, r io.Reader) (done bool, err error)) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("open gzip %s: %w", archive, err)
+	}
+	defer zr.Close()
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read tar %s: %w", archive, err)
+		}
+		done, err := fn(hdr, tr)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// FingerprintSize is how many leading bytes of a log its fingerprint covers
+// at most. VRChat starts every log with a timestamped header, so two sessions
+// differ well within it.
+const FingerprintSize = 16 << 10
+
+// MinFingerprintSize is the fewest bytes a fingerprint covers. A log shorter
+// than that may not have finished its header line and has no fingerprint yet.
+const MinFingerprintSize = 256
+
+// Fingerprint identifies a log by its content rather than its path, so a log
+// that was moved, renamed, archived or copied from another machine is
+// recognized.
+type Fingerprint struct {
+	// Hash is the hex SHA-256 of the first Size bytes of the log.
+	Hash string
+	// Size is the number of bytes hashed: FingerprintSize, or the whole log
+	// when it is shorter. The fingerprint of a short log therefore changes
+	// as it grows, and is matched against a later state of the log with
+	// Head.Prefix(Size).
+	Size int
+}
+
+// IsZero reports whether f is no fingerprint.
+func (f Fingerprint) IsZero() bool {
+	return f.Hash == ""
+}
+
+// Head is the start of a log, up to FingerprintSize bytes.
+type Head []byte
+
+// ReadHead reads the head of the log read by r. It reads no further than
+// FingerprintSize bytes, so a reader streamed from an archive is not
+// decompressed beyond them.
+func ReadHead(r io.Reader) (Head, error) {
+	head := make([]byte, FingerprintSize)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	return Head(head[:n]), nil
+}
+
+// Fingerprint returns the fingerprint of the whole head, or the zero
+// Fingerprint when it is shorter than MinFingerprintSize.
+func (h Head) Fingerprint() Fingerprint {
+	return h.Prefix(len(h))
+}
+
+// Prefix returns the fingerprint of the first size bytes of the head, or the
+// zero Fingerprint when the head is shorter than size or size is below
+// MinFingerprintSize.
+func (h Head) Prefix(size int) Fingerprint {
+	if size < MinFingerprintSize || size > len(h) {
+		return Fingerprint{}
+	}
+	sum := sha256.Sum256(h[:size])
+	return Fingerprint{Hash: hex.EncodeToString(sum[:]), Size: size}
+}
+
+// ReadFingerprint returns the Fingerprint of an opened log.
+func ReadFingerprint(r io.ReaderAt) (Fingerprint, error) {
+	head, err := ReadHead(io.NewSectionReader(r, 0, FingerprintSize))
+	if err != nil {
+		return Fingerprint{}, err
+	}
+	return head.Fingerprint(), nil
+}
+
+// ReadHeadPath returns the Head of a plain log path or an archive member
+// path.
+func ReadHeadPath(p string) (Head, error) {
+	f, err := Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadHead(f)
+}
@@ -0,0 +1,237 @@
+package logsource
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	logName  = "output_log_2026-01-02_03-04-05.txt"
+	logBody  = "line one\nline two\n"
+	logMTime = "2026-01-02T03:04:05Z"
+)
+
+func writeZip(t *testing.T, path string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create zip: %v", err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for name, body := range map[string]string{"logs/" + logName: logBody, "readme.txt": "not a log"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("zip create %s: %v", name, err)
+		}
+		if _, err := io.WriteString(w, body); err != nil {
+			t.Fatalf("zip write: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip close: %v", err)
+	}
+}
+
+func writeTarGz(t *testing.T, path string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create tar.gz: %v", err)
+	}
+	defer f.Close()
+	zw := gzip.NewWriter(f)
+	tw := tar.NewWriter(zw)
+	mod, _ := time.Parse(time.RFC3339, logMTime)
+	if err := tw.WriteHeader(&tar.Header{Name: logName, Mode: 0o600, Size: int64(len(logBody)), ModTime: mod, Typeflag: tar.TypeReg}); err != nil {
+		t.Fatalf("tar header: %v", err)
+	}
+	if _, err := io.WriteString(tw, logBody); err != nil {
+		t.Fatalf("tar write: %v", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("tar close: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip close: %v", err)
+	}
+}
+
+func writeGz(t *testing.T, path string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create gz: %v", err)
+	}
+	defer f.Close()
+	zw := gzip.NewWriter(f)
+	if _, err := io.WriteString(zw, logBody); err != nil {
+		t.Fatalf("gzip write: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip close: %v", err)
+	}
+}
+
+func TestListMembersAndOpen(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		archive string
+		write   func(*testing.T, string)
+		member  string
+	}{
+		{name: "zip", archive: filepath.Join(dir, "old.zip"), write: writeZip, member: filepath.Join(dir, "old.zip") + "!/logs/" + logName},
+		{name: "tar.gz", archive: filepath.Join(dir, "old.tar.gz"), write: writeTarGz, member: filepath.Join(dir, "old.tar.gz") + "!/" + logName},
+		{name: "gz", archive: filepath.Join(dir, logName+".gz"), write: writeGz, member: filepath.Join(dir, logName+".gz")},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.write(t, tc.archive)
+			if !IsArchive(tc.archive) {
+				t.Fatalf("IsArchive(%q) = false", tc.archive)
+			}
+
+			members, err := ListMembers(tc.archive)
+			if err != nil {
+				t.Fatalf("ListMembers: %v", err)
+			}
+			if len(members) != 1 || members[0].Path != tc.member {
+				t.Fatalf("members = %+v, want one member %q", members, tc.member)
+			}
+			if string(members[0].Head) != logBody {
+				t.Fatalf("member head = %q, want %q", members[0].Head, logBody)
+			}
+			if !IsMemberPath(members[0].Path) {
+				t.Fatalf("IsMemberPath(%q) = false", members[0].Path)
+			}
+
+			f, err := Open(members[0].Path)
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			defer f.Close()
+			got, err := io.ReadAll(f)
+			if err != nil {
+				t.Fatalf("read member: %v", err)
+			}
+			if string(got) != logBody {
+				t.Fatalf("member body = %q, want %q", got, logBody)
+			}
+			buf := make([]byte, 4)
+			if _, err := f.ReadAt(buf, 5); err != nil || string(buf) != "one\n" {
+				t.Fatalf("ReadAt = %q, %v", buf, err)
+			}
+		})
+	}
+}
+
+func TestOpenMissingMemberIsNotExist(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "old.zip")
+	writeZip(t, archive)
+
+	if _, err := Open(MemberPath(archive, "output_log_missing.txt")); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("missing member error = %v, want fs.ErrNotExist", err)
+	}
+	if _, err := Open(MemberPath(filepath.Join(dir, "gone.zip"), logName)); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("missing archive error = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestSplitMemberPathIgnoresPlainLogs(t *testing.T) {
+	t.Parallel()
+
+	if _, _, ok := SplitMemberPath(filepath.Join("logs", logName)); ok {
+		t.Fatalf("plain log path reported as archive member")
+	}
+	archive, member, ok := SplitMemberPath("a/b.zip!/c/" + logName)
+	if !ok || archive != "a/b.zip" || member != "c/"+logName {
+		t.Fatalf("SplitMemberPath = %q, %q, %v", archive, member, ok)
+	}
+}
+
+func TestFingerprintFollowsContentNotPath(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	header := "2026.01.02 03:04:05 Debug      -  VRChat started\n"
+	body := header + strings.Repeat("2026.01.02 03:04:06 Log        -  filler\n", FingerprintSize/40+1)
+	plain := filepath.Join(dir, logName)
+	if err := os.WriteFile(plain, []byte(body), 0o600); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+	fingerprint := func(p string) Fingerprint {
+		t.Helper()
+		head, err := ReadHeadPath(p)
+		if err != nil {
+			t.Fatalf("read head of %s: %v", p, err)
+		}
+		return head.Fingerprint()
+	}
+	fp := fingerprint(plain)
+	if fp.IsZero() || fp.Size != FingerprintSize {
+		t.Fatalf("fingerprint = %+v", fp)
+	}
+
+	// The same log archived and grown further keeps its fingerprint.
+	archive := filepath.Join(dir, "moved.gz")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatalf("create gz: %v", err)
+	}
+	zw := gzip.NewWriter(f)
+	if _, err := io.WriteString(zw, body+"2026.01.02 03:05:00 Log        -  later\n"); err != nil {
+		t.Fatalf("gzip write: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip close: %v", err)
+	}
+	_ = f.Close()
+	if got := fingerprint(archive); got != fp {
+		t.Fatalf("archived fingerprint = %+v, want %+v", got, fp)
+	}
+
+	// Another session differs.
+	other := filepath.Join(dir, "other.txt")
+	if err := os.WriteFile(other, []byte(strings.Replace(body, "03:04:05", "04:00:00", 1)), 0o600); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+	if got := fingerprint(other); got.IsZero() || got == fp {
+		t.Fatalf("other session fingerprint = %+v", got)
+	}
+
+	// A short log is fingerprinted in full and recognized once it grew,
+	// but a log without its whole header has no fingerprint yet.
+	short := filepath.Join(dir, "short.txt")
+	if err := os.WriteFile(short, []byte(body[:1000]), 0o600); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+	shortFP := fingerprint(short)
+	if shortFP.Size != 1000 || shortFP == fp {
+		t.Fatalf("short log fingerprint = %+v", shortFP)
+	}
+	head, err := ReadHeadPath(archive)
+	if err != nil {
+		t.Fatalf("read head: %v", err)
+	}
+	if got := head.Prefix(shortFP.Size); got != shortFP {
+		t.Fatalf("prefix of the grown log = %+v, want %+v", got, shortFP)
+	}
+	if got := Head(header).Fingerprint(); !got.IsZero() {
+		t.Fatalf("header-only fingerprint = %+v, want none", got)
+	}
+}
@@ -85,3 +85,165 @@ func TestInstanceTypeClassification(t *testing.T) {
 		t.Fatalf("expected both friends_plus and friends instance types, got: %+v", result.Hands)
 	}
 }
+
+func TestHandValidationAnomalies(t *testing.T) {
+	t.Parallel()
+
+	const header = "2026.02.22 19:00:00 Debug      -  [Behaviour] Joining wrld_aeba3422-1543-4e6f-bd9d-0f41ddc5c4f8:11111~region(jp)\n" +
+		"2026.02.22 19:00:01 Debug      -  [Manager]: Local Seat Assigned. ID: 1\n" +
+		"2026.02.22 19:00:02 Debug      -  [Table]: Preparing for New Game:\n"
+	const footer = "2026.02.22 19:01:00 Debug      -  [Table]: Preparing for New Game:\n"
+	const blinds = "2026.02.22 19:00:03 Debug      -  [Seat]: Player 0 SB BET IN = 10\n" +
+		"2026.02.22 19:00:03 Debug      -  [Seat]: Player 1 BB BET IN = 20\n"
+
+	tests := []struct {
+		name         string
+		body         string
+		wantCode     string
+		wantSeverity string
+		wantEligible bool
+	}{
+		{
+			name: "clean hand",
+			body: blinds +
+				"2026.02.22 19:00:04 Debug      -  [Seat]: Player 0 Folded.\n" +
+				"2026.02.22 19:00:05 Debug      -  [PotManager]: All players folded, player 1 won 30\n",
+			wantEligible: true,
+		},
+		{
+			name: "multi-raise street",
+			body: blinds +
+				"2026.02.22 19:00:04 Debug      -  [Seat]: Player 0 End Turn with BET IN = 60\n" +
+				"2026.02.22 19:00:04 Debug      -  [Table]: New Min Bet: 60 === New Min Raise: 40\n" +
+				"2026.02.22 19:00:05 Debug      -  [Seat]: Player 1 End Turn with BET IN = 180\n" +
+				"2026.02.22 19:00:05 Debug      -  [Table]: New Min Bet: 180 === New Min Raise: 120\n" +
+				"2026.02.22 19:00:06 Debug      -  [Seat]: Player 0 End Turn with BET IN = 180\n" +
+				"2026.02.22 19:00:06 Debug      -  [Table]: Next phase.True - 2\n" +
+				"2026.02.22 19:00:06 Debug      -  [Table]: Collecting Bets. ----------------\n" +
+				"2026.02.22 19:00:07 Debug      -  [Table]: New Community Card: 2c\n" +
+				"2026.02.22 19:00:07 Debug      -  [Table]: New Community Card: 7d\n" +
+				"2026.02.22 19:00:07 Debug      -  [Table]: New Community Card: Qs\n" +
+				"2026.02.22 19:00:08 Debug      -  [Seat]: Player 0 End Turn with BET IN = 50\n" +
+				"2026.02.22 19:00:09 Debug      -  [Seat]: Player 1 Folded.\n" +
+				"2026.02.22 19:00:09 Debug      -  [PotManager]: All players folded, player 0 won 410\n",
+			wantEligible: true,
+		},
+		{
+			name: "chip conservation",
+			body: blinds +
+				"2026.02.22 19:00:04 Debug      -  [Seat]: Player 0 Folded.\n" +
+				"2026.02.22 19:00:05 Debug      -  [PotManager]: All players folded, player 1 won 50\n",
+			wantCode:     AnomalyChipConservation,
+			wantSeverity: AnomalySeverityWarn,
+		},
+		{
+			name: "missing blinds",
+			body: "2026.02.22 19:00:04 Debug      -  [Seat]: Player 0 End Turn with BET IN = 20\n" +
+				"2026.02.22 19:00:05 Debug      -  [PotManager]: All players folded, player 0 won 20\n",
+			wantCode:     AnomalyMissingBlinds,
+			wantSeverity: AnomalySeverityInfo,
+			wantEligible: true,
+		},
+		{
+			name: "dead button without big blind",
+			body: "2026.02.22 19:00:03 Debug      -  [Seat]: Player 0 SB BET IN = 10\n" +
+				"2026.02.22 19:00:04 Debug      -  [Seat]: Player 1 End Turn with BET IN = 40\n" +
+				"2026.02.22 19:00:05 Debug      -  [Seat]: Player 0 Folded.\n" +
+				"2026.02.22 19:00:05 Debug      -  [PotManager]: All players folded, player 1 won 50\n",
+			wantCode:     AnomalyMissingBlinds,
+			wantSeverity: AnomalySeverityInfo,
+			wantEligible: true,
+		},
+		{
+			name: "folded winner",
+			body: blinds +
+				"2026.02.22 19:00:04 Debug      -  [Seat]: Player 0 Folded.\n" +
+				"2026.02.22 19:00:05 Debug      -  [PotManager]: All players folded, player 0 won 30\n",
+			wantCode:     AnomalyFoldedWinner,
+			wantSeverity: AnomalySeverityError,
+		},
+		{
+			name: "action after fold",
+			body: blinds +
+				"2026.02.22 19:00:04 Debug      -  [Seat]: Player 0 Folded.\n" +
+				"2026.02.22 19:00:05 Debug      -  [Table]: New Community Card: 2c\n" +
+				"2026.02.22 19:00:05 Debug      -  [Table]: New Community Card: 3c\n" +
+				"2026.02.22 19:00:05 Debug      -  [Table]: New Community Card: 4c\n" +
+				"2026.02.22 19:00:06 Debug      -  [Seat]: Player 0 SB BET IN = 0\n" +
+				"2026.02.22 19:00:07 Debug      -  [PotManager]: All players folded, player 1 won 30\n",
+			wantCode:     AnomalyActionOrder,
+			wantSeverity: AnomalySeverityWarn,
+		},
+		{
+			name: "hole card on board",
+			body: blinds +
+				"2026.02.22 19:00:03 Debug      -  [Seat]: Draw Local Hole Cards: Ah, Kd\n" +
+				"2026.02.22 19:00:04 Debug      -  [Seat]: Player 0 End Turn with BET IN = 20\n" +
+				"2026.02.22 19:00:04 Debug      -  [Seat]: Player 1 End Turn with BET IN = 20\n" +
+				"2026.02.22 19:00:05 Debug      -  [Table]: Next phase.True - 2\n" +
+				"2026.02.22 19:00:05 Debug      -  [Table]: New Community Card: Ah\n" +
+				"2026.02.22 19:00:05 Debug      -  [Table]: New Community Card: 3c\n" +
+				"2026.02.22 19:00:05 Debug      -  [Table]: New Community Card: 4c\n" +
+				"2026.02.22 19:00:06 Debug      -  [Pot]: Winner: 1 Pot Amount: 40\n",
+			wantCode:     AnomalyHoleCardOnBoard,
+			wantSeverity: AnomalySeverityError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result, err := ParseReader(strings.NewReader(header + tt.body + footer))
+			if err != nil {
+				t.Fatalf("ParseReader error: %v", err)
+			}
+			if len(result.Hands) == 0 {
+				t.Fatal("expected a hand")
+			}
+			h := result.Hands[0]
+			if got := h.IsStatsEligible(); got != tt.wantEligible {
+				t.Fatalf("IsStatsEligible = %v, want %v (anomalies %+v)", got, tt.wantEligible, h.Anomalies)
+			}
+			if tt.wantCode == "" {
+				if len(h.Anomalies) != 0 {
+					t.Fatalf("expected no anomalies, got %+v", h.Anomalies)
+				}
+				return
+			}
+			for _, a := range h.Anomalies {
+				if a.Code == tt.wantCode {
+					if a.Severity != tt.wantSeverity {
+						t.Fatalf("%s severity = %q, want %q", a.Code, a.Severity, tt.wantSeverity)
+					}
+					return
+				}
+			}
+			t.Fatalf("expected %s anomaly, got %+v", tt.wantCode, h.Anomalies)
+		})
+	}
+}
+
+func TestInfoAnomalyKeepsHandEligible(t *testing.T) {
+	t.Parallel()
+
+	log := `
+2026.02.22 19:00:00 Debug      -  [Table]: Preparing for New Game:
+2026.02.22 19:00:03 Debug      -  [Seat]: Player 0 SB BET IN = 10
+2026.02.22 19:00:03 Debug      -  [Seat]: Player 1 BB BET IN = 20
+2026.02.22 19:00:04 Debug      -  [Seat]: Player 0 Folded.
+2026.02.22 19:00:05 Debug      -  [PotManager]: All players folded, player 1 won 30
+2026.02.22 19:01:00 Debug      -  [Table]: Preparing for New Game:
+`
+	result, err := ParseReader(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("ParseReader error: %v", err)
+	}
+	h := result.Hands[0]
+	if len(h.Anomalies) != 1 || h.Anomalies[0].Code != AnomalyLocalSeatUnknown {
+		t.Fatalf("expected only LOCAL_SEAT_UNKNOWN, got %+v", h.Anomalies)
+	}
+	if !h.HasDataAnomaly() || !h.IsStatsEligible() {
+		t.Fatalf("info anomaly should flag the hand but keep it eligible")
+	}
+}
@@ -60,3 +60,13 @@ func (p *Parser) RestoreWorldContext(wc WorldContext) {
 	p.inPokerWorld = wc.InPokerWorld
 	p.worldDetected = wc.WorldDetected
 }
+
+// RestoreLocalSeat sets the local player's seat before any lines are fed, for
+// callers that re-parse a section of a log whose seat assignment line lies
+// outside that section. Seat events in the parsed lines still take precedence.
+func (p *Parser) RestoreLocalSeat(seat int) {
+	if seat < 0 {
+		return
+	}
+	p.result.LocalPlayerSeat = seat
+}
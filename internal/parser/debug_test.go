@@ -30,10 +30,7 @@ func TestDebugStats(t *testing.T) {
 		}
 
 		// 投資額計算
-		invested := 0
-		for _, act := range pi.Actions {
-			invested += act.Amount
-		}
+		invested := pi.Invested()
 		totalInvested += invested
 		totalPotWon += pi.PotWon
 
@@ -0,0 +1,235 @@
+package parser
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// diagnosticTags are the log tags emitted by the VR Poker world. A line with one
+// of these tags that no known poker-event regex matches is reported as
+// unrecognized, so log format drift shows up before the stats go wrong.
+// [PotManager] must come before [Pot] because the latter is its prefix.
+var diagnosticTags = []string{"[Table]", "[Seat]", "[PotManager]", "[Pot]", "[Manager]"}
+
+var (
+	reDiagCard   = regexp.MustCompile(`\b(?:10|[2-9JQKA])[hdcs]\b`)
+	reDiagNumber = regexp.MustCompile(`\d+`)
+	reDiagSpace  = regexp.MustCompile(`\s+`)
+)
+
+// maxDiagnosticPatterns bounds the number of distinct patterns kept per
+// collector. A badly broken log would otherwise grow the map without limit.
+const maxDiagnosticPatterns = 256
+
+// UnrecognizedPattern groups unrecognized log lines that share a normalized shape.
+type UnrecognizedPattern struct {
+	Tag       string `json:"tag"`
+	Pattern   string `json:"pattern"`
+	Example   string `json:"example"`
+	FirstLine int64  `json:"first_line"`
+	Count     int    `json:"count"`
+}
+
+// DiagnosticsSnapshot is an immutable copy of the counters held by Diagnostics.
+type DiagnosticsSnapshot struct {
+	Lines             int            `json:"lines"`
+	TaggedLines       int            `json:"tagged_lines"`
+	UnrecognizedLines int            `json:"unrecognized_lines"`
+	UnrecognizedRate  float64        `json:"unrecognized_rate"`
+	DroppedPatterns   int            `json:"dropped_patterns"`
+	Hands             int            `json:"hands"`
+	AnomalousHands    int            `json:"anomalous_hands"`
+	AnomalyRate       float64        `json:"anomaly_rate"`
+	AnomalyCodes      map[string]int `json:"anomaly_codes"`
+	// Patterns is ordered by Count descending.
+	Patterns []UnrecognizedPattern `json:"patterns"`
+}
+
+// Diagnostics collects unrecognized poker log lines and hand anomaly counts
+// for a single log source. It is safe for concurrent use, and all methods are
+// no-ops on a nil receiver so callers can leave diagnostics disabled.
+type Diagnostics struct {
+	mu             sync.Mutex
+	lines          int
+	taggedLines    int
+	unrecognized   int
+	dropped        int
+	hands          int
+	anomalousHands int
+	anomalyCodes   map[string]int
+	patterns       map[string]*UnrecognizedPattern
+}
+
+func NewDiagnostics() *Diagnostics {
+	return &Diagnostics{
+		anomalyCodes: make(map[string]int),
+		patterns:     make(map[string]*UnrecognizedPattern),
+	}
+}
+
+// ObserveLine records one raw log line. lineNo is the 1-based line number in
+// the source file and is only used to locate the first example of a pattern.
+func (d *Diagnostics) ObserveLine(lineNo int64, line string) {
+	if d == nil {
+		return
+	}
+	tag, msg, tagged := diagnosticMessage(line)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lines++
+	if !tagged {
+		return
+	}
+	d.taggedLines++
+	if isKnownPokerEvent(msg) {
+		return
+	}
+	d.unrecognized++
+
+	pattern := NormalizeLogPattern(msg)
+	if p, ok := d.patterns[pattern]; ok {
+		p.Count++
+		return
+	}
+	if len(d.patterns) >= maxDiagnosticPatterns {
+		d.dropped++
+		return
+	}
+	d.patterns[pattern] = &UnrecognizedPattern{
+		Tag:       tag,
+		Pattern:   pattern,
+		Example:   msg,
+		FirstLine: lineNo,
+		Count:     1,
+	}
+}
+
+// ObserveHand records a finalized hand so anomaly rates can be reported per
+// source. A hand counts as anomalous when its anomalies exclude it from stats;
+// informational anomalies only show up in AnomalyCodes.
+func (d *Diagnostics) ObserveHand(h *Hand) {
+	if d == nil || h == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.hands++
+	for _, a := range h.Anomalies {
+		d.anomalyCodes[a.Code]++
+	}
+	if !h.IsStatsEligible() {
+		d.anomalousHands++
+	}
+}
+
+// Merge adds the counters of other into d. Pattern examples already present
+// in d are kept.
+func (d *Diagnostics) Merge(other *Diagnostics) {
+	if d == nil || other == nil || d == other {
+		return
+	}
+	snap := other.Snapshot()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lines += snap.Lines
+	d.taggedLines += snap.TaggedLines
+	d.unrecognized += snap.UnrecognizedLines
+	d.dropped += snap.DroppedPatterns
+	d.hands += snap.Hands
+	d.anomalousHands += snap.AnomalousHands
+	for code, n := range snap.AnomalyCodes {
+		d.anomalyCodes[code] += n
+	}
+	for _, p := range snap.Patterns {
+		if existing, ok := d.patterns[p.Pattern]; ok {
+			existing.Count += p.Count
+			continue
+		}
+		if len(d.patterns) >= maxDiagnosticPatterns {
+			d.dropped += p.Count
+			continue
+		}
+		cp := p
+		d.patterns[p.Pattern] = &cp
+	}
+}
+
+func (d *Diagnostics) Snapshot() DiagnosticsSnapshot {
+	if d == nil {
+		return DiagnosticsSnapshot{AnomalyCodes: map[string]int{}}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	out := DiagnosticsSnapshot{
+		Lines:             d.lines,
+		TaggedLines:       d.taggedLines,
+		UnrecognizedLines: d.unrecognized,
+		DroppedPatterns:   d.dropped,
+		Hands:             d.hands,
+		AnomalousHands:    d.anomalousHands,
+		AnomalyCodes:      make(map[string]int, len(d.anomalyCodes)),
+		Patterns:          make([]UnrecognizedPattern, 0, len(d.patterns)),
+	}
+	if d.taggedLines > 0 {
+		out.UnrecognizedRate = float64(d.unrecognized) / float64(d.taggedLines)
+	}
+	if d.hands > 0 {
+		out.AnomalyRate = float64(d.anomalousHands) / float64(d.hands)
+	}
+	for code, n := range d.anomalyCodes {
+		out.AnomalyCodes[code] = n
+	}
+	for _, p := range d.patterns {
+		out.Patterns = append(out.Patterns, *p)
+	}
+	sort.Slice(out.Patterns, func(i, j int) bool {
+		if out.Patterns[i].Count != out.Patterns[j].Count {
+			return out.Patterns[i].Count > out.Patterns[j].Count
+		}
+		return out.Patterns[i].Pattern < out.Patterns[j].Pattern
+	})
+	return out
+}
+
+// NormalizeLogPattern replaces the variable parts of a log message (cards and
+// numbers) with placeholders so lines of the same shape group together.
+func NormalizeLogPattern(msg string) string {
+	out := reDiagCard.ReplaceAllString(msg, "<card>")
+	out = reDiagNumber.ReplaceAllString(out, "<n>")
+	out = reDiagSpace.ReplaceAllString(out, " ")
+	return strings.TrimSpace(out)
+}
+
+// diagnosticMessage returns the tag and message of a timestamped line that
+// carries one of diagnosticTags. The cheap substring check keeps the timestamp
+// regex off the vast majority of non-poker lines.
+func diagnosticMessage(line string) (string, string, bool) {
+	if !strings.Contains(line, "]:") {
+		return "", "", false
+	}
+	m := reTimestamp.FindStringSubmatch(line)
+	if m == nil {
+		return "", "", false
+	}
+	msg := strings.TrimSpace(m[2])
+	for _, tag := range diagnosticTags {
+		if strings.HasPrefix(msg, tag) {
+			return tag, msg, true
+		}
+	}
+	return "", "", false
+}
+
+func isKnownPokerEvent(msg string) bool {
+	for _, re := range knownPokerEvents {
+		if re.MatchString(msg) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package parser
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+const diagnosticsLog = `2026.02.22 18:10:00 Debug      -  [Behaviour] Joining wrld_aeba3422-1543-4e6f-bd9d-0f41ddc5c4f8:11111~region(jp)
+2026.02.22 18:10:01 Debug      -  [Table]: Preparing for New Game:
+2026.02.22 18:10:01 Debug      -  [Seat]: Player 1 SB BET IN = 10
+2026.02.22 18:10:01 Debug      -  [Seat]: Player 2 BB BET IN = 20
+2026.02.22 18:10:02 Debug      -  [Seat]: Player 1 Start Turn
+2026.02.22 18:10:02 Debug      -  [Seat]: Player 2 Start Turn
+2026.02.22 18:10:03 Debug      -  [Table]: Dealt Burn Card: Ah
+2026.02.22 18:10:04 Debug      -  [Table]: New Community Card: Xx
+2026.02.22 18:10:05 Debug      -  [PotManager]: All players folded, player 2 won 30
+2026.02.22 18:10:06 Debug      -  [Table]: Preparing for New Game:
+`
+
+func TestDiagnosticsCollectsUnrecognizedPatterns(t *testing.T) {
+	t.Parallel()
+
+	d := NewDiagnostics()
+	p := NewParser()
+	scanner := bufio.NewScanner(strings.NewReader(diagnosticsLog))
+	lineNo := int64(0)
+	for scanner.Scan() {
+		lineNo++
+		d.ObserveLine(lineNo, scanner.Text())
+		_ = p.ParseLine(scanner.Text())
+	}
+	p.finalizeCurrentHand()
+	for _, h := range p.GetHands() {
+		d.ObserveHand(h)
+	}
+
+	snap := d.Snapshot()
+	if snap.Lines != 10 {
+		t.Fatalf("lines = %d, want 10", snap.Lines)
+	}
+	if snap.TaggedLines != 9 {
+		t.Fatalf("tagged lines = %d, want 9", snap.TaggedLines)
+	}
+	if snap.UnrecognizedLines != 3 {
+		t.Fatalf("unrecognized lines = %d, want 3", snap.UnrecognizedLines)
+	}
+	if len(snap.Patterns) != 2 {
+		t.Fatalf("patterns = %+v, want 2 entries", snap.Patterns)
+	}
+	top := snap.Patterns[0]
+	if top.Pattern != "[Seat]: Player <n> Start Turn" || top.Count != 2 || top.FirstLine != 5 || top.Tag != "[Seat]" {
+		t.Fatalf("unexpected top pattern: %+v", top)
+	}
+	if snap.Patterns[1].Pattern != "[Table]: Dealt Burn Card: <card>" {
+		t.Fatalf("unexpected second pattern: %+v", snap.Patterns[1])
+	}
+
+	if snap.Hands != 2 || snap.AnomalousHands != 1 {
+		t.Fatalf("hands=%d anomalous=%d, want 2/1", snap.Hands, snap.AnomalousHands)
+	}
+	if snap.AnomalyCodes["BOARD_PARSE_ERROR"] != 1 {
+		t.Fatalf("anomaly codes = %v, want BOARD_PARSE_ERROR", snap.AnomalyCodes)
+	}
+}
+
+func TestDiagnosticsMergeAndNil(t *testing.T) {
+	t.Parallel()
+
+	var disabled *Diagnostics
+	disabled.ObserveLine(1, "2026.02.22 18:10:02 Debug      -  [Seat]: Player 1 Start Turn")
+	if snap := disabled.Snapshot(); snap.Lines != 0 {
+		t.Fatalf("nil diagnostics should be empty, got %+v", snap)
+	}
+
+	a := NewDiagnostics()
+	b := NewDiagnostics()
+	a.ObserveLine(1, "2026.02.22 18:10:02 Debug      -  [Seat]: Player 1 Start Turn")
+	b.ObserveLine(7, "2026.02.22 18:10:03 Debug      -  [Seat]: Player 4 Start Turn")
+	b.ObserveLine(8, "2026.02.22 18:10:04 Debug      -  [Manager]: Seat Released. ID: 3")
+	a.Merge(b)
+
+	snap := a.Snapshot()
+	if snap.UnrecognizedLines != 3 || len(snap.Patterns) != 2 {
+		t.Fatalf("unexpected merged snapshot: %+v", snap)
+	}
+	if snap.Patterns[0].Count != 2 || snap.Patterns[0].FirstLine != 1 {
+		t.Fatalf("merge should keep the first example, got %+v", snap.Patterns[0])
+	}
+}
+
+func TestNormalizeLogPattern(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "[Seat]: Player 3 Show hole cards: 10h, Ks", want: "[Seat]: Player <n> Show hole cards: <card>, <card>"},
+		{in: "[Pot]: Side Pot 2   Amount: 120", want: "[Pot]: Side Pot <n> Amount: <n>"},
+		{in: "[Table]: Shuffle", want: "[Table]: Shuffle"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeLogPattern(tt.in); got != tt.want {
+			t.Errorf("NormalizeLogPattern(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
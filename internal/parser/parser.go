@@ -38,6 +38,15 @@ var (
 	rePotManager = regexp.MustCompile(`\[PotManager\]: All players folded, player (\d+) won (\d+)`)
 )
 
+// knownPokerEvents lists every poker-event regex processPokerEvent understands.
+// Diagnostics uses it to decide whether a [Table]/[Seat]/[Pot]/[Manager] line
+// was recognized, so new patterns must be added here as well.
+var knownPokerEvents = []*regexp.Regexp{
+	reNewGame, reNewCommunity, reFoldToOne, reNextPhase, reCollectingBets, reNewMinBet,
+	reDrawLocalHole, reSBBet, reBBBet, rePlayerFolded, rePlayerEndTurn, reShowHoleCards, reLocalSeatID,
+	rePotWinner, rePotManager,
+}
+
 // validCardSuits and validCardRanks are package-level constants used by
 // parseCard to avoid allocating a new map on every card parse call.
 var validCardSuits = map[string]bool{"h": true, "d": true, "c": true, "s": true}
@@ -267,16 +276,16 @@ func (p *Parser) processPokerEvent(ts time.Time, msg string) error {
 	if m := reNewCommunity.FindStringSubmatch(msg); m != nil {
 		card, err := parseCard(strings.TrimSpace(m[1]))
 		if err != nil {
-			p.currentHand.addAnomaly("BOARD_PARSE_ERROR", "warn", fmt.Sprintf("invalid board token: %s", strings.TrimSpace(m[1])))
+			p.currentHand.addAnomaly(AnomalyBoardParseError, AnomalySeverityWarn, fmt.Sprintf("invalid board token: %s", strings.TrimSpace(m[1])))
 			return nil
 		}
 		if len(p.currentHand.CommunityCards) >= 5 {
-			p.currentHand.addAnomaly("BOARD_OVERFLOW", "warn", "board card count exceeded 5")
+			p.currentHand.addAnomaly(AnomalyBoardOverflow, AnomalySeverityWarn, "board card count exceeded 5")
 			return nil
 		}
 		for _, existing := range p.currentHand.CommunityCards {
 			if existing.Rank == card.Rank && existing.Suit == card.Suit {
-				p.currentHand.addAnomaly("BOARD_DUPLICATE_CARD", "warn", card.String())
+				p.currentHand.addAnomaly(AnomalyBoardDuplicateCard, AnomalySeverityWarn, card.String())
 				return nil
 			}
 		}
@@ -490,6 +499,7 @@ func (p *Parser) startNewHand(ts time.Time) {
 		BBSeat:           -1,
 		WinnerSeat:       -1,
 		StatsEligible:    true,
+		ParserVersion:    Version,
 	}
 	if len(p.currentInstanceUsers) > 0 {
 		p.currentHand.InstanceUsers = make([]InstanceUser, 0, len(p.currentInstanceUsers))
@@ -533,7 +543,7 @@ func (p *Parser) finalizeCurrentHand() {
 
 	// Determine won/lost status based on profit/loss and participation
 	for _, pi := range h.Players {
-		invested := p.calculateInvestedAmount(pi)
+		invested := pi.Invested()
 		profit := pi.PotWon - invested
 
 		// Determine participation: participated if VPIP is true OR showed down
@@ -570,11 +580,9 @@ func (p *Parser) finalizeCurrentHand() {
 	h.EndTime = p.lastTimestamp
 	h.IsComplete = len(p.pendingWinners) > 0 || len(h.CommunityCards) > 0
 	if !validBoardCount(len(h.CommunityCards)) {
-		h.addAnomaly("BOARD_COUNT_INVALID", "warn", fmt.Sprintf("board_count=%d", len(h.CommunityCards)))
-	}
-	if h.HasAnomaly {
-		h.StatsEligible = false
+		h.addAnomaly(AnomalyBoardCountInvalid, AnomalySeverityWarn, fmt.Sprintf("board_count=%d", len(h.CommunityCards)))
 	}
+	validateHand(h)
 
 	p.result.Hands = append(p.result.Hands, h)
 
@@ -649,9 +657,12 @@ func (h *Hand) addAnomaly(code, severity, detail string) {
 			return
 		}
 	}
-	h.Anomalies = append(h.Anomalies, HandAnomaly{Code: code, Severity: severity, Detail: detail})
+	anomaly := HandAnomaly{Code: code, Severity: severity, Detail: detail}
+	h.Anomalies = append(h.Anomalies, anomaly)
 	h.HasAnomaly = true
-	h.StatsEligible = false
+	if anomaly.ExcludesFromStats() {
+		h.StatsEligible = false
+	}
 }
 
 func validBoardCount(n int) bool {
@@ -732,15 +743,3 @@ func parseLocationTags(tagsPart string) map[string]string {
 	}
 	return out
 }
-
-// calculateInvestedAmount calculates the total amount a player invested in a hand
-func (p *Parser) calculateInvestedAmount(pi *PlayerHandInfo) int {
-	if pi == nil {
-		return 0
-	}
-	total := 0
-	for _, act := range pi.Actions {
-		total += act.Amount
-	}
-	return total
-}
@@ -332,11 +332,10 @@ func TestIssue33SidePotDoesNotMeanVictory(t *testing.T) {
 		},
 	}
 
-	// Expected after calculateInvestedAmount and Win determination logic:
+	// Expected after Invested and Win determination logic:
 	// Player 3: invested=200, PotWon=100, profit=100-200=-100 < 0 -> Should NOT be Won
 
-	p := NewParser()
-	invested := p.calculateInvestedAmount(h.Players[3])
+	invested := h.Players[3].Invested()
 
 	if invested != 200 {
 		t.Errorf("expected invested=200, got %d", invested)
@@ -362,6 +361,74 @@ func TestIssue33SidePotDoesNotMeanVictory(t *testing.T) {
 
 // TestParticipationFlagForPreflopFold verifies that players who fold preflop without VPIP
 // are marked as not participating.
+// A 3-bet pot split at showdown: each winner gets back only a little more
+// than the 180 they put in, which counts as a win only when BET IN is read as
+// a running street total instead of being summed with the blind.
+const splitThreeBetLog = `
+2026.02.21 00:30:00 Debug      -  [Table]: Preparing for New Game: 
+2026.02.21 00:30:01 Debug      -  [Seat]: Player 0 SB BET IN = 10
+2026.02.21 00:30:01 Debug      -  [Seat]: Player 1 BB BET IN = 20
+2026.02.21 00:30:01 Debug      -  [Seat]: Draw Local Hole Cards: Ac, Kh
+2026.02.21 00:30:02 Debug      -  [Seat]: Player 2 End Turn with BET IN = 60
+2026.02.21 00:30:02 Debug      -  [Table]: New Min Bet: 60 === New Min Raise: 40
+2026.02.21 00:30:03 Debug      -  [Seat]: Player 0 End Turn with BET IN = 180
+2026.02.21 00:30:03 Debug      -  [Table]: New Min Bet: 180 === New Min Raise: 120
+2026.02.21 00:30:04 Debug      -  [Seat]: Player 1 Folded.
+2026.02.21 00:30:04 Debug      -  [Seat]: Player 2 End Turn with BET IN = 180
+2026.02.21 00:30:04 Debug      -  [Table]: Next phase.True - 2
+2026.02.21 00:30:04 Debug      -  [Table]: Collecting Bets. ----------------
+2026.02.21 00:30:05 Debug      -  [Table]: New Community Card: Qd
+2026.02.21 00:30:05 Debug      -  [Table]: New Community Card: 3s
+2026.02.21 00:30:05 Debug      -  [Table]: New Community Card: 8c
+2026.02.21 00:30:06 Debug      -  [Seat]: Player 0 End Turn with BET IN = 0
+2026.02.21 00:30:06 Debug      -  [Seat]: Player 2 End Turn with BET IN = 0
+2026.02.21 00:30:06 Debug      -  [Table]: Next phase.True - 2
+2026.02.21 00:30:06 Debug      -  [Table]: Collecting Bets. ----------------
+2026.02.21 00:30:07 Debug      -  [Table]: New Community Card: 5h
+2026.02.21 00:30:08 Debug      -  [Seat]: Player 0 End Turn with BET IN = 0
+2026.02.21 00:30:08 Debug      -  [Seat]: Player 2 End Turn with BET IN = 0
+2026.02.21 00:30:08 Debug      -  [Table]: Next phase.True - 2
+2026.02.21 00:30:08 Debug      -  [Table]: Collecting Bets. ----------------
+2026.02.21 00:30:09 Debug      -  [Table]: New Community Card: 2d
+2026.02.21 00:30:10 Debug      -  [Seat]: Player 0 End Turn with BET IN = 0
+2026.02.21 00:30:10 Debug      -  [Seat]: Player 2 End Turn with BET IN = 0
+2026.02.21 00:30:10 Debug      -  [Table]: Next phase.True - 2
+2026.02.21 00:30:10 Debug      -  [Table]: Collecting Bets. ----------------
+2026.02.21 00:30:11 Debug      -  [Seat]: Player 0 Show hole cards: Ac, Kh
+2026.02.21 00:30:11 Debug      -  [Seat]: Player 2 Show hole cards: Ad, Ks
+2026.02.21 00:30:12 Debug      -  [Pot]: Winner: 0 Pot Amount: 190
+2026.02.21 00:30:12 Debug      -  [Pot]: Winner: 2 Pot Amount: 190
+2026.02.21 00:30:20 Debug      -  [Table]: Preparing for New Game: 
+`
+
+func TestInvestedUsesRunningStreetTotals(t *testing.T) {
+	result, err := ParseReader(strings.NewReader(splitThreeBetLog))
+	if err != nil {
+		t.Fatalf("ParseReader error: %v", err)
+	}
+	if len(result.Hands) == 0 {
+		t.Fatal("expected a hand")
+	}
+	h := result.Hands[0]
+	if len(h.Anomalies) != 0 {
+		t.Fatalf("expected no anomalies, got %+v", h.Anomalies)
+	}
+	if h.TotalPot != 380 {
+		t.Fatalf("TotalPot = %d, want 380", h.TotalPot)
+	}
+	for seat, want := range map[int]int{0: 180, 1: 20, 2: 180} {
+		if got := h.Players[seat].Invested(); got != want {
+			t.Errorf("seat %d invested %d, want %d", seat, got, want)
+		}
+	}
+	if !h.Players[0].Won || !h.Players[2].Won {
+		t.Errorf("expected both split pot winners to have won: seat 0 %v, seat 2 %v", h.Players[0].Won, h.Players[2].Won)
+	}
+	if h.Players[1].Won {
+		t.Error("expected the folded big blind to have lost")
+	}
+}
+
 func TestParticipationFlagForPreflopFold(t *testing.T) {
 	h := &Hand{
 		ID:            2,
@@ -2,6 +2,12 @@ package parser
 
 import "time"
 
+// Version identifies the parsing logic that produced a Hand. Bump it whenever a
+// parser change alters the hands it produces, so stored hands parsed by an
+// older version can be found and reprocessed from their source logs.
+// Hands stored before versioning was introduced have version 0.
+const Version = 3
+
 type InstanceType string
 
 const (
@@ -16,12 +22,41 @@ const (
 	InstanceTypeGroupPublic InstanceType = "group_public"
 )
 
+// Anomaly severities. Info anomalies are informational only; warn and error
+// anomalies exclude the hand from stats (see HandAnomaly.ExcludesFromStats).
+const (
+	AnomalySeverityInfo  = "info"
+	AnomalySeverityWarn  = "warn"
+	AnomalySeverityError = "error"
+)
+
+// Anomaly codes recorded on HandAnomaly.Code.
+const (
+	AnomalyBoardParseError    = "BOARD_PARSE_ERROR"
+	AnomalyBoardOverflow      = "BOARD_OVERFLOW"
+	AnomalyBoardDuplicateCard = "BOARD_DUPLICATE_CARD"
+	AnomalyBoardCountInvalid  = "BOARD_COUNT_INVALID"
+	AnomalyChipConservation   = "CHIP_CONSERVATION"
+	AnomalyActionOrder        = "ACTION_ORDER_INVALID"
+	AnomalyHoleCardOnBoard    = "HOLE_CARD_ON_BOARD"
+	AnomalyFoldedWinner       = "FOLDED_WINNER"
+	AnomalyMissingBlinds      = "MISSING_BLINDS"
+	AnomalyLocalSeatUnknown   = "LOCAL_SEAT_UNKNOWN"
+)
+
 type HandAnomaly struct {
 	Code     string
 	Severity string
 	Detail   string
 }
 
+// ExcludesFromStats reports whether the anomaly makes its hand ineligible for
+// stats. Anything other than info does, including severities written by older
+// versions.
+func (a HandAnomaly) ExcludesFromStats() bool {
+	return a.Severity != AnomalySeverityInfo
+}
+
 type InstanceUser struct {
 	UserUID     string
 	DisplayName string
@@ -170,6 +205,26 @@ type PlayerHandInfo struct {
 	Participated bool // Participated in hand (not a pre-flop fold)
 }
 
+// Invested returns the chips the player put into the pot. A BET IN amount is
+// the player's running total for the street, not an increment, so each street
+// contributes the largest amount the player reached on it.
+func (pi *PlayerHandInfo) Invested() int {
+	if pi == nil {
+		return 0
+	}
+	perStreet := make(map[Street]int)
+	for _, act := range pi.Actions {
+		if act.Amount > perStreet[act.Street] {
+			perStreet[act.Street] = act.Amount
+		}
+	}
+	total := 0
+	for _, amount := range perStreet {
+		total += amount
+	}
+	return total
+}
+
 // Hand represents a single poker hand
 type Hand struct {
 	ID               int
@@ -198,6 +253,7 @@ type Hand struct {
 	HasAnomaly       bool
 	StatsEligible    bool
 	Anomalies        []HandAnomaly
+	ParserVersion    int // Version of the parser that produced the hand
 }
 
 func (h *Hand) HasDataAnomaly() bool {
@@ -211,8 +267,10 @@ func (h *Hand) IsStatsEligible() bool {
 	if h == nil {
 		return false
 	}
-	if h.HasDataAnomaly() {
-		return false
+	for _, a := range h.Anomalies {
+		if a.ExcludesFromStats() {
+			return false
+		}
 	}
 	return h.StatsEligible
 }
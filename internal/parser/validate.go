@@ -0,0 +1,149 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+)
+
+// validateHand runs the structural consistency checks on a finalized hand and
+// records an anomaly for each violation. The board checks happen while parsing
+// because they need the raw tokens; everything here only looks at the
+// assembled Hand, so it works the same on hands loaded from storage.
+func validateHand(h *Hand) {
+	if h == nil {
+		return
+	}
+	checkLocalSeat(h)
+	if !h.IsComplete {
+		return
+	}
+	checkBlinds(h)
+	checkActionOrder(h)
+	checkHoleCardsOnBoard(h)
+	checkFoldedWinner(h)
+	checkChipConservation(h)
+}
+
+func checkLocalSeat(h *Hand) {
+	if h.LocalPlayerSeat < 0 {
+		h.addAnomaly(AnomalyLocalSeatUnknown, AnomalySeverityInfo, "local seat was never assigned")
+	}
+}
+
+// checkBlinds flags hands without posted blinds. Valid hands can miss them:
+// a just vacated SB seat, a dead button or a straddle in place of the BB. So
+// the anomaly is only informational and keeps the hand in the stats; a hand
+// whose blinds were really lost to the log usually breaks chip conservation.
+func checkBlinds(h *Hand) {
+	hasSB, hasBB := false, false
+	for _, pi := range h.Players {
+		if pi == nil {
+			continue
+		}
+		for _, act := range pi.Actions {
+			switch act.Action {
+			case ActionBlindSB:
+				hasSB = true
+			case ActionBlindBB:
+				hasBB = true
+			}
+		}
+	}
+	switch {
+	case !hasBB && !hasSB:
+		h.addAnomaly(AnomalyMissingBlinds, AnomalySeverityInfo, "missing=SB,BB")
+	case !hasBB:
+		h.addAnomaly(AnomalyMissingBlinds, AnomalySeverityInfo, "missing=BB")
+	case !hasSB:
+		h.addAnomaly(AnomalyMissingBlinds, AnomalySeverityInfo, "missing=SB")
+	}
+}
+
+// checkActionOrder flags players who act after folding or whose actions move
+// back to an earlier street.
+func checkActionOrder(h *Hand) {
+	for _, seat := range sortedSeats(h) {
+		pi := h.Players[seat]
+		folded := false
+		lastStreet := StreetPreFlop
+		for _, act := range pi.Actions {
+			if folded && act.Action != ActionFold {
+				h.addAnomaly(AnomalyActionOrder, AnomalySeverityWarn,
+					fmt.Sprintf("seat %d %s after fold", seat, act.Action))
+				break
+			}
+			if act.Street < lastStreet {
+				h.addAnomaly(AnomalyActionOrder, AnomalySeverityWarn,
+					fmt.Sprintf("seat %d %s on %s after %s", seat, act.Action, act.Street, lastStreet))
+				break
+			}
+			lastStreet = act.Street
+			if act.Action == ActionFold {
+				folded = true
+			}
+		}
+	}
+}
+
+func checkHoleCardsOnBoard(h *Hand) {
+	if len(h.CommunityCards) == 0 {
+		return
+	}
+	board := make(map[Card]bool, len(h.CommunityCards))
+	for _, c := range h.CommunityCards {
+		board[c] = true
+	}
+	for _, seat := range sortedSeats(h) {
+		for _, c := range h.Players[seat].HoleCards {
+			if board[c] {
+				h.addAnomaly(AnomalyHoleCardOnBoard, AnomalySeverityError,
+					fmt.Sprintf("seat %d %s", seat, c))
+			}
+		}
+	}
+}
+
+func checkFoldedWinner(h *Hand) {
+	for _, seat := range sortedSeats(h) {
+		pi := h.Players[seat]
+		if pi.PotWon <= 0 {
+			continue
+		}
+		for _, act := range pi.Actions {
+			if act.Action == ActionFold {
+				h.addAnomaly(AnomalyFoldedWinner, AnomalySeverityError,
+					fmt.Sprintf("seat %d won %d after folding", seat, pi.PotWon))
+				break
+			}
+		}
+	}
+}
+
+// checkChipConservation compares the chips put in by every player with the
+// amount paid out.
+func checkChipConservation(h *Hand) {
+	if h.TotalPot <= 0 {
+		return
+	}
+	invested := 0
+	for _, seat := range sortedSeats(h) {
+		invested += h.Players[seat].Invested()
+	}
+	if invested != h.TotalPot {
+		h.addAnomaly(AnomalyChipConservation, AnomalySeverityWarn,
+			fmt.Sprintf("invested=%d pot=%d", invested, h.TotalPot))
+	}
+}
+
+// sortedSeats returns the seats with non-nil player info in ascending order so
+// anomaly details are deterministic.
+func sortedSeats(h *Hand) []int {
+	seats := make([]int, 0, len(h.Players))
+	for seat, pi := range h.Players {
+		if pi != nil {
+			seats = append(seats, seat)
+		}
+	}
+	sort.Ints(seats)
+	return seats
+}
@@ -0,0 +1,49 @@
+package persistence_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/persistence"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/persistence/repotest"
+)
+
+func TestMemoryRepositoryConformance(t *testing.T) {
+	t.Parallel()
+	repotest.TestRepository(t, func(_ *testing.T) persistence.ImportBatchRepository {
+		return persistence.NewMemoryRepository()
+	})
+}
+
+func TestSQLiteRepositoryConformance(t *testing.T) {
+	t.Parallel()
+	repotest.TestRepository(t, func(t *testing.T) persistence.ImportBatchRepository {
+		repo, err := persistence.NewSQLiteRepository(filepath.Join(t.TempDir(), "stats.db"))
+		if err != nil {
+			t.Fatalf("new sqlite repo: %v", err)
+		}
+		t.Cleanup(func() {
+			_ = repo.Close()
+		})
+		return damageableSQLite{repo}
+	})
+}
+
+// damageableSQLite damages a SQLite repository for the integrity tests of
+// the conformance suite.
+type damageableSQLite struct {
+	*persistence.SQLiteRepository
+}
+
+func (r damageableSQLite) DropHandSources(ctx context.Context, handUID string) error {
+	return r.ExecForTest(ctx, `DELETE FROM hand_occurrences WHERE hand_uid = ?`, handUID)
+}
+
+func (r damageableSQLite) DropHandRow(ctx context.Context, handUID string) error {
+	return r.ExecForTest(ctx, `DELETE FROM hands WHERE hand_uid = ?`, handUID)
+}
+
+func (r damageableSQLite) DropFilterIDs(ctx context.Context, handUID string) error {
+	return r.ExecForTest(ctx, `UPDATE hand_players SET pocket_category_id = NULL WHERE hand_uid = ?`, handUID)
+}
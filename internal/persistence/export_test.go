@@ -0,0 +1,10 @@
+package persistence
+
+import "context"
+
+// ExecForTest runs query against the database of r, so tests can damage the
+// stored data on purpose.
+func (r *SQLiteRepository) ExecForTest(ctx context.Context, query string, args ...any) error {
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return err
+}
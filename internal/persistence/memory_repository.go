@@ -2,37 +2,98 @@ package persistence
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
 )
 
 type inMemoryEntry struct {
-	hand   *parser.Hand
-	source HandSourceRef
+	hand *parser.Hand
+	// sources holds the first occurrence of the hand in every log file.
+	sources []HandSourceRef
+	rawLog  *RawLogSnippet
+	// pocketID and finalID are the local player's pocket category and final
+	// hand class IDs, 0 when unknown.
+	pocketID int
+	finalID  int
 }
 
+// sourceSpan identifies a hand occurrence by its byte range in a log.
+type sourceSpan struct {
+	path       string
+	start, end int64
+}
+
+// memoryRollup is a stored StatsRollup with the seat and version it was
+// built for.
+type memoryRollup struct {
+	StatsRollup
+	localSeat int
+	version   int
+}
+
+// MemoryRepository is an ImportBatchRepository, StatsRollupRepository and
+// CalculatorStateRepository kept in memory. It behaves like the SQLite
+// repository; both pass the repotest conformance suite.
 type MemoryRepository struct {
-	mu      sync.RWMutex
-	hands   map[string]inMemoryEntry
-	cursors map[string]ImportCursor
+	mu       sync.RWMutex
+	hands    map[string]inMemoryEntry
+	spans    map[sourceSpan]string
+	cursors  map[string]ImportCursor
+	settings map[string]string
+	notes    map[string]HandAnnotation
+	// dirty maps rollup days to their generation.
+	dirty      map[string]int64
+	rollups    map[string][]memoryRollup
+	calculator *CalculatorSnapshot
 }
 
 func NewMemoryRepository() *MemoryRepository {
 	return &MemoryRepository{
-		hands:   make(map[string]inMemoryEntry),
-		cursors: make(map[string]ImportCursor),
+		hands:    make(map[string]inMemoryEntry),
+		spans:    make(map[sourceSpan]string),
+		cursors:  make(map[string]ImportCursor),
+		settings: make(map[string]string),
+		notes:    make(map[string]HandAnnotation),
+		dirty:    make(map[string]int64),
+		rollups:  make(map[string][]memoryRollup),
 	}
 }
 
-func (r *MemoryRepository) UpsertHands(_ context.Context, hands []PersistedHand) (UpsertResult, error) {
+func (r *MemoryRepository) UpsertHands(ctx context.Context, hands []PersistedHand) (UpsertResult, error) {
+	if err := ctx.Err(); err != nil {
+		return UpsertResult{}, err
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	return r.upsertHandsLocked(hands), nil
 }
 
+// resolveHandUIDLocked returns the UID an upsert of ph is stored under: the
+// hand already stored for its source span, or else its own UID.
+func (r *MemoryRepository) resolveHandUIDLocked(ph PersistedHand) string {
+	if ph.Source.SourcePath != "" {
+		if uid, ok := r.spans[spanOf(ph.Source)]; ok {
+			return uid
+		}
+	}
+	if ph.Source.HandUID != "" {
+		return ph.Source.HandUID
+	}
+	return GenerateHandUID(ph.Hand, ph.Source)
+}
+
+func spanOf(src HandSourceRef) sourceSpan {
+	return sourceSpan{path: src.SourcePath, start: src.StartByte, end: src.EndByte}
+}
+
 func (r *MemoryRepository) upsertHandsLocked(hands []PersistedHand) UpsertResult {
 	res := UpsertResult{}
 	for _, ph := range hands {
@@ -40,54 +101,137 @@ func (r *MemoryRepository) upsertHandsLocked(hands []PersistedHand) UpsertResult
 			res.Skipped++
 			continue
 		}
-		uid := ph.Source.HandUID
-		if uid == "" {
-			uid = GenerateHandUID(ph.Hand, ph.Source)
+		if r.calculator != nil && !ph.Hand.StartTime.After(r.calculator.Watermark) {
+			r.calculator = nil
 		}
-		if _, ok := r.hands[uid]; ok {
+		uid := r.resolveHandUIDLocked(ph)
+		prev, ok := r.hands[uid]
+		if ok {
 			res.Updated++
+			if rollupDayOf(prev.hand.StartTime) != rollupDayOf(ph.Hand.StartTime) {
+				r.markRollupDayDirtyLocked(rollupDayOf(prev.hand.StartTime))
+			}
 		} else {
 			res.Inserted++
 		}
-		r.hands[uid] = inMemoryEntry{hand: parser.CloneHand(ph.Hand), source: ph.Source}
+		r.markRollupDayDirtyLocked(rollupDayOf(ph.Hand.StartTime))
+
+		entry := inMemoryEntry{hand: parser.CloneHand(ph.Hand), sources: prev.sources, rawLog: prev.rawLog}
+		entry.pocketID, entry.finalID = handFilterIDs(entry.hand)
+		if !slices.ContainsFunc(entry.sources, func(src HandSourceRef) bool { return src.SourcePath == ph.Source.SourcePath }) {
+			src := ph.Source
+			src.HandUID = uid
+			entry.sources = append(slices.Clip(entry.sources), src)
+			if src.SourcePath != "" {
+				r.spans[spanOf(src)] = uid
+			}
+		}
+		if ph.RawLog != nil && len(ph.RawLog.Data) > 0 {
+			raw := *ph.RawLog
+			raw.Data = append([]byte(nil), ph.RawLog.Data...)
+			entry.rawLog = &raw
+		}
+		r.hands[uid] = entry
 	}
 	return res
 }
 
+// handFilterIDs returns the IDs of the local player's pocket category and
+// final hand class, as the SQLite repository stores them in hand_players.
+// An ID is 0 when the hand has no such class.
+func handFilterIDs(h *parser.Hand) (pocketID, finalID int) {
+	if h == nil || h.LocalPlayerSeat < 0 {
+		return 0, 0
+	}
+	pi := h.Players[h.LocalPlayerSeat]
+	if pi == nil || len(pi.HoleCards) != 2 {
+		return 0, 0
+	}
+	if cats := stats.ClassifyPocketHand(pi.HoleCards[0], pi.HoleCards[1]); len(cats) > 0 {
+		pocketID = int(choosePocketCategory(cats)) + 1
+	}
+	if len(h.CommunityCards) >= 5 {
+		finalID = stats.MadeHandClassID(stats.ClassifyMadeHand(pi.HoleCards, h.CommunityCards))
+	}
+	return pocketID, finalID
+}
+
+// handMatchesLocked applies the conditions of f shared by every query; the
+// hand list applies LocalSeat, OnlyComplete and OnlyStatsExcluded on top.
+// The caller must hold r.mu.
+func (r *MemoryRepository) handMatchesLocked(uid string, entry inMemoryEntry, f HandFilter) (bool, error) {
+	h := entry.hand
+	if f.FromTime != nil && h.StartTime.Before(*f.FromTime) {
+		return false, nil
+	}
+	if f.ToTime != nil && h.StartTime.After(*f.ToTime) {
+		return false, nil
+	}
+	if len(f.PocketCategoryIDs) > 0 && !slices.Contains(f.PocketCategoryIDs, entry.pocketID) {
+		return false, nil
+	}
+	if len(f.FinalClassIDs) > 0 && !slices.Contains(f.FinalClassIDs, entry.finalID) {
+		return false, nil
+	}
+	if !r.annotationMatchesLocked(uid, f) {
+		return false, nil
+	}
+	if !tableSizeMatches(f.TableSizes, h.NumPlayers) {
+		return false, nil
+	}
+	if f.Query == nil {
+		return true, nil
+	}
+	return matchQuery(f.Query, h, r.notes[uid])
+}
+
+// listHandMatchesLocked is handMatchesLocked for ListHands and CountHands.
+func (r *MemoryRepository) listHandMatchesLocked(uid string, entry inMemoryEntry, f HandFilter) (bool, error) {
+	h := entry.hand
+	if h == nil || (f.OnlyComplete && !h.IsComplete) {
+		return false, nil
+	}
+	if f.OnlyStatsExcluded && h.IsStatsEligible() {
+		return false, nil
+	}
+	if f.LocalSeat != nil {
+		if _, ok := h.Players[*f.LocalSeat]; !ok {
+			return false, nil
+		}
+	}
+	return r.handMatchesLocked(uid, entry, f)
+}
+
 func (r *MemoryRepository) ListHands(_ context.Context, f HandFilter) ([]*parser.Hand, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	out := make([]*parser.Hand, 0, len(r.hands))
 	for uid, entry := range r.hands {
-		h := entry.hand
-		if h == nil {
-			continue
+		ok, err := r.listHandMatchesLocked(uid, entry, f)
+		if err != nil {
+			return nil, err
 		}
-		if f.OnlyComplete && !h.IsComplete {
+		if !ok {
 			continue
 		}
-		if f.FromTime != nil && h.StartTime.Before(*f.FromTime) {
-			continue
-		}
-		if f.ToTime != nil && h.StartTime.After(*f.ToTime) {
-			continue
-		}
-		if f.LocalSeat != nil {
-			if _, ok := h.Players[*f.LocalSeat]; !ok {
-				continue
-			}
-		}
-		copyHand := parser.CloneHand(h)
+		copyHand := parser.CloneHand(entry.hand)
 		copyHand.HandUID = uid
 		out = append(out, copyHand)
 	}
 
 	sort.Slice(out, func(i, j int) bool {
-		return out[i].StartTime.Before(out[j].StartTime)
+		a, b := out[i], out[j]
+		if f.NewestFirst {
+			a, b = b, a
+		}
+		if !a.StartTime.Equal(b.StartTime) {
+			return a.StartTime.Before(b.StartTime)
+		}
+		return a.HandUID < b.HandUID
 	})
 
-	return out, nil
+	return pageSlice(out, f.Limit, f.Offset), nil
 }
 
 func (r *MemoryRepository) CountHands(ctx context.Context, f HandFilter) (int, error) {
@@ -95,28 +239,186 @@ func (r *MemoryRepository) CountHands(ctx context.Context, f HandFilter) (int, e
 	defer r.mu.RUnlock()
 
 	count := 0
-	for _, entry := range r.hands {
-		h := entry.hand
-		if h == nil {
-			continue
+	for uid, entry := range r.hands {
+		ok, err := r.listHandMatchesLocked(uid, entry, f)
+		if err != nil {
+			return 0, err
 		}
-		if f.OnlyComplete && !h.IsComplete {
-			continue
+		if ok {
+			count++
 		}
-		if f.FromTime != nil && h.StartTime.Before(*f.FromTime) {
+	}
+	return count, nil
+}
+
+// ListOutdatedHandSources returns the source spans of hands produced by a
+// parser older than version.
+func (r *MemoryRepository) ListOutdatedHandSources(_ context.Context, version int) ([]HandSourceRef, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]HandSourceRef, 0)
+	for _, entry := range r.hands {
+		if entry.hand == nil || entry.hand.ParserVersion >= version {
 			continue
 		}
-		if f.ToTime != nil && h.StartTime.After(*f.ToTime) {
+		out = append(out, entry.sources...)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].SourcePath != out[j].SourcePath {
+			return out[i].SourcePath < out[j].SourcePath
+		}
+		return out[i].StartByte < out[j].StartByte
+	})
+	return out, nil
+}
+
+// GetHandRawLog returns a copy of the raw log snippet stored for uid.
+func (r *MemoryRepository) GetHandRawLog(_ context.Context, uid string) (*RawLogSnippet, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entry, ok := r.hands[uid]
+	if !ok || entry.rawLog == nil {
+		return nil, nil
+	}
+	raw := *entry.rawLog
+	raw.Data = append([]byte(nil), entry.rawLog.Data...)
+	return &raw, nil
+}
+
+// UserDisplayNames collects the instance users of the stored hands.
+func (r *MemoryRepository) UserDisplayNames(_ context.Context) (map[string]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make(map[string]string)
+	for _, entry := range r.hands {
+		if entry.hand == nil || entry.hand.InstanceUID == "" {
 			continue
 		}
-		if f.LocalSeat != nil {
-			if _, ok := h.Players[*f.LocalSeat]; !ok {
-				continue
+		for _, u := range entry.hand.InstanceUsers {
+			if u.UserUID != "" {
+				out[u.UserUID] = u.DisplayName
 			}
 		}
-		count++
 	}
-	return count, nil
+	return out, nil
+}
+
+func (r *MemoryRepository) GetSettings(_ context.Context) (map[string]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make(map[string]string, len(r.settings))
+	for key, value := range r.settings {
+		out[key] = value
+	}
+	return out, nil
+}
+
+func (r *MemoryRepository) SaveSettings(_ context.Context, values map[string]string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, value := range values {
+		r.settings[key] = value
+	}
+	return nil
+}
+
+// tableSizeMatches reports whether a hand dealt to players players has one
+// of sizes. An empty sizes matches every hand.
+func tableSizeMatches(sizes []stats.TableSize, players int) bool {
+	if len(sizes) == 0 {
+		return true
+	}
+	for _, size := range sizes {
+		minPlayers, maxPlayers := size.PlayerRange()
+		if players >= minPlayers && players <= maxPlayers {
+			return true
+		}
+	}
+	return false
+}
+
+// annotationMatchesLocked applies the annotation conditions of f to uid.
+// The caller must hold r.mu.
+func (r *MemoryRepository) annotationMatchesLocked(uid string, f HandFilter) bool {
+	a, ok := r.notes[uid]
+	if f.OnlyStarred && !a.Starred {
+		return false
+	}
+	for _, tag := range NormalizeTags(f.Tags) {
+		if !slices.Contains(a.Tags, tag) {
+			return false
+		}
+	}
+	if q := strings.ToLower(strings.TrimSpace(f.AnnotationSearch)); q != "" {
+		if !ok {
+			return false
+		}
+		found := strings.Contains(strings.ToLower(a.Note), q)
+		for _, tag := range a.Tags {
+			found = found || strings.Contains(tag, q)
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *MemoryRepository) GetHandAnnotation(_ context.Context, uid string) (*HandAnnotation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	a, ok := r.notes[uid]
+	if !ok {
+		return nil, nil
+	}
+	a.Tags = append([]string(nil), a.Tags...)
+	return &a, nil
+}
+
+func (r *MemoryRepository) SaveHandAnnotation(_ context.Context, a HandAnnotation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if a.IsEmpty() {
+		delete(r.notes, a.HandUID)
+		return nil
+	}
+	a.Note = strings.TrimSpace(a.Note)
+	a.Tags = NormalizeTags(a.Tags)
+	if a.UpdatedAt.IsZero() {
+		a.UpdatedAt = time.Now()
+	}
+	r.notes[a.HandUID] = a
+	return nil
+}
+
+func (r *MemoryRepository) ListHandAnnotations(_ context.Context) ([]HandAnnotation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]HandAnnotation, 0, len(r.notes))
+	for _, a := range r.notes {
+		a.Tags = append([]string(nil), a.Tags...)
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].HandUID < out[j].HandUID })
+	return out, nil
+}
+
+func (r *MemoryRepository) ListHandTags(_ context.Context) ([]TagCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	counts := make(map[string]int)
+	for _, a := range r.notes {
+		for _, tag := range a.Tags {
+			counts[tag]++
+		}
+	}
+	out := make([]TagCount, 0, len(counts))
+	for tag, n := range counts {
+		out = append(out, TagCount{Tag: tag, Count: n})
+	}
+	sortTagCounts(out)
+	return out, nil
 }
 
 // GetHandByUID returns the full hand for the given UID, or nil if not found.
@@ -132,6 +434,23 @@ func (r *MemoryRepository) GetHandByUID(_ context.Context, uid string) (*parser.
 	return h, nil
 }
 
+func (r *MemoryRepository) StoredHands(_ context.Context, rows []PersistedHand) ([]*parser.Hand, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*parser.Hand, len(rows))
+	for i, ph := range rows {
+		if ph.Hand == nil {
+			continue
+		}
+		uid := r.resolveHandUIDLocked(ph)
+		if entry, ok := r.hands[uid]; ok && entry.hand != nil {
+			out[i] = parser.CloneHand(entry.hand)
+			out[i].HandUID = uid
+		}
+	}
+	return out, nil
+}
+
 func (r *MemoryRepository) ListHandSummaries(_ context.Context, f HandFilter) ([]HandSummary, int, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -146,23 +465,25 @@ func (r *MemoryRepository) ListHandSummaries(_ context.Context, f HandFilter) ([
 		if localSeat < 0 {
 			continue
 		}
-		if f.FromTime != nil && h.StartTime.Before(*f.FromTime) {
+		if _, ok := h.Players[localSeat]; !ok {
 			continue
 		}
-		if f.ToTime != nil && h.StartTime.After(*f.ToTime) {
-			continue
+		ok, err := r.handMatchesLocked(uid, entry, f)
+		if err != nil {
+			return nil, 0, err
 		}
-		if _, ok := h.Players[localSeat]; !ok {
+		if !ok {
 			continue
 		}
 
 		s := HandSummary{
-			HandUID:    uid,
-			StartTime:  h.StartTime,
-			NumPlayers: h.NumPlayers,
-			TotalPot:   h.TotalPot,
-			IsComplete: h.IsComplete,
-			LocalSeat:  localSeat,
+			HandUID:        uid,
+			StartTime:      h.StartTime,
+			NumPlayers:     h.NumPlayers,
+			TotalPot:       h.TotalPot,
+			IsComplete:     h.IsComplete,
+			LocalSeat:      localSeat,
+			CommunityCards: joinCards(h.CommunityCards),
 		}
 
 		// Populate local player fields.
@@ -177,12 +498,12 @@ func (r *MemoryRepository) ListHandSummaries(_ context.Context, f HandFilter) ([
 				s.HoleCard0 = pi.HoleCards[0].Rank + pi.HoleCards[0].Suit
 				s.HoleCard1 = pi.HoleCards[1].Rank + pi.HoleCards[1].Suit
 			}
-			// Compute NetChips = PotWon - total invested by local player.
-			var invested int
-			for _, act := range pi.Actions {
-				invested += act.Amount
-			}
-			s.NetChips = s.PotWon - invested
+			s.NetChips = s.PotWon - pi.Invested()
+		}
+		if a, ok := r.notes[uid]; ok {
+			s.Starred = a.Starred
+			s.HasNote = a.Note != ""
+			s.Tags = append([]string(nil), a.Tags...)
 		}
 
 		out = append(out, s)
@@ -194,19 +515,18 @@ func (r *MemoryRepository) ListHandSummaries(_ context.Context, f HandFilter) ([
 	})
 
 	fullCount := len(out)
-	if f.Limit > 0 {
-		start := f.Offset
-		if start > len(out) {
-			start = len(out)
-		}
-		end := start + f.Limit
-		if end > len(out) {
-			end = len(out)
-		}
-		out = out[start:end]
-	}
+	return pageSlice(out, f.Limit, f.Offset), fullCount, nil
+}
 
-	return out, fullCount, nil
+// pageSlice returns the page of s selected by limit and offset. A zero limit
+// returns all of s.
+func pageSlice[T any](s []T, limit, offset int) []T {
+	if limit <= 0 {
+		return s
+	}
+	start := min(offset, len(s))
+	end := min(start+limit, len(s))
+	return s[start:end]
 }
 
 func (r *MemoryRepository) ListHandsAfter(_ context.Context, after time.Time, localSeat int) ([]*parser.Hand, error) {
@@ -216,10 +536,10 @@ func (r *MemoryRepository) ListHandsAfter(_ context.Context, after time.Time, lo
 	out := make([]*parser.Hand, 0, len(r.hands))
 	for uid, entry := range r.hands {
 		h := entry.hand
-		if h == nil || !h.IsComplete {
+		if h == nil || !h.IsComplete || !h.IsStatsEligible() {
 			continue
 		}
-		if h.StartTime.IsZero() || !h.StartTime.After(after) {
+		if !h.StartTime.After(after) {
 			continue
 		}
 		if localSeat >= 0 {
@@ -255,15 +575,70 @@ func (r *MemoryRepository) GetCursor(_ context.Context, sourcePath string) (*Imp
 	return &copyCursor, nil
 }
 
-func (r *MemoryRepository) SaveCursor(_ context.Context, c ImportCursor) error {
+func (r *MemoryRepository) SaveCursor(ctx context.Context, c ImportCursor) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	r.saveCursorLocked(c)
+	return nil
+}
+
+func (r *MemoryRepository) saveCursorLocked(c ImportCursor) {
 	if c.UpdatedAt.IsZero() {
 		c.UpdatedAt = time.Now()
 	}
+	if c.Fingerprint == "" {
+		prev := r.cursors[c.SourcePath]
+		c.Fingerprint, c.FingerprintSize = prev.Fingerprint, prev.FingerprintSize
+	}
 	r.cursors[c.SourcePath] = c
-	return nil
+}
+
+func (r *MemoryRepository) FindCursorByFingerprint(ctx context.Context, fingerprint string, size int) (*ImportCursor, error) {
+	if fingerprint == "" {
+		return nil, nil
+	}
+	r.mu.RLock()
+	var best ImportCursor
+	found := false
+	for _, c := range r.cursors {
+		if c.Fingerprint != fingerprint || c.FingerprintSize != size {
+			continue
+		}
+		if !found || cursorAhead(c, best) || (!cursorAhead(best, c) && c.SourcePath < best.SourcePath) {
+			best, found = c, true
+		}
+	}
+	r.mu.RUnlock()
+	if !found {
+		return nil, nil
+	}
+	return r.GetCursor(ctx, best.SourcePath)
+}
+
+func (r *MemoryRepository) FingerprintSizes(_ context.Context) ([]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []int
+	for _, c := range r.cursors {
+		if c.Fingerprint != "" && !slices.Contains(out, c.FingerprintSize) {
+			out = append(out, c.FingerprintSize)
+		}
+	}
+	slices.SortFunc(out, func(a, b int) int { return b - a })
+	return out, nil
+}
+
+// cursorAhead reports whether a is ahead of b: fully imported, or further
+// into the log.
+func cursorAhead(a, b ImportCursor) bool {
+	if a.IsFullyImported != b.IsFullyImported {
+		return a.IsFullyImported
+	}
+	return a.NextByteOffset > b.NextByteOffset
 }
 
 func (r *MemoryRepository) MarkFullyImported(_ context.Context, sourcePath string) error {
@@ -278,14 +653,203 @@ func (r *MemoryRepository) MarkFullyImported(_ context.Context, sourcePath strin
 	return nil
 }
 
-func (r *MemoryRepository) SaveImportBatch(_ context.Context, hands []PersistedHand, c ImportCursor) (UpsertResult, error) {
+// CheckIntegrity finds nothing: the memory repository keeps whole hands
+// and cannot be left half written.
+func (r *MemoryRepository) CheckIntegrity(_ context.Context) ([]IntegrityIssue, error) {
+	return nil, nil
+}
+
+func (r *MemoryRepository) RepairIntegrity(_ context.Context) ([]IntegrityIssue, error) {
+	return nil, nil
+}
+
+func (r *MemoryRepository) ListCursors(_ context.Context) ([]ImportCursor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]ImportCursor, 0, len(r.cursors))
+	for _, c := range r.cursors {
+		if c.WorldCtx != nil {
+			wc := c.WorldCtx.Clone()
+			c.WorldCtx = &wc
+		}
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].SourcePath < out[j].SourcePath })
+	return out, nil
+}
+
+func (r *MemoryRepository) DeleteCursor(_ context.Context, sourcePath string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	res := r.upsertHandsLocked(hands)
-	if c.UpdatedAt.IsZero() {
-		c.UpdatedAt = time.Now()
+	delete(r.cursors, sourcePath)
+	return nil
+}
+
+func (r *MemoryRepository) SaveImportBatch(ctx context.Context, hands []PersistedHand, c ImportCursor) (UpsertResult, error) {
+	if err := ctx.Err(); err != nil {
+		return UpsertResult{}, err
 	}
-	r.cursors[c.SourcePath] = c
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res := r.upsertHandsLocked(hands)
+	r.saveCursorLocked(c)
 	return res, nil
 }
+
+// joinCards formats cards like the community_cards column of hand summaries.
+func joinCards(cards []parser.Card) string {
+	parts := make([]string, len(cards))
+	for i, c := range cards {
+		parts[i] = c.Rank + c.Suit
+	}
+	return strings.Join(parts, " ")
+}
+
+// rollupDayOf returns the UTC rollup day of a hand start time.
+func rollupDayOf(t time.Time) string {
+	return t.UTC().Format(StatsRollupDayLayout)
+}
+
+func (r *MemoryRepository) markRollupDayDirtyLocked(day string) {
+	if gen, ok := r.dirty[day]; ok {
+		r.dirty[day] = gen + 1
+		return
+	}
+	r.dirty[day] = 0
+}
+
+func (r *MemoryRepository) DirtyRollupDays(ctx context.Context, localSeat, version int) ([]RollupDay, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stale := false
+	for _, day := range r.rollups {
+		for _, ru := range day {
+			stale = stale || ru.localSeat != localSeat || ru.version != version
+		}
+	}
+	if stale {
+		clear(r.rollups)
+		days := make(map[string]bool)
+		for _, entry := range r.hands {
+			days[rollupDayOf(entry.hand.StartTime)] = true
+		}
+		for day := range days {
+			r.markRollupDayDirtyLocked(day)
+		}
+	}
+
+	out := make([]RollupDay, 0, len(r.dirty))
+	for day, gen := range r.dirty {
+		out = append(out, RollupDay{Day: day, Generation: gen})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Day < out[j].Day })
+	return out, nil
+}
+
+func (r *MemoryRepository) SaveStatsRollups(ctx context.Context, d RollupDay, localSeat, version int, rollups []StatsRollup) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	stored := make([]memoryRollup, 0, len(rollups))
+	for _, ru := range rollups {
+		state, err := cloneJSON(ru.State)
+		if err != nil {
+			return fmt.Errorf("encode rollup of %s: %w", d.Day, err)
+		}
+		ru.Day = d.Day
+		ru.State = state
+		stored = append(stored, memoryRollup{StatsRollup: ru, localSeat: localSeat, version: version})
+	}
+	sort.Slice(stored, func(i, j int) bool { return stored[i].TableSize < stored[j].TableSize })
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(stored) == 0 {
+		delete(r.rollups, d.Day)
+	} else {
+		r.rollups[d.Day] = stored
+	}
+	if gen, ok := r.dirty[d.Day]; ok && gen == d.Generation {
+		delete(r.dirty, d.Day)
+	}
+	return nil
+}
+
+func (r *MemoryRepository) ListStatsRollups(_ context.Context, fromDay, toDay string) ([]StatsRollup, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	days := make([]string, 0, len(r.rollups))
+	for day := range r.rollups {
+		if day >= fromDay && day <= toDay {
+			days = append(days, day)
+		}
+	}
+	slices.Sort(days)
+	var out []StatsRollup
+	for _, day := range days {
+		for _, ru := range r.rollups[day] {
+			state, err := cloneJSON(ru.State)
+			if err != nil {
+				return nil, fmt.Errorf("decode rollup of %s: %w", day, err)
+			}
+			ru.State = state
+			out = append(out, ru.StatsRollup)
+		}
+	}
+	return out, nil
+}
+
+func (r *MemoryRepository) LoadCalculatorState(_ context.Context) (*CalculatorSnapshot, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.calculator == nil {
+		return nil, nil
+	}
+	state, err := cloneJSON(r.calculator.State)
+	if err != nil {
+		return nil, fmt.Errorf("decode calculator state: %w", err)
+	}
+	return &CalculatorSnapshot{Watermark: r.calculator.Watermark, State: state}, nil
+}
+
+func (r *MemoryRepository) SaveCalculatorState(ctx context.Context, snap CalculatorSnapshot) error {
+	if snap.State == nil {
+		return r.DeleteCalculatorState(ctx)
+	}
+	state, err := cloneJSON(snap.State)
+	if err != nil {
+		return fmt.Errorf("encode calculator state: %w", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calculator = &CalculatorSnapshot{Watermark: snap.Watermark, State: state}
+	return nil
+}
+
+func (r *MemoryRepository) DeleteCalculatorState(_ context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calculator = nil
+	return nil
+}
+
+// cloneJSON deep-copies v through its JSON encoding, the way the SQLite
+// repository stores it.
+func cloneJSON[T any](v *T) (*T, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	out := new(T)
+	if err := json.Unmarshal(data, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
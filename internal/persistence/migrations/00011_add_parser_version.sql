@@ -0,0 +1,11 @@
+-- +goose Up
+-- Hands stored before this migration were produced by an unversioned parser
+-- and get version 0, so the reprocess job treats them as outdated.
+ALTER TABLE hands ADD COLUMN parser_version INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_hands_parser_version ON hands(parser_version);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_hands_parser_version;
+
+-- SQLite does not support DROP COLUMN in older versions; keep the column.
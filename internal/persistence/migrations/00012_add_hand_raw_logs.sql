@@ -0,0 +1,17 @@
+-- +goose Up
+-- Compressed copy of the raw log lines each hand was parsed from, so the
+-- evidence survives VRChat rotating or deleting old output_log files.
+CREATE TABLE IF NOT EXISTS hand_raw_logs (
+    hand_uid TEXT PRIMARY KEY,
+    source_path TEXT NOT NULL,
+    start_byte INTEGER NOT NULL,
+    end_byte INTEGER NOT NULL,
+    encoding TEXT NOT NULL,
+    raw_size INTEGER NOT NULL,
+    data BLOB NOT NULL,
+    updated_at TEXT NOT NULL,
+    FOREIGN KEY(hand_uid) REFERENCES hands(hand_uid)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS hand_raw_logs;
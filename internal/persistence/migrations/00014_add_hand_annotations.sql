@@ -0,0 +1,26 @@
+-- +goose Up
+-- User annotations on hands. They are keyed by hand_uid only, so they survive
+-- re-parsing and re-importing the hand.
+CREATE TABLE IF NOT EXISTS hand_notes (
+    hand_uid TEXT PRIMARY KEY,
+    note TEXT NOT NULL DEFAULT '',
+    starred INTEGER NOT NULL DEFAULT 0,
+    updated_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_hand_notes_starred ON hand_notes(starred);
+
+CREATE TABLE IF NOT EXISTS hand_tags (
+    hand_uid TEXT NOT NULL,
+    tag TEXT NOT NULL,
+    created_at TEXT NOT NULL,
+    PRIMARY KEY (hand_uid, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_hand_tags_tag ON hand_tags(tag);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_hand_tags_tag;
+DROP TABLE IF EXISTS hand_tags;
+DROP INDEX IF EXISTS idx_hand_notes_starred;
+DROP TABLE IF EXISTS hand_notes;
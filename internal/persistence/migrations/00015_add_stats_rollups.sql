@@ -0,0 +1,28 @@
+-- +goose Up
+-- Per-day stats rollups. state holds the JSON encoded stats.Rollup of the
+-- complete hands that started on day (UTC, YYYY-MM-DD) at one table size.
+CREATE TABLE IF NOT EXISTS stats_rollups (
+    day TEXT NOT NULL,
+    table_size INTEGER NOT NULL,
+    local_seat INTEGER NOT NULL,
+    version INTEGER NOT NULL,
+    hand_count INTEGER NOT NULL,
+    state TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    PRIMARY KEY (day, table_size)
+);
+
+-- Days whose rollups are missing or out of date. generation is bumped every
+-- time a hand of the day is written, so a rebuild that raced with an import
+-- leaves the day dirty.
+CREATE TABLE IF NOT EXISTS stats_rollup_dirty (
+    day TEXT PRIMARY KEY,
+    generation INTEGER NOT NULL DEFAULT 0
+);
+
+INSERT OR IGNORE INTO stats_rollup_dirty(day, generation)
+SELECT DISTINCT substr(start_time, 1, 10), 0 FROM hands;
+
+-- +goose Down
+DROP TABLE IF EXISTS stats_rollup_dirty;
+DROP TABLE IF EXISTS stats_rollups;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Persisted all-time stats calculator. state holds the JSON encoded
+-- stats.CalculatorState of every eligible hand up to watermark; there is at
+-- most one row.
+CREATE TABLE IF NOT EXISTS stats_calculator_state (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    local_seat INTEGER NOT NULL,
+    version INTEGER NOT NULL,
+    watermark TEXT NOT NULL,
+    state TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS stats_calculator_state;
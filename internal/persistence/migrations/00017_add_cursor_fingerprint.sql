@@ -0,0 +1,10 @@
+-- +goose Up
+-- Content fingerprint of the log a cursor belongs to (see
+-- logsource.Fingerprint), so a moved or copied log resumes from the cursor
+-- of its previous path.
+ALTER TABLE import_cursors ADD COLUMN fingerprint TEXT;
+CREATE INDEX IF NOT EXISTS idx_import_cursors_fingerprint ON import_cursors(fingerprint);
+
+-- +goose Down
+-- SQLite does not support DROP COLUMN; keep the column.
+DROP INDEX IF EXISTS idx_import_cursors_fingerprint;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Number of leading log bytes a cursor's fingerprint covers. Fingerprints
+-- stored before this column covered logsource.FingerprintSize bytes; a log
+-- shorter than that is now fingerprinted in full.
+ALTER TABLE import_cursors ADD COLUMN fingerprint_size INTEGER NOT NULL DEFAULT 0;
+UPDATE import_cursors SET fingerprint_size = 16384 WHERE fingerprint IS NOT NULL;
+
+-- +goose Down
+-- SQLite does not support DROP COLUMN; keep the column.
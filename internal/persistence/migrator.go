@@ -16,17 +16,55 @@ var migrationFS embed.FS
 
 var migrationSetupOnce sync.Once
 
-func runMigrations(db *sql.DB) error {
-	var setupErr error
+var migrationSetupErr error
+
+func setupMigrations() error {
 	migrationSetupOnce.Do(func() {
 		goose.SetBaseFS(migrationFS)
-		setupErr = goose.SetDialect("sqlite3")
+		migrationSetupErr = goose.SetDialect("sqlite3")
 	})
-	if setupErr != nil {
-		return fmt.Errorf("setup goose: %w", setupErr)
+	if migrationSetupErr != nil {
+		return fmt.Errorf("setup goose: %w", migrationSetupErr)
+	}
+	return nil
+}
+
+func runMigrations(db *sql.DB) error {
+	if err := setupMigrations(); err != nil {
+		return err
 	}
 	if err := goose.Up(db, "migrations"); err != nil {
 		return fmt.Errorf("run migrations: %w", err)
 	}
 	return nil
 }
+
+// checkMigrated returns an error unless every migration was applied to db.
+// Unlike goose's own version lookup it does not write to the database.
+func checkMigrated(db *sql.DB) error {
+	if err := setupMigrations(); err != nil {
+		return err
+	}
+	migrations, err := goose.CollectMigrations("migrations", 0, goose.MaxVersion)
+	if err != nil {
+		return fmt.Errorf("collect migrations: %w", err)
+	}
+	latest, err := migrations.Last()
+	if err != nil {
+		return fmt.Errorf("latest migration: %w", err)
+	}
+	var applied int64
+	var hasTable int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'goose_db_version'`).Scan(&hasTable); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	if hasTable > 0 {
+		if err := db.QueryRow(`SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied = 1`).Scan(&applied); err != nil {
+			return fmt.Errorf("read schema version: %w", err)
+		}
+	}
+	if applied < latest.Version {
+		return fmt.Errorf("database schema version %d is older than %d; start the app once to migrate it", applied, latest.Version)
+	}
+	return nil
+}
@@ -0,0 +1,362 @@
+package persistence
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/handquery"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
+)
+
+// compileQuery compiles a search query into a SQL boolean expression. hand is
+// the name or alias of the hands table in the outer query. Hero conditions
+// use the hand's local_seat and never match hands without one.
+func compileQuery(e handquery.Expr, hand string) (string, []any, error) {
+	uid := hand + ".hand_uid"
+	hero := func(table, alias string) string {
+		return table + " " + alias + " WHERE " + alias + ".hand_uid = " + uid + " AND " + alias + ".seat_id = " + hand + ".local_seat"
+	}
+	switch q := e.(type) {
+	case handquery.And:
+		return compileQueryList(q.Terms, hand, " AND ")
+	case handquery.Or:
+		return compileQueryList(q.Terms, hand, " OR ")
+	case handquery.Not:
+		where, args, err := compileQuery(q.Term, hand)
+		return "NOT " + where, args, err
+	case handquery.Position:
+		return "EXISTS (SELECT 1 FROM " + hero("hand_players", "qp") + " AND qp.position = ?)", []any{int(q.Position)}, nil
+	case handquery.Hole:
+		if len(q.Cards) == 1 {
+			cond, args := cardPatternSQL("qc", q.Cards[0])
+			return "EXISTS (SELECT 1 FROM " + hero("hand_hole_cards", "qc") + cond + ")", args, nil
+		}
+		cond0, args := cardPatternSQL("qc", q.Cards[0])
+		cond1, args1 := cardPatternSQL("qc2", q.Cards[1])
+		switch q.Suitedness {
+		case handquery.Suited:
+			cond1 += " AND qc2.suit = qc.suit"
+		case handquery.Offsuit:
+			cond1 += " AND qc2.suit <> qc.suit"
+		}
+		return "EXISTS (SELECT 1 FROM " + hero("hand_hole_cards", "qc") +
+			" AND EXISTS (SELECT 1 FROM hand_hole_cards qc2 WHERE qc2.hand_uid = qc.hand_uid AND qc2.seat_id = qc.seat_id AND qc2.card_index <> qc.card_index" +
+			cond0 + cond1 + "))", append(args, args1...), nil
+	case handquery.Board:
+		switch q.Texture {
+		case handquery.BoardFlush:
+			return "EXISTS (SELECT 1 FROM hand_board_cards qb WHERE qb.hand_uid = " + uid + " GROUP BY qb.suit HAVING COUNT(*) >= 3)", nil, nil
+		case handquery.BoardPaired:
+			return "EXISTS (SELECT 1 FROM hand_board_cards qb WHERE qb.hand_uid = " + uid + " GROUP BY qb.rank HAVING COUNT(*) >= 2)", nil, nil
+		case handquery.BoardMonotone:
+			return "(SELECT COUNT(*) >= 3 AND COUNT(DISTINCT qb.suit) = 1 FROM hand_board_cards qb WHERE qb.hand_uid = " + uid + ")", nil, nil
+		case handquery.BoardRainbow:
+			return "(SELECT COUNT(DISTINCT qb.suit) = 3 FROM hand_board_cards qb WHERE qb.hand_uid = " + uid + " AND qb.card_index < 3)", nil, nil
+		default:
+			cond, args := cardPatternSQL("qb", q.Card)
+			return "EXISTS (SELECT 1 FROM hand_board_cards qb WHERE qb.hand_uid = " + uid + cond + ")", args, nil
+		}
+	case handquery.Pocket:
+		return "EXISTS (SELECT 1 FROM " + hero("hand_players", "qp") +
+			" AND qp.pocket_category_id = (SELECT id FROM pocket_categories WHERE code = ?))", []any{pocketCategoryCode(q.Category)}, nil
+	case handquery.Instance:
+		return hand + ".instance_type = ?", []any{string(q.Type)}, nil
+	case handquery.MadeHand:
+		return "EXISTS (SELECT 1 FROM " + hero("hand_players", "qp") + " AND qp.final_class_id = ?)", []any{stats.MadeHandClassID(q.Class)}, nil
+	case handquery.Compare:
+		where, args := compileCompare(q, hand)
+		return where, args, nil
+	case handquery.Action:
+		street := ""
+		var args []any
+		if !q.AnyStreet {
+			street = " AND qa.street = ?"
+			args = append(args, int(q.Street))
+		}
+		if q.Kind == handquery.ActionCheckRaise {
+			return "EXISTS (SELECT 1 FROM " + hero("hand_actions", "qa") + street + " AND qa.action = ?" +
+					" AND EXISTS (SELECT 1 FROM hand_actions qr WHERE qr.hand_uid = qa.hand_uid AND qr.seat_id = qa.seat_id" +
+					" AND qr.street = qa.street AND qr.action_index > qa.action_index AND qr.action = ?))",
+				append(args, int(parser.ActionCheck), int(parser.ActionRaise)), nil
+		}
+		return "EXISTS (SELECT 1 FROM " + hero("hand_actions", "qa") + street + " AND qa.action = ?)",
+			append(args, int(queryActionType(q.Kind))), nil
+	case handquery.Result:
+		switch q.Outcome {
+		case handquery.OutcomeWon:
+			return "EXISTS (SELECT 1 FROM " + hero("hand_players", "qp") + " AND qp.won = 1)", nil, nil
+		case handquery.OutcomeShowdown:
+			return "EXISTS (SELECT 1 FROM " + hero("hand_players", "qp") + " AND qp.showed_down = 1)", nil, nil
+		default:
+			where, args := compileCompare(handquery.Compare{Field: handquery.FieldNet, Op: handquery.OpLt}, hand)
+			return where, args, nil
+		}
+	case handquery.Starred:
+		return uid + " IN (SELECT hand_uid FROM hand_notes WHERE starred = 1)", nil, nil
+	case handquery.Tag:
+		return uid + " IN (SELECT hand_uid FROM hand_tags WHERE tag = ?)", []any{q.Tag}, nil
+	case handquery.Text:
+		pattern := "%" + escapeLike(q.Text) + "%"
+		return "(" + uid + ` IN (SELECT hand_uid FROM hand_notes WHERE lower(note) LIKE ? ESCAPE '\')` +
+			" OR " + uid + ` IN (SELECT hand_uid FROM hand_tags WHERE tag LIKE ? ESCAPE '\'))`, []any{pattern, pattern}, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported query node %T", e)
+	}
+}
+
+func compileQueryList(terms []handquery.Expr, hand, sep string) (string, []any, error) {
+	parts := make([]string, 0, len(terms))
+	var args []any
+	for _, t := range terms {
+		where, a, err := compileQuery(t, hand)
+		if err != nil {
+			return "", nil, err
+		}
+		parts = append(parts, where)
+		args = append(args, a...)
+	}
+	return "(" + strings.Join(parts, sep) + ")", args, nil
+}
+
+func cardPatternSQL(alias string, p handquery.CardPattern) (string, []any) {
+	cond := ""
+	var args []any
+	if p.Rank != "" {
+		cond += " AND " + alias + ".rank = ?"
+		args = append(args, p.Rank)
+	}
+	if p.Suit != "" {
+		cond += " AND " + alias + ".suit = ?"
+		args = append(args, p.Suit)
+	}
+	return cond, args
+}
+
+// compileCompare compiles a numeric comparison. The result is never NULL so
+// that NOT keeps the same meaning as in matchQuery.
+func compileCompare(q handquery.Compare, hand string) (string, []any) {
+	uid := hand + ".hand_uid"
+	var value string
+	switch q.Field {
+	case handquery.FieldPot:
+		value = hand + ".total_pot"
+	case handquery.FieldPlayers:
+		value = hand + ".num_players"
+	default:
+		// BET IN is a running street total: the hero invested the largest
+		// amount of each street.
+		value = "(SELECT qp.pot_won - (SELECT COALESCE(SUM(qs.street_max), 0) FROM (SELECT MAX(qa.amount) AS street_max" +
+			" FROM hand_actions qa WHERE qa.hand_uid = qp.hand_uid AND qa.seat_id = qp.seat_id GROUP BY qa.street) qs)" +
+			" FROM hand_players qp WHERE qp.hand_uid = " + uid + " AND qp.seat_id = " + hand + ".local_seat)"
+	}
+	target := "?"
+	if q.InBB {
+		target = "? * (SELECT MAX(qbb.amount) FROM hand_actions qbb WHERE qbb.hand_uid = " + uid + " AND qbb.action = " + fmt.Sprint(int(parser.ActionBlindBB)) + ")"
+	}
+	ops := map[handquery.Op]string{
+		handquery.OpEq: "=",
+		handquery.OpNe: "<>",
+		handquery.OpLt: "<",
+		handquery.OpLe: "<=",
+		handquery.OpGt: ">",
+		handquery.OpGe: ">=",
+	}
+	return "COALESCE(" + value + " " + ops[q.Op] + " " + target + ", 0)", []any{q.Value}
+}
+
+func queryActionType(k handquery.ActionKind) parser.ActionType {
+	switch k {
+	case handquery.ActionFold:
+		return parser.ActionFold
+	case handquery.ActionCheck:
+		return parser.ActionCheck
+	case handquery.ActionCall:
+		return parser.ActionCall
+	case handquery.ActionBet:
+		return parser.ActionBet
+	case handquery.ActionAllIn:
+		return parser.ActionAllIn
+	default:
+		return parser.ActionRaise
+	}
+}
+
+// matchQuery evaluates a search query against a hand and its annotation
+// (the zero value when the hand has none). It mirrors compileQuery.
+func matchQuery(e handquery.Expr, h *parser.Hand, a HandAnnotation) (bool, error) {
+	hero := h.Players[h.LocalPlayerSeat]
+	switch q := e.(type) {
+	case handquery.And:
+		for _, t := range q.Terms {
+			if ok, err := matchQuery(t, h, a); err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	case handquery.Or:
+		for _, t := range q.Terms {
+			if ok, err := matchQuery(t, h, a); err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	case handquery.Not:
+		ok, err := matchQuery(q.Term, h, a)
+		return !ok && err == nil, err
+	case handquery.Position:
+		return hero != nil && hero.Position == q.Position, nil
+	case handquery.Hole:
+		if hero == nil {
+			return false, nil
+		}
+		cards := hero.HoleCards
+		if len(q.Cards) == 1 {
+			return slices.ContainsFunc(cards, q.Cards[0].Matches), nil
+		}
+		if len(cards) != 2 {
+			return false, nil
+		}
+		p0, p1 := q.Cards[0], q.Cards[1]
+		if !(p0.Matches(cards[0]) && p1.Matches(cards[1])) && !(p0.Matches(cards[1]) && p1.Matches(cards[0])) {
+			return false, nil
+		}
+		switch q.Suitedness {
+		case handquery.Suited:
+			return cards[0].Suit == cards[1].Suit, nil
+		case handquery.Offsuit:
+			return cards[0].Suit != cards[1].Suit, nil
+		}
+		return true, nil
+	case handquery.Board:
+		return matchBoard(q, h.CommunityCards), nil
+	case handquery.Pocket:
+		if hero == nil || len(hero.HoleCards) != 2 {
+			return false, nil
+		}
+		cats := stats.ClassifyPocketHand(hero.HoleCards[0], hero.HoleCards[1])
+		return len(cats) > 0 && choosePocketCategory(cats) == q.Category, nil
+	case handquery.Instance:
+		return defaultInstanceType(h.InstanceType) == q.Type, nil
+	case handquery.MadeHand:
+		if hero == nil || len(hero.HoleCards) != 2 || len(h.CommunityCards) < 5 {
+			return false, nil
+		}
+		return stats.ClassifyMadeHand(hero.HoleCards, h.CommunityCards) == q.Class, nil
+	case handquery.Compare:
+		return matchCompare(q, h, hero), nil
+	case handquery.Action:
+		if hero == nil {
+			return false, nil
+		}
+		checked := map[parser.Street]bool{}
+		for _, act := range hero.Actions {
+			if !q.AnyStreet && act.Street != q.Street {
+				continue
+			}
+			if q.Kind == handquery.ActionCheckRaise {
+				if act.Action == parser.ActionRaise && checked[act.Street] {
+					return true, nil
+				}
+				if act.Action == parser.ActionCheck {
+					checked[act.Street] = true
+				}
+				continue
+			}
+			if act.Action == queryActionType(q.Kind) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case handquery.Result:
+		if hero == nil {
+			return false, nil
+		}
+		switch q.Outcome {
+		case handquery.OutcomeWon:
+			return hero.Won, nil
+		case handquery.OutcomeShowdown:
+			return hero.ShowedDown, nil
+		default:
+			return matchCompare(handquery.Compare{Field: handquery.FieldNet, Op: handquery.OpLt}, h, hero), nil
+		}
+	case handquery.Starred:
+		return a.Starred, nil
+	case handquery.Tag:
+		return slices.Contains(a.Tags, q.Tag), nil
+	case handquery.Text:
+		found := a.Note != "" && strings.Contains(strings.ToLower(a.Note), q.Text)
+		for _, tag := range a.Tags {
+			found = found || strings.Contains(tag, q.Text)
+		}
+		return found, nil
+	default:
+		return false, fmt.Errorf("unsupported query node %T", e)
+	}
+}
+
+func matchBoard(q handquery.Board, board []parser.Card) bool {
+	suits := map[string]int{}
+	ranks := map[string]int{}
+	for _, c := range board {
+		suits[c.Suit]++
+		ranks[c.Rank]++
+	}
+	switch q.Texture {
+	case handquery.BoardFlush:
+		for _, n := range suits {
+			if n >= 3 {
+				return true
+			}
+		}
+		return false
+	case handquery.BoardPaired:
+		for _, n := range ranks {
+			if n >= 2 {
+				return true
+			}
+		}
+		return false
+	case handquery.BoardMonotone:
+		return len(board) >= 3 && len(suits) == 1
+	case handquery.BoardRainbow:
+		if len(board) < 3 {
+			return false
+		}
+		flop := map[string]bool{board[0].Suit: true, board[1].Suit: true, board[2].Suit: true}
+		return len(flop) == 3
+	default:
+		return slices.ContainsFunc(board, q.Card.Matches)
+	}
+}
+
+func matchCompare(q handquery.Compare, h *parser.Hand, hero *parser.PlayerHandInfo) bool {
+	var value int
+	switch q.Field {
+	case handquery.FieldPot:
+		value = h.TotalPot
+	case handquery.FieldPlayers:
+		value = h.NumPlayers
+	default:
+		if hero == nil {
+			return false
+		}
+		value = hero.PotWon - hero.Invested()
+	}
+	if !q.InBB {
+		return q.Holds(float64(value))
+	}
+	bb := 0
+	for _, pi := range h.Players {
+		for _, act := range pi.Actions {
+			if act.Action == parser.ActionBlindBB && act.Amount > bb {
+				bb = act.Amount
+			}
+		}
+	}
+	if bb == 0 {
+		return false
+	}
+	return q.Holds(float64(value) / float64(bb))
+}
@@ -10,17 +10,41 @@ import (
 	"strings"
 	"time"
 
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/handquery"
 	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
 )
 
 type HandFilter struct {
-	FromTime          *time.Time
-	ToTime            *time.Time
-	OnlyComplete      bool
-	LocalSeat         *int
+	FromTime     *time.Time
+	ToTime       *time.Time
+	OnlyComplete bool
+	LocalSeat    *int
+	// PocketCategoryIDs and FinalClassIDs restrict results to hands where the
+	// local player's hole cards fall in one of these categories, or made one
+	// of these final hand classes. IDs are int(stats.PocketCategory)+1 and
+	// stats.MadeHandClassID.
 	PocketCategoryIDs []int
 	FinalClassIDs     []int
-	// Limit and Offset are used by ListHandSummaries for pagination.
+	// OnlyStatsExcluded restricts results to hands whose anomalies exclude them
+	// from stats (stats_eligible = 0). Used by the data quality view.
+	OnlyStatsExcluded bool
+	// OnlyStarred restricts results to hands the user starred.
+	OnlyStarred bool
+	// Tags restricts results to hands carrying every one of these tags.
+	// Tags are compared after NormalizeTag.
+	Tags []string
+	// AnnotationSearch restricts results to hands whose note or one of whose
+	// tags contains this text, ignoring case.
+	AnnotationSearch string
+	// TableSizes restricts results to hands of one of these table sizes.
+	TableSizes []stats.TableSize
+	// Query restricts results to hands matching a parsed search expression.
+	Query handquery.Expr
+	// NewestFirst orders ListHands by descending start time instead of
+	// ascending. ListHandSummaries is always newest first.
+	NewestFirst bool
+	// Limit and Offset page the results of ListHands and ListHandSummaries.
 	// Limit == 0 means no limit (return all matching rows).
 	Limit  int
 	Offset int
@@ -38,6 +62,19 @@ type HandSourceRef struct {
 type PersistedHand struct {
 	Hand   *parser.Hand
 	Source HandSourceRef
+	// RawLog is the optional raw log text of the hand. When nil, an already
+	// stored snippet is kept as is.
+	RawLog *RawLogSnippet
+}
+
+// RawLogSnippet holds the raw log lines a hand was parsed from. StartByte and
+// EndByte are the offsets of Data in the source file; the snippet may start a
+// little before the hand's source span so the hand's "New Game" line is kept.
+type RawLogSnippet struct {
+	SourcePath string
+	StartByte  int64
+	EndByte    int64
+	Data       []byte
 }
 
 // HandSummary is a lightweight hand record for list display.
@@ -55,11 +92,74 @@ type HandSummary struct {
 	HoleCard1 string
 	Position  string // position string, empty if unknown
 	PotWon    int
-	NetChips  int // PotWon - sum(actions.amount) for local player
+	NetChips  int // PotWon - Invested() for local player
 	Won       bool
 
 	// Community cards as space-separated string, e.g. "Ah Kd 2c"
 	CommunityCards string
+
+	// User annotations
+	Starred bool
+	HasNote bool
+	Tags    []string
+}
+
+// HandAnnotation is the user's note, tags and star flag on a hand.
+type HandAnnotation struct {
+	HandUID   string
+	Note      string
+	Tags      []string
+	Starred   bool
+	UpdatedAt time.Time
+}
+
+// IsEmpty reports whether the annotation carries no user data.
+func (a HandAnnotation) IsEmpty() bool {
+	return strings.TrimSpace(a.Note) == "" && !a.Starred && len(NormalizeTags(a.Tags)) == 0
+}
+
+// TagCount is a tag and the number of hands carrying it.
+type TagCount struct {
+	Tag   string
+	Count int
+}
+
+// NormalizeTag lower-cases a tag and collapses its whitespace. Commas are
+// not allowed in tags because tag lists are edited as comma separated text.
+func NormalizeTag(tag string) string {
+	tag = strings.ReplaceAll(tag, ",", " ")
+	return strings.ToLower(strings.Join(strings.Fields(tag), " "))
+}
+
+// NormalizeTags normalizes tags, drops empty ones and duplicates, and sorts
+// the result.
+func NormalizeTags(tags []string) []string {
+	out := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func sortTagCounts(tags []TagCount) {
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+}
+
+// ParseTagList splits comma separated tags and normalizes them.
+func ParseTagList(s string) []string {
+	return NormalizeTags(strings.Split(s, ","))
 }
 
 type UpsertResult struct {
@@ -79,8 +179,13 @@ type ImportCursor struct {
 	// WorldCtx holds the parser world/instance context at the cursor position.
 	// When set, the parser can resume from NextByteOffset without full re-scan.
 	// Uses parser.WorldContext directly to avoid duplicating the struct definition.
-	WorldCtx  *parser.WorldContext
-	UpdatedAt time.Time
+	WorldCtx *parser.WorldContext
+	// Fingerprint identifies the log by content (logsource.Fingerprint)
+	// and FingerprintSize is the number of leading bytes it covers. An
+	// empty Fingerprint keeps the one already stored.
+	Fingerprint     string
+	FingerprintSize int
+	UpdatedAt       time.Time
 }
 
 type HandRepository interface {
@@ -97,6 +202,18 @@ type HandRepository interface {
 	// GetHandByUID returns the full hand data for a single hand UID.
 	// Returns nil, nil if not found.
 	GetHandByUID(ctx context.Context, uid string) (*parser.Hand, error)
+	// StoredHands returns, for each row, the stored hand that upserting the
+	// row would overwrite, or nil if it would insert a new hand.
+	StoredHands(ctx context.Context, rows []PersistedHand) ([]*parser.Hand, error)
+	// ListOutdatedHandSources returns the source spans of hands whose parser
+	// version is below version, ordered by source path and start byte.
+	ListOutdatedHandSources(ctx context.Context, version int) ([]HandSourceRef, error)
+	// GetHandRawLog returns the stored raw log snippet of a hand.
+	// Returns nil, nil if no snippet was stored.
+	GetHandRawLog(ctx context.Context, uid string) (*RawLogSnippet, error)
+	// UserDisplayNames returns the last known display name of every user
+	// seen in an instance, keyed by user UID.
+	UserDisplayNames(ctx context.Context) (map[string]string, error)
 }
 
 type CursorRepository interface {
@@ -105,11 +222,41 @@ type CursorRepository interface {
 	// MarkFullyImported atomically sets is_fully_imported=1 on an existing cursor.
 	// If no cursor row exists yet the call is a no-op.
 	MarkFullyImported(ctx context.Context, sourcePath string) error
+	// FindCursorByFingerprint returns the most advanced cursor of a log with
+	// the given content fingerprint of size bytes, whatever its path.
+	// Returns nil, nil if no cursor has it.
+	FindCursorByFingerprint(ctx context.Context, fingerprint string, size int) (*ImportCursor, error)
+	// FingerprintSizes returns the distinct sizes of the stored
+	// fingerprints, largest first.
+	FingerprintSizes(ctx context.Context) ([]int, error)
+}
+
+// SettingsRepository stores application settings as string key/value pairs.
+type SettingsRepository interface {
+	// GetSettings returns every stored setting.
+	GetSettings(ctx context.Context) (map[string]string, error)
+	SaveSettings(ctx context.Context, values map[string]string) error
+}
+
+// AnnotationRepository stores user notes, tags and star flags keyed by hand UID.
+type AnnotationRepository interface {
+	// GetHandAnnotation returns the annotation of a hand.
+	// Returns nil, nil if the hand has none.
+	GetHandAnnotation(ctx context.Context, uid string) (*HandAnnotation, error)
+	// SaveHandAnnotation replaces the annotation of a.HandUID. Saving an
+	// empty annotation removes it.
+	SaveHandAnnotation(ctx context.Context, a HandAnnotation) error
+	// ListHandAnnotations returns every stored annotation ordered by hand UID.
+	ListHandAnnotations(ctx context.Context) ([]HandAnnotation, error)
+	// ListHandTags returns every tag in use, most used first.
+	ListHandTags(ctx context.Context) ([]TagCount, error)
 }
 
 type ImportRepository interface {
 	HandRepository
 	CursorRepository
+	SettingsRepository
+	AnnotationRepository
 }
 
 type ImportBatchRepository interface {
@@ -117,6 +264,113 @@ type ImportBatchRepository interface {
 	SaveImportBatch(ctx context.Context, hands []PersistedHand, cursor ImportCursor) (UpsertResult, error)
 }
 
+// RollupDay is a UTC day, formatted YYYY-MM-DD, whose stats rollups must be
+// rebuilt. Generation changes every time a hand of the day is written.
+type RollupDay struct {
+	Day        string
+	Generation int64
+}
+
+// StatsRollup is the rollup of the complete hands of one day at one table size.
+type StatsRollup struct {
+	Day       string
+	TableSize stats.TableSize
+	HandCount int
+	State     *stats.Rollup
+}
+
+// StatsRollupDayLayout is the time layout of RollupDay.Day and StatsRollup.Day.
+const StatsRollupDayLayout = "2006-01-02"
+
+// StatsRollupRepository stores per-day stats rollups. Writing a hand marks
+// its day dirty; the caller rebuilds dirty days from the hands and saves them.
+type StatsRollupRepository interface {
+	// DirtyRollupDays returns the days whose rollups are missing or out of
+	// date. Rollups built for another local seat or rollup version are
+	// dropped first, which marks every day dirty.
+	DirtyRollupDays(ctx context.Context, localSeat, version int) ([]RollupDay, error)
+	// SaveStatsRollups replaces the rollups of d.Day. The day stays dirty if
+	// its generation changed since DirtyRollupDays returned d.
+	SaveStatsRollups(ctx context.Context, d RollupDay, localSeat, version int, rollups []StatsRollup) error
+	// ListStatsRollups returns the rollups of the days from fromDay to toDay,
+	// both inclusive, ordered by day.
+	ListStatsRollups(ctx context.Context, fromDay, toDay string) ([]StatsRollup, error)
+}
+
+// CalculatorSnapshot is the persisted state of the all-time stats calculator
+// and the start time of the last hand it was fed.
+type CalculatorSnapshot struct {
+	Watermark time.Time
+	State     *stats.CalculatorState
+}
+
+// CalculatorStateRepository persists the all-time stats calculator so it can
+// resume from its watermark instead of re-feeding every hand. Writing a hand
+// that starts at or before the watermark discards the snapshot.
+type CalculatorStateRepository interface {
+	// LoadCalculatorState returns the stored snapshot.
+	// Returns nil, nil if there is none.
+	LoadCalculatorState(ctx context.Context) (*CalculatorSnapshot, error)
+	SaveCalculatorState(ctx context.Context, snap CalculatorSnapshot) error
+	DeleteCalculatorState(ctx context.Context) error
+}
+
+// IntegrityProblem is a kind of damage found by a database check.
+type IntegrityProblem string
+
+const (
+	// ProblemCorruption is a message of SQLite's integrity_check. It cannot
+	// be repaired in place.
+	ProblemCorruption IntegrityProblem = "corruption"
+	// ProblemOrphanRows are hand_* rows whose hand or player row is gone.
+	ProblemOrphanRows IntegrityProblem = "orphan_rows"
+	// ProblemHandWithoutSource are hands without a hand_occurrences row, so
+	// they cannot be traced to their log or reprocessed.
+	ProblemHandWithoutSource IntegrityProblem = "hand_without_source"
+	// ProblemStaleFilterIDs are player rows whose pocket category or final
+	// class ID does not match their cards.
+	ProblemStaleFilterIDs IntegrityProblem = "stale_filter_ids"
+	// ProblemCursorBeyondFile is an import cursor past the end of its log,
+	// for instance because the log was truncated. The repository cannot see
+	// the logs, so the caller checks cursors itself.
+	ProblemCursorBeyondFile IntegrityProblem = "cursor_beyond_file"
+)
+
+// IntegrityIssue is one problem found by a database check.
+type IntegrityIssue struct {
+	Problem IntegrityProblem
+	// Subject is the table or log path the problem was found in.
+	Subject string
+	// Count is the number of rows affected.
+	Count int
+	// Detail is the SQLite message of a corruption, or the offset and size
+	// of a cursor beyond its file.
+	Detail string
+}
+
+// Repairable reports whether the issue can be repaired in place.
+func (i IntegrityIssue) Repairable() bool {
+	return i.Problem != ProblemCorruption
+}
+
+// IntegrityRepository checks the stored data for damage left by crashes and
+// old migrations, and repairs it.
+type IntegrityRepository interface {
+	// CheckIntegrity returns the problems found in the stored data. It
+	// changes nothing.
+	CheckIntegrity(ctx context.Context) ([]IntegrityIssue, error)
+	// RepairIntegrity repairs every repairable problem CheckIntegrity finds
+	// and returns what it repaired. Orphan rows are deleted and filter IDs
+	// recomputed. A hand without a source gets its source back from its raw
+	// log snippet, or is deleted when it has none.
+	RepairIntegrity(ctx context.Context) ([]IntegrityIssue, error)
+	// ListCursors returns every import cursor ordered by source path.
+	ListCursors(ctx context.Context) ([]ImportCursor, error)
+	// DeleteCursor removes the cursor of a log, so the log is imported
+	// again from its start.
+	DeleteCursor(ctx context.Context, sourcePath string) error
+}
+
 func GenerateHandUID(h *parser.Hand, src HandSourceRef) string {
 	if h == nil {
 		payload := fmt.Sprintf("src:%s|%d|%d|%d|%d", src.SourcePath, src.StartByte, src.EndByte, src.StartLine, src.EndLine)
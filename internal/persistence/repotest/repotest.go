@@ -0,0 +1,1378 @@
+// Package repotest is the conformance suite of the persistence repositories.
+// Every storage backend must pass it, so the application behaves the same
+// whichever backend it runs on. Backend tests call TestRepository:
+//
+//	func TestConformance(t *testing.T) {
+//		repotest.TestRepository(t, func(t *testing.T) persistence.ImportBatchRepository {
+//			return persistence.NewMemoryRepository()
+//		})
+//	}
+package repotest
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/handquery"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/persistence"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
+)
+
+// NewRepository returns an empty repository for one test and registers its
+// cleanup with t.
+type NewRepository func(t *testing.T) persistence.ImportBatchRepository
+
+// TestRepository runs the conformance suite with a fresh repository from
+// newRepo for each test. Tests of the optional StatsRollupRepository,
+// CalculatorStateRepository, IntegrityRepository and Damager interfaces are
+// skipped when the repository does not implement them.
+func TestRepository(t *testing.T, newRepo NewRepository) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repo persistence.ImportBatchRepository)
+	}{
+		{"UpsertIsIdempotent", testUpsertIsIdempotent},
+		{"UpsertResolvesSourceSpan", testUpsertResolvesSourceSpan},
+		{"HandRoundTrip", testHandRoundTrip},
+		{"ListHandsFilters", testListHandsFilters},
+		{"StatsExcludedHands", testStatsExcludedHands},
+		{"HandQuery", testHandQuery},
+		{"HandSummaries", testHandSummaries},
+		{"HandSummaryPages", testHandSummaryPages},
+		{"ListHandsPages", testListHandsPages},
+		{"ListHandsAfter", testListHandsAfter},
+		{"OutdatedHandSources", testOutdatedHandSources},
+		{"RawLogs", testRawLogs},
+		{"UserDisplayNames", testUserDisplayNames},
+		{"Cursors", testCursors},
+		{"CursorFingerprints", testCursorFingerprints},
+		{"ImportBatch", testImportBatch},
+		{"ImportBatchCancelled", testImportBatchCancelled},
+		{"Settings", testSettings},
+		{"Annotations", testAnnotations},
+		{"AnnotationFilters", testAnnotationFilters},
+		{"StatsRollups", testStatsRollups},
+		{"CalculatorState", testCalculatorState},
+		{"Integrity", testIntegrity},
+		{"IntegrityRepair", testIntegrityRepair},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tt.run(t, newRepo(t))
+		})
+	}
+}
+
+// base is the start time of slot 0. Hands use whole seconds so every
+// backend orders them the same.
+var base = time.Date(2026, 2, 21, 12, 0, 0, 0, time.UTC)
+
+func slotTime(slot int) time.Time {
+	return base.Add(time.Duration(slot) * 10 * time.Minute)
+}
+
+func cards(cs ...string) []parser.Card {
+	out := make([]parser.Card, 0, len(cs))
+	for _, c := range cs {
+		out = append(out, parser.Card{Rank: c[:len(c)-1], Suit: c[len(c)-1:]})
+	}
+	return out
+}
+
+// newHand returns a complete heads-up hand in slot: the local player on seat
+// 0 raises pocket aces from the button and wins 120 chips, netting 60.
+func newHand(slot int) *parser.Hand {
+	start := slotTime(slot)
+	return &parser.Hand{
+		ID:              slot,
+		StartTime:       start,
+		EndTime:         start.Add(time.Minute),
+		LocalPlayerSeat: 0,
+		WorldID:         "wrld_test",
+		CommunityCards:  cards("Ad", "7c", "7s", "3h", "2d"),
+		Players: map[int]*parser.PlayerHandInfo{
+			0: {
+				SeatID:    0,
+				Position:  parser.PosBTN,
+				HoleCards: cards("Ah", "As"),
+				Actions: []parser.PlayerAction{
+					{PlayerID: 0, Street: parser.StreetPreFlop, Action: parser.ActionBlindSB, Amount: 10},
+					{PlayerID: 0, Street: parser.StreetPreFlop, Action: parser.ActionRaise, Amount: 60},
+				},
+				Won:    true,
+				PotWon: 120,
+			},
+			1: {
+				SeatID:   1,
+				Position: parser.PosBB,
+				Actions: []parser.PlayerAction{
+					{PlayerID: 1, Street: parser.StreetPreFlop, Action: parser.ActionBlindBB, Amount: 20},
+					{PlayerID: 1, Street: parser.StreetPreFlop, Action: parser.ActionCall, Amount: 60},
+				},
+			},
+		},
+		SBSeat:        0,
+		BBSeat:        1,
+		NumPlayers:    2,
+		TotalPot:      120,
+		WinnerSeat:    0,
+		WinType:       "showdown",
+		IsComplete:    true,
+		StatsEligible: true,
+		ParserVersion: 1,
+	}
+}
+
+// row wraps h as read from bytes slot*100 to slot*100+99 of path.
+func row(h *parser.Hand, path string, slot int) persistence.PersistedHand {
+	src := persistence.HandSourceRef{
+		SourcePath: path,
+		StartByte:  int64(slot * 100),
+		EndByte:    int64(slot*100 + 99),
+		StartLine:  int64(slot * 10),
+		EndLine:    int64(slot*10 + 9),
+	}
+	src.HandUID = persistence.GenerateHandUID(h, src)
+	return persistence.PersistedHand{Hand: h, Source: src}
+}
+
+func upsert(t *testing.T, repo persistence.ImportBatchRepository, rows ...persistence.PersistedHand) persistence.UpsertResult {
+	t.Helper()
+	res, err := repo.UpsertHands(context.Background(), rows)
+	if err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	return res
+}
+
+func handUIDs(hands []*parser.Hand) []string {
+	out := make([]string, 0, len(hands))
+	for _, h := range hands {
+		out = append(out, h.HandUID)
+	}
+	return out
+}
+
+func summaryUIDs(sums []persistence.HandSummary) []string {
+	out := make([]string, 0, len(sums))
+	for _, s := range sums {
+		out = append(out, s.HandUID)
+	}
+	return out
+}
+
+func testUpsertIsIdempotent(t *testing.T, repo persistence.ImportBatchRepository) {
+	ctx := context.Background()
+	rows := []persistence.PersistedHand{row(newHand(0), "a.log", 0), row(newHand(1), "a.log", 1), row(newHand(2), "a.log", 2)}
+
+	if res := upsert(t, repo, rows...); res != (persistence.UpsertResult{Inserted: 3}) {
+		t.Fatalf("first upsert = %+v, want 3 inserted", res)
+	}
+	if res := upsert(t, repo, rows...); res != (persistence.UpsertResult{Updated: 3}) {
+		t.Fatalf("second upsert = %+v, want 3 updated", res)
+	}
+	if res := upsert(t, repo, persistence.PersistedHand{Source: persistence.HandSourceRef{SourcePath: "a.log"}}); res != (persistence.UpsertResult{Skipped: 1}) {
+		t.Fatalf("nil hand upsert = %+v, want 1 skipped", res)
+	}
+
+	n, err := repo.CountHands(ctx, persistence.HandFilter{})
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if n != 3 {
+		t.Fatalf("count = %d, want 3", n)
+	}
+	hands, err := repo.ListHands(ctx, persistence.HandFilter{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	want := []string{rows[0].Source.HandUID, rows[1].Source.HandUID, rows[2].Source.HandUID}
+	if got := handUIDs(hands); !reflect.DeepEqual(got, want) {
+		t.Fatalf("hands = %v, want %v", got, want)
+	}
+}
+
+// testUpsertResolvesSourceSpan checks that a hand re-parsed into a different
+// UID replaces the hand stored for the same source span.
+func testUpsertResolvesSourceSpan(t *testing.T, repo persistence.ImportBatchRepository) {
+	ctx := context.Background()
+	orig := row(newHand(0), "a.log", 0)
+	upsert(t, repo, orig)
+
+	h := newHand(0)
+	h.TotalPot = 140
+	h.Players[0].PotWon = 140
+	reparsed := row(h, "a.log", 0)
+	if reparsed.Source.HandUID == orig.Source.HandUID {
+		t.Fatal("test hands share a UID")
+	}
+	fresh := row(newHand(1), "a.log", 1)
+
+	stored, err := repo.StoredHands(ctx, []persistence.PersistedHand{reparsed, fresh})
+	if err != nil {
+		t.Fatalf("stored hands: %v", err)
+	}
+	if len(stored) != 2 || stored[0] == nil || stored[0].HandUID != orig.Source.HandUID || stored[0].TotalPot != 120 || stored[1] != nil {
+		t.Fatalf("stored hands = %+v, want the original hand and nil", stored)
+	}
+
+	if res := upsert(t, repo, reparsed); res != (persistence.UpsertResult{Updated: 1}) {
+		t.Fatalf("reparsed upsert = %+v, want 1 updated", res)
+	}
+	n, err := repo.CountHands(ctx, persistence.HandFilter{})
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("count = %d, want 1", n)
+	}
+	got, err := repo.GetHandByUID(ctx, orig.Source.HandUID)
+	if err != nil {
+		t.Fatalf("get hand: %v", err)
+	}
+	if got == nil || got.TotalPot != 140 {
+		t.Fatalf("hand = %+v, want the reparsed hand under the original UID", got)
+	}
+}
+
+func testHandRoundTrip(t *testing.T, repo persistence.ImportBatchRepository) {
+	ctx := context.Background()
+	h := newHand(0)
+	h.Anomalies = []parser.HandAnomaly{{Code: parser.AnomalyLocalSeatUnknown, Severity: parser.AnomalySeverityInfo}}
+	h.HasAnomaly = true
+	r := row(h, "a.log", 0)
+	upsert(t, repo, r)
+
+	got, err := repo.GetHandByUID(ctx, r.Source.HandUID)
+	if err != nil {
+		t.Fatalf("get hand: %v", err)
+	}
+	if got == nil {
+		t.Fatal("hand not found")
+	}
+	if got.HandUID != r.Source.HandUID || !got.StartTime.Equal(h.StartTime) || !got.EndTime.Equal(h.EndTime) {
+		t.Fatalf("hand identity = %s %v %v", got.HandUID, got.StartTime, got.EndTime)
+	}
+	if got.LocalPlayerSeat != 0 || got.NumPlayers != 2 || got.TotalPot != 120 || got.WinnerSeat != 0 ||
+		got.WinType != "showdown" || got.SBSeat != 0 || got.BBSeat != 1 || got.WorldID != "wrld_test" ||
+		!got.IsComplete || !got.StatsEligible || !got.HasAnomaly || got.ParserVersion != 1 {
+		t.Fatalf("hand fields = %+v", got)
+	}
+	if !reflect.DeepEqual(got.CommunityCards, h.CommunityCards) {
+		t.Fatalf("community cards = %v, want %v", got.CommunityCards, h.CommunityCards)
+	}
+	if len(got.Anomalies) != 1 || got.Anomalies[0].Code != parser.AnomalyLocalSeatUnknown {
+		t.Fatalf("anomalies = %+v", got.Anomalies)
+	}
+	if len(got.Players) != 2 {
+		t.Fatalf("players = %d, want 2", len(got.Players))
+	}
+	for seat, want := range h.Players {
+		p := got.Players[seat]
+		if p == nil {
+			t.Fatalf("seat %d missing", seat)
+		}
+		if p.Position != want.Position || p.Won != want.Won || p.PotWon != want.PotWon || !reflect.DeepEqual(p.HoleCards, want.HoleCards) {
+			t.Fatalf("seat %d = %+v, want %+v", seat, p, want)
+		}
+		if len(p.Actions) != len(want.Actions) {
+			t.Fatalf("seat %d actions = %+v, want %+v", seat, p.Actions, want.Actions)
+		}
+		for i, a := range p.Actions {
+			w := want.Actions[i]
+			if a.Street != w.Street || a.Action != w.Action || a.Amount != w.Amount {
+				t.Fatalf("seat %d action %d = %+v, want %+v", seat, i, a, w)
+			}
+		}
+	}
+
+	missing, err := repo.GetHandByUID(ctx, "missing")
+	if err != nil || missing != nil {
+		t.Fatalf("missing hand = %+v, %v; want nil, nil", missing, err)
+	}
+}
+
+// filterFixture is a set of hands that differ in one filtered property each.
+type filterFixture struct {
+	aa, incomplete, excluded, sixMax, otherSeat, noLocal string
+	all                                                  []string
+}
+
+func newFilterFixture(t *testing.T, repo persistence.ImportBatchRepository) filterFixture {
+	t.Helper()
+	ctx := context.Background()
+
+	aa := newHand(0)
+
+	incomplete := newHand(1)
+	incomplete.IsComplete = false
+	incomplete.CommunityCards = nil
+
+	excluded := newHand(2)
+	excluded.Anomalies = []parser.HandAnomaly{{Code: parser.AnomalyFoldedWinner, Severity: parser.AnomalySeverityError, Detail: "seat 1"}}
+	excluded.HasAnomaly = true
+	excluded.StatsEligible = false
+
+	sixMax := newHand(3)
+	sixMax.NumPlayers = 6
+	sixMax.Players[0].Position = parser.PosCO
+	sixMax.Players[0].HoleCards = cards("9c", "4d")
+
+	otherSeat := newHand(4)
+	otherSeat.LocalPlayerSeat = 3
+	otherSeat.Players[3] = otherSeat.Players[0]
+	otherSeat.Players[3].SeatID = 3
+	delete(otherSeat.Players, 0)
+
+	noLocal := newHand(5)
+	noLocal.LocalPlayerSeat = -1
+
+	rows := make([]persistence.PersistedHand, 0, 6)
+	for i, h := range []*parser.Hand{aa, incomplete, excluded, sixMax, otherSeat, noLocal} {
+		rows = append(rows, row(h, "a.log", i))
+	}
+	upsert(t, repo, rows...)
+
+	fx := filterFixture{
+		aa:         rows[0].Source.HandUID,
+		incomplete: rows[1].Source.HandUID,
+		excluded:   rows[2].Source.HandUID,
+		sixMax:     rows[3].Source.HandUID,
+		otherSeat:  rows[4].Source.HandUID,
+		noLocal:    rows[5].Source.HandUID,
+	}
+	fx.all = []string{fx.aa, fx.incomplete, fx.excluded, fx.sixMax, fx.otherSeat, fx.noLocal}
+
+	for _, a := range []persistence.HandAnnotation{
+		{HandUID: fx.aa, Starred: true},
+		{HandUID: fx.excluded, Note: "River hero"},
+		{HandUID: fx.sixMax, Tags: []string{"Bluff"}},
+	} {
+		if err := repo.SaveHandAnnotation(ctx, a); err != nil {
+			t.Fatalf("save annotation: %v", err)
+		}
+	}
+	return fx
+}
+
+func pocketPremiumID() int {
+	return int(stats.PocketPremium) + 1
+}
+
+func fullHouseID() int {
+	return stats.MadeHandClassID(stats.ClassifyMadeHand(cards("Ah", "As"), cards("Ad", "7c", "7s", "3h", "2d")))
+}
+
+func testListHandsFilters(t *testing.T, repo persistence.ImportBatchRepository) {
+	ctx := context.Background()
+	fx := newFilterFixture(t, repo)
+
+	from, to := slotTime(1), slotTime(3)
+	seat0 := 0
+	query, err := handquery.Parse("pos:CO")
+	if err != nil {
+		t.Fatalf("parse query: %v", err)
+	}
+
+	cases := []struct {
+		name string
+		f    persistence.HandFilter
+		want []string
+	}{
+		{"none", persistence.HandFilter{}, fx.all},
+		{"only complete", persistence.HandFilter{OnlyComplete: true}, []string{fx.aa, fx.excluded, fx.sixMax, fx.otherSeat, fx.noLocal}},
+		{"only stats excluded", persistence.HandFilter{OnlyStatsExcluded: true}, []string{fx.excluded}},
+		{"inclusive time range", persistence.HandFilter{FromTime: &from, ToTime: &to}, []string{fx.incomplete, fx.excluded, fx.sixMax}},
+		{"table size", persistence.HandFilter{TableSizes: []stats.TableSize{stats.TableSizeForPlayers(6)}}, []string{fx.sixMax}},
+		{"local seat", persistence.HandFilter{LocalSeat: &seat0}, []string{fx.aa, fx.incomplete, fx.excluded, fx.sixMax, fx.noLocal}},
+		{"starred", persistence.HandFilter{OnlyStarred: true}, []string{fx.aa}},
+		{"tag", persistence.HandFilter{Tags: []string{" BLUFF "}}, []string{fx.sixMax}},
+		{"note search", persistence.HandFilter{AnnotationSearch: "RIVER"}, []string{fx.excluded}},
+		{"tag search", persistence.HandFilter{AnnotationSearch: "blu"}, []string{fx.sixMax}},
+		{"query", persistence.HandFilter{Query: query}, []string{fx.sixMax}},
+		{"pocket category", persistence.HandFilter{PocketCategoryIDs: []int{pocketPremiumID()}}, []string{fx.aa, fx.incomplete, fx.excluded, fx.otherSeat}},
+		{"final class", persistence.HandFilter{FinalClassIDs: []int{fullHouseID()}}, []string{fx.aa, fx.excluded, fx.otherSeat}},
+		{"combined", persistence.HandFilter{OnlyComplete: true, PocketCategoryIDs: []int{pocketPremiumID()}, LocalSeat: &seat0}, []string{fx.aa, fx.excluded}},
+	}
+	for _, tc := range cases {
+		hands, err := repo.ListHands(ctx, tc.f)
+		if err != nil {
+			t.Fatalf("%s: list: %v", tc.name, err)
+		}
+		if got := handUIDs(hands); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: hands = %v, want %v", tc.name, got, tc.want)
+		}
+		n, err := repo.CountHands(ctx, tc.f)
+		if err != nil {
+			t.Fatalf("%s: count: %v", tc.name, err)
+		}
+		if n != len(tc.want) {
+			t.Errorf("%s: count = %d, want %d", tc.name, n, len(tc.want))
+		}
+	}
+}
+
+func testStatsExcludedHands(t *testing.T, repo persistence.ImportBatchRepository) {
+	ctx := context.Background()
+	// An informational anomaly leaves a hand in the stats; an error removes
+	// it.
+	info := newHand(1)
+	info.Anomalies = []parser.HandAnomaly{{Code: parser.AnomalyLocalSeatUnknown, Severity: parser.AnomalySeverityInfo}}
+	info.HasAnomaly = true
+	excluded := newHand(2)
+	excluded.Anomalies = []parser.HandAnomaly{{Code: parser.AnomalyFoldedWinner, Severity: parser.AnomalySeverityError, Detail: "seat 1"}}
+	excluded.HasAnomaly = true
+	excluded.StatsEligible = false
+	rows := []persistence.PersistedHand{row(newHand(0), "a.log", 0), row(info, "a.log", 1), row(excluded, "a.log", 2)}
+	upsert(t, repo, rows...)
+
+	f := persistence.HandFilter{OnlyComplete: true, OnlyStatsExcluded: true}
+	hands, err := repo.ListHands(ctx, f)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if got := handUIDs(hands); !slices.Equal(got, []string{rows[2].Source.HandUID}) {
+		t.Fatalf("excluded hands = %v, want only the hand with an error", got)
+	}
+	if a := hands[0].Anomalies; len(a) != 1 || a[0].Severity != parser.AnomalySeverityError || a[0].Detail != "seat 1" {
+		t.Fatalf("anomalies = %+v", a)
+	}
+	if n, err := repo.CountHands(ctx, f); err != nil || n != 1 {
+		t.Fatalf("count = %d, %v; want 1", n, err)
+	}
+}
+
+// queryHand returns a complete hand in slot where the local player on seat 0
+// plays hole from pos against a big blind of bb on seat 1.
+func queryHand(slot int, pos parser.Position, hole, board []parser.Card, hero []parser.PlayerAction, bb int) *parser.Hand {
+	heroInfo := &parser.PlayerHandInfo{SeatID: 0, Position: pos, HoleCards: hole, Actions: hero}
+	bbInfo := &parser.PlayerHandInfo{SeatID: 1, Position: parser.PosBB}
+	if pos != parser.PosBB {
+		bbInfo.Actions = []parser.PlayerAction{{PlayerID: 1, Street: parser.StreetPreFlop, Action: parser.ActionBlindBB, Amount: bb}}
+	}
+	start := slotTime(slot)
+	return &parser.Hand{
+		ID:              slot,
+		StartTime:       start,
+		EndTime:         start.Add(30 * time.Second),
+		LocalPlayerSeat: 0,
+		CommunityCards:  board,
+		Players:         map[int]*parser.PlayerHandInfo{0: heroInfo, 1: bbInfo},
+		IsComplete:      true,
+		StatsEligible:   true,
+	}
+}
+
+func testHandQuery(t *testing.T, repo persistence.ImportBatchRepository) {
+	ctx := context.Background()
+
+	// Button raises, 4-bets to 90 and check-raises the flop to 100 with AKs
+	// and wins: net +210 (BET IN is a running street total), 20bb pot.
+	h1 := queryHand(1, parser.PosBTN, cards("As", "Ks"), cards("Ah", "7h", "2h", "9h", "Kd"),
+		[]parser.PlayerAction{
+			{Street: parser.StreetPreFlop, Action: parser.ActionRaise, Amount: 30},
+			{Street: parser.StreetPreFlop, Action: parser.ActionRaise, Amount: 90},
+			{Street: parser.StreetFlop, Action: parser.ActionCheck},
+			{Street: parser.StreetFlop, Action: parser.ActionRaise, Amount: 100},
+		}, 20)
+	h1.Players[0].Won, h1.Players[0].PotWon = true, 400
+	h1.TotalPot, h1.NumPlayers = 400, 3
+	h1.InstanceType = parser.InstanceTypePublic
+
+	// Cutoff calls and bets with QQ on a paired rainbow board and loses 60.
+	h2 := queryHand(2, parser.PosCO, cards("Qd", "Qc"), cards("5s", "5d", "9h", "Jc", "2s"),
+		[]parser.PlayerAction{
+			{Street: parser.StreetPreFlop, Action: parser.ActionCall, Amount: 20},
+			{Street: parser.StreetFlop, Action: parser.ActionBet, Amount: 40},
+		}, 20)
+	h2.Players[0].ShowedDown = true
+	h2.TotalPot, h2.NumPlayers = 120, 4
+
+	// Big blind folds 72o preflop: net -10 (one big blind).
+	h3 := queryHand(3, parser.PosBB, cards("7c", "2d"), nil,
+		[]parser.PlayerAction{
+			{Street: parser.StreetPreFlop, Action: parser.ActionBlindBB, Amount: 10},
+			{Street: parser.StreetPreFlop, Action: parser.ActionFold},
+		}, 10)
+	h3.TotalPot, h3.NumPlayers = 30, 2
+
+	rows := []persistence.PersistedHand{row(h1, "a.log", 1), row(h2, "a.log", 2), row(h3, "a.log", 3)}
+	upsert(t, repo, rows...)
+	uid1, uid2, uid3 := rows[0].Source.HandUID, rows[1].Source.HandUID, rows[2].Source.HandUID
+	if err := repo.SaveHandAnnotation(ctx, persistence.HandAnnotation{HandUID: uid3, Starred: true, Tags: []string{"review"}}); err != nil {
+		t.Fatalf("save annotation: %v", err)
+	}
+
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{"pos:BTN", []string{uid1}},
+		{"hole:AKs", []string{uid1}},
+		{"hole:AKo", nil},
+		{"hole:QQ", []string{uid2}},
+		{"hole:2", []string{uid3}},
+		{"board:flush", []string{uid1}},
+		{"board:paired board:rainbow", []string{uid2}},
+		{"board:Ah", []string{uid1}},
+		{"pocket:premium", []string{uid1, uid2}},
+		{"-pocket:premium,second_premium", []string{uid3}},
+		{"instance:public", []string{uid1}},
+		{"instance:unknown", []string{uid2, uid3}},
+		{"made:one_pair", nil},
+		{"made:two_pair", []string{uid1, uid2}},
+		{"pot>=20bb", []string{uid1}},
+		{"players<=3", []string{uid1, uid3}},
+		{"net<-2bb", []string{uid2}},
+		{"net>0", []string{uid1}},
+		{"net>200 net<=210", []string{uid1}},
+		{"action:flop:checkraise", []string{uid1}},
+		{"action:turn:checkraise", nil},
+		{"action:fold", []string{uid3}},
+		{"action:flop:bet", []string{uid2}},
+		{"result:won", []string{uid1}},
+		{"result:lost", []string{uid2, uid3}},
+		{"result:showdown", []string{uid2}},
+		{"is:starred OR pos:CO", []string{uid2, uid3}},
+		{"-(pos:BTN OR pos:CO)", []string{uid3}},
+		{"-pot>=20bb", []string{uid2, uid3}},
+		{"tag:review", []string{uid3}},
+		{"revi", []string{uid3}},
+		{"pos:BTN hole:AKs board:flush pot>10bb action:flop:checkraise result:won players<=4", []string{uid1}},
+	}
+	for _, tc := range cases {
+		q, err := handquery.Parse(tc.query)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tc.query, err)
+		}
+		f := persistence.HandFilter{Query: q}
+		hands, err := repo.ListHands(ctx, f)
+		if err != nil {
+			t.Fatalf("%q: list: %v", tc.query, err)
+		}
+		if got := handUIDs(hands); !slices.Equal(got, tc.want) {
+			t.Errorf("%q: hands = %v, want %v", tc.query, got, tc.want)
+		}
+		sums, total, err := repo.ListHandSummaries(ctx, f)
+		if err != nil {
+			t.Fatalf("%q: summaries: %v", tc.query, err)
+		}
+		if total != len(tc.want) || len(sums) != len(tc.want) {
+			t.Errorf("%q: summaries = %v (total %d), want %d", tc.query, summaryUIDs(sums), total, len(tc.want))
+		}
+		if n, err := repo.CountHands(ctx, f); err != nil || n != len(tc.want) {
+			t.Errorf("%q: count = %d, %v; want %d", tc.query, n, err, len(tc.want))
+		}
+	}
+
+	// A node the repository does not know fails the query instead of
+	// matching nothing or everything.
+	bad := persistence.HandFilter{Query: handquery.And{Terms: []handquery.Expr{handquery.Starred{}, unknownQueryNode{}}}}
+	if _, err := repo.ListHands(ctx, bad); err == nil {
+		t.Errorf("unknown query node: list succeeded")
+	}
+	if _, _, err := repo.ListHandSummaries(ctx, bad); err == nil {
+		t.Errorf("unknown query node: summaries succeeded")
+	}
+	if _, err := repo.CountHands(ctx, bad); err == nil {
+		t.Errorf("unknown query node: count succeeded")
+	}
+
+	for size, want := range map[stats.TableSize]int{stats.TableSizeHeadsUp: 1, stats.TableSizeShort: 2, stats.TableSizeFullRing: 0} {
+		f := persistence.HandFilter{TableSizes: []stats.TableSize{size}}
+		if _, total, err := repo.ListHandSummaries(ctx, f); err != nil || total != want {
+			t.Errorf("table size %s: summaries total = %d, %v; want %d", size.Code(), total, err, want)
+		}
+		if n, err := repo.CountHands(ctx, f); err != nil || n != want {
+			t.Errorf("table size %s: count = %d, %v; want %d", size.Code(), n, err, want)
+		}
+	}
+}
+
+// unknownQueryNode is a search query node no repository supports.
+type unknownQueryNode struct{}
+
+func (unknownQueryNode) String() string { return "unknown" }
+
+func testHandSummaries(t *testing.T, repo persistence.ImportBatchRepository) {
+	ctx := context.Background()
+	fx := newFilterFixture(t, repo)
+
+	sums, total, err := repo.ListHandSummaries(ctx, persistence.HandFilter{})
+	if err != nil {
+		t.Fatalf("summaries: %v", err)
+	}
+	// Only complete hands of a seated local player, newest first.
+	want := []string{fx.otherSeat, fx.sixMax, fx.excluded, fx.aa}
+	if got := summaryUIDs(sums); !reflect.DeepEqual(got, want) || total != len(want) {
+		t.Fatalf("summaries = %v (total %d), want %v", got, total, want)
+	}
+
+	aa := sums[3]
+	if !aa.StartTime.Equal(slotTime(0)) || aa.NumPlayers != 2 || aa.TotalPot != 120 || !aa.IsComplete || aa.LocalSeat != 0 {
+		t.Fatalf("aa summary = %+v", aa)
+	}
+	if aa.HoleCard0 != "Ah" || aa.HoleCard1 != "As" || aa.Position != "BTN" || aa.CommunityCards != "Ad 7c 7s 3h 2d" {
+		t.Fatalf("aa summary cards = %+v", aa)
+	}
+	if aa.PotWon != 120 || aa.NetChips != 60 || !aa.Won || !aa.Starred || aa.HasNote || len(aa.Tags) != 0 {
+		t.Fatalf("aa summary result = %+v", aa)
+	}
+	if s := sums[2]; !s.HasNote || s.Starred {
+		t.Fatalf("excluded summary = %+v", s)
+	}
+	if s := sums[1]; !reflect.DeepEqual(s.Tags, []string{"bluff"}) || s.Position != "CO" {
+		t.Fatalf("six max summary = %+v", s)
+	}
+	if s := sums[0]; s.LocalSeat != 3 || s.HoleCard0 != "Ah" {
+		t.Fatalf("other seat summary = %+v", s)
+	}
+
+	sums, total, err = repo.ListHandSummaries(ctx, persistence.HandFilter{
+		PocketCategoryIDs: []int{pocketPremiumID()},
+		FinalClassIDs:     []int{fullHouseID()},
+	})
+	if err != nil {
+		t.Fatalf("summaries: %v", err)
+	}
+	want = []string{fx.otherSeat, fx.excluded, fx.aa}
+	if got := summaryUIDs(sums); !reflect.DeepEqual(got, want) || total != len(want) {
+		t.Fatalf("class summaries = %v (total %d), want %v", got, total, want)
+	}
+}
+
+func testHandSummaryPages(t *testing.T, repo persistence.ImportBatchRepository) {
+	ctx := context.Background()
+	rows := make([]persistence.PersistedHand, 0, 7)
+	for i := range 7 {
+		rows = append(rows, row(newHand(i), "a.log", i))
+	}
+	upsert(t, repo, rows...)
+
+	var seen []string
+	for offset := 0; offset < 7; offset += 3 {
+		sums, total, err := repo.ListHandSummaries(ctx, persistence.HandFilter{Limit: 3, Offset: offset})
+		if err != nil {
+			t.Fatalf("page at %d: %v", offset, err)
+		}
+		if total != 7 {
+			t.Fatalf("page at %d total = %d, want 7", offset, total)
+		}
+		if want := min(3, 7-offset); len(sums) != want {
+			t.Fatalf("page at %d has %d hands, want %d", offset, len(sums), want)
+		}
+		seen = append(seen, summaryUIDs(sums)...)
+	}
+	want := make([]string, 0, 7)
+	for i := 6; i >= 0; i-- {
+		want = append(want, rows[i].Source.HandUID)
+	}
+	if !reflect.DeepEqual(seen, want) {
+		t.Fatalf("pages = %v, want %v", seen, want)
+	}
+
+	sums, total, err := repo.ListHandSummaries(ctx, persistence.HandFilter{Limit: 3, Offset: 10})
+	if err != nil {
+		t.Fatalf("page past the end: %v", err)
+	}
+	if len(sums) != 0 || total != 7 {
+		t.Fatalf("page past the end = %d hands (total %d), want 0 (total 7)", len(sums), total)
+	}
+}
+
+func testListHandsPages(t *testing.T, repo persistence.ImportBatchRepository) {
+	ctx := context.Background()
+	rows := make([]persistence.PersistedHand, 0, 5)
+	for i := range 5 {
+		rows = append(rows, row(newHand(i), "a.log", i))
+	}
+	// Seat 1 sits out the first hand.
+	delete(rows[0].Hand.Players, 1)
+	upsert(t, repo, rows...)
+	uids := func(idx ...int) []string {
+		out := make([]string, 0, len(idx))
+		for _, i := range idx {
+			out = append(out, rows[i].Source.HandUID)
+		}
+		return out
+	}
+
+	seat := 1
+	tests := []struct {
+		name string
+		f    persistence.HandFilter
+		want []string
+	}{
+		{"oldest first", persistence.HandFilter{}, uids(0, 1, 2, 3, 4)},
+		{"newest first", persistence.HandFilter{NewestFirst: true}, uids(4, 3, 2, 1, 0)},
+		{"first page", persistence.HandFilter{Limit: 2}, uids(0, 1)},
+		{"newest page", persistence.HandFilter{NewestFirst: true, Limit: 2, Offset: 1}, uids(3, 2)},
+		{"last page", persistence.HandFilter{Limit: 2, Offset: 4}, uids(4)},
+		{"past the end", persistence.HandFilter{Limit: 2, Offset: 9}, nil},
+		// The limit counts only the hands seat 1 sits in.
+		{"local seat", persistence.HandFilter{LocalSeat: &seat, Limit: 3}, uids(1, 2, 3)},
+	}
+	for _, tt := range tests {
+		hands, err := repo.ListHands(ctx, tt.f)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := handUIDs(hands); !slices.Equal(got, tt.want) {
+			t.Fatalf("%s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func testListHandsAfter(t *testing.T, repo persistence.ImportBatchRepository) {
+	ctx := context.Background()
+	fx := newFilterFixture(t, repo)
+
+	cases := []struct {
+		name      string
+		after     time.Time
+		localSeat int
+		want      []string
+	}{
+		{"any seat", slotTime(0), -1, []string{fx.sixMax, fx.otherSeat, fx.noLocal}},
+		{"seat 0", slotTime(0), 0, []string{fx.sixMax, fx.noLocal}},
+		{"strictly after", slotTime(4), -1, []string{fx.noLocal}},
+		{"none left", slotTime(5), -1, nil},
+	}
+	for _, tc := range cases {
+		hands, err := repo.ListHandsAfter(ctx, tc.after, tc.localSeat)
+		if err != nil {
+			t.Fatalf("%s: list after: %v", tc.name, err)
+		}
+		if got := handUIDs(hands); !slices.Equal(got, tc.want) {
+			t.Errorf("%s: hands = %v, want %v", tc.name, got, tc.want)
+		}
+		for _, h := range hands {
+			if len(h.Players) == 0 {
+				t.Errorf("%s: hand %s has no players", tc.name, h.HandUID)
+			}
+		}
+	}
+}
+
+func testOutdatedHandSources(t *testing.T, repo persistence.ImportBatchRepository) {
+	ctx := context.Background()
+	old := newHand(0)
+	old.ParserVersion = 1
+	current := newHand(1)
+	current.ParserVersion = 2
+
+	// The same hand read from a copy of the log is one hand with two
+	// sources.
+	b := row(old, "b.log", 5)
+	a := row(old, "a.log", 0)
+	a.Source.HandUID = b.Source.HandUID
+	upsert(t, repo, b, a, row(current, "a.log", 1))
+
+	srcs, err := repo.ListOutdatedHandSources(ctx, 2)
+	if err != nil {
+		t.Fatalf("outdated sources: %v", err)
+	}
+	if len(srcs) != 2 {
+		t.Fatalf("outdated sources = %+v, want 2", srcs)
+	}
+	if srcs[0].SourcePath != "a.log" || srcs[0].StartByte != 0 || srcs[1].SourcePath != "b.log" || srcs[1].StartByte != 500 {
+		t.Fatalf("outdated sources = %+v, want a.log then b.log", srcs)
+	}
+	for _, s := range srcs {
+		if s.HandUID != b.Source.HandUID || s.EndByte != s.StartByte+99 {
+			t.Fatalf("outdated source = %+v", s)
+		}
+	}
+}
+
+func testRawLogs(t *testing.T, repo persistence.ImportBatchRepository) {
+	ctx := context.Background()
+	r := row(newHand(1), "a.log", 1)
+	upsert(t, repo, r)
+	if got, err := repo.GetHandRawLog(ctx, r.Source.HandUID); err != nil || got != nil {
+		t.Fatalf("raw log without snippet = %+v, %v; want nil, nil", got, err)
+	}
+
+	// A snippet may start before its hand, with the lines that set up the
+	// table.
+	snippet := &persistence.RawLogSnippet{SourcePath: "a.log", StartByte: 40, EndByte: 199, Data: []byte("line 1\nline 2\n")}
+	r.RawLog = snippet
+	upsert(t, repo, r)
+	// Writing the hand again without a snippet keeps the stored one.
+	r.RawLog = nil
+	upsert(t, repo, r)
+
+	got, err := repo.GetHandRawLog(ctx, r.Source.HandUID)
+	if err != nil {
+		t.Fatalf("raw log: %v", err)
+	}
+	if got == nil || got.SourcePath != "a.log" || got.StartByte != 40 || got.EndByte != 199 || string(got.Data) != string(snippet.Data) {
+		t.Fatalf("raw log = %+v, want %+v", got, snippet)
+	}
+}
+
+func testUserDisplayNames(t *testing.T, repo persistence.ImportBatchRepository) {
+	ctx := context.Background()
+	h := newHand(0)
+	h.InstanceUID = "wrld_test:1"
+	h.InstanceUsers = []parser.InstanceUser{{UserUID: "usr_a", DisplayName: "Alice"}, {UserUID: "usr_b", DisplayName: "Bob"}}
+	upsert(t, repo, row(h, "a.log", 0))
+
+	names, err := repo.UserDisplayNames(ctx)
+	if err != nil {
+		t.Fatalf("display names: %v", err)
+	}
+	if want := map[string]string{"usr_a": "Alice", "usr_b": "Bob"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("display names = %v, want %v", names, want)
+	}
+}
+
+func testCursors(t *testing.T, repo persistence.ImportBatchRepository) {
+	ctx := context.Background()
+	if c, err := repo.GetCursor(ctx, "a.log"); err != nil || c != nil {
+		t.Fatalf("missing cursor = %+v, %v; want nil, nil", c, err)
+	}
+	// Marking a log without a cursor does not create one.
+	if err := repo.MarkFullyImported(ctx, "a.log"); err != nil {
+		t.Fatalf("mark missing: %v", err)
+	}
+	if c, err := repo.GetCursor(ctx, "a.log"); err != nil || c != nil {
+		t.Fatalf("cursor after marking a missing one = %+v, %v; want nil, nil", c, err)
+	}
+
+	last := base.Add(time.Hour)
+	in := persistence.ImportCursor{
+		SourcePath:      "a.log",
+		NextByteOffset:  4096,
+		NextLineNumber:  80,
+		LastEventTime:   &last,
+		LastHandUID:     "hand-1",
+		WorldCtx:        &parser.WorldContext{WorldID: "wrld_test", InstanceUID: "wrld_test:1"},
+		Fingerprint:     "fp-a",
+		FingerprintSize: 1000,
+	}
+	if err := repo.SaveCursor(ctx, in); err != nil {
+		t.Fatalf("save cursor: %v", err)
+	}
+	got, err := repo.GetCursor(ctx, "a.log")
+	if err != nil {
+		t.Fatalf("get cursor: %v", err)
+	}
+	if got == nil || got.NextByteOffset != 4096 || got.NextLineNumber != 80 || got.LastHandUID != "hand-1" ||
+		got.IsFullyImported || got.Fingerprint != "fp-a" || got.FingerprintSize != 1000 || got.LastEventTime == nil || !got.LastEventTime.Equal(last) {
+		t.Fatalf("cursor = %+v, want %+v", got, in)
+	}
+	if got.WorldCtx == nil || got.WorldCtx.WorldID != "wrld_test" || got.WorldCtx.InstanceUID != "wrld_test:1" {
+		t.Fatalf("cursor world = %+v", got.WorldCtx)
+	}
+
+	// An empty fingerprint keeps the stored one.
+	in.NextByteOffset = 8192
+	in.Fingerprint, in.FingerprintSize = "", 0
+	if err := repo.SaveCursor(ctx, in); err != nil {
+		t.Fatalf("save cursor: %v", err)
+	}
+	if err := repo.MarkFullyImported(ctx, "a.log"); err != nil {
+		t.Fatalf("mark fully imported: %v", err)
+	}
+	got, err = repo.GetCursor(ctx, "a.log")
+	if err != nil {
+		t.Fatalf("get cursor: %v", err)
+	}
+	if got == nil || got.NextByteOffset != 8192 || got.Fingerprint != "fp-a" || got.FingerprintSize != 1000 || !got.IsFullyImported {
+		t.Fatalf("updated cursor = %+v", got)
+	}
+}
+
+func testCursorFingerprints(t *testing.T, repo persistence.ImportBatchRepository) {
+	ctx := context.Background()
+	if sizes, err := repo.FingerprintSizes(ctx); err != nil || len(sizes) != 0 {
+		t.Fatalf("sizes without fingerprints = %v, %v; want none", sizes, err)
+	}
+	for _, c := range []persistence.ImportCursor{
+		{SourcePath: "a.log", NextByteOffset: 100, Fingerprint: "fp", FingerprintSize: 16384},
+		{SourcePath: "b.log", NextByteOffset: 300, Fingerprint: "fp", FingerprintSize: 16384},
+		{SourcePath: "c.log", NextByteOffset: 900, Fingerprint: "other", FingerprintSize: 16384},
+		// A short log's fingerprint covers fewer bytes.
+		{SourcePath: "d.log", NextByteOffset: 900, Fingerprint: "fp", FingerprintSize: 900},
+		{SourcePath: "e.log", NextByteOffset: 50},
+	} {
+		if err := repo.SaveCursor(ctx, c); err != nil {
+			t.Fatalf("save cursor: %v", err)
+		}
+	}
+	if sizes, err := repo.FingerprintSizes(ctx); err != nil || !slices.Equal(sizes, []int{16384, 900}) {
+		t.Fatalf("sizes = %v, %v; want 16384, 900", sizes, err)
+	}
+
+	got, err := repo.FindCursorByFingerprint(ctx, "fp", 16384)
+	if err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	if got == nil || got.SourcePath != "b.log" {
+		t.Fatalf("cursor = %+v, want the most advanced b.log", got)
+	}
+	got, err = repo.FindCursorByFingerprint(ctx, "fp", 900)
+	if err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	if got == nil || got.SourcePath != "d.log" {
+		t.Fatalf("cursor = %+v, want d.log with the shorter fingerprint", got)
+	}
+
+	// A fully imported log wins over one that is further along.
+	if err := repo.MarkFullyImported(ctx, "a.log"); err != nil {
+		t.Fatalf("mark fully imported: %v", err)
+	}
+	got, err = repo.FindCursorByFingerprint(ctx, "fp", 16384)
+	if err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	if got == nil || got.SourcePath != "a.log" {
+		t.Fatalf("cursor = %+v, want the fully imported a.log", got)
+	}
+
+	for _, fp := range []string{"", "unknown"} {
+		if got, err := repo.FindCursorByFingerprint(ctx, fp, 16384); err != nil || got != nil {
+			t.Fatalf("find %q = %+v, %v; want nil, nil", fp, got, err)
+		}
+	}
+	if got, err := repo.FindCursorByFingerprint(ctx, "other", 900); err != nil || got != nil {
+		t.Fatalf("find with another size = %+v, %v; want nil, nil", got, err)
+	}
+}
+
+func testImportBatch(t *testing.T, repo persistence.ImportBatchRepository) {
+	ctx := context.Background()
+	rows := []persistence.PersistedHand{row(newHand(0), "a.log", 0), row(newHand(1), "a.log", 1)}
+	res, err := repo.SaveImportBatch(ctx, rows, persistence.ImportCursor{SourcePath: "a.log", NextByteOffset: 200, NextLineNumber: 20})
+	if err != nil {
+		t.Fatalf("save batch: %v", err)
+	}
+	if res != (persistence.UpsertResult{Inserted: 2}) {
+		t.Fatalf("batch = %+v, want 2 inserted", res)
+	}
+	n, err := repo.CountHands(ctx, persistence.HandFilter{})
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	c, err := repo.GetCursor(ctx, "a.log")
+	if err != nil {
+		t.Fatalf("get cursor: %v", err)
+	}
+	if n != 2 || c == nil || c.NextByteOffset != 200 || c.NextLineNumber != 20 {
+		t.Fatalf("after batch: %d hands, cursor %+v", n, c)
+	}
+}
+
+// testImportBatchCancelled checks that a batch that fails writes neither
+// its hands nor its cursor.
+func testImportBatchCancelled(t *testing.T, repo persistence.ImportBatchRepository) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rows := []persistence.PersistedHand{row(newHand(0), "a.log", 0)}
+	if _, err := repo.SaveImportBatch(ctx, rows, persistence.ImportCursor{SourcePath: "a.log", NextByteOffset: 100}); err == nil {
+		t.Fatal("save batch with a cancelled context succeeded")
+	}
+
+	n, err := repo.CountHands(context.Background(), persistence.HandFilter{})
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	c, err := repo.GetCursor(context.Background(), "a.log")
+	if err != nil {
+		t.Fatalf("get cursor: %v", err)
+	}
+	if n != 0 || c != nil {
+		t.Fatalf("after a failed batch: %d hands, cursor %+v; want neither", n, c)
+	}
+}
+
+func testSettings(t *testing.T, repo persistence.ImportBatchRepository) {
+	ctx := context.Background()
+	got, err := repo.GetSettings(ctx)
+	if err != nil {
+		t.Fatalf("get settings: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("initial settings = %v, want none", got)
+	}
+	if err := repo.SaveSettings(ctx, map[string]string{"a": "1", "b": "2"}); err != nil {
+		t.Fatalf("save settings: %v", err)
+	}
+	// Saving merges into the stored settings.
+	if err := repo.SaveSettings(ctx, map[string]string{"b": "3"}); err != nil {
+		t.Fatalf("save settings: %v", err)
+	}
+	got, err = repo.GetSettings(ctx)
+	if err != nil {
+		t.Fatalf("get settings: %v", err)
+	}
+	if want := map[string]string{"a": "1", "b": "3"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("settings = %v, want %v", got, want)
+	}
+}
+
+func testAnnotations(t *testing.T, repo persistence.ImportBatchRepository) {
+	ctx := context.Background()
+	for _, a := range []persistence.HandAnnotation{
+		{HandUID: "b", Note: "check the river", Tags: []string{"Bluff", "river ", "bluff"}},
+		{HandUID: "a", Starred: true, Tags: []string{"bluff"}},
+		{HandUID: "c", Note: "  "},
+	} {
+		if err := repo.SaveHandAnnotation(ctx, a); err != nil {
+			t.Fatalf("save annotation: %v", err)
+		}
+	}
+
+	got, err := repo.GetHandAnnotation(ctx, "b")
+	if err != nil {
+		t.Fatalf("get annotation: %v", err)
+	}
+	if got == nil || got.Note != "check the river" || got.Starred || !reflect.DeepEqual(got.Tags, []string{"bluff", "river"}) {
+		t.Fatalf("annotation = %+v", got)
+	}
+	if got, err := repo.GetHandAnnotation(ctx, "c"); err != nil || got != nil {
+		t.Fatalf("empty annotation = %+v, %v; want nil, nil", got, err)
+	}
+
+	all, err := repo.ListHandAnnotations(ctx)
+	if err != nil {
+		t.Fatalf("list annotations: %v", err)
+	}
+	if len(all) != 2 || all[0].HandUID != "a" || all[1].HandUID != "b" {
+		t.Fatalf("annotations = %+v, want a and b", all)
+	}
+	tags, err := repo.ListHandTags(ctx)
+	if err != nil {
+		t.Fatalf("list tags: %v", err)
+	}
+	if want := []persistence.TagCount{{Tag: "bluff", Count: 2}, {Tag: "river", Count: 1}}; !reflect.DeepEqual(tags, want) {
+		t.Fatalf("tags = %+v, want %+v", tags, want)
+	}
+
+	// Saving an empty annotation removes it.
+	if err := repo.SaveHandAnnotation(ctx, persistence.HandAnnotation{HandUID: "a"}); err != nil {
+		t.Fatalf("clear annotation: %v", err)
+	}
+	if got, err := repo.GetHandAnnotation(ctx, "a"); err != nil || got != nil {
+		t.Fatalf("cleared annotation = %+v, %v; want nil, nil", got, err)
+	}
+}
+
+func testAnnotationFilters(t *testing.T, repo persistence.ImportBatchRepository) {
+	ctx := context.Background()
+	rows := []persistence.PersistedHand{row(newHand(0), "a.log", 0), row(newHand(1), "a.log", 1), row(newHand(2), "a.log", 2)}
+	upsert(t, repo, rows...)
+	uid0, uid1 := rows[0].Source.HandUID, rows[1].Source.HandUID
+
+	// Notes are trimmed; tags are lower-cased, their spaces collapsed and
+	// blank or repeated tags dropped.
+	for _, a := range []persistence.HandAnnotation{
+		{HandUID: uid0, Note: " Hero call vs 3bet ", Tags: []string{"Hero  Call", "review with coach", "hero call", " "}, Starred: true},
+		{HandUID: uid1, Tags: []string{"hero call"}},
+	} {
+		if err := repo.SaveHandAnnotation(ctx, a); err != nil {
+			t.Fatalf("save annotation: %v", err)
+		}
+	}
+	got, err := repo.GetHandAnnotation(ctx, uid0)
+	if err != nil {
+		t.Fatalf("get annotation: %v", err)
+	}
+	if got == nil || got.Note != "Hero call vs 3bet" || !got.Starred || !reflect.DeepEqual(got.Tags, []string{"hero call", "review with coach"}) {
+		t.Fatalf("annotation = %+v", got)
+	}
+
+	cases := []struct {
+		name string
+		f    persistence.HandFilter
+		want []string
+	}{
+		{"starred", persistence.HandFilter{OnlyStarred: true}, []string{uid0}},
+		{"one tag", persistence.HandFilter{Tags: []string{"Hero Call"}}, []string{uid1, uid0}},
+		{"all tags", persistence.HandFilter{Tags: []string{"hero call", "review with coach"}}, []string{uid0}},
+		{"note search", persistence.HandFilter{AnnotationSearch: "3BET"}, []string{uid0}},
+		{"tag search", persistence.HandFilter{AnnotationSearch: "coach"}, []string{uid0}},
+		{"wildcard is literal", persistence.HandFilter{AnnotationSearch: "%"}, nil},
+	}
+	for _, tc := range cases {
+		sums, total, err := repo.ListHandSummaries(ctx, tc.f)
+		if err != nil {
+			t.Fatalf("%s: summaries: %v", tc.name, err)
+		}
+		if got := summaryUIDs(sums); !slices.Equal(got, tc.want) || total != len(tc.want) {
+			t.Errorf("%s: summaries = %v (total %d), want %v", tc.name, got, total, tc.want)
+		}
+		if n, err := repo.CountHands(ctx, tc.f); err != nil || n != len(tc.want) {
+			t.Errorf("%s: count = %d, %v; want %d", tc.name, n, err, len(tc.want))
+		}
+	}
+
+	sums, _, err := repo.ListHandSummaries(ctx, persistence.HandFilter{OnlyStarred: true})
+	if err != nil || len(sums) != 1 {
+		t.Fatalf("starred summaries = %+v, %v", sums, err)
+	}
+	if s := sums[0]; !s.Starred || !s.HasNote || !reflect.DeepEqual(s.Tags, []string{"hero call", "review with coach"}) {
+		t.Fatalf("starred summary = %+v", s)
+	}
+	tags, err := repo.ListHandTags(ctx)
+	if err != nil {
+		t.Fatalf("list tags: %v", err)
+	}
+	if want := []persistence.TagCount{{Tag: "hero call", Count: 2}, {Tag: "review with coach", Count: 1}}; !reflect.DeepEqual(tags, want) {
+		t.Fatalf("tags = %+v, want %+v", tags, want)
+	}
+}
+
+func rollupDays(days []persistence.RollupDay) []string {
+	out := make([]string, 0, len(days))
+	for _, d := range days {
+		out = append(out, d.Day)
+	}
+	return out
+}
+
+func testStatsRollups(t *testing.T, repo persistence.ImportBatchRepository) {
+	rr, ok := repo.(persistence.StatsRollupRepository)
+	if !ok {
+		t.Skip("repository does not store stats rollups")
+	}
+	ctx := context.Background()
+	dirty := func() []persistence.RollupDay {
+		t.Helper()
+		days, err := rr.DirtyRollupDays(ctx, 0, stats.RollupVersion)
+		if err != nil {
+			t.Fatalf("dirty days: %v", err)
+		}
+		return days
+	}
+	save := func(days []persistence.RollupDay, seat int) {
+		t.Helper()
+		for _, d := range days {
+			rollups := []persistence.StatsRollup{{Day: d.Day, TableSize: stats.TableSizeHeadsUp, HandCount: 1, State: &stats.Rollup{TotalHands: 1}}}
+			if err := rr.SaveStatsRollups(ctx, d, seat, stats.RollupVersion, rollups); err != nil {
+				t.Fatalf("save rollups: %v", err)
+			}
+		}
+	}
+
+	// The hands fall on 2026-02-21 and 2026-02-22.
+	late := newHand(0)
+	late.StartTime = time.Date(2026, 2, 22, 1, 0, 0, 0, time.UTC)
+	late.EndTime = late.StartTime.Add(time.Minute)
+	upsert(t, repo, row(newHand(0), "a.log", 0), row(late, "a.log", 80))
+
+	days := dirty()
+	if got := rollupDays(days); !reflect.DeepEqual(got, []string{"2026-02-21", "2026-02-22"}) {
+		t.Fatalf("dirty days = %v", got)
+	}
+	// A write after DirtyRollupDays keeps its day dirty.
+	upsert(t, repo, row(newHand(1), "a.log", 1))
+	save(days, 0)
+	if got := rollupDays(dirty()); !reflect.DeepEqual(got, []string{"2026-02-21"}) {
+		t.Fatalf("dirty days after a racing write = %v", got)
+	}
+
+	got, err := rr.ListStatsRollups(ctx, "2026-02-22", "2026-02-22")
+	if err != nil {
+		t.Fatalf("list rollups: %v", err)
+	}
+	if len(got) != 1 || got[0].Day != "2026-02-22" || got[0].TableSize != stats.TableSizeHeadsUp || got[0].HandCount != 1 || got[0].State.TotalHands != 1 {
+		t.Fatalf("rollups = %+v", got)
+	}
+
+	// Rollups of another local seat are dropped and every day is dirty.
+	save(dirty(), 3)
+	if got := rollupDays(dirty()); !reflect.DeepEqual(got, []string{"2026-02-21", "2026-02-22"}) {
+		t.Fatalf("dirty days for another seat = %v", got)
+	}
+	if got, err := rr.ListStatsRollups(ctx, "", "9999-12-31"); err != nil || len(got) != 0 {
+		t.Fatalf("rollups after a seat change = %+v, %v; want none", got, err)
+	}
+}
+
+func testCalculatorState(t *testing.T, repo persistence.ImportBatchRepository) {
+	cr, ok := repo.(persistence.CalculatorStateRepository)
+	if !ok {
+		t.Skip("repository does not store calculator state")
+	}
+	ctx := context.Background()
+	if snap, err := cr.LoadCalculatorState(ctx); err != nil || snap != nil {
+		t.Fatalf("initial snapshot = %+v, %v; want nil, nil", snap, err)
+	}
+
+	upsert(t, repo, row(newHand(0), "a.log", 0), row(newHand(1), "a.log", 1))
+	ic := stats.NewIncrementalCalculator(0)
+	ic.Feed(newHand(0))
+	ic.Feed(newHand(1))
+	watermark := slotTime(1)
+	if err := cr.SaveCalculatorState(ctx, persistence.CalculatorSnapshot{Watermark: watermark, State: ic.State()}); err != nil {
+		t.Fatalf("save snapshot: %v", err)
+	}
+	snap, err := cr.LoadCalculatorState(ctx)
+	if err != nil {
+		t.Fatalf("load snapshot: %v", err)
+	}
+	if snap == nil || !snap.Watermark.Equal(watermark) || snap.State == nil || snap.State.Total.TotalHands != 2 {
+		t.Fatalf("snapshot = %+v", snap)
+	}
+
+	// A hand after the watermark keeps the snapshot.
+	upsert(t, repo, row(newHand(2), "a.log", 2))
+	if snap, err := cr.LoadCalculatorState(ctx); err != nil || snap == nil {
+		t.Fatalf("snapshot after a later hand = %+v, %v; want it kept", snap, err)
+	}
+	// A hand at the watermark discards it.
+	upsert(t, repo, row(newHand(1), "a.log", 1))
+	if snap, err := cr.LoadCalculatorState(ctx); err != nil || snap != nil {
+		t.Fatalf("snapshot after an earlier hand = %+v, %v; want nil, nil", snap, err)
+	}
+
+	if err := cr.SaveCalculatorState(ctx, persistence.CalculatorSnapshot{Watermark: watermark, State: ic.State()}); err != nil {
+		t.Fatalf("save snapshot: %v", err)
+	}
+	if err := cr.DeleteCalculatorState(ctx); err != nil {
+		t.Fatalf("delete snapshot: %v", err)
+	}
+	if snap, err := cr.LoadCalculatorState(ctx); err != nil || snap != nil {
+		t.Fatalf("deleted snapshot = %+v, %v; want nil, nil", snap, err)
+	}
+}
+
+func testIntegrity(t *testing.T, repo persistence.ImportBatchRepository) {
+	ir, ok := repo.(persistence.IntegrityRepository)
+	if !ok {
+		t.Skip("repository does not check integrity")
+	}
+	ctx := context.Background()
+	upsert(t, repo, row(newHand(0), "a.log", 0), row(newHand(1), "b.log", 1))
+	for _, path := range []string{"b.log", "a.log"} {
+		if _, err := repo.SaveImportBatch(ctx, nil, persistence.ImportCursor{SourcePath: path, NextByteOffset: 10}); err != nil {
+			t.Fatalf("save cursor %s: %v", path, err)
+		}
+	}
+
+	if issues, err := ir.CheckIntegrity(ctx); err != nil || len(issues) != 0 {
+		t.Fatalf("check = %+v, %v; want no issues", issues, err)
+	}
+	if repaired, err := ir.RepairIntegrity(ctx); err != nil || len(repaired) != 0 {
+		t.Fatalf("repair = %+v, %v; want nothing repaired", repaired, err)
+	}
+
+	cursors, err := ir.ListCursors(ctx)
+	if err != nil {
+		t.Fatalf("list cursors: %v", err)
+	}
+	if len(cursors) != 2 || cursors[0].SourcePath != "a.log" || cursors[1].SourcePath != "b.log" {
+		t.Fatalf("cursors = %+v; want a.log, b.log", cursors)
+	}
+	if err := ir.DeleteCursor(ctx, "a.log"); err != nil {
+		t.Fatalf("delete cursor: %v", err)
+	}
+	if c, err := repo.GetCursor(ctx, "a.log"); err != nil || c != nil {
+		t.Fatalf("deleted cursor = %+v, %v; want nil, nil", c, err)
+	}
+	if hands, err := repo.ListHands(ctx, persistence.HandFilter{}); err != nil || len(hands) != 2 {
+		t.Fatalf("hands after deleting a cursor = %d, %v; want 2", len(hands), err)
+	}
+}
+
+// Damager is implemented by repositories under test that can damage their
+// stored data the way a crash or an older version of the application could.
+// The tests of repairing damaged data are skipped for repositories without
+// it.
+type Damager interface {
+	// DropHandSources removes the source spans of a hand.
+	DropHandSources(ctx context.Context, handUID string) error
+	// DropHandRow removes a hand but leaves its players, actions and
+	// other rows behind.
+	DropHandRow(ctx context.Context, handUID string) error
+	// DropFilterIDs clears the hand category filter IDs of a hand.
+	DropFilterIDs(ctx context.Context, handUID string) error
+}
+
+func integrityProblems(issues []persistence.IntegrityIssue) map[persistence.IntegrityProblem]int {
+	out := make(map[persistence.IntegrityProblem]int)
+	for _, issue := range issues {
+		out[issue.Problem] += issue.Count
+	}
+	return out
+}
+
+func testIntegrityRepair(t *testing.T, repo persistence.ImportBatchRepository) {
+	ir, ok := repo.(persistence.IntegrityRepository)
+	dmg, damages := repo.(Damager)
+	if !ok || !damages {
+		t.Skip("repository cannot be damaged for an integrity check")
+	}
+	ctx := context.Background()
+	rows := make([]persistence.PersistedHand, 0, 4)
+	for i := range 4 {
+		rows = append(rows, row(newHand(i), "a.log", i))
+	}
+	rows[0].RawLog = &persistence.RawLogSnippet{SourcePath: "a.log", StartByte: 0, EndByte: 99, Data: []byte("hand 0\n")}
+	upsert(t, repo, rows...)
+
+	// Hand 0 loses its source but keeps its raw log, hand 1 loses its
+	// source with nothing to restore it from, hand 2 is removed without its
+	// rows and hand 3 loses its filter IDs.
+	for _, err := range []error{
+		dmg.DropHandSources(ctx, rows[0].Source.HandUID),
+		dmg.DropHandSources(ctx, rows[1].Source.HandUID),
+		dmg.DropHandRow(ctx, rows[2].Source.HandUID),
+		dmg.DropFilterIDs(ctx, rows[3].Source.HandUID),
+	} {
+		if err != nil {
+			t.Fatalf("damage: %v", err)
+		}
+	}
+
+	issues, err := ir.CheckIntegrity(ctx)
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	got := integrityProblems(issues)
+	if got[persistence.ProblemHandWithoutSource] != 2 || got[persistence.ProblemStaleFilterIDs] != 1 ||
+		got[persistence.ProblemOrphanRows] == 0 || got[persistence.ProblemCorruption] != 0 {
+		t.Fatalf("check = %+v", issues)
+	}
+	repaired, err := ir.RepairIntegrity(ctx)
+	if err != nil {
+		t.Fatalf("repair: %v", err)
+	}
+	got = integrityProblems(repaired)
+	if got[persistence.ProblemHandWithoutSource] != 2 || got[persistence.ProblemStaleFilterIDs] != 1 || got[persistence.ProblemOrphanRows] == 0 {
+		t.Fatalf("repaired = %+v", repaired)
+	}
+	if issues, err := ir.CheckIntegrity(ctx); err != nil || len(issues) != 0 {
+		t.Fatalf("check after repair = %+v, %v; want no issues", issues, err)
+	}
+
+	// Hand 0 got its source back from its raw log; hands 1 and 2 are gone.
+	hands, err := repo.ListHands(ctx, persistence.HandFilter{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if got := handUIDs(hands); !slices.Equal(got, []string{rows[0].Source.HandUID, rows[3].Source.HandUID}) {
+		t.Fatalf("hands after repair = %v, want hands 0 and 3", got)
+	}
+	srcs, err := repo.ListOutdatedHandSources(ctx, parser.Version+1)
+	if err != nil {
+		t.Fatalf("sources: %v", err)
+	}
+	// A source restored from a raw log spans the snippet and has no line
+	// numbers.
+	if len(srcs) != 2 || srcs[0].HandUID != rows[0].Source.HandUID || srcs[0].StartByte != 0 || srcs[0].EndByte != 99 || srcs[1] != rows[3].Source {
+		t.Fatalf("sources after repair = %+v", srcs)
+	}
+	hands, err = repo.ListHands(ctx, persistence.HandFilter{PocketCategoryIDs: []int{pocketPremiumID()}})
+	if err != nil || len(hands) != 2 {
+		t.Fatalf("hands by pocket category after repair = %d, %v; want 2", len(hands), err)
+	}
+}
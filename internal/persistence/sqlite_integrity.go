@@ -0,0 +1,366 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
+)
+
+// orphanRowChecks select, per table, the rows whose parent row is gone.
+// Players come before their hole cards and actions, so a repair deletes the
+// children of the orphan players it deletes too. Annotations are keyed by
+// hand UID on purpose and are never orphans.
+var orphanRowChecks = []struct {
+	table string
+	where string
+}{
+	{"hand_occurrences", `hand_uid NOT IN (SELECT hand_uid FROM hands)`},
+	{"hand_players", `hand_uid NOT IN (SELECT hand_uid FROM hands)`},
+	{"hand_hole_cards", `NOT EXISTS (SELECT 1 FROM hand_players hp
+		WHERE hp.hand_uid = hand_hole_cards.hand_uid AND hp.seat_id = hand_hole_cards.seat_id)`},
+	{"hand_actions", `NOT EXISTS (SELECT 1 FROM hand_players hp
+		WHERE hp.hand_uid = hand_actions.hand_uid AND hp.seat_id = hand_actions.seat_id)`},
+	{"hand_board_cards", `hand_uid NOT IN (SELECT hand_uid FROM hands)`},
+	{"hand_anomalies", `hand_uid NOT IN (SELECT hand_uid FROM hands)`},
+	{"hand_raw_logs", `hand_uid NOT IN (SELECT hand_uid FROM hands)`},
+}
+
+// maxCorruptionMessages bounds the integrity_check messages reported.
+const maxCorruptionMessages = 100
+
+// CheckIntegrity runs SQLite's integrity_check and looks for orphan hand_*
+// rows, hands without a source and stale filter IDs.
+func (r *SQLiteRepository) CheckIntegrity(ctx context.Context) ([]IntegrityIssue, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("begin integrity check: %w", err)
+	}
+	defer tx.Rollback()
+
+	issues, err := corruptionIssuesTx(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range orphanRowChecks {
+		var n int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+c.table+` WHERE `+c.where).Scan(&n); err != nil {
+			return nil, fmt.Errorf("count orphan %s: %w", c.table, err)
+		}
+		if n > 0 {
+			issues = append(issues, IntegrityIssue{Problem: ProblemOrphanRows, Subject: c.table, Count: n})
+		}
+	}
+	sourceless, err := handsWithoutSourceTx(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	if len(sourceless) > 0 {
+		issues = append(issues, IntegrityIssue{Problem: ProblemHandWithoutSource, Subject: "hands", Count: len(sourceless)})
+	}
+	stale, err := staleFilterIDsTx(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	if len(stale) > 0 {
+		issues = append(issues, IntegrityIssue{Problem: ProblemStaleFilterIDs, Subject: "hand_players", Count: len(stale)})
+	}
+	return issues, nil
+}
+
+// RepairIntegrity repairs orphan rows, hands without a source and stale
+// filter IDs in one transaction. Deleting a hand marks its rollup day dirty
+// and discards the calculator snapshot, like writing one does.
+func (r *SQLiteRepository) RepairIntegrity(ctx context.Context) ([]IntegrityIssue, error) {
+	var repaired []IntegrityIssue
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		repaired = nil
+		// Hands go first so the children of the deleted ones are not left
+		// behind as orphans.
+		sourceless, err := handsWithoutSourceTx(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if len(sourceless) > 0 {
+			if err := repairHandsWithoutSourceTx(ctx, tx, sourceless); err != nil {
+				return err
+			}
+			repaired = append(repaired, IntegrityIssue{Problem: ProblemHandWithoutSource, Subject: "hands", Count: len(sourceless)})
+		}
+		for _, c := range orphanRowChecks {
+			res, err := tx.ExecContext(ctx, `DELETE FROM `+c.table+` WHERE `+c.where)
+			if err != nil {
+				return fmt.Errorf("delete orphan %s: %w", c.table, err)
+			}
+			if n, err := res.RowsAffected(); err == nil && n > 0 {
+				repaired = append(repaired, IntegrityIssue{Problem: ProblemOrphanRows, Subject: c.table, Count: int(n)})
+			}
+		}
+		stale, err := staleFilterIDsTx(ctx, tx)
+		if err != nil {
+			return err
+		}
+		for _, row := range stale {
+			if _, err := tx.ExecContext(ctx, `UPDATE hand_players SET pocket_category_id = ?, final_class_id = ?
+				WHERE hand_uid = ? AND seat_id = ?`,
+				nullIfZero(row.pocketID), nullIfZero(row.finalID), row.uid, row.seat); err != nil {
+				return fmt.Errorf("update filter ids: %w", err)
+			}
+		}
+		if len(stale) > 0 {
+			repaired = append(repaired, IntegrityIssue{Problem: ProblemStaleFilterIDs, Subject: "hand_players", Count: len(stale)})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("repair integrity: %w", err)
+	}
+	return repaired, nil
+}
+
+func (r *SQLiteRepository) ListCursors(ctx context.Context) ([]ImportCursor, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+cursorColumns+` FROM import_cursors ORDER BY source_path`)
+	if err != nil {
+		return nil, fmt.Errorf("list cursors: %w", err)
+	}
+	defer rows.Close()
+	var out []ImportCursor
+	for rows.Next() {
+		c, err := scanCursor(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan cursor: %w", err)
+		}
+		out = append(out, *c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list cursors rows: %w", err)
+	}
+	return out, nil
+}
+
+func (r *SQLiteRepository) DeleteCursor(ctx context.Context, sourcePath string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM import_cursors WHERE source_path = ?`, sourcePath); err != nil {
+		return fmt.Errorf("delete cursor: %w", err)
+	}
+	return nil
+}
+
+func corruptionIssuesTx(ctx context.Context, tx *sql.Tx) ([]IntegrityIssue, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`PRAGMA integrity_check(%d)`, maxCorruptionMessages))
+	if err != nil {
+		return nil, fmt.Errorf("integrity check: %w", err)
+	}
+	defer rows.Close()
+	var out []IntegrityIssue
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			return nil, fmt.Errorf("scan integrity check: %w", err)
+		}
+		if msg != "ok" {
+			out = append(out, IntegrityIssue{Problem: ProblemCorruption, Subject: "database", Count: 1, Detail: msg})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("integrity check rows: %w", err)
+	}
+	return out, nil
+}
+
+// sourcelessHand is a hand without a hand_occurrences row and the span of
+// its raw log snippet, if it has one.
+type sourcelessHand struct {
+	uid       string
+	startTime string
+	raw       *HandSourceRef
+}
+
+func handsWithoutSourceTx(ctx context.Context, tx *sql.Tx) ([]sourcelessHand, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT h.hand_uid, h.start_time, rl.source_path, rl.start_byte, rl.end_byte
+		FROM hands h
+		LEFT JOIN hand_raw_logs rl ON rl.hand_uid = h.hand_uid
+		WHERE NOT EXISTS (SELECT 1 FROM hand_occurrences o WHERE o.hand_uid = h.hand_uid)
+		ORDER BY h.hand_uid`)
+	if err != nil {
+		return nil, fmt.Errorf("list hands without source: %w", err)
+	}
+	defer rows.Close()
+	var out []sourcelessHand
+	for rows.Next() {
+		var h sourcelessHand
+		var path sql.NullString
+		var start, end sql.NullInt64
+		if err := rows.Scan(&h.uid, &h.startTime, &path, &start, &end); err != nil {
+			return nil, fmt.Errorf("scan hand without source: %w", err)
+		}
+		if path.Valid && path.String != "" {
+			h.raw = &HandSourceRef{HandUID: h.uid, SourcePath: path.String, StartByte: start.Int64, EndByte: end.Int64}
+		}
+		out = append(out, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list hands without source rows: %w", err)
+	}
+	return out, nil
+}
+
+// repairHandsWithoutSourceTx restores the source of hands from their raw log
+// snippet and deletes the hands without one. A snippet may start a little
+// before the hand; it ends where the hand does, which is what reprocessing
+// matches on.
+func repairHandsWithoutSourceTx(ctx context.Context, tx *sql.Tx, hands []sourcelessHand) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	deleted := false
+	for _, h := range hands {
+		if h.raw != nil {
+			if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO hand_occurrences(
+				hand_uid, source_path, start_byte, end_byte, start_line, end_line, updated_at
+			) VALUES(?, ?, ?, ?, 0, 0, ?)`,
+				h.uid, h.raw.SourcePath, h.raw.StartByte, h.raw.EndByte, now); err != nil {
+				return fmt.Errorf("restore hand source: %w", err)
+			}
+			continue
+		}
+		if err := clearHandChildrenTx(ctx, tx, h.uid); err != nil {
+			return fmt.Errorf("delete hand children: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM hands WHERE hand_uid = ?`, h.uid); err != nil {
+			return fmt.Errorf("delete hand: %w", err)
+		}
+		if err := markRollupDayDirtyTx(ctx, tx, h.startTime); err != nil {
+			return err
+		}
+		deleted = true
+	}
+	if deleted {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM stats_calculator_state`); err != nil {
+			return fmt.Errorf("discard calculator state: %w", err)
+		}
+	}
+	return nil
+}
+
+// filterIDRow is a hand_players row and the filter IDs it should have.
+type filterIDRow struct {
+	uid      string
+	seat     int
+	pocketID int
+	finalID  int
+}
+
+// staleFilterIDsTx returns the player rows whose pocket category or final
+// class ID differs from what insertHandChildrenTx would write today: the IDs
+// of the local player's cards, and none for everyone else.
+func staleFilterIDsTx(ctx context.Context, tx *sql.Tx) ([]filterIDRow, error) {
+	pocketIDs, err := codeIDsTx(ctx, tx, "pocket_categories")
+	if err != nil {
+		return nil, err
+	}
+	finalIDs, err := codeIDsTx(ctx, tx, "final_classes")
+	if err != nil {
+		return nil, err
+	}
+	holes, err := handCardsTx(ctx, tx, `SELECT hc.hand_uid, hc.rank, hc.suit
+		FROM hand_hole_cards hc
+		JOIN hands h ON h.hand_uid = hc.hand_uid AND hc.seat_id = h.local_seat
+		ORDER BY hc.hand_uid, hc.card_index`)
+	if err != nil {
+		return nil, err
+	}
+	boards, err := handCardsTx(ctx, tx, `SELECT hand_uid, rank, suit FROM hand_board_cards ORDER BY hand_uid, card_index`)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT hp.hand_uid, hp.seat_id, hp.seat_id = h.local_seat,
+		COALESCE(hp.pocket_category_id, 0), COALESCE(hp.final_class_id, 0)
+		FROM hand_players hp
+		JOIN hands h ON h.hand_uid = hp.hand_uid
+		WHERE hp.seat_id = h.local_seat OR hp.pocket_category_id IS NOT NULL OR hp.final_class_id IS NOT NULL
+		ORDER BY hp.hand_uid, hp.seat_id`)
+	if err != nil {
+		return nil, fmt.Errorf("list filter ids: %w", err)
+	}
+	defer rows.Close()
+	var out []filterIDRow
+	for rows.Next() {
+		var row filterIDRow
+		var local bool
+		var pocketID, finalID int
+		if err := rows.Scan(&row.uid, &row.seat, &local, &pocketID, &finalID); err != nil {
+			return nil, fmt.Errorf("scan filter ids: %w", err)
+		}
+		if local {
+			row.pocketID, row.finalID = expectedFilterIDs(holes[row.uid], boards[row.uid], pocketIDs, finalIDs)
+		}
+		if row.pocketID != pocketID || row.finalID != finalID {
+			out = append(out, row)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list filter ids rows: %w", err)
+	}
+	return out, nil
+}
+
+// expectedFilterIDs is computeHandFilterIDs with the ID tables preloaded.
+func expectedFilterIDs(hole, board []parser.Card, pocketIDs, finalIDs map[string]int) (pocketID, finalID int) {
+	if len(hole) != 2 {
+		return 0, 0
+	}
+	if cats := stats.ClassifyPocketHand(hole[0], hole[1]); len(cats) > 0 {
+		pocketID = pocketIDs[pocketCategoryCode(choosePocketCategory(cats))]
+	}
+	if len(board) >= 5 {
+		finalID = finalIDs[finalClassCode(stats.ClassifyMadeHand(hole, board))]
+	}
+	return pocketID, finalID
+}
+
+// codeIDsTx returns the IDs of pocket_categories or final_classes by code.
+func codeIDsTx(ctx context.Context, tx *sql.Tx, table string) (map[string]int, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT code, id FROM `+table)
+	if err != nil {
+		return nil, fmt.Errorf("list %s: %w", table, err)
+	}
+	defer rows.Close()
+	out := make(map[string]int)
+	for rows.Next() {
+		var code string
+		var id int
+		if err := rows.Scan(&code, &id); err != nil {
+			return nil, fmt.Errorf("scan %s: %w", table, err)
+		}
+		out[code] = id
+	}
+	return out, rows.Err()
+}
+
+// handCardsTx groups the (hand_uid, rank, suit) rows of query by hand.
+func handCardsTx(ctx context.Context, tx *sql.Tx, query string) (map[string][]parser.Card, error) {
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list cards: %w", err)
+	}
+	defer rows.Close()
+	out := make(map[string][]parser.Card)
+	for rows.Next() {
+		var uid string
+		var c parser.Card
+		if err := rows.Scan(&uid, &c.Rank, &c.Suit); err != nil {
+			return nil, fmt.Errorf("scan card: %w", err)
+		}
+		out[uid] = append(out[uid], c)
+	}
+	return out, rows.Err()
+}
+
+func nullIfZero(id int) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
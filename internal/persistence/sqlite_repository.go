@@ -1,9 +1,15 @@
 package persistence
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"time"
@@ -36,6 +42,28 @@ func NewSQLiteRepository(dbPath string) (*SQLiteRepository, error) {
 	return repo, nil
 }
 
+// OpenSQLiteRepositoryReadOnly opens an existing database for reading only.
+// Unlike NewSQLiteRepository it neither creates a missing file nor runs
+// migrations, so checking a database leaves it as it was; a database behind
+// the current schema is refused.
+func OpenSQLiteRepositoryReadOnly(dbPath string) (*SQLiteRepository, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	// A URI filename, so characters that start its query or fragment must
+	// be escaped.
+	name := strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23").Replace(filepath.ToSlash(dbPath))
+	db, err := sql.Open("sqlite", "file:"+name+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	if err := checkMigrated(db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &SQLiteRepository{db: db}, nil
+}
+
 func (r *SQLiteRepository) Close() error {
 	if r == nil || r.db == nil {
 		return nil
@@ -59,6 +87,10 @@ func (r *SQLiteRepository) UpsertHands(ctx context.Context, hands []PersistedHan
 func (r *SQLiteRepository) upsertHandsTx(ctx context.Context, tx *sql.Tx, hands []PersistedHand) (UpsertResult, error) {
 	res := UpsertResult{}
 	now := time.Now().UTC().Format(time.RFC3339Nano)
+	watermark, hasSnapshot, err := calculatorWatermarkTx(ctx, tx)
+	if err != nil {
+		return UpsertResult{}, err
+	}
 
 	for _, ph := range hands {
 		if ph.Hand == nil {
@@ -66,20 +98,34 @@ func (r *SQLiteRepository) upsertHandsTx(ctx context.Context, tx *sql.Tx, hands
 			continue
 		}
 		h := ph.Hand
-		uid := ph.Source.HandUID
-		if resolvedUID, ok, err := findHandUIDBySourceSpanTx(ctx, tx, ph.Source); err != nil {
-			return UpsertResult{}, err
-		} else if ok {
-			uid = resolvedUID
+		if hasSnapshot && !h.StartTime.After(watermark) {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM stats_calculator_state`); err != nil {
+				return UpsertResult{}, fmt.Errorf("discard calculator state: %w", err)
+			}
+			hasSnapshot = false
 		}
-		if uid == "" {
-			uid = GenerateHandUID(h, ph.Source)
+		uid, err := resolveHandUIDTx(ctx, tx, ph)
+		if err != nil {
+			return UpsertResult{}, err
 		}
 
-		exists, err := rowExists(ctx, tx, `SELECT 1 FROM hands WHERE hand_uid = ? LIMIT 1`, uid)
-		if err != nil {
+		var oldStart string
+		exists := true
+		err = tx.QueryRowContext(ctx, `SELECT start_time FROM hands WHERE hand_uid = ?`, uid).Scan(&oldStart)
+		if err == sql.ErrNoRows {
+			exists = false
+		} else if err != nil {
+			return UpsertResult{}, err
+		}
+		startTime := h.StartTime.UTC().Format(time.RFC3339Nano)
+		if err := markRollupDayDirtyTx(ctx, tx, startTime); err != nil {
 			return UpsertResult{}, err
 		}
+		if exists && rollupDay(oldStart) != rollupDay(startTime) {
+			if err := markRollupDayDirtyTx(ctx, tx, oldStart); err != nil {
+				return UpsertResult{}, err
+			}
+		}
 
 		if err := upsertWorldAndInstanceTx(ctx, tx, h, now); err != nil {
 			return UpsertResult{}, err
@@ -88,8 +134,8 @@ func (r *SQLiteRepository) upsertHandsTx(ctx context.Context, tx *sql.Tx, hands
 		if _, err := tx.ExecContext(ctx, `INSERT INTO hands(
 			hand_uid, start_time, end_time, is_complete, stats_eligible, has_anomaly, local_seat,
 			world_id, world_display_name, instance_uid, instance_type, instance_owner_user_uid, instance_region,
-			sb_seat, bb_seat, num_players, total_pot, winner_seat, win_type, updated_at
-		) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			sb_seat, bb_seat, num_players, total_pot, winner_seat, win_type, parser_version, updated_at
+		) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(hand_uid) DO UPDATE SET
 			start_time=excluded.start_time,
 			end_time=excluded.end_time,
@@ -109,9 +155,10 @@ func (r *SQLiteRepository) upsertHandsTx(ctx context.Context, tx *sql.Tx, hands
 			total_pot=excluded.total_pot,
 			winner_seat=excluded.winner_seat,
 			win_type=excluded.win_type,
+			parser_version=excluded.parser_version,
 			updated_at=excluded.updated_at`,
 			uid,
-			h.StartTime.UTC().Format(time.RFC3339Nano),
+			startTime,
 			h.EndTime.UTC().Format(time.RFC3339Nano),
 			boolToInt(h.IsComplete),
 			boolToInt(h.IsStatsEligible()),
@@ -129,6 +176,7 @@ func (r *SQLiteRepository) upsertHandsTx(ctx context.Context, tx *sql.Tx, hands
 			h.TotalPot,
 			h.WinnerSeat,
 			h.WinType,
+			h.ParserVersion,
 			now,
 		); err != nil {
 			return UpsertResult{}, err
@@ -160,6 +208,12 @@ func (r *SQLiteRepository) upsertHandsTx(ctx context.Context, tx *sql.Tx, hands
 			return UpsertResult{}, err
 		}
 
+		if ph.RawLog != nil && len(ph.RawLog.Data) > 0 {
+			if err := upsertRawLogTx(ctx, tx, uid, ph.RawLog, now); err != nil {
+				return UpsertResult{}, err
+			}
+		}
+
 		if exists {
 			res.Updated++
 		} else {
@@ -170,6 +224,43 @@ func (r *SQLiteRepository) upsertHandsTx(ctx context.Context, tx *sql.Tx, hands
 	return res, nil
 }
 
+// rawLogEncodingGzip is the only encoding written to hand_raw_logs.encoding.
+const rawLogEncodingGzip = "gzip"
+
+func upsertRawLogTx(ctx context.Context, tx *sql.Tx, uid string, raw *RawLogSnippet, now string) error {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(raw.Data); err != nil {
+		return fmt.Errorf("compress raw log: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("compress raw log: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO hand_raw_logs(
+		hand_uid, source_path, start_byte, end_byte, encoding, raw_size, data, updated_at
+	) VALUES(?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(hand_uid) DO UPDATE SET
+		source_path=excluded.source_path,
+		start_byte=excluded.start_byte,
+		end_byte=excluded.end_byte,
+		encoding=excluded.encoding,
+		raw_size=excluded.raw_size,
+		data=excluded.data,
+		updated_at=excluded.updated_at`,
+		uid,
+		raw.SourcePath,
+		raw.StartByte,
+		raw.EndByte,
+		rawLogEncodingGzip,
+		len(raw.Data),
+		buf.Bytes(),
+		now,
+	); err != nil {
+		return fmt.Errorf("upsert raw log: %w", err)
+	}
+	return nil
+}
+
 func upsertWorldAndInstanceTx(ctx context.Context, tx *sql.Tx, h *parser.Hand, now string) error {
 	if h == nil {
 		return nil
@@ -335,11 +426,27 @@ func insertHandChildrenTx(ctx context.Context, tx *sql.Tx, uid string, h *parser
 func (r *SQLiteRepository) ListHands(ctx context.Context, f HandFilter) ([]*parser.Hand, error) {
 	query := `SELECT hand_uid, start_time, end_time, is_complete, stats_eligible, has_anomaly,
 		local_seat, world_id, world_display_name, instance_uid, instance_type, instance_owner_user_uid, instance_region,
-		sb_seat, bb_seat, num_players, total_pot, winner_seat, win_type
+		sb_seat, bb_seat, num_players, total_pot, winner_seat, win_type, parser_version
 		FROM hands`
-	where, args := buildHandsFilterWhere(f)
+	where, args, err := buildHandsFilterWhere(f)
+	if err != nil {
+		return nil, err
+	}
 	query += where
-	query += ` ORDER BY start_time ASC`
+	if f.LocalSeat != nil {
+		// Filtered in SQL rather than after loading so Limit counts only
+		// matching hands.
+		query += ` AND EXISTS (SELECT 1 FROM hand_players WHERE hand_players.hand_uid = hands.hand_uid AND hand_players.seat_id = ?)`
+		args = append(args, *f.LocalSeat)
+	}
+	if f.NewestFirst {
+		query += ` ORDER BY start_time DESC, hand_uid DESC`
+	} else {
+		query += ` ORDER BY start_time ASC, hand_uid ASC`
+	}
+	if f.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d OFFSET %d", f.Limit, f.Offset)
+	}
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -372,16 +479,10 @@ func (r *SQLiteRepository) ListHands(ctx context.Context, f HandFilter) ([]*pars
 		return nil, err
 	}
 
-	// Apply optional LocalSeat post-filter and assemble result in original order.
+	// Assemble the result in query order.
 	out := make([]*parser.Hand, 0, len(uids))
 	for _, uid := range uids {
-		h := byUID[uid]
-		if f.LocalSeat != nil {
-			if _, ok := h.Players[*f.LocalSeat]; !ok {
-				continue
-			}
-		}
-		out = append(out, h)
+		out = append(out, byUID[uid])
 	}
 	return out, nil
 }
@@ -391,7 +492,7 @@ func (r *SQLiteRepository) ListHands(ctx context.Context, f HandFilter) ([]*pars
 func (r *SQLiteRepository) GetHandByUID(ctx context.Context, uid string) (*parser.Hand, error) {
 	row := r.db.QueryRowContext(ctx, `SELECT hand_uid, start_time, end_time, is_complete, stats_eligible, has_anomaly,
 		local_seat, world_id, world_display_name, instance_uid, instance_type, instance_owner_user_uid, instance_region,
-		sb_seat, bb_seat, num_players, total_pot, winner_seat, win_type
+		sb_seat, bb_seat, num_players, total_pot, winner_seat, win_type, parser_version
 		FROM hands WHERE hand_uid = ?`, uid)
 
 	h, err := scanHandRow(row)
@@ -409,6 +510,453 @@ func (r *SQLiteRepository) GetHandByUID(ctx context.Context, uid string) (*parse
 	return h, nil
 }
 
+// ListOutdatedHandSources returns the hand_occurrences spans of hands stored by
+// a parser older than version.
+func (r *SQLiteRepository) ListOutdatedHandSources(ctx context.Context, version int) ([]HandSourceRef, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT o.hand_uid, o.source_path, o.start_byte, o.end_byte, o.start_line, o.end_line
+		FROM hand_occurrences o
+		JOIN hands h ON h.hand_uid = o.hand_uid
+		WHERE h.parser_version < ?
+		ORDER BY o.source_path ASC, o.start_byte ASC`, version)
+	if err != nil {
+		return nil, fmt.Errorf("list outdated hand sources: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]HandSourceRef, 0)
+	for rows.Next() {
+		var src HandSourceRef
+		if err := rows.Scan(&src.HandUID, &src.SourcePath, &src.StartByte, &src.EndByte, &src.StartLine, &src.EndLine); err != nil {
+			return nil, fmt.Errorf("scan outdated hand source: %w", err)
+		}
+		out = append(out, src)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list outdated hand sources rows: %w", err)
+	}
+	return out, nil
+}
+
+// GetHandRawLog returns the decompressed raw log snippet stored for uid.
+func (r *SQLiteRepository) GetHandRawLog(ctx context.Context, uid string) (*RawLogSnippet, error) {
+	var encoding string
+	var data []byte
+	raw := &RawLogSnippet{}
+	err := r.db.QueryRowContext(ctx, `SELECT source_path, start_byte, end_byte, encoding, data
+		FROM hand_raw_logs WHERE hand_uid = ?`, uid).Scan(&raw.SourcePath, &raw.StartByte, &raw.EndByte, &encoding, &data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get raw log: %w", err)
+	}
+	if encoding != rawLogEncodingGzip {
+		return nil, fmt.Errorf("get raw log: unsupported encoding %q", encoding)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decompress raw log: %w", err)
+	}
+	defer zr.Close()
+	if raw.Data, err = io.ReadAll(zr); err != nil {
+		return nil, fmt.Errorf("decompress raw log: %w", err)
+	}
+	return raw, nil
+}
+
+// UserDisplayNames reads the users table.
+func (r *SQLiteRepository) UserDisplayNames(ctx context.Context) (map[string]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT user_uid, display_name FROM users`)
+	if err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	defer rows.Close()
+	out := make(map[string]string)
+	for rows.Next() {
+		var uid, name string
+		if err := rows.Scan(&uid, &name); err != nil {
+			return nil, fmt.Errorf("scan user: %w", err)
+		}
+		out[uid] = name
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list users rows: %w", err)
+	}
+	return out, nil
+}
+
+func (r *SQLiteRepository) GetSettings(ctx context.Context) (map[string]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT key, value FROM app_settings`)
+	if err != nil {
+		return nil, fmt.Errorf("get settings: %w", err)
+	}
+	defer rows.Close()
+	out := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("scan setting: %w", err)
+		}
+		out[key] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get settings rows: %w", err)
+	}
+	return out, nil
+}
+
+func (r *SQLiteRepository) SaveSettings(ctx context.Context, values map[string]string) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		for key, value := range values {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO app_settings(key, value, updated_at) VALUES(?, ?, ?)
+				ON CONFLICT(key) DO UPDATE SET value=excluded.value, updated_at=excluded.updated_at`,
+				key, value, now); err != nil {
+				return fmt.Errorf("save setting %s: %w", key, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *SQLiteRepository) GetHandAnnotation(ctx context.Context, uid string) (*HandAnnotation, error) {
+	a := &HandAnnotation{HandUID: uid}
+	var starred int
+	var updatedAt string
+	err := r.db.QueryRowContext(ctx, `SELECT note, starred, updated_at FROM hand_notes WHERE hand_uid = ?`, uid).
+		Scan(&a.Note, &starred, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get hand annotation: %w", err)
+	}
+	a.Starred = starred == 1
+	a.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updatedAt)
+
+	rows, err := r.db.QueryContext(ctx, `SELECT tag FROM hand_tags WHERE hand_uid = ? ORDER BY tag`, uid)
+	if err != nil {
+		return nil, fmt.Errorf("get hand tags: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("scan hand tag: %w", err)
+		}
+		a.Tags = append(a.Tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get hand tags rows: %w", err)
+	}
+	return a, nil
+}
+
+func (r *SQLiteRepository) SaveHandAnnotation(ctx context.Context, a HandAnnotation) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM hand_tags WHERE hand_uid = ?`, a.HandUID); err != nil {
+			return fmt.Errorf("clear hand tags: %w", err)
+		}
+		if a.IsEmpty() {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM hand_notes WHERE hand_uid = ?`, a.HandUID); err != nil {
+				return fmt.Errorf("delete hand annotation: %w", err)
+			}
+			return nil
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO hand_notes(hand_uid, note, starred, updated_at) VALUES(?, ?, ?, ?)
+			ON CONFLICT(hand_uid) DO UPDATE SET note=excluded.note, starred=excluded.starred, updated_at=excluded.updated_at`,
+			a.HandUID, strings.TrimSpace(a.Note), boolToInt(a.Starred), now); err != nil {
+			return fmt.Errorf("save hand annotation: %w", err)
+		}
+		for _, tag := range NormalizeTags(a.Tags) {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO hand_tags(hand_uid, tag, created_at) VALUES(?, ?, ?)`,
+				a.HandUID, tag, now); err != nil {
+				return fmt.Errorf("save hand tag: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *SQLiteRepository) ListHandAnnotations(ctx context.Context) ([]HandAnnotation, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT hand_uid, note, starred, updated_at FROM hand_notes ORDER BY hand_uid`)
+	if err != nil {
+		return nil, fmt.Errorf("list hand annotations: %w", err)
+	}
+	defer rows.Close()
+	var out []HandAnnotation
+	index := make(map[string]int)
+	for rows.Next() {
+		var a HandAnnotation
+		var starred int
+		var updatedAt string
+		if err := rows.Scan(&a.HandUID, &a.Note, &starred, &updatedAt); err != nil {
+			return nil, fmt.Errorf("scan hand annotation: %w", err)
+		}
+		a.Starred = starred == 1
+		a.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updatedAt)
+		index[a.HandUID] = len(out)
+		out = append(out, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list hand annotations rows: %w", err)
+	}
+
+	tagRows, err := r.db.QueryContext(ctx, `SELECT hand_uid, tag FROM hand_tags ORDER BY hand_uid, tag`)
+	if err != nil {
+		return nil, fmt.Errorf("list hand tags: %w", err)
+	}
+	defer tagRows.Close()
+	for tagRows.Next() {
+		var uid, tag string
+		if err := tagRows.Scan(&uid, &tag); err != nil {
+			return nil, fmt.Errorf("scan hand tag: %w", err)
+		}
+		if i, ok := index[uid]; ok {
+			out[i].Tags = append(out[i].Tags, tag)
+		}
+	}
+	if err := tagRows.Err(); err != nil {
+		return nil, fmt.Errorf("list hand tags rows: %w", err)
+	}
+	return out, nil
+}
+
+func (r *SQLiteRepository) ListHandTags(ctx context.Context) ([]TagCount, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT tag, COUNT(*) FROM hand_tags GROUP BY tag`)
+	if err != nil {
+		return nil, fmt.Errorf("list tags: %w", err)
+	}
+	defer rows.Close()
+	var out []TagCount
+	for rows.Next() {
+		var tc TagCount
+		if err := rows.Scan(&tc.Tag, &tc.Count); err != nil {
+			return nil, fmt.Errorf("scan tag: %w", err)
+		}
+		out = append(out, tc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list tags rows: %w", err)
+	}
+	sortTagCounts(out)
+	return out, nil
+}
+
+// rollupDay returns the UTC day of a stored start_time.
+func rollupDay(startTime string) string {
+	if len(startTime) < len(StatsRollupDayLayout) {
+		return startTime
+	}
+	return startTime[:len(StatsRollupDayLayout)]
+}
+
+func markRollupDayDirtyTx(ctx context.Context, tx *sql.Tx, startTime string) error {
+	if _, err := tx.ExecContext(ctx, `INSERT INTO stats_rollup_dirty(day, generation) VALUES(?, 0)
+		ON CONFLICT(day) DO UPDATE SET generation = generation + 1`, rollupDay(startTime)); err != nil {
+		return fmt.Errorf("mark rollup day dirty: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) DirtyRollupDays(ctx context.Context, localSeat, version int) ([]RollupDay, error) {
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		stale, err := rowExists(ctx, tx, `SELECT 1 FROM stats_rollups WHERE local_seat <> ? OR version <> ? LIMIT 1`, localSeat, version)
+		if err != nil {
+			return fmt.Errorf("check stale rollups: %w", err)
+		}
+		if !stale {
+			return nil
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM stats_rollups`); err != nil {
+			return fmt.Errorf("drop stale rollups: %w", err)
+		}
+		// The WHERE clause keeps SQLite from parsing ON CONFLICT as a join.
+		if _, err := tx.ExecContext(ctx, `INSERT INTO stats_rollup_dirty(day, generation)
+			SELECT DISTINCT substr(start_time, 1, 10), 0 FROM hands WHERE 1=1
+			ON CONFLICT(day) DO UPDATE SET generation = generation + 1`); err != nil {
+			return fmt.Errorf("mark rollup days dirty: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT day, generation FROM stats_rollup_dirty ORDER BY day ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("list dirty rollup days: %w", err)
+	}
+	defer rows.Close()
+	var out []RollupDay
+	for rows.Next() {
+		var d RollupDay
+		if err := rows.Scan(&d.Day, &d.Generation); err != nil {
+			return nil, fmt.Errorf("scan dirty rollup day: %w", err)
+		}
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list dirty rollup days rows: %w", err)
+	}
+	return out, nil
+}
+
+func (r *SQLiteRepository) SaveStatsRollups(ctx context.Context, d RollupDay, localSeat, version int, rollups []StatsRollup) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM stats_rollups WHERE day = ?`, d.Day); err != nil {
+			return fmt.Errorf("clear rollups of %s: %w", d.Day, err)
+		}
+		for _, ru := range rollups {
+			state, err := json.Marshal(ru.State)
+			if err != nil {
+				return fmt.Errorf("encode rollup of %s: %w", d.Day, err)
+			}
+			if _, err := tx.ExecContext(ctx, `INSERT INTO stats_rollups(
+				day, table_size, local_seat, version, hand_count, state, updated_at
+			) VALUES(?, ?, ?, ?, ?, ?, ?)`,
+				d.Day, int(ru.TableSize), localSeat, version, ru.HandCount, string(state), now); err != nil {
+				return fmt.Errorf("save rollup of %s: %w", d.Day, err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM stats_rollup_dirty WHERE day = ? AND generation = ?`,
+			d.Day, d.Generation); err != nil {
+			return fmt.Errorf("clear dirty rollup day %s: %w", d.Day, err)
+		}
+		return nil
+	})
+}
+
+func (r *SQLiteRepository) ListStatsRollups(ctx context.Context, fromDay, toDay string) ([]StatsRollup, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT day, table_size, hand_count, state FROM stats_rollups
+		WHERE day >= ? AND day <= ? ORDER BY day ASC, table_size ASC`, fromDay, toDay)
+	if err != nil {
+		return nil, fmt.Errorf("list rollups: %w", err)
+	}
+	defer rows.Close()
+	var out []StatsRollup
+	for rows.Next() {
+		var ru StatsRollup
+		var size int
+		var state string
+		if err := rows.Scan(&ru.Day, &size, &ru.HandCount, &state); err != nil {
+			return nil, fmt.Errorf("scan rollup: %w", err)
+		}
+		ru.TableSize = stats.TableSize(size)
+		ru.State = &stats.Rollup{}
+		if err := json.Unmarshal([]byte(state), ru.State); err != nil {
+			return nil, fmt.Errorf("decode rollup of %s: %w", ru.Day, err)
+		}
+		out = append(out, ru)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list rollups rows: %w", err)
+	}
+	return out, nil
+}
+
+func calculatorWatermarkTx(ctx context.Context, tx *sql.Tx) (time.Time, bool, error) {
+	var raw string
+	err := tx.QueryRowContext(ctx, `SELECT watermark FROM stats_calculator_state WHERE id = 1`).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("read calculator watermark: %w", err)
+	}
+	wm, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		// A snapshot without a readable watermark cannot be resumed.
+		if _, err := tx.ExecContext(ctx, `DELETE FROM stats_calculator_state`); err != nil {
+			return time.Time{}, false, fmt.Errorf("discard calculator state: %w", err)
+		}
+		return time.Time{}, false, nil
+	}
+	return wm, true, nil
+}
+
+func (r *SQLiteRepository) LoadCalculatorState(ctx context.Context) (*CalculatorSnapshot, error) {
+	var watermark, state string
+	err := r.db.QueryRowContext(ctx, `SELECT watermark, state FROM stats_calculator_state WHERE id = 1`).Scan(&watermark, &state)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load calculator state: %w", err)
+	}
+	snap := &CalculatorSnapshot{State: &stats.CalculatorState{}}
+	if snap.Watermark, err = time.Parse(time.RFC3339Nano, watermark); err != nil {
+		return nil, fmt.Errorf("parse calculator watermark: %w", err)
+	}
+	if err := json.Unmarshal([]byte(state), snap.State); err != nil {
+		return nil, fmt.Errorf("decode calculator state: %w", err)
+	}
+	return snap, nil
+}
+
+func (r *SQLiteRepository) SaveCalculatorState(ctx context.Context, snap CalculatorSnapshot) error {
+	if snap.State == nil {
+		return r.DeleteCalculatorState(ctx)
+	}
+	state, err := json.Marshal(snap.State)
+	if err != nil {
+		return fmt.Errorf("encode calculator state: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, `INSERT INTO stats_calculator_state(
+		id, local_seat, version, watermark, state, updated_at
+	) VALUES(1, ?, ?, ?, ?, ?)
+	ON CONFLICT(id) DO UPDATE SET
+		local_seat=excluded.local_seat,
+		version=excluded.version,
+		watermark=excluded.watermark,
+		state=excluded.state,
+		updated_at=excluded.updated_at`,
+		snap.State.LocalSeat,
+		snap.State.Version,
+		snap.Watermark.UTC().Format(time.RFC3339Nano),
+		string(state),
+		time.Now().UTC().Format(time.RFC3339Nano),
+	); err != nil {
+		return fmt.Errorf("save calculator state: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) DeleteCalculatorState(ctx context.Context) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM stats_calculator_state`); err != nil {
+		return fmt.Errorf("delete calculator state: %w", err)
+	}
+	return nil
+}
+
+// buildAnnotationFilterWhere returns the " AND ..." conditions for the
+// annotation fields of f. uidCol is the hand UID column of the outer query.
+func buildAnnotationFilterWhere(f HandFilter, uidCol string) (string, []any) {
+	where := ""
+	var args []any
+	if f.OnlyStarred {
+		where += ` AND ` + uidCol + ` IN (SELECT hand_uid FROM hand_notes WHERE starred = 1)`
+	}
+	for _, tag := range NormalizeTags(f.Tags) {
+		where += ` AND ` + uidCol + ` IN (SELECT hand_uid FROM hand_tags WHERE tag = ?)`
+		args = append(args, tag)
+	}
+	if q := strings.ToLower(strings.TrimSpace(f.AnnotationSearch)); q != "" {
+		pattern := "%" + escapeLike(q) + "%"
+		where += ` AND (` + uidCol + ` IN (SELECT hand_uid FROM hand_notes WHERE lower(note) LIKE ? ESCAPE '\')` +
+			` OR ` + uidCol + ` IN (SELECT hand_uid FROM hand_tags WHERE tag LIKE ? ESCAPE '\'))`
+		args = append(args, pattern, pattern)
+	}
+	return where, args
+}
+
+// escapeLike escapes the LIKE wildcards in s using backslash as the escape character.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
 // inClause builds a SQL "IN (?, ?, ...)" placeholder string and returns the
 // UIDs as a []any slice suitable for use as variadic query arguments.
 func inClause(uids []string) (string, []any) {
@@ -591,17 +1139,22 @@ func (r *SQLiteRepository) ListHandSummaries(ctx context.Context, f HandFilter)
 		where += ` AND h.start_time <= ?`
 		args = append(args, f.ToTime.UTC().Format(time.RFC3339Nano))
 	}
-	if len(f.PocketCategoryIDs) > 0 {
-		where += " AND hp.pocket_category_id IN (" + strings.TrimRight(strings.Repeat("?,", len(f.PocketCategoryIDs)), ",") + ")"
-		for _, id := range f.PocketCategoryIDs {
-			args = append(args, id)
-		}
-	}
-	if len(f.FinalClassIDs) > 0 {
-		where += " AND hp.final_class_id IN (" + strings.TrimRight(strings.Repeat("?,", len(f.FinalClassIDs)), ",") + ")"
-		for _, id := range f.FinalClassIDs {
-			args = append(args, id)
+	classWhere, classArgs := buildHandClassWhere(f, "hp")
+	where += classWhere
+	args = append(args, classArgs...)
+	annWhere, annArgs := buildAnnotationFilterWhere(f, "h.hand_uid")
+	where += annWhere
+	args = append(args, annArgs...)
+	sizeWhere, sizeArgs := buildTableSizeWhere(f.TableSizes, "h.num_players")
+	where += sizeWhere
+	args = append(args, sizeArgs...)
+	if f.Query != nil {
+		queryWhere, queryArgs, err := compileQuery(f.Query, "h")
+		if err != nil {
+			return nil, 0, err
 		}
+		where += " AND " + queryWhere
+		args = append(args, queryArgs...)
 	}
 
 	// Lightweight summary query for list view. Only local-player data is joined.
@@ -620,16 +1173,23 @@ SELECT
     COALESCE(hc0.rank || hc0.suit, '')                              AS hole_card_0,
     COALESCE(hc1.rank || hc1.suit, '')                              AS hole_card_1,
     COALESCE(bc.community_cards, '')                               AS community_cards,
+    COALESCE(hn.starred, 0)                                         AS starred,
+    COALESCE(hn.note, '') <> ''                                     AS has_note,
+    COALESCE((SELECT GROUP_CONCAT(tag, ',') FROM hand_tags ht WHERE ht.hand_uid = h.hand_uid), '') AS tags,
     COUNT(*) OVER()                                                 AS total_count
 FROM hands h
 INNER JOIN hand_players hp
     ON hp.hand_uid = h.hand_uid AND hp.seat_id = h.local_seat
 LEFT JOIN (
-    SELECT ha.hand_uid, SUM(ha.amount) AS invested
-    FROM hand_actions ha
-    JOIN hands h2 ON h2.hand_uid = ha.hand_uid
-    WHERE ha.seat_id = h2.local_seat
-    GROUP BY ha.hand_uid
+    SELECT hand_uid, SUM(street_max) AS invested
+    FROM (
+        SELECT ha.hand_uid, MAX(ha.amount) AS street_max
+        FROM hand_actions ha
+        JOIN hands h2 ON h2.hand_uid = ha.hand_uid
+        WHERE ha.seat_id = h2.local_seat
+        GROUP BY ha.hand_uid, ha.street
+    )
+    GROUP BY hand_uid
 ) ag ON ag.hand_uid = h.hand_uid
 LEFT JOIN hand_hole_cards hc0
     ON hc0.hand_uid = h.hand_uid AND hc0.seat_id = hp.seat_id AND hc0.card_index = 0
@@ -640,7 +1200,8 @@ LEFT JOIN (
            GROUP_CONCAT(rank || suit, ' ') AS community_cards
     FROM (SELECT hand_uid, rank, suit FROM hand_board_cards ORDER BY hand_uid ASC, card_index ASC)
     GROUP BY hand_uid
-) bc ON bc.hand_uid = h.hand_uid` +
+) bc ON bc.hand_uid = h.hand_uid
+LEFT JOIN hand_notes hn ON hn.hand_uid = h.hand_uid` +
 		where + `
 ORDER BY h.start_time DESC`
 
@@ -659,9 +1220,10 @@ ORDER BY h.start_time DESC`
 	for rows.Next() {
 		var s HandSummary
 		var startStr string
-		var isComplete, won int
+		var isComplete, won, starred, hasNote int
 		var positionInt int
 		var rowTotal int
+		var tags string
 		if err := rows.Scan(
 			&s.HandUID,
 			&startStr,
@@ -676,6 +1238,9 @@ ORDER BY h.start_time DESC`
 			&s.HoleCard0,
 			&s.HoleCard1,
 			&s.CommunityCards,
+			&starred,
+			&hasNote,
+			&tags,
 			&rowTotal,
 		); err != nil {
 			return nil, 0, fmt.Errorf("ListHandSummaries scan: %w", err)
@@ -686,6 +1251,12 @@ ORDER BY h.start_time DESC`
 		s.StartTime, _ = time.Parse(time.RFC3339Nano, startStr)
 		s.IsComplete = isComplete == 1
 		s.Won = won == 1
+		s.Starred = starred == 1
+		s.HasNote = hasNote == 1
+		if tags != "" {
+			s.Tags = strings.Split(tags, ",")
+			sort.Strings(s.Tags)
+		}
 		if positionInt != 0 {
 			s.Position = parser.Position(positionInt).String()
 		}
@@ -694,13 +1265,22 @@ ORDER BY h.start_time DESC`
 	if err := rows.Err(); err != nil {
 		return nil, 0, fmt.Errorf("ListHandSummaries rows: %w", err)
 	}
+	if len(out) == 0 && f.Limit > 0 && f.Offset > 0 {
+		// A page past the end has no row to carry the total.
+		countQuery := `SELECT COUNT(*) FROM hands h
+INNER JOIN hand_players hp
+    ON hp.hand_uid = h.hand_uid AND hp.seat_id = h.local_seat` + where
+		if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&totalCount); err != nil {
+			return nil, 0, fmt.Errorf("ListHandSummaries count: %w", err)
+		}
+	}
 	return out, totalCount, nil
 }
 
 func (r *SQLiteRepository) ListHandsAfter(ctx context.Context, after time.Time, localSeat int) ([]*parser.Hand, error) {
 	query := `SELECT hand_uid, start_time, end_time, is_complete, stats_eligible, has_anomaly,
 		local_seat, world_id, world_display_name, instance_uid, instance_type, instance_owner_user_uid, instance_region,
-		sb_seat, bb_seat, num_players, total_pot, winner_seat, win_type
+		sb_seat, bb_seat, num_players, total_pot, winner_seat, win_type, parser_version
 		FROM hands
 		WHERE is_complete = 1 AND stats_eligible = 1 AND start_time > ?
 		ORDER BY start_time ASC`
@@ -748,18 +1328,15 @@ func (r *SQLiteRepository) ListHandsAfter(ctx context.Context, after time.Time,
 }
 
 func (r *SQLiteRepository) CountHands(ctx context.Context, f HandFilter) (int, error) {
-	var query string
-	var args []any
+	where, args, err := buildHandsFilterWhere(f)
+	if err != nil {
+		return 0, err
+	}
+	query := `SELECT COUNT(*) FROM hands` + where
 	if f.LocalSeat != nil {
 		// Join hand_players to filter by local seat without loading all hand children.
-		baseWhere, baseArgs := buildHandsFilterWhere(f)
-		query = `SELECT COUNT(*) FROM hands` + baseWhere +
-			` AND EXISTS (SELECT 1 FROM hand_players WHERE hand_players.hand_uid = hands.hand_uid AND hand_players.seat_id = ?)`
-		args = append(baseArgs, *f.LocalSeat)
-	} else {
-		where, whereArgs := buildHandsFilterWhere(f)
-		query = `SELECT COUNT(*) FROM hands` + where
-		args = whereArgs
+		query += ` AND EXISTS (SELECT 1 FROM hand_players WHERE hand_players.hand_uid = hands.hand_uid AND hand_players.seat_id = ?)`
+		args = append(args, *f.LocalSeat)
 	}
 
 	var count int
@@ -789,6 +1366,7 @@ func scanHandRow(scanner rowScanner) (*parser.Hand, error) {
 	var instanceRegion sql.NullString
 	var sbSeat, bbSeat, numPlayers, totalPot, winnerSeat int
 	var winType string
+	var parserVersion int
 
 	if err := scanner.Scan(
 		&uid,
@@ -810,6 +1388,7 @@ func scanHandRow(scanner rowScanner) (*parser.Hand, error) {
 		&totalPot,
 		&winnerSeat,
 		&winType,
+		&parserVersion,
 	); err != nil {
 		return nil, err
 	}
@@ -837,21 +1416,61 @@ func scanHandRow(scanner rowScanner) (*parser.Hand, error) {
 		TotalPot:         totalPot,
 		WinnerSeat:       winnerSeat,
 		WinType:          winType,
+		ParserVersion:    parserVersion,
 		Players:          make(map[int]*parser.PlayerHandInfo),
 	}
 	return h, nil
 }
 
 func (r *SQLiteRepository) GetCursor(ctx context.Context, sourcePath string) (*ImportCursor, error) {
-	q := `SELECT source_path, next_byte_offset, next_line_number, last_event_time, last_hand_uid, parser_state_json,
+	return r.queryCursor(ctx, `WHERE source_path = ?`, sourcePath)
+}
+
+func (r *SQLiteRepository) FindCursorByFingerprint(ctx context.Context, fingerprint string, size int) (*ImportCursor, error) {
+	if fingerprint == "" {
+		return nil, nil
+	}
+	return r.queryCursor(ctx, `WHERE fingerprint = ? AND fingerprint_size = ? ORDER BY is_fully_imported DESC, next_byte_offset DESC, source_path LIMIT 1`, fingerprint, size)
+}
+
+func (r *SQLiteRepository) FingerprintSizes(ctx context.Context) ([]int, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT DISTINCT fingerprint_size FROM import_cursors WHERE fingerprint IS NOT NULL ORDER BY fingerprint_size DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []int
+	for rows.Next() {
+		var size int
+		if err := rows.Scan(&size); err != nil {
+			return nil, err
+		}
+		out = append(out, size)
+	}
+	return out, rows.Err()
+}
+
+// cursorColumns are the import_cursors columns read by scanCursor.
+const cursorColumns = `source_path, next_byte_offset, next_line_number, last_event_time, last_hand_uid, parser_state_json,
 		is_fully_imported,
 		world_id, world_display_name, instance_uid, instance_type, instance_owner, instance_region,
 		in_poker_world,
-		updated_at
-		FROM import_cursors WHERE source_path = ?`
-	row := r.db.QueryRowContext(ctx, q, sourcePath)
+		fingerprint, fingerprint_size,
+		updated_at`
+
+// queryCursor returns the first cursor selected by the where clause, or nil.
+func (r *SQLiteRepository) queryCursor(ctx context.Context, where string, args ...any) (*ImportCursor, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+cursorColumns+` FROM import_cursors `+where, args...)
+	c, err := scanCursor(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+func scanCursor(row interface{ Scan(...any) error }) (*ImportCursor, error) {
 	var c ImportCursor
-	var lastEvent sql.NullString
+	var lastEvent, fingerprint sql.NullString
 	var updatedAt string
 	var isFullyImported, inPokerWorld int
 	var worldID, worldDisplayName, instanceUID, instanceType, instanceOwner, instanceRegion sql.NullString
@@ -870,14 +1489,14 @@ func (r *SQLiteRepository) GetCursor(ctx context.Context, sourcePath string) (*I
 		&instanceOwner,
 		&instanceRegion,
 		&inPokerWorld,
+		&fingerprint,
+		&c.FingerprintSize,
 		&updatedAt,
 	); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
 		return nil, err
 	}
 	c.IsFullyImported = isFullyImported == 1
+	c.Fingerprint = fingerprint.String
 	if lastEvent.Valid {
 		ts, err := time.Parse(time.RFC3339Nano, lastEvent.String)
 		if err == nil {
@@ -967,8 +1586,9 @@ func saveCursorTx(ctx context.Context, tx *sql.Tx, c ImportCursor) error {
 		is_fully_imported,
 		world_id, world_display_name, instance_uid, instance_type, instance_owner, instance_region,
 		in_poker_world,
+		fingerprint, fingerprint_size,
 		updated_at
-	) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	ON CONFLICT(source_path) DO UPDATE SET
 		next_byte_offset=excluded.next_byte_offset,
 		next_line_number=excluded.next_line_number,
@@ -983,6 +1603,8 @@ func saveCursorTx(ctx context.Context, tx *sql.Tx, c ImportCursor) error {
 		instance_owner=excluded.instance_owner,
 		instance_region=excluded.instance_region,
 		in_poker_world=excluded.in_poker_world,
+		fingerprint=COALESCE(excluded.fingerprint, import_cursors.fingerprint),
+		fingerprint_size=CASE WHEN excluded.fingerprint IS NULL THEN import_cursors.fingerprint_size ELSE excluded.fingerprint_size END,
 		updated_at=excluded.updated_at`
 	_, err := tx.ExecContext(
 		ctx,
@@ -1001,6 +1623,8 @@ func saveCursorTx(ctx context.Context, tx *sql.Tx, c ImportCursor) error {
 		instanceOwner,
 		instanceRegion,
 		inPokerWorld,
+		nullIfEmpty(c.Fingerprint),
+		c.FingerprintSize,
 		updatedAt.UTC().Format(time.RFC3339Nano),
 	)
 	return err
@@ -1018,6 +1642,53 @@ func rowExists(ctx context.Context, tx *sql.Tx, query string, args ...any) (bool
 	return true, nil
 }
 
+// resolveHandUIDTx returns the UID an upsert of ph is stored under: the hand
+// already stored for its source span, or else its own UID.
+func resolveHandUIDTx(ctx context.Context, tx *sql.Tx, ph PersistedHand) (string, error) {
+	uid := ph.Source.HandUID
+	if resolvedUID, ok, err := findHandUIDBySourceSpanTx(ctx, tx, ph.Source); err != nil {
+		return "", err
+	} else if ok {
+		uid = resolvedUID
+	}
+	if uid == "" {
+		uid = GenerateHandUID(ph.Hand, ph.Source)
+	}
+	return uid, nil
+}
+
+func (r *SQLiteRepository) StoredHands(ctx context.Context, rows []PersistedHand) ([]*parser.Hand, error) {
+	uids := make([]string, len(rows))
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		for i, ph := range rows {
+			if ph.Hand == nil {
+				continue
+			}
+			uid, err := resolveHandUIDTx(ctx, tx, ph)
+			if err != nil {
+				return err
+			}
+			uids[i] = uid
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("resolve hand uids: %w", err)
+	}
+	out := make([]*parser.Hand, len(rows))
+	for i, uid := range uids {
+		if uid == "" {
+			continue
+		}
+		h, err := r.GetHandByUID(ctx, uid)
+		if err != nil {
+			return nil, fmt.Errorf("load stored hand %s: %w", uid, err)
+		}
+		out[i] = h
+	}
+	return out, nil
+}
+
 func findHandUIDBySourceSpanTx(ctx context.Context, tx *sql.Tx, src HandSourceRef) (string, bool, error) {
 	if src.SourcePath == "" {
 		return "", false, nil
@@ -1190,12 +1861,15 @@ func execDeleteHandUID(ctx context.Context, tx *sql.Tx, table string, handUID st
 	}
 }
 
-func buildHandsFilterWhere(f HandFilter) (string, []any) {
+func buildHandsFilterWhere(f HandFilter) (string, []any, error) {
 	where := " WHERE 1=1"
 	args := make([]any, 0, 3)
 	if f.OnlyComplete {
 		where += ` AND is_complete=1`
 	}
+	if f.OnlyStatsExcluded {
+		where += ` AND stats_eligible=0`
+	}
 	if f.FromTime != nil {
 		where += ` AND start_time >= ?`
 		args = append(args, f.FromTime.UTC().Format(time.RFC3339Nano))
@@ -1204,9 +1878,64 @@ func buildHandsFilterWhere(f HandFilter) (string, []any) {
 		where += ` AND start_time <= ?`
 		args = append(args, f.ToTime.UTC().Format(time.RFC3339Nano))
 	}
+	if classWhere, classArgs := buildHandClassWhere(f, "hp"); classWhere != "" {
+		where += ` AND EXISTS (SELECT 1 FROM hand_players hp WHERE hp.hand_uid = hands.hand_uid AND hp.seat_id = hands.local_seat` + classWhere + `)`
+		args = append(args, classArgs...)
+	}
+	annWhere, annArgs := buildAnnotationFilterWhere(f, "hands.hand_uid")
+	where += annWhere
+	args = append(args, annArgs...)
+	sizeWhere, sizeArgs := buildTableSizeWhere(f.TableSizes, "hands.num_players")
+	where += sizeWhere
+	args = append(args, sizeArgs...)
+	if f.Query != nil {
+		queryWhere, queryArgs, err := compileQuery(f.Query, "hands")
+		if err != nil {
+			return "", nil, err
+		}
+		where += " AND " + queryWhere
+		args = append(args, queryArgs...)
+	}
+	return where, args, nil
+}
+
+// buildHandClassWhere returns the " AND ..." conditions on the pocket
+// category and final class IDs of f. alias is the local player's
+// hand_players row. It is empty when f sets neither.
+func buildHandClassWhere(f HandFilter, alias string) (string, []any) {
+	where := ""
+	args := make([]any, 0, len(f.PocketCategoryIDs)+len(f.FinalClassIDs))
+	if len(f.PocketCategoryIDs) > 0 {
+		where += " AND " + alias + ".pocket_category_id IN (" + strings.TrimRight(strings.Repeat("?,", len(f.PocketCategoryIDs)), ",") + ")"
+		for _, id := range f.PocketCategoryIDs {
+			args = append(args, id)
+		}
+	}
+	if len(f.FinalClassIDs) > 0 {
+		where += " AND " + alias + ".final_class_id IN (" + strings.TrimRight(strings.Repeat("?,", len(f.FinalClassIDs)), ",") + ")"
+		for _, id := range f.FinalClassIDs {
+			args = append(args, id)
+		}
+	}
 	return where, args
 }
 
+// buildTableSizeWhere returns an " AND ..." clause restricting column, a
+// player count, to the given table sizes. It is empty when sizes is.
+func buildTableSizeWhere(sizes []stats.TableSize, column string) (string, []any) {
+	if len(sizes) == 0 {
+		return "", nil
+	}
+	parts := make([]string, 0, len(sizes))
+	args := make([]any, 0, 2*len(sizes))
+	for _, size := range sizes {
+		minPlayers, maxPlayers := size.PlayerRange()
+		parts = append(parts, column+" BETWEEN ? AND ?")
+		args = append(args, minPlayers, maxPlayers)
+	}
+	return " AND (" + strings.Join(parts, " OR ") + ")", args
+}
+
 func (r *SQLiteRepository) withTx(ctx context.Context, fn func(*sql.Tx) error) error {
 	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{})
 	if err != nil {
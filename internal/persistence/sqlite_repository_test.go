@@ -0,0 +1,68 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestOpenSQLiteRepositoryReadOnlyLeavesDatabaseAlone(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	missing := filepath.Join(dir, "missing.db")
+	if _, err := OpenSQLiteRepositoryReadOnly(missing); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("open missing database: err = %v, want fs.ErrNotExist", err)
+	}
+	if _, err := os.Stat(missing); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("opening a missing database created it: %v", err)
+	}
+
+	// A database behind the schema is refused instead of migrated.
+	old := filepath.Join(dir, "old.db")
+	db, err := sql.Open("sqlite", old)
+	if err != nil {
+		t.Fatalf("create old database: %v", err)
+	}
+	if _, err := db.Exec(`CREATE TABLE hands (hand_uid TEXT PRIMARY KEY)`); err != nil {
+		t.Fatalf("create old schema: %v", err)
+	}
+	_ = db.Close()
+	if repo, err := OpenSQLiteRepositoryReadOnly(old); err == nil {
+		_ = repo.Close()
+		t.Fatalf("opened a database without migrations")
+	}
+
+	current := filepath.Join(dir, "current #1%.db")
+	repo, err := NewSQLiteRepository(current)
+	if err != nil {
+		t.Fatalf("create database: %v", err)
+	}
+	if _, err := repo.SaveImportBatch(ctx, nil, ImportCursor{SourcePath: "a.log", NextByteOffset: 10}); err != nil {
+		t.Fatalf("save cursor: %v", err)
+	}
+	_ = repo.Close()
+
+	ro, err := OpenSQLiteRepositoryReadOnly(current)
+	if err != nil {
+		t.Fatalf("open read-only: %v", err)
+	}
+	defer ro.Close()
+	if issues, err := ro.CheckIntegrity(ctx); err != nil || len(issues) != 0 {
+		t.Fatalf("check = %+v, %v", issues, err)
+	}
+	if c, err := ro.GetCursor(ctx, "a.log"); err != nil || c == nil || c.NextByteOffset != 10 {
+		t.Fatalf("cursor = %+v, %v", c, err)
+	}
+	if err := ro.DeleteCursor(ctx, "a.log"); err == nil {
+		t.Fatalf("read-only repository deleted a cursor")
+	}
+}
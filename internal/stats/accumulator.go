@@ -9,6 +9,7 @@ type metricAccumulator struct {
 	callPostflop int
 	foldPostflop int
 	bbNet        float64
+	bbNetSq      float64 // sum of squared per-hand results, for the bb/100 variance
 	bbHands      int
 }
 
@@ -61,7 +62,9 @@ func (m *metricAccumulator) consumeHand(h *parser.Hand, pi *parser.PlayerHandInf
 
 	bb := bbAmountFromHand(h)
 	if bb > 0 {
-		m.bbNet += float64(pi.PotWon-invested) / float64(bb)
+		net := float64(pi.PotWon-invested) / float64(bb)
+		m.bbNet += net
+		m.bbNetSq += net * net
 		m.bbHands++
 	}
 
@@ -228,6 +231,7 @@ func (m *metricAccumulator) clone() *metricAccumulator {
 		callPostflop: m.callPostflop,
 		foldPostflop: m.foldPostflop,
 		bbNet:        m.bbNet,
+		bbNetSq:      m.bbNetSq,
 		bbHands:      m.bbHands,
 	}
 	for k, v := range m.counts {
@@ -239,6 +243,27 @@ func (m *metricAccumulator) clone() *metricAccumulator {
 	return c
 }
 
+// merge adds the counts of o to m, or subtracts them when sign is negative.
+func (m *metricAccumulator) merge(o *metricAccumulator, sign int) {
+	if sign < 0 {
+		sign = -1
+	} else {
+		sign = 1
+	}
+	for k, v := range o.counts {
+		m.counts[k] += sign * v
+	}
+	for k, v := range o.opps {
+		m.opps[k] += sign * v
+	}
+	m.aggPostflop += sign * o.aggPostflop
+	m.callPostflop += sign * o.callPostflop
+	m.foldPostflop += sign * o.foldPostflop
+	m.bbNet += float64(sign) * o.bbNet
+	m.bbNetSq += float64(sign) * o.bbNetSq
+	m.bbHands += sign * o.bbHands
+}
+
 func (m *metricAccumulator) finalize(s *Stats) {
 	if s == nil {
 		return
@@ -255,7 +280,7 @@ func (m *metricAccumulator) finalize(s *Stats) {
 		rate := metricRate(def.ID, m.counts[def.ID], m.opps[def.ID], m)
 		count := m.counts[def.ID]
 		opp := m.opps[def.ID]
-		s.Metrics[def.ID] = MetricValue{
+		v := MetricValue{
 			ID:          def.ID,
 			Count:       count,
 			Opportunity: opp,
@@ -264,6 +289,8 @@ func (m *metricAccumulator) finalize(s *Stats) {
 			MinSample:   threshold,
 			Format:      def.Format,
 		}
+		m.setInterval(&v)
+		s.Metrics[def.ID] = v
 	}
 }
 
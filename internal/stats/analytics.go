@@ -0,0 +1,113 @@
+package stats
+
+import (
+	"time"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
+)
+
+// SessionGap is the idle time after which the next hand starts a new session.
+const SessionGap = 30 * time.Minute
+
+// SessionPhase groups hands by how many hands into their session they were
+// played.
+type SessionPhase int
+
+const (
+	SessionPhaseEarly    SessionPhase = iota // hands 1-50
+	SessionPhaseMid                          // hands 51-100
+	SessionPhaseLate                         // hands 101-200
+	SessionPhaseExtended                     // hands 201 and later
+	sessionPhaseCount
+)
+
+// AllSessionPhases returns the session phases in display order.
+func AllSessionPhases() []SessionPhase {
+	return []SessionPhase{SessionPhaseEarly, SessionPhaseMid, SessionPhaseLate, SessionPhaseExtended}
+}
+
+// SessionPhaseForHand returns the phase of the n-th hand (1-based) of a
+// session.
+func SessionPhaseForHand(n int) SessionPhase {
+	switch {
+	case n <= 50:
+		return SessionPhaseEarly
+	case n <= 100:
+		return SessionPhaseMid
+	case n <= 200:
+		return SessionPhaseLate
+	default:
+		return SessionPhaseExtended
+	}
+}
+
+// TimeAnalytics holds statistics split by local hour of day, day of week and
+// session phase. Every bucket is non-nil; buckets without hands hold empty
+// statistics.
+type TimeAnalytics struct {
+	ByHour         [24]*Stats
+	ByWeekday      [7]*Stats // indexed by time.Weekday
+	BySessionPhase [sessionPhaseCount]*Stats
+	// Sessions is the number of sessions the hands were split into.
+	Sessions int
+}
+
+// TimeAnalyticsCalculator accumulates TimeAnalytics. Hands must be fed in
+// chronological order so sessions can be detected; hands without a start
+// time are ignored.
+type TimeAnalyticsCalculator struct {
+	hours    [24]*IncrementalCalculator
+	weekdays [7]*IncrementalCalculator
+	phases   [sessionPhaseCount]*IncrementalCalculator
+
+	lastStart     time.Time
+	sessionHands  int
+	sessionsCount int
+}
+
+// NewTimeAnalyticsCalculator creates a calculator for the player at localSeat.
+func NewTimeAnalyticsCalculator(localSeat int) *TimeAnalyticsCalculator {
+	c := &TimeAnalyticsCalculator{}
+	for i := range c.hours {
+		c.hours[i] = newIncrementalCalculator(localSeat)
+	}
+	for i := range c.weekdays {
+		c.weekdays[i] = newIncrementalCalculator(localSeat)
+	}
+	for i := range c.phases {
+		c.phases[i] = newIncrementalCalculator(localSeat)
+	}
+	return c
+}
+
+// Feed adds a hand to its hour, weekday and session phase buckets.
+func (c *TimeAnalyticsCalculator) Feed(h *parser.Hand) {
+	if h == nil || h.StartTime.IsZero() {
+		return
+	}
+	if c.lastStart.IsZero() || h.StartTime.Sub(c.lastStart) > SessionGap {
+		c.sessionsCount++
+		c.sessionHands = 0
+	}
+	c.lastStart = h.StartTime
+	c.sessionHands++
+
+	c.hours[h.StartTime.Hour()].Feed(h)
+	c.weekdays[h.StartTime.Weekday()].Feed(h)
+	c.phases[SessionPhaseForHand(c.sessionHands)].Feed(h)
+}
+
+// Compute returns the statistics of every bucket.
+func (c *TimeAnalyticsCalculator) Compute() *TimeAnalytics {
+	out := &TimeAnalytics{Sessions: c.sessionsCount}
+	for i, ic := range c.hours {
+		out.ByHour[i] = ic.Compute()
+	}
+	for i, ic := range c.weekdays {
+		out.ByWeekday[i] = ic.Compute()
+	}
+	for i, ic := range c.phases {
+		out.BySessionPhase[i] = ic.Compute()
+	}
+	return out
+}
@@ -34,15 +34,7 @@ func (c *Calculator) ensurePositionStats(s *Stats, pos parser.Position) *Positio
 
 // investedAmount calculates total chips invested in a hand by a player
 func (c *Calculator) investedAmount(h *parser.Hand, seat int) int {
-	pi, ok := h.Players[seat]
-	if !ok {
-		return 0
-	}
-	total := 0
-	for _, act := range pi.Actions {
-		total += act.Amount
-	}
-	return total
+	return h.Players[seat].Invested()
 }
 
 // updateHandRange updates the hand range table for a hand
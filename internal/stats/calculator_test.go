@@ -58,15 +58,17 @@ func TestCalculatorInvestedAmount(t *testing.T) {
 		t.Errorf("boundary - 1 action: expected 20, got %d", amount)
 	}
 
-	// Normal case: Multiple actions
+	// Normal case: BET IN is a running total per street, so the blind and
+	// the raise on top of it count once, and the flop bet adds to them.
 	hand.Players[0].Actions = []parser.PlayerAction{
-		{Amount: 10},
-		{Amount: 20},
-		{Amount: 30},
+		{Street: parser.StreetPreFlop, Action: parser.ActionBlindSB, Amount: 10},
+		{Street: parser.StreetPreFlop, Action: parser.ActionRaise, Amount: 60},
+		{Street: parser.StreetPreFlop, Action: parser.ActionCall, Amount: 180},
+		{Street: parser.StreetFlop, Action: parser.ActionBet, Amount: 30},
 	}
 	amount = calc.investedAmount(hand, 0)
-	if amount != 60 {
-		t.Errorf("normal case: expected 60, got %d", amount)
+	if amount != 210 {
+		t.Errorf("normal case: expected 210, got %d", amount)
 	}
 }
 
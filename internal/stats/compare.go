@@ -0,0 +1,111 @@
+package stats
+
+import (
+	"math"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
+)
+
+// RateComparison compares a percentage between two samples, A and B. Rates
+// are zero for a sample without opportunities.
+type RateComparison struct {
+	A, B   float64
+	NA, NB int
+	// Diff is B minus A in percentage points.
+	Diff        float64
+	Z           float64
+	Significant bool
+}
+
+func compareRate(xa, na, xb, nb int) RateComparison {
+	out := RateComparison{NA: na, NB: nb}
+	if na > 0 {
+		out.A = float64(xa) / float64(na) * 100
+	}
+	if nb > 0 {
+		out.B = float64(xb) / float64(nb) * 100
+	}
+	out.Diff = out.B - out.A
+	out.Z = twoProportionZ(xb, nb, xa, na)
+	out.Significant = math.Abs(out.Z) >= significanceZ
+	return out
+}
+
+// PositionComparison compares the preflop rates of one position.
+type PositionComparison struct {
+	Position parser.Position
+	VPIP     RateComparison
+	PFR      RateComparison
+	ThreeBet RateComparison
+}
+
+// Comparison holds the differences between two samples. A is the reference:
+// every difference is B minus A.
+type Comparison struct {
+	A, B *Stats
+	// Metrics compares every registry metric, in registry order.
+	Metrics []MetricComparison
+	// Positions holds the positions with hands in either sample.
+	Positions map[parser.Position]PositionComparison
+	// HandRange compares, for each cell of the range grid, the share of
+	// dealt hands that were not folded preflop.
+	HandRange [13][13]RateComparison
+}
+
+// Compare compares sample b against the reference sample a.
+func Compare(a, b *Stats) *Comparison {
+	out := &Comparison{
+		A:         a,
+		B:         b,
+		Metrics:   CompareStats(b, a),
+		Positions: make(map[parser.Position]PositionComparison),
+	}
+
+	for _, pos := range positionsOf(a, b) {
+		pa, pb := a.ByPosition[pos], b.ByPosition[pos]
+		if pa == nil {
+			pa = &PositionStats{}
+		}
+		if pb == nil {
+			pb = &PositionStats{}
+		}
+		out.Positions[pos] = PositionComparison{
+			Position: pos,
+			VPIP:     compareRate(pa.VPIP, pa.Hands, pb.VPIP, pb.Hands),
+			PFR:      compareRate(pa.PFR, pa.Hands, pb.PFR, pb.Hands),
+			ThreeBet: compareRate(pa.ThreeBet, pa.ThreeBetOpp, pb.ThreeBet, pb.ThreeBetOpp),
+		}
+	}
+
+	for r := 0; r < 13; r++ {
+		for c := 0; c < 13; c++ {
+			ca, cb := rangeCell(a, r, c), rangeCell(b, r, c)
+			out.HandRange[r][c] = compareRate(
+				ca.Dealt-ca.Actions[RangeActionFold], ca.Dealt,
+				cb.Dealt-cb.Actions[RangeActionFold], cb.Dealt,
+			)
+		}
+	}
+	return out
+}
+
+func positionsOf(samples ...*Stats) []parser.Position {
+	seen := make(map[parser.Position]bool)
+	var out []parser.Position
+	for _, s := range samples {
+		for pos, ps := range s.ByPosition {
+			if ps != nil && ps.Hands > 0 && !seen[pos] {
+				seen[pos] = true
+				out = append(out, pos)
+			}
+		}
+	}
+	return out
+}
+
+func rangeCell(s *Stats, r, c int) HandRangeCell {
+	if s.HandRange == nil || s.HandRange.Cells[r][c] == nil {
+		return HandRangeCell{}
+	}
+	return *s.HandRange.Cells[r][c]
+}
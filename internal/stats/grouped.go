@@ -0,0 +1,91 @@
+package stats
+
+import (
+	"sort"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
+)
+
+// Breakdown is a hand attribute that statistics can be grouped by.
+type Breakdown int
+
+const (
+	// BreakdownInstanceType groups hands by VRChat instance type.
+	BreakdownInstanceType Breakdown = iota
+	// BreakdownRegion groups hands by instance region.
+	BreakdownRegion
+	// BreakdownOwner groups hands by the user or group owning the instance.
+	BreakdownOwner
+)
+
+// Key returns the group key of h. Hands without the attribute share the
+// empty key, except for instance types, which fall back to "unknown".
+func (b Breakdown) Key(h *parser.Hand) string {
+	switch b {
+	case BreakdownRegion:
+		return h.InstanceRegion
+	case BreakdownOwner:
+		return h.InstanceOwner
+	default:
+		if h.InstanceType == "" {
+			return string(parser.InstanceTypeUnknown)
+		}
+		return string(h.InstanceType)
+	}
+}
+
+// StatsGroup is the statistics of the hands sharing one breakdown key.
+type StatsGroup struct {
+	Key   string
+	Stats *Stats
+}
+
+// GroupedCalculator accumulates statistics separately for each value of a
+// breakdown.
+type GroupedCalculator struct {
+	localSeat int
+	breakdown Breakdown
+	groups    map[string]*IncrementalCalculator
+}
+
+// NewGroupedCalculator creates a calculator grouping hands by breakdown.
+func NewGroupedCalculator(localSeat int, breakdown Breakdown) *GroupedCalculator {
+	return &GroupedCalculator{
+		localSeat: localSeat,
+		breakdown: breakdown,
+		groups:    make(map[string]*IncrementalCalculator),
+	}
+}
+
+// Feed adds a hand to the group of its breakdown key.
+func (g *GroupedCalculator) Feed(h *parser.Hand) {
+	if h == nil {
+		return
+	}
+	key := g.breakdown.Key(h)
+	ic, ok := g.groups[key]
+	if !ok {
+		ic = newIncrementalCalculator(g.localSeat)
+		g.groups[key] = ic
+	}
+	ic.Feed(h)
+}
+
+// Compute returns the groups holding at least one eligible hand, largest
+// first and by key among groups of equal size.
+func (g *GroupedCalculator) Compute() []StatsGroup {
+	out := make([]StatsGroup, 0, len(g.groups))
+	for key, ic := range g.groups {
+		if ic.HandCount() == 0 {
+			continue
+		}
+		out = append(out, StatsGroup{Key: key, Stats: ic.Compute()})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Stats.TotalHands != out[j].Stats.TotalHands {
+			return out[i].Stats.TotalHands > out[j].Stats.TotalHands
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
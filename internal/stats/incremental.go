@@ -10,10 +10,23 @@ type IncrementalCalculator struct {
 	s         *Stats
 	ma        *metricAccumulator
 	calc      *Calculator
+	// bySize holds one calculator per table size seen so far. It is nil in
+	// those per-size calculators themselves.
+	bySize map[TableSize]*IncrementalCalculator
+	// tilt detects loss events; like bySize it is only set on the top-level
+	// calculator.
+	tilt *tiltDetector
 }
 
 // NewIncrementalCalculator creates a new incremental calculator for the given local seat.
 func NewIncrementalCalculator(localSeat int) *IncrementalCalculator {
+	ic := newIncrementalCalculator(localSeat)
+	ic.bySize = make(map[TableSize]*IncrementalCalculator)
+	ic.tilt = newTiltDetector()
+	return ic
+}
+
+func newIncrementalCalculator(localSeat int) *IncrementalCalculator {
 	return &IncrementalCalculator{
 		localSeat: localSeat,
 		s: &Stats{
@@ -29,19 +42,7 @@ func NewIncrementalCalculator(localSeat int) *IncrementalCalculator {
 // Feed processes a single hand into the accumulator.
 // Only complete, stats-eligible hands are processed; others are silently skipped.
 func (ic *IncrementalCalculator) Feed(h *parser.Hand) {
-	if h == nil || !h.IsComplete || !h.IsStatsEligible() {
-		return
-	}
-
-	handSeat := ic.localSeat
-	if h.LocalPlayerSeat >= 0 {
-		handSeat = h.LocalPlayerSeat
-	}
-	if handSeat < 0 {
-		return
-	}
-
-	localInfo, ok := h.Players[handSeat]
+	localInfo, handSeat, ok := localPlayer(h, ic.localSeat)
 	if !ok {
 		return
 	}
@@ -101,6 +102,37 @@ func (ic *IncrementalCalculator) Feed(h *parser.Hand) {
 	}
 
 	ic.ma.consumeHand(h, localInfo, invested)
+	if ic.tilt != nil {
+		ic.tilt.feed(h, localInfo, invested)
+	}
+
+	if ic.bySize != nil {
+		if size := TableSizeForPlayers(h.NumPlayers); size != TableSizeAny {
+			sub, ok := ic.bySize[size]
+			if !ok {
+				sub = newIncrementalCalculator(ic.localSeat)
+				ic.bySize[size] = sub
+			}
+			sub.Feed(h)
+		}
+	}
+}
+
+// localPlayer returns the local player of a complete, stats-eligible hand and
+// their seat. The hand's own local seat wins over localSeat.
+func localPlayer(h *parser.Hand, localSeat int) (*parser.PlayerHandInfo, int, bool) {
+	if h == nil || !h.IsComplete || !h.IsStatsEligible() {
+		return nil, 0, false
+	}
+	seat := localSeat
+	if h.LocalPlayerSeat >= 0 {
+		seat = h.LocalPlayerSeat
+	}
+	if seat < 0 {
+		return nil, 0, false
+	}
+	pi, ok := h.Players[seat]
+	return pi, seat, ok
 }
 
 // Compute returns the current aggregated Stats with all metrics finalized.
@@ -129,6 +161,15 @@ func (ic *IncrementalCalculator) Compute() *Stats {
 	// Clone the accumulator for finalization so the original is not mutated.
 	maClone := ic.ma.clone()
 	maClone.finalize(out)
+	if ic.bySize != nil {
+		out.ByTableSize = make(map[TableSize]*Stats, len(ic.bySize))
+		for size, sub := range ic.bySize {
+			out.ByTableSize[size] = sub.Compute()
+		}
+	}
+	if ic.tilt != nil {
+		out.Tilt = ic.tilt.report()
+	}
 	return out
 }
 
@@ -1,7 +1,11 @@
 package stats
 
 import (
+	"encoding/json"
+	"math"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
 )
@@ -175,6 +179,403 @@ func TestIncrementalCalculatorComputeAfterFeedUpdates(t *testing.T) {
 	}
 }
 
+func TestIncrementalCalculatorSplitsByTableSize(t *testing.T) {
+	ic := NewIncrementalCalculator(0)
+
+	for _, players := range []int{2, 4, 4, 8} {
+		hand := createValidTestHand(0)
+		hand.NumPlayers = players
+		ic.Feed(hand)
+	}
+	s := ic.Compute()
+
+	if s.TotalHands != 4 {
+		t.Fatalf("expected 4 hands overall, got %d", s.TotalHands)
+	}
+	want := map[TableSize]int{TableSizeHeadsUp: 1, TableSizeShort: 2, TableSizeFullRing: 1}
+	if len(s.ByTableSize) != len(want) {
+		t.Fatalf("expected %d table sizes, got %d", len(want), len(s.ByTableSize))
+	}
+	for size, n := range want {
+		sub := s.ForTableSize(size)
+		if sub.TotalHands != n {
+			t.Errorf("table size %s: expected %d hands, got %d", size.Code(), n, sub.TotalHands)
+		}
+		if sub.ByTableSize != nil {
+			t.Errorf("table size %s: nested split should be nil", size.Code())
+		}
+		if _, ok := sub.Metric(MetricVPIP); !ok {
+			t.Errorf("table size %s: missing VPIP metric", size.Code())
+		}
+	}
+	if got := s.ForTableSize(TableSizeSixMax).TotalHands; got != 0 {
+		t.Errorf("six-max: expected 0 hands, got %d", got)
+	}
+	if s.ForTableSize(TableSizeAny) != s {
+		t.Error("TableSizeAny should return the overall stats")
+	}
+}
+
+func TestGroupedCalculatorBreakdowns(t *testing.T) {
+	hands := []struct {
+		typ    parser.InstanceType
+		region string
+		owner  string
+	}{
+		{typ: parser.InstanceTypePublic, region: "jp"},
+		{typ: parser.InstanceTypeGroup, region: "jp", owner: "grp_team"},
+		{typ: parser.InstanceTypeGroup, region: "us", owner: "grp_team"},
+		{region: "jp"},
+	}
+
+	want := map[Breakdown][]StatsGroup{
+		BreakdownInstanceType: {{Key: "group"}, {Key: "public"}, {Key: "unknown"}},
+		BreakdownRegion:       {{Key: "jp"}, {Key: "us"}},
+		BreakdownOwner:        {{Key: ""}, {Key: "grp_team"}},
+	}
+	for breakdown, wantGroups := range want {
+		g := NewGroupedCalculator(0, breakdown)
+		for _, spec := range hands {
+			h := createValidTestHand(0)
+			h.InstanceType, h.InstanceRegion, h.InstanceOwner = spec.typ, spec.region, spec.owner
+			g.Feed(h)
+		}
+		g.Feed(nil)
+		groups := g.Compute()
+		if len(groups) != len(wantGroups) {
+			t.Fatalf("breakdown %d: got %d groups, want %d", breakdown, len(groups), len(wantGroups))
+		}
+		total := 0
+		for i, group := range groups {
+			if group.Key != wantGroups[i].Key {
+				t.Errorf("breakdown %d group %d: key %q, want %q", breakdown, i, group.Key, wantGroups[i].Key)
+			}
+			if _, ok := group.Stats.Metric(MetricVPIP); !ok {
+				t.Errorf("breakdown %d group %q: missing VPIP metric", breakdown, group.Key)
+			}
+			total += group.Stats.TotalHands
+		}
+		if total != len(hands) {
+			t.Errorf("breakdown %d: groups hold %d hands, want %d", breakdown, total, len(hands))
+		}
+	}
+}
+
+func TestTimeAnalyticsSplitsSessions(t *testing.T) {
+	c := NewTimeAnalyticsCalculator(0)
+	// Monday 2026-03-02: 60 hands a minute apart from 21:00, then a second
+	// session after a two hour break.
+	start := time.Date(2026, 3, 2, 21, 0, 0, 0, time.UTC)
+	for i := 0; i < 60; i++ {
+		h := createValidTestHand(0)
+		h.StartTime = start.Add(time.Duration(i) * time.Minute)
+		c.Feed(h)
+	}
+	h := createValidTestHand(0)
+	h.StartTime = start.Add(4 * time.Hour)
+	c.Feed(h)
+	c.Feed(createValidTestHand(0)) // no start time: ignored
+
+	a := c.Compute()
+	if a.Sessions != 2 {
+		t.Errorf("sessions = %d, want 2", a.Sessions)
+	}
+	if got := a.BySessionPhase[SessionPhaseEarly].TotalHands; got != 51 {
+		t.Errorf("early phase hands = %d, want 51", got)
+	}
+	if got := a.BySessionPhase[SessionPhaseMid].TotalHands; got != 10 {
+		t.Errorf("mid phase hands = %d, want 10", got)
+	}
+	if got := a.ByHour[21].TotalHands; got != 60 {
+		t.Errorf("21:00 hands = %d, want 60", got)
+	}
+	if got := a.ByWeekday[time.Tuesday].TotalHands; got != 1 {
+		t.Errorf("tuesday hands = %d, want 1", got)
+	}
+	if a.ByHour[3] == nil || a.ByHour[3].TotalHands != 0 {
+		t.Errorf("empty hour bucket = %+v, want empty stats", a.ByHour[3])
+	}
+}
+
+func TestTiltDetectionAfterBigLoss(t *testing.T) {
+	ic := NewIncrementalCalculator(0)
+	start := time.Date(2026, 3, 2, 20, 0, 0, 0, time.UTC)
+	n := 0
+	feed := func(vpip bool, invested, won int) {
+		h := createValidTestHand(0)
+		h.ID = n + 1
+		h.StartTime = start.Add(time.Duration(n) * time.Minute)
+		h.Players[2] = &parser.PlayerHandInfo{SeatID: 2, Actions: []parser.PlayerAction{
+			{Action: parser.ActionBlindBB, Amount: 20, Street: parser.StreetPreFlop},
+		}}
+		hero := h.Players[0]
+		hero.VPIP, hero.Won, hero.PotWon = vpip, won > 0, won
+		hero.Actions = nil
+		if invested > 0 {
+			hero.Actions = []parser.PlayerAction{{Action: parser.ActionCall, Amount: invested, Street: parser.StreetPreFlop}}
+		}
+		ic.Feed(h)
+		n++
+	}
+	for i := 0; i < 200; i++ {
+		feed(i%5 == 0, 0, 0)
+	}
+	feed(true, 1000, 0) // 50bb loss
+	for i := 0; i < TiltWindowHands; i++ {
+		feed(true, 20, 20)
+	}
+
+	r := ic.Compute().Tilt
+	if r == nil || len(r.Events) != 1 || r.Events[0].Kind != TiltEventBigLoss || r.Events[0].LossBB != 50 {
+		t.Fatalf("tilt events = %+v", r)
+	}
+	if r.AfterHands != TiltWindowHands {
+		t.Errorf("after hands = %d, want %d", r.AfterHands, TiltWindowHands)
+	}
+	if r.Comparisons[0].Metric != MetricVPIP || !r.Comparisons[0].Significant || r.Comparisons[0].Z <= 0 {
+		t.Errorf("VPIP comparison = %+v, want significant increase", r.Comparisons[0])
+	}
+	if r.CurrentEvent == nil || r.CurrentHands != TiltWindowHands || !Drifting(r.Current) {
+		t.Errorf("current session tilt = %+v, %d hands, %+v", r.CurrentEvent, r.CurrentHands, r.Current)
+	}
+
+	// A new session after a break clears the live warning.
+	n += 120
+	feed(false, 0, 0)
+	if r := ic.Compute().Tilt; r.CurrentEvent != nil {
+		t.Errorf("current event survived a new session: %+v", r.CurrentEvent)
+	}
+}
+
+func TestSlidingCalculatorMatchesWindowRecompute(t *testing.T) {
+	hands := make([]*parser.Hand, 0, 30)
+	for i := 0; i < 30; i++ {
+		h := createValidTestHand(0)
+		h.ID = i + 1
+		h.Players[0].VPIP = i%3 == 0
+		h.Players[0].PFR = i%6 == 0
+		hands = append(hands, h)
+	}
+	hands = append(hands, &parser.Hand{ID: 99}) // incomplete: skipped
+
+	c := NewSlidingCalculator(0, 10)
+	for _, h := range hands {
+		c.Feed(h)
+	}
+	if c.Len() != 10 {
+		t.Fatalf("window len = %d, want 10", c.Len())
+	}
+	want := NewCalculator().Calculate(hands[20:30], 0)
+	for _, id := range []MetricID{MetricVPIP, MetricPFR, MetricBBPer100} {
+		got, _ := c.Metric(id)
+		if exp := want.Metrics[id]; got.Count != exp.Count || got.Opportunity != exp.Opportunity || math.Abs(got.Rate-exp.Rate) > 1e-9 {
+			t.Errorf("%s = %+v, want %+v", id, got, exp)
+		}
+	}
+
+	points := MetricTrend(hands, 0, MetricVPIP, 10, 5)
+	if len(points) == 0 || len(points) > 5 {
+		t.Fatalf("trend points = %d, want 1-5", len(points))
+	}
+	if last := points[len(points)-1]; last.Hand != 30 || last.Value.Opportunity != 10 {
+		t.Errorf("last point = %+v, want hand 30 over 10 hands", last)
+	}
+	if first := points[0]; first.Hand < 10 {
+		t.Errorf("first point at hand %d, before the window filled", first.Hand)
+	}
+	if got := MetricTrend(hands[:3], 0, MetricVPIP, 10, 5); len(got) != 1 || got[0].Hand != 3 {
+		t.Errorf("short trend = %+v, want one point at hand 3", got)
+	}
+}
+
+func TestRollupsAddUpToFedHands(t *testing.T) {
+	hands := make([]*parser.Hand, 0, 12)
+	for i := 0; i < 12; i++ {
+		h := createValidTestHand(0)
+		h.ID = i + 1
+		h.NumPlayers = 2 + i%6
+		h.Players[0].Position = parser.Position(i % 4)
+		h.Players[0].PFR = i%3 == 0
+		hands = append(hands, h)
+	}
+
+	want := NewIncrementalCalculator(0)
+	for _, h := range hands {
+		want.Feed(h)
+	}
+
+	// One rollup per table size, round-tripped through JSON like the
+	// persisted ones.
+	parts := make(map[TableSize]*IncrementalCalculator)
+	for _, h := range hands {
+		size := TableSizeForPlayers(h.NumPlayers)
+		if parts[size] == nil {
+			parts[size] = newIncrementalCalculator(0)
+		}
+		parts[size].Feed(h)
+	}
+	got := NewIncrementalCalculator(0)
+	for size, part := range parts {
+		data, err := json.Marshal(part.Rollup())
+		if err != nil {
+			t.Fatalf("marshal rollup: %v", err)
+		}
+		var r Rollup
+		if err := json.Unmarshal(data, &r); err != nil {
+			t.Fatalf("unmarshal rollup: %v", err)
+		}
+		got.AddRollup(size, &r)
+	}
+
+	gotStats, wantStats := got.Compute(), want.Compute()
+	if gotStats.Tilt != nil {
+		t.Errorf("calculator with rollups reported tilt: %+v", gotStats.Tilt)
+	}
+	wantStats.Tilt = nil
+	if !reflect.DeepEqual(gotStats, wantStats) {
+		t.Errorf("stats from rollups differ from fed hands:\n got %+v\nwant %+v", gotStats, wantStats)
+	}
+}
+
+func TestCalculatorStateRestoreAndMerge(t *testing.T) {
+	start := time.Date(2026, 3, 2, 20, 0, 0, 0, time.UTC)
+	hands := make([]*parser.Hand, 0, 90)
+	for i := 0; i < 90; i++ {
+		h := createValidTestHand(0)
+		h.ID = i + 1
+		h.StartTime = start.Add(time.Duration(i) * time.Minute)
+		h.NumPlayers = 2 + i%6
+		h.Players[2] = &parser.PlayerHandInfo{SeatID: 2, Actions: []parser.PlayerAction{
+			{Action: parser.ActionBlindBB, Amount: 20, Street: parser.StreetPreFlop},
+		}}
+		hero := h.Players[0]
+		hero.VPIP = i%3 == 0
+		hero.Actions = nil
+		if i == 40 {
+			hero.Actions = []parser.PlayerAction{{Action: parser.ActionCall, Amount: 1000, Street: parser.StreetPreFlop}}
+			hero.Won, hero.PotWon = false, 0
+		}
+		hands = append(hands, h)
+	}
+	feed := func(hs []*parser.Hand) *IncrementalCalculator {
+		ic := NewIncrementalCalculator(0)
+		for _, h := range hs {
+			ic.Feed(h)
+		}
+		return ic
+	}
+	roundTrip := func(st *CalculatorState) *CalculatorState {
+		t.Helper()
+		data, err := json.Marshal(st)
+		if err != nil {
+			t.Fatalf("marshal state: %v", err)
+		}
+		var out CalculatorState
+		if err := json.Unmarshal(data, &out); err != nil {
+			t.Fatalf("unmarshal state: %v", err)
+		}
+		return &out
+	}
+	want := feed(hands).Compute()
+
+	// Restoring mid-stream and feeding the rest matches feeding everything,
+	// tilt detection included.
+	restored, err := RestoreIncrementalCalculator(roundTrip(feed(hands[:45]).State()))
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	for _, h := range hands[45:] {
+		restored.Feed(h)
+	}
+	if got := restored.Compute(); !reflect.DeepEqual(got, want) {
+		t.Errorf("restored calculator differs:\n got %+v\nwant %+v", got, want)
+	}
+	if want.Tilt == nil || len(want.Tilt.Events) != 1 {
+		t.Fatalf("tilt events = %+v, want one", want.Tilt)
+	}
+
+	// Adding shard states gives the same counters in any grouping.
+	a, b, c := feed(hands[:30]).State(), feed(hands[30:60]).State(), feed(hands[60:]).State()
+	left := roundTrip(a)
+	if err := left.Add(b); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if err := left.Add(c); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	right := roundTrip(b)
+	if err := right.Add(c); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if err := a.Add(right); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if !reflect.DeepEqual(left, a) {
+		t.Errorf("state addition is not associative")
+	}
+	merged, err := RestoreIncrementalCalculator(left)
+	if err != nil {
+		t.Fatalf("restore merged: %v", err)
+	}
+	got := merged.Compute()
+	if got.Tilt != nil {
+		t.Errorf("merged state reported tilt: %+v", got.Tilt)
+	}
+	want.Tilt = nil
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("merged state differs:\n got %+v\nwant %+v", got, want)
+	}
+
+	other := NewIncrementalCalculator(3).State()
+	if err := left.Add(other); err == nil {
+		t.Errorf("adding a state of another local seat succeeded")
+	}
+	other.Version = RollupVersion + 1
+	if _, err := RestoreIncrementalCalculator(other); err == nil {
+		t.Errorf("restoring a state of another version succeeded")
+	}
+}
+
+func TestIncrementalCalculatorInsertAndRetract(t *testing.T) {
+	hands := make([]*parser.Hand, 0, 30)
+	for i := 0; i < 30; i++ {
+		h := createValidTestHand(0)
+		h.ID = i + 1
+		h.NumPlayers = 2 + i%6
+		h.Players[0].Position = parser.Position(i % 5)
+		h.Players[0].VPIP = i%2 == 0
+		hands = append(hands, h)
+	}
+	want := NewIncrementalCalculator(0)
+	for _, h := range hands {
+		want.Feed(h)
+	}
+	wantStats := want.Compute()
+	wantStats.Tilt = nil
+
+	// Newer hands fed first, the older ones inserted afterwards, and one
+	// hand first stored with other contents and then rewritten.
+	ic := NewIncrementalCalculator(0)
+	for _, h := range hands[10:] {
+		ic.Feed(h)
+	}
+	stale := createValidTestHand(0)
+	stale.NumPlayers = 7
+	stale.Players[0].Position = parser.PosBB
+	stale.Players[0].PFR = true
+	ic.Insert(stale)
+	for _, h := range hands[:10] {
+		ic.Insert(h)
+	}
+	ic.Retract(stale)
+
+	got := ic.Compute()
+	got.Tilt = nil
+	if !reflect.DeepEqual(got, wantStats) {
+		t.Errorf("stats after insert and retract differ:\n got %+v\nwant %+v", got, wantStats)
+	}
+}
+
 func TestClonePositionStatsEmpty(t *testing.T) {
 	original := make(map[parser.Position]*PositionStats)
 	cloned := clonePositionStats(original)
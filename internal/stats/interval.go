@@ -0,0 +1,133 @@
+package stats
+
+import "math"
+
+// significanceZ is the two-sided 95% critical value of the standard normal
+// distribution. It is used for every interval and significance test.
+const significanceZ = 1.959964
+
+// setInterval fills the 95% interval and standard error of v. Rate metrics
+// use the Wilson score interval; AF uses the Wilson interval of the
+// aggressive share of bets, raises and calls, mapped back to a ratio; bb/100
+// uses a normal interval from the per-hand variance.
+func (m *metricAccumulator) setInterval(v *MetricValue) {
+	if v.Opportunity <= 0 {
+		return
+	}
+	n := float64(v.Opportunity)
+	switch v.Format {
+	case MetricFormatBBPer100:
+		if m.bbHands < 2 {
+			v.Low, v.High = v.Rate, v.Rate
+			return
+		}
+		mean := m.bbNet / float64(m.bbHands)
+		variance := (m.bbNetSq - float64(m.bbHands)*mean*mean) / float64(m.bbHands-1)
+		if variance < 0 {
+			variance = 0
+		}
+		v.StdErr = math.Sqrt(variance/float64(m.bbHands)) * 100
+		v.Low = v.Rate - significanceZ*v.StdErr
+		v.High = v.Rate + significanceZ*v.StdErr
+	case MetricFormatRatio:
+		lo, hi := wilsonInterval(v.Count, v.Opportunity)
+		v.Low = shareToRatio(lo)
+		v.High = shareToRatio(hi)
+	default:
+		p := clampUnit(float64(v.Count) / n)
+		lo, hi := wilsonInterval(v.Count, v.Opportunity)
+		v.Low, v.High = lo*100, hi*100
+		v.StdErr = math.Sqrt(p*(1-p)/n) * 100
+	}
+}
+
+// wilsonInterval returns the 95% Wilson score interval of the proportion
+// count/n.
+func wilsonInterval(count, n int) (lo, hi float64) {
+	if n <= 0 {
+		return 0, 0
+	}
+	nf := float64(n)
+	p := clampUnit(float64(count) / nf)
+	z2 := significanceZ * significanceZ
+	denom := 1 + z2/nf
+	center := (p + z2/(2*nf)) / denom
+	half := significanceZ * math.Sqrt(p*(1-p)/nf+z2/(4*nf*nf)) / denom
+	return math.Max(0, center-half), math.Min(1, center+half)
+}
+
+// shareToRatio converts the aggressive share a/(a+c) to the ratio a/c. A
+// share of one, which has no finite ratio, maps to +Inf.
+func shareToRatio(p float64) float64 {
+	if p >= 1 {
+		return math.Inf(1)
+	}
+	return p / (1 - p)
+}
+
+func clampUnit(p float64) float64 {
+	return math.Max(0, math.Min(1, p))
+}
+
+// MetricComparison tells whether a metric differs significantly between two
+// samples.
+type MetricComparison struct {
+	ID MetricID
+	// Diff is the rate of the first sample minus the rate of the second.
+	Diff float64
+	// Z is the test statistic: a two-proportion z-test for rate metrics and
+	// AF, and a z-test on the standard errors for bb/100. It is zero when
+	// either sample is empty.
+	Z           float64
+	Significant bool
+}
+
+// CompareMetric tests whether a differs significantly from b at the 95%
+// level. Both values must be of the same metric.
+func CompareMetric(a, b MetricValue) MetricComparison {
+	out := MetricComparison{ID: a.ID, Diff: a.Rate - b.Rate}
+	if a.Opportunity <= 0 || b.Opportunity <= 0 {
+		return out
+	}
+	if a.Format == MetricFormatBBPer100 {
+		se := math.Sqrt(a.StdErr*a.StdErr + b.StdErr*b.StdErr)
+		if se > 0 {
+			out.Z = out.Diff / se
+		}
+	} else {
+		out.Z = twoProportionZ(a.Count, a.Opportunity, b.Count, b.Opportunity)
+	}
+	out.Significant = math.Abs(out.Z) >= significanceZ
+	return out
+}
+
+// CompareStats compares every registry metric of a with b, in registry
+// order. Metrics missing from either side are skipped.
+func CompareStats(a, b *Stats) []MetricComparison {
+	out := make([]MetricComparison, 0, len(metricRegistry))
+	for _, def := range metricRegistry {
+		va, okA := a.Metric(def.ID)
+		vb, okB := b.Metric(def.ID)
+		if !okA || !okB {
+			continue
+		}
+		out = append(out, CompareMetric(va, vb))
+	}
+	return out
+}
+
+// twoProportionZ returns the pooled two-proportion z statistic of x1/n1
+// against x2/n2, or zero when it is undefined.
+func twoProportionZ(x1, n1, x2, n2 int) float64 {
+	if n1 <= 0 || n2 <= 0 {
+		return 0
+	}
+	p1 := clampUnit(float64(x1) / float64(n1))
+	p2 := clampUnit(float64(x2) / float64(n2))
+	p := clampUnit(float64(x1+x2) / float64(n1+n2))
+	se := math.Sqrt(p * (1 - p) * (1/float64(n1) + 1/float64(n2)))
+	if se == 0 {
+		return 0
+	}
+	return (p1 - p2) / se
+}
@@ -0,0 +1,96 @@
+package stats
+
+import (
+	"math"
+	"testing"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
+)
+
+func TestWilsonInterval(t *testing.T) {
+	lo, hi := wilsonInterval(20, 100)
+	// Reference values for 20/100 at 95%.
+	if math.Abs(lo-0.1333) > 0.001 || math.Abs(hi-0.2888) > 0.001 {
+		t.Errorf("wilson(20/100) = [%.4f, %.4f], want [0.1333, 0.2888]", lo, hi)
+	}
+	if lo, hi := wilsonInterval(0, 10); lo != 0 || hi <= 0 {
+		t.Errorf("wilson(0/10) = [%.4f, %.4f], want lower bound 0 and positive upper bound", lo, hi)
+	}
+	if lo, hi := wilsonInterval(0, 0); lo != 0 || hi != 0 {
+		t.Errorf("wilson(0/0) = [%.4f, %.4f], want empty", lo, hi)
+	}
+}
+
+func TestMetricIntervalsAndComparison(t *testing.T) {
+	m := newMetricAccumulator()
+	m.counts[MetricVPIP], m.opps[MetricVPIP] = 25, 100
+	m.aggPostflop, m.callPostflop = 30, 10
+	for _, net := range []float64{10, -5, 2, -3} {
+		m.bbNet += net
+		m.bbNetSq += net * net
+		m.bbHands++
+	}
+	s := &Stats{Metrics: make(map[MetricID]MetricValue)}
+	m.finalize(s)
+
+	vpip := s.Metrics[MetricVPIP]
+	if !(vpip.Low < vpip.Rate && vpip.Rate < vpip.High) || vpip.StdErr <= 0 {
+		t.Errorf("VPIP interval = [%.2f, %.2f] around %.2f, se %.2f", vpip.Low, vpip.High, vpip.Rate, vpip.StdErr)
+	}
+	af := s.Metrics[MetricAF]
+	if !(af.Low < af.Rate && af.Rate < af.High) {
+		t.Errorf("AF interval = [%.2f, %.2f] around %.2f", af.Low, af.High, af.Rate)
+	}
+	bb := s.Metrics[MetricBBPer100]
+	// Sample sd of {10,-5,2,-3} is 6.6833; se = sd/2 * 100.
+	if math.Abs(bb.StdErr-334.17) > 0.1 || math.Abs(bb.High-bb.Rate-significanceZ*bb.StdErr) > 1e-9 {
+		t.Errorf("bb/100 se = %.2f, interval [%.2f, %.2f] around %.2f", bb.StdErr, bb.Low, bb.High, bb.Rate)
+	}
+
+	other := vpip
+	other.Count, other.Opportunity, other.Rate = 45, 100, 45
+	if c := CompareMetric(other, vpip); !c.Significant || c.Diff != 20 || c.Z <= 0 {
+		t.Errorf("VPIP 45%% vs 25%% = %+v, want significant increase", c)
+	}
+	other.Count, other.Rate = 28, 28
+	if c := CompareMetric(other, vpip); c.Significant {
+		t.Errorf("VPIP 28%% vs 25%% = %+v, want not significant", c)
+	}
+	if c := CompareMetric(bb, bb); c.Significant || c.Z != 0 {
+		t.Errorf("bb/100 against itself = %+v", c)
+	}
+	if got := CompareStats(s, s); len(got) != len(metricRegistry) {
+		t.Errorf("CompareStats returned %d comparisons, want %d", len(got), len(metricRegistry))
+	}
+}
+
+func TestCompareStatsSamples(t *testing.T) {
+	a := newIncrementalCalculator(-1).Compute()
+	b := newIncrementalCalculator(-1).Compute()
+	a.ByPosition = map[parser.Position]*PositionStats{parser.PosBTN: {Hands: 200, VPIP: 40, PFR: 30}}
+	b.ByPosition = map[parser.Position]*PositionStats{
+		parser.PosBTN: {Hands: 200, VPIP: 90, PFR: 32},
+		parser.PosCO:  {Hands: 10, VPIP: 3},
+	}
+	a.HandRange.Cells[0][0].Dealt = 10
+	b.HandRange.Cells[0][0].Dealt = 10
+	b.HandRange.Cells[0][0].Actions[RangeActionFold] = 5
+
+	c := Compare(a, b)
+	btn := c.Positions[parser.PosBTN]
+	if btn.VPIP.A != 20 || btn.VPIP.B != 45 || btn.VPIP.Diff != 25 || !btn.VPIP.Significant {
+		t.Errorf("BTN VPIP = %+v, want significant 20%% -> 45%%", btn.VPIP)
+	}
+	if btn.PFR.Significant {
+		t.Errorf("BTN PFR = %+v, want not significant", btn.PFR)
+	}
+	if co, ok := c.Positions[parser.PosCO]; !ok || co.VPIP.NA != 0 || co.VPIP.B != 30 {
+		t.Errorf("CO = %+v, ok %v, want position only in B", co, ok)
+	}
+	if aa := c.HandRange[0][0]; aa.A != 100 || aa.B != 50 || aa.Diff != -50 {
+		t.Errorf("AA play rate = %+v, want 100%% -> 50%%", aa)
+	}
+	if len(c.Metrics) != len(metricRegistry) {
+		t.Errorf("Compare returned %d metric comparisons, want %d", len(c.Metrics), len(metricRegistry))
+	}
+}
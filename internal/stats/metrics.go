@@ -61,6 +61,13 @@ type MetricValue struct {
 	Confident   bool
 	MinSample   int
 	Format      MetricFormat
+	// Low and High bound the 95% confidence interval of Rate, in the same
+	// unit. Both are zero without opportunities.
+	Low  float64
+	High float64
+	// StdErr is the standard error of Rate for percentage metrics and bb/100.
+	// It is zero for AF, whose interval is derived from the aggressive share.
+	StdErr float64
 }
 
 const (
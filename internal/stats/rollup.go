@@ -0,0 +1,299 @@
+package stats
+
+import (
+	"slices"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
+)
+
+// RollupVersion is the version of the Rollup and CalculatorState layouts and
+// of the counting rules behind them. Persisted state of another version must
+// be rebuilt from the hands.
+const RollupVersion = 2
+
+// Rollup is the mergeable counter state of a set of hands: the legacy
+// totals, the position stats, the range cells and the metric accumulator.
+// Adding the rollups of disjoint hand sets gives the same Stats as feeding
+// all the hands, except for tilt detection, which needs the hands in order.
+type Rollup struct {
+	TotalHands              int `json:"total_hands"`
+	WonHands                int `json:"won_hands"`
+	ShowdownHands           int `json:"showdown_hands"`
+	WonShowdowns            int `json:"won_showdowns"`
+	VPIPHands               int `json:"vpip_hands"`
+	PFRHands                int `json:"pfr_hands"`
+	ThreeBetHands           int `json:"three_bet_hands"`
+	ThreeBetOpportunities   int `json:"three_bet_opportunities"`
+	FoldTo3BetHands         int `json:"fold_to_3bet_hands"`
+	FoldTo3BetOpportunities int `json:"fold_to_3bet_opportunities"`
+	TotalPotWon             int `json:"total_pot_won"`
+	TotalInvested           int `json:"total_invested"`
+
+	Positions []PositionStats `json:"positions,omitempty"`
+	// Cells holds the range cells that were dealt at least once.
+	Cells        []RollupCell                `json:"cells,omitempty"`
+	TotalActions [RangeActionBucketCount]int `json:"total_actions"`
+	HandClasses  map[string]HandClassStats   `json:"hand_classes,omitempty"`
+	Metrics      RollupMetrics               `json:"metrics"`
+}
+
+// RollupCell is one range grid cell of a Rollup.
+type RollupCell struct {
+	Row         int                                       `json:"row"`
+	Col         int                                       `json:"col"`
+	Dealt       int                                       `json:"dealt"`
+	Actions     [RangeActionBucketCount]int               `json:"actions"`
+	Won         int                                       `json:"won"`
+	ByPosition  map[parser.Position]HandRangePositionCell `json:"by_position,omitempty"`
+	ByHandClass map[string]HandClassStats                 `json:"by_hand_class,omitempty"`
+}
+
+// RollupMetrics is the metric accumulator state of a Rollup.
+type RollupMetrics struct {
+	Counts       map[MetricID]int `json:"counts,omitempty"`
+	Opps         map[MetricID]int `json:"opps,omitempty"`
+	AggPostflop  int              `json:"agg_postflop"`
+	CallPostflop int              `json:"call_postflop"`
+	FoldPostflop int              `json:"fold_postflop"`
+	BBNet        float64          `json:"bb_net"`
+	BBNetSq      float64          `json:"bb_net_sq"`
+	BBHands      int              `json:"bb_hands"`
+}
+
+// Rollup returns the counter state of the hands fed so far. The per-size
+// split and tilt detection are not included.
+func (ic *IncrementalCalculator) Rollup() *Rollup {
+	s := ic.s
+	r := &Rollup{
+		TotalHands:              s.TotalHands,
+		WonHands:                s.WonHands,
+		ShowdownHands:           s.ShowdownHands,
+		WonShowdowns:            s.WonShowdowns,
+		VPIPHands:               s.VPIPHands,
+		PFRHands:                s.PFRHands,
+		ThreeBetHands:           s.ThreeBetHands,
+		ThreeBetOpportunities:   s.ThreeBetOpportunities,
+		FoldTo3BetHands:         s.FoldTo3BetHands,
+		FoldTo3BetOpportunities: s.FoldTo3BetOpportunities,
+		TotalPotWon:             s.TotalPotWon,
+		TotalInvested:           s.TotalInvested,
+		TotalActions:            s.HandRange.TotalActions,
+		HandClasses:             copyHandClasses(s.HandRange.ByHandClass),
+		Metrics:                 rollupMetricsOf(ic.ma),
+	}
+	for _, pos := range sortedPositions(s.ByPosition) {
+		r.Positions = append(r.Positions, *s.ByPosition[pos])
+	}
+	for row := 0; row < 13; row++ {
+		for col := 0; col < 13; col++ {
+			cell := s.HandRange.Cells[row][col]
+			if cell == nil || cell.Dealt == 0 {
+				continue
+			}
+			rc := RollupCell{
+				Row:         row,
+				Col:         col,
+				Dealt:       cell.Dealt,
+				Actions:     cell.Actions,
+				Won:         cell.Won,
+				ByHandClass: copyHandClasses(cell.ByHandClass),
+			}
+			if len(cell.ByPosition) > 0 {
+				rc.ByPosition = make(map[parser.Position]HandRangePositionCell, len(cell.ByPosition))
+				for pos, pc := range cell.ByPosition {
+					if pc != nil {
+						rc.ByPosition[pos] = *pc
+					}
+				}
+			}
+			r.Cells = append(r.Cells, rc)
+		}
+	}
+	return r
+}
+
+// AddRollup adds the hands summarized by r, all of table size size, as if
+// they had been fed. Rollups carry no hand order, so a calculator that added
+// one reports no tilt.
+func (ic *IncrementalCalculator) AddRollup(size TableSize, r *Rollup) {
+	if r == nil {
+		return
+	}
+	ic.addRollup(r, 1)
+	ic.tilt = nil
+	if ic.bySize != nil && size != TableSizeAny {
+		sub, ok := ic.bySize[size]
+		if !ok {
+			sub = newIncrementalCalculator(ic.localSeat)
+			ic.bySize[size] = sub
+		}
+		sub.addRollup(r, 1)
+	}
+}
+
+// addRollup adds r to the calculator, or subtracts it when sign is negative.
+// Subtracting drops the entries left empty, so retracting every hand gives
+// back the state of a calculator that never saw them.
+func (ic *IncrementalCalculator) addRollup(r *Rollup, sign int) {
+	if sign < 0 {
+		sign = -1
+	} else {
+		sign = 1
+	}
+	s := ic.s
+	s.TotalHands += sign * r.TotalHands
+	s.WonHands += sign * r.WonHands
+	s.ShowdownHands += sign * r.ShowdownHands
+	s.WonShowdowns += sign * r.WonShowdowns
+	s.VPIPHands += sign * r.VPIPHands
+	s.PFRHands += sign * r.PFRHands
+	s.ThreeBetHands += sign * r.ThreeBetHands
+	s.ThreeBetOpportunities += sign * r.ThreeBetOpportunities
+	s.FoldTo3BetHands += sign * r.FoldTo3BetHands
+	s.FoldTo3BetOpportunities += sign * r.FoldTo3BetOpportunities
+	s.TotalPotWon += sign * r.TotalPotWon
+	s.TotalInvested += sign * r.TotalInvested
+
+	for _, p := range r.Positions {
+		ps := ic.calc.ensurePositionStats(s, p.Position)
+		ps.Hands += sign * p.Hands
+		ps.Won += sign * p.Won
+		ps.VPIP += sign * p.VPIP
+		ps.PFR += sign * p.PFR
+		ps.ThreeBet += sign * p.ThreeBet
+		ps.ThreeBetOpp += sign * p.ThreeBetOpp
+		ps.FoldTo3Bet += sign * p.FoldTo3Bet
+		ps.FoldTo3BetOpp += sign * p.FoldTo3BetOpp
+		ps.Showdowns += sign * p.Showdowns
+		ps.WonShowdowns += sign * p.WonShowdowns
+		ps.PotWon += sign * p.PotWon
+		ps.Invested += sign * p.Invested
+		if ps.Hands == 0 {
+			delete(s.ByPosition, p.Position)
+		}
+	}
+
+	table := s.HandRange
+	for i, v := range r.TotalActions {
+		table.TotalActions[i] += sign * v
+	}
+	addHandClasses(table.ByHandClass, r.HandClasses, sign)
+	for _, rc := range r.Cells {
+		if rc.Row < 0 || rc.Row >= 13 || rc.Col < 0 || rc.Col >= 13 {
+			continue
+		}
+		cell := table.Cells[rc.Row][rc.Col]
+		cell.Dealt += sign * rc.Dealt
+		cell.Won += sign * rc.Won
+		for i, v := range rc.Actions {
+			cell.Actions[i] += sign * v
+		}
+		// Like updateHandRange, a dealt cell has both maps.
+		if cell.ByPosition == nil {
+			cell.ByPosition = make(map[parser.Position]*HandRangePositionCell)
+		}
+		if cell.ByHandClass == nil {
+			cell.ByHandClass = make(map[string]*HandClassStats)
+		}
+		for pos, pc := range rc.ByPosition {
+			dst := cell.ByPosition[pos]
+			if dst == nil {
+				dst = &HandRangePositionCell{}
+				cell.ByPosition[pos] = dst
+			}
+			dst.Dealt += sign * pc.Dealt
+			dst.Won += sign * pc.Won
+			for i, v := range pc.Actions {
+				dst.Actions[i] += sign * v
+			}
+			if dst.Dealt == 0 {
+				delete(cell.ByPosition, pos)
+			}
+		}
+		addHandClasses(cell.ByHandClass, rc.ByHandClass, sign)
+		if cell.Dealt == 0 {
+			cell.ByPosition = nil
+			cell.ByHandClass = nil
+		}
+	}
+
+	ic.ma.merge(r.Metrics.accumulator(), sign)
+}
+
+func rollupMetricsOf(ma *metricAccumulator) RollupMetrics {
+	return RollupMetrics{
+		Counts:       copyMetricCounts(ma.counts),
+		Opps:         copyMetricCounts(ma.opps),
+		AggPostflop:  ma.aggPostflop,
+		CallPostflop: ma.callPostflop,
+		FoldPostflop: ma.foldPostflop,
+		BBNet:        ma.bbNet,
+		BBNetSq:      ma.bbNetSq,
+		BBHands:      ma.bbHands,
+	}
+}
+
+// accumulator returns a new accumulator holding m.
+func (m RollupMetrics) accumulator() *metricAccumulator {
+	ma := newMetricAccumulator()
+	ma.merge(&metricAccumulator{
+		counts:       m.Counts,
+		opps:         m.Opps,
+		aggPostflop:  m.AggPostflop,
+		callPostflop: m.CallPostflop,
+		foldPostflop: m.FoldPostflop,
+		bbNet:        m.BBNet,
+		bbNetSq:      m.BBNetSq,
+		bbHands:      m.BBHands,
+	}, 1)
+	return ma
+}
+
+func addHandClasses(dst map[string]*HandClassStats, src map[string]HandClassStats, sign int) {
+	for name, hcs := range src {
+		d := dst[name]
+		if d == nil {
+			d = &HandClassStats{}
+			dst[name] = d
+		}
+		d.Hands += sign * hcs.Hands
+		for i, v := range hcs.Actions {
+			d.Actions[i] += sign * v
+		}
+		if d.Hands == 0 {
+			delete(dst, name)
+		}
+	}
+}
+
+func copyHandClasses(in map[string]*HandClassStats) map[string]HandClassStats {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make(map[string]HandClassStats, len(in))
+	for name, hcs := range in {
+		if hcs != nil {
+			out[name] = *hcs
+		}
+	}
+	return out
+}
+
+func copyMetricCounts(in map[MetricID]int) map[MetricID]int {
+	out := make(map[MetricID]int, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
+func sortedPositions(in map[parser.Position]*PositionStats) []parser.Position {
+	out := make([]parser.Position, 0, len(in))
+	for pos, ps := range in {
+		if ps != nil {
+			out = append(out, pos)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
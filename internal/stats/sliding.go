@@ -0,0 +1,126 @@
+package stats
+
+import (
+	"time"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
+)
+
+// SlidingCalculator keeps the registry metrics of the last Window eligible
+// hands. Each hand's contribution is kept so it can be subtracted again when
+// the hand leaves the window; a rolling trend over n hands therefore costs
+// O(n) instead of recomputing every window from scratch.
+type SlidingCalculator struct {
+	localSeat int
+	window    int
+	calc      *Calculator
+	ma        *metricAccumulator
+	// hands is a ring buffer of the contributions inside the window; head
+	// is the oldest one.
+	hands []*metricAccumulator
+	head  int
+}
+
+// NewSlidingCalculator creates a sliding calculator over the last window
+// hands of the player at localSeat. A window below one is treated as one.
+func NewSlidingCalculator(localSeat, window int) *SlidingCalculator {
+	if window < 1 {
+		window = 1
+	}
+	return &SlidingCalculator{
+		localSeat: localSeat,
+		window:    window,
+		calc:      NewCalculator(),
+		ma:        newMetricAccumulator(),
+		hands:     make([]*metricAccumulator, 0, window),
+	}
+}
+
+// Feed adds a hand to the window, removing the oldest hand once the window
+// is full. It reports whether the hand was eligible; other hands are
+// skipped like in IncrementalCalculator.
+func (c *SlidingCalculator) Feed(h *parser.Hand) bool {
+	pi, seat, ok := localPlayer(h, c.localSeat)
+	if !ok {
+		return false
+	}
+	delta := newMetricAccumulator()
+	delta.consumeHand(h, pi, c.calc.investedAmount(h, seat))
+
+	if len(c.hands) < c.window {
+		c.hands = append(c.hands, delta)
+	} else {
+		c.ma.merge(c.hands[c.head], -1)
+		c.hands[c.head] = delta
+		c.head = (c.head + 1) % c.window
+	}
+	c.ma.merge(delta, 1)
+	return true
+}
+
+// Len returns the number of hands currently inside the window.
+func (c *SlidingCalculator) Len() int {
+	return len(c.hands)
+}
+
+// Metric returns the value of a registry metric over the hands in the window.
+func (c *SlidingCalculator) Metric(id MetricID) (MetricValue, bool) {
+	v, ok := finalizedMetrics(c.ma)[id]
+	return v, ok
+}
+
+// TrendPoint is the value of a metric over the window ending at one hand.
+type TrendPoint struct {
+	// Hand is the 1-based index of the window's last hand among the
+	// eligible hands, and Time its start time.
+	Hand  int
+	Time  time.Time
+	Value MetricValue
+}
+
+// MetricTrend computes a registry metric over a rolling window of the last
+// window eligible hands. Hands must be in chronological order. At most
+// maxPoints points are returned, evenly spaced and always including the
+// last hand; points start once the window is full, or at the last hand when
+// there are fewer hands than the window.
+func MetricTrend(hands []*parser.Hand, localSeat int, id MetricID, window, maxPoints int) []TrendPoint {
+	total := 0
+	for _, h := range hands {
+		if _, _, ok := localPlayer(h, localSeat); ok {
+			total++
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	if maxPoints < 1 {
+		maxPoints = 1
+	}
+	first := window
+	if first > total {
+		first = total
+	}
+	step := (total - first + maxPoints) / maxPoints
+	if step < 1 {
+		step = 1
+	}
+
+	c := NewSlidingCalculator(localSeat, window)
+	out := make([]TrendPoint, 0, maxPoints)
+	n := 0
+	for _, h := range hands {
+		if !c.Feed(h) {
+			continue
+		}
+		n++
+		if n < first || ((total-n)%step != 0) {
+			continue
+		}
+		v, ok := c.Metric(id)
+		if !ok {
+			return nil
+		}
+		out = append(out, TrendPoint{Hand: n, Time: h.StartTime, Value: v})
+	}
+	return out
+}
@@ -0,0 +1,208 @@
+package stats
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
+)
+
+// CalculatorState is the serializable state of an IncrementalCalculator.
+// States of disjoint hand sets can be added up, so a calculator can be
+// restored instead of re-fed and shards can be computed in parallel.
+type CalculatorState struct {
+	// Version is the RollupVersion the state was written with.
+	Version   int                   `json:"version"`
+	LocalSeat int                   `json:"local_seat"`
+	Total     Rollup                `json:"total"`
+	BySize    map[TableSize]*Rollup `json:"by_size,omitempty"`
+	// Tilt is the tilt detector state. Loss events depend on the order of
+	// every hand, so it is nil once states were merged.
+	Tilt *TiltState `json:"tilt,omitempty"`
+}
+
+// TiltState is the serializable state of tilt detection.
+type TiltState struct {
+	Baseline     RollupMetrics  `json:"baseline"`
+	After        RollupMetrics  `json:"after"`
+	Current      *RollupMetrics `json:"current,omitempty"`
+	Events       []TiltEvent    `json:"events,omitempty"`
+	AfterHands   int            `json:"after_hands"`
+	WindowLeft   int            `json:"window_left"`
+	RecentNet    []float64      `json:"recent_net,omitempty"`
+	LastStart    time.Time      `json:"last_start"`
+	SessionStart time.Time      `json:"session_start"`
+	CurrentEvent *TiltEvent     `json:"current_event,omitempty"`
+	CurrentHands int            `json:"current_hands"`
+}
+
+// State returns the state of the hands fed so far.
+func (ic *IncrementalCalculator) State() *CalculatorState {
+	st := &CalculatorState{
+		Version:   RollupVersion,
+		LocalSeat: ic.localSeat,
+		Total:     *ic.Rollup(),
+	}
+	if len(ic.bySize) > 0 {
+		st.BySize = make(map[TableSize]*Rollup, len(ic.bySize))
+		for size, sub := range ic.bySize {
+			st.BySize[size] = sub.Rollup()
+		}
+	}
+	if ic.tilt != nil {
+		st.Tilt = ic.tilt.state()
+	}
+	return st
+}
+
+// RestoreIncrementalCalculator returns a calculator holding st, ready to be
+// fed the hands that follow it.
+func RestoreIncrementalCalculator(st *CalculatorState) (*IncrementalCalculator, error) {
+	if st == nil {
+		return nil, fmt.Errorf("restore calculator: no state")
+	}
+	if st.Version != RollupVersion {
+		return nil, fmt.Errorf("restore calculator: state version %d, want %d", st.Version, RollupVersion)
+	}
+	ic := NewIncrementalCalculator(st.LocalSeat)
+	ic.addRollup(&st.Total, 1)
+	for size, r := range st.BySize {
+		if r == nil || size == TableSizeAny {
+			continue
+		}
+		sub := newIncrementalCalculator(st.LocalSeat)
+		sub.addRollup(r, 1)
+		ic.bySize[size] = sub
+	}
+	ic.tilt = nil
+	if st.Tilt != nil {
+		ic.tilt = restoreTiltDetector(st.Tilt)
+	}
+	return ic, nil
+}
+
+// Merge adds the hands fed to o to ic. Merged calculators report no tilt.
+func (ic *IncrementalCalculator) Merge(o *IncrementalCalculator) {
+	if o == nil {
+		return
+	}
+	ic.addRollup(o.Rollup(), 1)
+	ic.tilt = nil
+	if ic.bySize == nil {
+		return
+	}
+	for size, osub := range o.bySize {
+		sub, ok := ic.bySize[size]
+		if !ok {
+			sub = newIncrementalCalculator(ic.localSeat)
+			ic.bySize[size] = sub
+		}
+		sub.addRollup(osub.Rollup(), 1)
+	}
+}
+
+// Insert adds a hand that starts before hands already fed, for instance
+// from an older log imported late. Its counters are added as by Feed, but
+// tilt detection, which needs the hands in order, does not see it.
+func (ic *IncrementalCalculator) Insert(h *parser.Hand) {
+	ic.applyHand(h, 1)
+}
+
+// Retract removes a hand that was fed or inserted before, such as the
+// stored version of a hand being written again. Tilt detection keeps it.
+func (ic *IncrementalCalculator) Retract(h *parser.Hand) {
+	ic.applyHand(h, -1)
+}
+
+func (ic *IncrementalCalculator) applyHand(h *parser.Hand, sign int) {
+	delta := newIncrementalCalculator(ic.localSeat)
+	delta.Feed(h)
+	if delta.s.TotalHands == 0 {
+		return
+	}
+	r := delta.Rollup()
+	ic.addRollup(r, sign)
+	if ic.bySize == nil {
+		return
+	}
+	size := TableSizeForPlayers(h.NumPlayers)
+	if size == TableSizeAny {
+		return
+	}
+	sub, ok := ic.bySize[size]
+	if !ok {
+		sub = newIncrementalCalculator(ic.localSeat)
+		ic.bySize[size] = sub
+	}
+	sub.addRollup(r, sign)
+	if sub.s.TotalHands == 0 {
+		delete(ic.bySize, size)
+	}
+}
+
+// Add adds the hands of o to st. Both states must have the same version and
+// local seat. Add is associative; the result carries no tilt state.
+func (st *CalculatorState) Add(o *CalculatorState) error {
+	if o == nil {
+		return nil
+	}
+	if st.LocalSeat != o.LocalSeat {
+		return fmt.Errorf("add calculator state: local seat %d and %d differ", st.LocalSeat, o.LocalSeat)
+	}
+	ic, err := RestoreIncrementalCalculator(st)
+	if err != nil {
+		return err
+	}
+	other, err := RestoreIncrementalCalculator(o)
+	if err != nil {
+		return err
+	}
+	ic.Merge(other)
+	*st = *ic.State()
+	return nil
+}
+
+func (d *tiltDetector) state() *TiltState {
+	st := &TiltState{
+		Baseline:     rollupMetricsOf(d.baseline),
+		After:        rollupMetricsOf(d.after),
+		Events:       append([]TiltEvent(nil), d.events...),
+		AfterHands:   d.afterHands,
+		WindowLeft:   d.windowLeft,
+		RecentNet:    append([]float64(nil), d.recentNet...),
+		LastStart:    d.lastStart,
+		SessionStart: d.sessionStart,
+		CurrentHands: d.currentHands,
+	}
+	if d.current != nil {
+		cur := rollupMetricsOf(d.current)
+		st.Current = &cur
+	}
+	if d.currentEvent != nil {
+		ev := *d.currentEvent
+		st.CurrentEvent = &ev
+	}
+	return st
+}
+
+func restoreTiltDetector(st *TiltState) *tiltDetector {
+	d := &tiltDetector{
+		baseline:     st.Baseline.accumulator(),
+		after:        st.After.accumulator(),
+		events:       append([]TiltEvent(nil), st.Events...),
+		afterHands:   st.AfterHands,
+		windowLeft:   st.WindowLeft,
+		recentNet:    append([]float64(nil), st.RecentNet...),
+		lastStart:    st.LastStart,
+		sessionStart: st.SessionStart,
+		currentHands: st.CurrentHands,
+	}
+	if st.Current != nil {
+		d.current = st.Current.accumulator()
+	}
+	if st.CurrentEvent != nil {
+		ev := *st.CurrentEvent
+		d.currentEvent = &ev
+	}
+	return d
+}
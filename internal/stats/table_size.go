@@ -0,0 +1,79 @@
+package stats
+
+// TableSize groups hands by the number of players dealt in.
+type TableSize int
+
+const (
+	// TableSizeAny is the zero value and stands for hands of every size.
+	TableSizeAny TableSize = iota
+	TableSizeHeadsUp
+	TableSizeShort    // 3-4 players
+	TableSizeSixMax   // 5-6 players
+	TableSizeFullRing // 7-8 players
+)
+
+// AllTableSizes returns the table sizes hands are split into, in display order.
+func AllTableSizes() []TableSize {
+	return []TableSize{TableSizeHeadsUp, TableSizeShort, TableSizeSixMax, TableSizeFullRing}
+}
+
+// TableSizeForPlayers returns the table size of a hand dealt to players
+// players, or TableSizeAny when the count is below two.
+func TableSizeForPlayers(players int) TableSize {
+	switch {
+	case players < 2:
+		return TableSizeAny
+	case players == 2:
+		return TableSizeHeadsUp
+	case players <= 4:
+		return TableSizeShort
+	case players <= 6:
+		return TableSizeSixMax
+	default:
+		return TableSizeFullRing
+	}
+}
+
+// PlayerRange returns the inclusive player counts of the table size. Hands
+// with more than eight players, which VR Poker does not deal, would still be
+// counted as full ring by TableSizeForPlayers.
+func (t TableSize) PlayerRange() (minPlayers, maxPlayers int) {
+	switch t {
+	case TableSizeHeadsUp:
+		return 2, 2
+	case TableSizeShort:
+		return 3, 4
+	case TableSizeSixMax:
+		return 5, 6
+	case TableSizeFullRing:
+		return 7, 8
+	default:
+		return 0, 0
+	}
+}
+
+// Code returns the stable identifier of the table size used in presets.
+func (t TableSize) Code() string {
+	switch t {
+	case TableSizeHeadsUp:
+		return "hu"
+	case TableSizeShort:
+		return "3-4"
+	case TableSizeSixMax:
+		return "5-6"
+	case TableSizeFullRing:
+		return "7-8"
+	default:
+		return ""
+	}
+}
+
+// TableSizeByCode returns the table size with the given Code.
+func TableSizeByCode(code string) (TableSize, bool) {
+	for _, t := range AllTableSizes() {
+		if t.Code() == code {
+			return t, true
+		}
+	}
+	return TableSizeAny, false
+}
@@ -0,0 +1,198 @@
+package stats
+
+import (
+	"time"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
+)
+
+// Tilt detection thresholds. A big loss is a single hand losing at least
+// TiltBigLossBB big blinds; a downswing is TiltDownswingHands consecutive
+// hands losing at least TiltDownswingBB in total. The TiltWindowHands hands
+// after either event are compared with the rest of the sample.
+const (
+	TiltBigLossBB      = 40.0
+	TiltDownswingHands = 20
+	TiltDownswingBB    = 80.0
+	TiltWindowHands    = 30
+)
+
+// tiltMetrics are the metrics compared after loss events. AF is tested as
+// the share of aggressive actions among bets, raises and calls.
+var tiltMetrics = []MetricID{MetricVPIP, MetricPFR, MetricThreeBet, MetricAF}
+
+// TiltEventKind tells what triggered a tilt event.
+type TiltEventKind int
+
+const (
+	TiltEventBigLoss TiltEventKind = iota
+	TiltEventDownswing
+)
+
+// TiltEvent is a big loss or downswing after which play was compared.
+type TiltEvent struct {
+	Kind      TiltEventKind
+	StartTime time.Time
+	// LossBB is the loss of the hand or downswing in big blinds, positive.
+	LossBB float64
+}
+
+// TiltComparison compares one metric after loss events with its baseline.
+type TiltComparison struct {
+	Metric   MetricID
+	Baseline MetricValue
+	After    MetricValue
+	// Z is the two-proportion z statistic of After against Baseline; it is
+	// zero when either side has no opportunities.
+	Z           float64
+	Significant bool
+}
+
+// TiltReport is the result of tilt detection over a hand sample.
+type TiltReport struct {
+	Events []TiltEvent
+	// AfterHands is the number of hands inside post-event windows.
+	AfterHands  int
+	Comparisons []TiltComparison
+	// CurrentEvent is the latest event when it happened in the latest
+	// session, and Current compares the hands played since it (within the
+	// window) with the baseline. Both are empty otherwise.
+	CurrentEvent *TiltEvent
+	CurrentHands int
+	Current      []TiltComparison
+}
+
+// Drifting reports whether any comparison in cmp is significant.
+func Drifting(cmp []TiltComparison) bool {
+	for _, c := range cmp {
+		if c.Significant {
+			return true
+		}
+	}
+	return false
+}
+
+// tiltDetector finds loss events in a chronological hand stream and splits
+// the hands into post-event windows and the baseline.
+type tiltDetector struct {
+	baseline *metricAccumulator
+	after    *metricAccumulator
+	current  *metricAccumulator
+
+	events       []TiltEvent
+	afterHands   int
+	windowLeft   int
+	recentNet    []float64
+	lastStart    time.Time
+	sessionStart time.Time
+	currentEvent *TiltEvent
+	currentHands int
+}
+
+func newTiltDetector() *tiltDetector {
+	return &tiltDetector{
+		baseline: newMetricAccumulator(),
+		after:    newMetricAccumulator(),
+	}
+}
+
+// feed processes one eligible hand. Hands must arrive in start time order.
+func (d *tiltDetector) feed(h *parser.Hand, pi *parser.PlayerHandInfo, invested int) {
+	if !h.StartTime.IsZero() {
+		if d.lastStart.IsZero() || h.StartTime.Sub(d.lastStart) > SessionGap {
+			d.sessionStart = h.StartTime
+			d.currentEvent = nil
+			d.current = nil
+			d.currentHands = 0
+		}
+		d.lastStart = h.StartTime
+	}
+
+	if d.windowLeft > 0 {
+		d.windowLeft--
+		d.afterHands++
+		d.after.consumeHand(h, pi, invested)
+		if d.current != nil && d.currentHands < TiltWindowHands {
+			d.currentHands++
+			d.current.consumeHand(h, pi, invested)
+		}
+	} else {
+		d.baseline.consumeHand(h, pi, invested)
+	}
+
+	bb := bbAmountFromHand(h)
+	if bb <= 0 {
+		return
+	}
+	net := float64(pi.PotWon-invested) / float64(bb)
+	d.recentNet = append(d.recentNet, net)
+	if len(d.recentNet) > TiltDownswingHands {
+		d.recentNet = d.recentNet[1:]
+	}
+	if d.windowLeft > 0 {
+		// Losses inside a window belong to the event that opened it.
+		return
+	}
+
+	var ev *TiltEvent
+	if -net >= TiltBigLossBB {
+		ev = &TiltEvent{Kind: TiltEventBigLoss, StartTime: h.StartTime, LossBB: -net}
+	} else if len(d.recentNet) == TiltDownswingHands {
+		sum := 0.0
+		for _, v := range d.recentNet {
+			sum += v
+		}
+		if -sum >= TiltDownswingBB {
+			ev = &TiltEvent{Kind: TiltEventDownswing, StartTime: h.StartTime, LossBB: -sum}
+		}
+	}
+	if ev == nil {
+		return
+	}
+	d.events = append(d.events, *ev)
+	d.windowLeft = TiltWindowHands
+	d.recentNet = d.recentNet[:0]
+	if !d.sessionStart.IsZero() && !ev.StartTime.Before(d.sessionStart) {
+		d.currentEvent = ev
+		d.current = newMetricAccumulator()
+		d.currentHands = 0
+	}
+}
+
+func (d *tiltDetector) report() *TiltReport {
+	base := finalizedMetrics(d.baseline)
+	r := &TiltReport{
+		Events:      append([]TiltEvent(nil), d.events...),
+		AfterHands:  d.afterHands,
+		Comparisons: compareTiltMetrics(base, finalizedMetrics(d.after)),
+	}
+	if d.currentEvent != nil {
+		ev := *d.currentEvent
+		r.CurrentEvent = &ev
+		r.CurrentHands = d.currentHands
+		r.Current = compareTiltMetrics(base, finalizedMetrics(d.current))
+	}
+	return r
+}
+
+func finalizedMetrics(m *metricAccumulator) map[MetricID]MetricValue {
+	s := &Stats{Metrics: make(map[MetricID]MetricValue)}
+	m.clone().finalize(s)
+	return s.Metrics
+}
+
+func compareTiltMetrics(base, after map[MetricID]MetricValue) []TiltComparison {
+	out := make([]TiltComparison, 0, len(tiltMetrics))
+	for _, id := range tiltMetrics {
+		b, a := base[id], after[id]
+		c := CompareMetric(a, b)
+		out = append(out, TiltComparison{
+			Metric:      id,
+			Baseline:    b,
+			After:       a,
+			Z:           c.Z,
+			Significant: c.Significant,
+		})
+	}
+	return out
+}
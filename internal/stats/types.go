@@ -30,6 +30,26 @@ type Stats struct {
 
 	// Registry-driven metrics (new framework, legacy fields remain above)
 	Metrics map[MetricID]MetricValue
+
+	// ByTableSize holds the same statistics restricted to each table size
+	// that has hands. It is nil in the per-size Stats themselves.
+	ByTableSize map[TableSize]*Stats
+
+	// Tilt holds the loss events found in the sample and how play changed
+	// after them. Like ByTableSize it is nil in the per-size Stats.
+	Tilt *TiltReport
+}
+
+// ForTableSize returns the statistics of one table size. TableSizeAny
+// returns s itself; a size without hands returns empty statistics.
+func (s *Stats) ForTableSize(size TableSize) *Stats {
+	if s == nil || size == TableSizeAny {
+		return s
+	}
+	if sub, ok := s.ByTableSize[size]; ok && sub != nil {
+		return sub
+	}
+	return newIncrementalCalculator(-1).Compute()
 }
 
 // PositionStats holds stats for a specific position
@@ -0,0 +1,399 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
+)
+
+// analyticsMetric is a metric that can be charted on the analytics tab.
+type analyticsMetric struct {
+	ID    stats.MetricID
+	Label string
+}
+
+var analyticsMetrics = []analyticsMetric{
+	{ID: stats.MetricBBPer100, Label: "bb/100"},
+	{ID: stats.MetricVPIP, Label: "VPIP"},
+	{ID: stats.MetricPFR, Label: "PFR"},
+	{ID: stats.MetricAF, Label: "AF"},
+	{ID: stats.MetricAFq, Label: "AFq"},
+	{ID: stats.MetricWTSD, Label: "WTSD"},
+}
+
+// analyticsDriftMetrics are compared between the start of sessions and their
+// extended part in the drift summary.
+var analyticsDriftMetrics = []analyticsMetric{
+	{ID: stats.MetricBBPer100, Label: "bb/100"},
+	{ID: stats.MetricVPIP, Label: "VPIP"},
+	{ID: stats.MetricPFR, Label: "PFR"},
+	{ID: stats.MetricAF, Label: "AF"},
+}
+
+// trendWindows are the rolling window sizes offered for the metric trend.
+var trendWindows = []int{100, 250, 500, 1000, 2500}
+
+// trendMaxPoints caps the points of the trend chart.
+const trendMaxPoints = 200
+
+// analyticsTabView charts one metric by hour of day, day of week and how far
+// into a session hands were played, and any registry metric over a rolling
+// window of hands. The analytics and the trend are loaded in the background
+// by the app and passed to Update and UpdateTrend.
+type analyticsTabView struct {
+	tabRoot
+	metric    stats.MetricID
+	analytics *stats.TimeAnalytics
+	loaded    bool
+
+	trendMetric stats.MetricID
+	trendWindow int
+	trendByDate bool
+	trend       []stats.TrendPoint
+	trendLoaded bool
+	// onTrendChanged is called when the user picks another trend metric or
+	// window.
+	onTrendChanged func(id stats.MetricID, window int)
+}
+
+func newAnalyticsTabView(onTrendChanged func(id stats.MetricID, window int)) *analyticsTabView {
+	return &analyticsTabView{
+		tabRoot:        newTabRoot(),
+		metric:         stats.MetricBBPer100,
+		trendMetric:    stats.MetricVPIP,
+		trendWindow:    500,
+		onTrendChanged: onTrendChanged,
+	}
+}
+
+// Update replaces the shown analytics. Must be called from the Fyne main thread.
+func (v *analyticsTabView) Update(a *stats.TimeAnalytics) {
+	v.analytics = a
+	v.loaded = true
+	v.rebuild()
+}
+
+// UpdateTrend replaces the shown trend. Trends of a metric or window that is
+// no longer selected are ignored. Must be called from the Fyne main thread.
+func (v *analyticsTabView) UpdateTrend(id stats.MetricID, window int, points []stats.TrendPoint) {
+	if id != v.trendMetric || window != v.trendWindow {
+		return
+	}
+	v.trend = points
+	v.trendLoaded = true
+	v.rebuild()
+}
+
+func (v *analyticsTabView) rebuild() {
+	title := widget.NewLabelWithStyle(lang.X("analytics.title", "Analytics"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	subtitle := widget.NewLabel(lang.X("analytics.subtitle", "See how your results and tendencies change with the time of day, the day of the week and the length of your sessions."))
+	subtitle.Wrapping = fyne.TextWrapWord
+
+	labels := make([]string, 0, len(analyticsMetrics))
+	for _, m := range analyticsMetrics {
+		labels = append(labels, m.Label)
+	}
+	sel := widget.NewSelect(labels, nil)
+	for _, m := range analyticsMetrics {
+		if m.ID == v.metric {
+			sel.SetSelected(m.Label)
+		}
+	}
+	sel.OnChanged = func(s string) {
+		for _, m := range analyticsMetrics {
+			if m.Label == s && m.ID != v.metric {
+				v.metric = m.ID
+				v.rebuild()
+			}
+		}
+	}
+	selector := container.NewHBox(
+		widget.NewLabel(lang.X("analytics.metric", "Metric")),
+		container.NewGridWrap(fyne.NewSize(160, sel.MinSize().Height), sel),
+	)
+	header := container.NewVBox(title, subtitle, selector, newSectionDivider())
+
+	var content fyne.CanvasObject
+	a := v.analytics
+	switch {
+	case !v.loaded:
+		loadingLabel := widget.NewLabel(lang.X("app.status.loading_stats", "Loading stats…"))
+		loadingLabel.Alignment = fyne.TextAlignCenter
+		content = container.NewCenter(loadingLabel)
+	case a == nil || a.Sessions == 0:
+		content = newCenteredEmptyState(lang.X("analytics.no_data", "No hands with a start time yet."))
+	default:
+		content = container.NewVScroll(container.NewVBox(
+			v.trendSection(),
+			v.chartSection(lang.X("analytics.session.title", "Hands into session"),
+				lang.X("analytics.session.subtitle", "{{.Sessions}} sessions. A break of more than 30 minutes starts a new session.", map[string]any{"Sessions": a.Sessions}),
+				v.sessionBars(a)),
+			newSectionCard(buildDriftSummary(a)),
+			v.chartSection(lang.X("analytics.hour.title", "Hour of day"), "", v.hourBars(a)),
+			v.chartSection(lang.X("analytics.weekday.title", "Day of week"), "", v.weekdayBars(a)),
+		))
+	}
+	replaceViewContentPreservingLayout(v.root, container.NewPadded(container.NewBorder(header, nil, nil, nil, content)))
+}
+
+// trendSection charts the selected metric over a rolling window of hands,
+// with its 95% interval shaded.
+func (v *analyticsTabView) trendSection() fyne.CanvasObject {
+	var defs []MetricDefinition
+	for _, m := range metricRegistry {
+		if m.StatsMetricID != nil {
+			defs = append(defs, m)
+		}
+	}
+	labels := make([]string, 0, len(defs))
+	for _, m := range defs {
+		labels = append(labels, m.Label)
+	}
+	metricSel := widget.NewSelect(labels, nil)
+	for _, m := range defs {
+		if *m.StatsMetricID == v.trendMetric {
+			metricSel.SetSelected(m.Label)
+		}
+	}
+	metricSel.OnChanged = func(s string) {
+		for _, m := range defs {
+			if m.Label == s && *m.StatsMetricID != v.trendMetric {
+				v.trendMetric = *m.StatsMetricID
+				v.reloadTrend()
+			}
+		}
+	}
+
+	windowLabels := make([]string, 0, len(trendWindows))
+	for _, w := range trendWindows {
+		windowLabels = append(windowLabels, lang.X("analytics.trend.window_option", "Last {{.N}} hands", map[string]any{"N": w}))
+	}
+	windowSel := widget.NewSelect(windowLabels, nil)
+	for i, w := range trendWindows {
+		if w == v.trendWindow {
+			windowSel.SetSelected(windowLabels[i])
+		}
+	}
+	windowSel.OnChanged = func(s string) {
+		for i, l := range windowLabels {
+			if l == s && trendWindows[i] != v.trendWindow {
+				v.trendWindow = trendWindows[i]
+				v.reloadTrend()
+			}
+		}
+	}
+
+	byHand := lang.X("analytics.trend.axis.hand", "By hand")
+	byDate := lang.X("analytics.trend.axis.date", "By date")
+	axis := widget.NewRadioGroup([]string{byHand, byDate}, nil)
+	axis.Horizontal = true
+	if v.trendByDate {
+		axis.SetSelected(byDate)
+	} else {
+		axis.SetSelected(byHand)
+	}
+	axis.OnChanged = func(s string) {
+		if s == "" {
+			return
+		}
+		if next := s == byDate; next != v.trendByDate {
+			v.trendByDate = next
+			v.rebuild()
+		}
+	}
+
+	controls := container.NewHBox(
+		container.NewGridWrap(fyne.NewSize(180, metricSel.MinSize().Height), metricSel),
+		container.NewGridWrap(fyne.NewSize(180, windowSel.MinSize().Height), windowSel),
+		axis,
+	)
+
+	var chart fyne.CanvasObject
+	switch {
+	case !v.trendLoaded:
+		chart = newSubtleText(lang.X("app.status.loading_stats", "Loading stats…"))
+	case len(v.trend) == 0:
+		chart = newSubtleText(lang.X("analytics.trend.no_data", "No hands for this metric yet."))
+	default:
+		chart = v.trendChart()
+	}
+	return newSectionCard(container.NewVBox(
+		widget.NewLabelWithStyle(lang.X("analytics.trend.title", "Rolling trend"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		newSubtleText(lang.X("analytics.trend.subtitle", "Each point is the metric over the hands in the window ending there. The shaded band is the 95% confidence interval.")),
+		controls,
+		chart,
+	))
+}
+
+func (v *analyticsTabView) reloadTrend() {
+	v.trend = nil
+	v.trendLoaded = false
+	v.rebuild()
+	if v.onTrendChanged != nil {
+		v.onTrendChanged(v.trendMetric, v.trendWindow)
+	}
+}
+
+func (v *analyticsTabView) trendChart() fyne.CanvasObject {
+	points := make([]lineChartPoint, 0, len(v.trend))
+	for _, p := range v.trend {
+		x := float64(p.Hand)
+		if v.trendByDate {
+			if p.Time.IsZero() {
+				continue
+			}
+			x = float64(p.Time.Unix())
+		}
+		points = append(points, lineChartPoint{
+			X:        x,
+			Y:        p.Value.Rate,
+			HasRange: p.Value.Low != p.Value.High,
+			Low:      p.Value.Low,
+			High:     p.Value.High,
+		})
+	}
+	if len(points) == 0 {
+		return newSubtleText(lang.X("analytics.no_data", "No hands with a start time yet."))
+	}
+
+	first, last := v.trend[0], v.trend[len(v.trend)-1]
+	firstX := lang.X("analytics.trend.hand_label", "Hand {{.N}}", map[string]any{"N": first.Hand})
+	lastX := lang.X("analytics.trend.hand_label", "Hand {{.N}}", map[string]any{"N": last.Hand})
+	if v.trendByDate {
+		for _, p := range v.trend {
+			if !p.Time.IsZero() {
+				firstX = p.Time.Format("2006-01-02")
+				break
+			}
+		}
+		lastX = last.Time.Format("2006-01-02")
+	}
+	format := last.Value.Format
+	return newLineChart(points, firstX, lastX, func(y float64) string { return formatMetricRate(format, y) })
+}
+
+func (v *analyticsTabView) chartSection(title, note string, bars []barChartBar) fyne.CanvasObject {
+	items := []fyne.CanvasObject{widget.NewLabelWithStyle(title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})}
+	if note != "" {
+		items = append(items, newSubtleText(note))
+	}
+	items = append(items, newBarChart(bars))
+	return newSectionCard(container.NewVBox(items...))
+}
+
+func (v *analyticsTabView) bar(label string, s *stats.Stats) barChartBar {
+	b := barChartBar{Label: label, Display: "-"}
+	m, ok := s.Metric(v.metric)
+	if !ok || m.Opportunity == 0 {
+		return b
+	}
+	b.Value = m.Rate
+	b.Display = statsMetricValue(s, v.metric).Display
+	b.Samples = m.Opportunity
+	b.LowSample = !m.Confident
+	b.HasRange = m.Low != m.High
+	b.Low, b.High = m.Low, m.High
+	return b
+}
+
+func (v *analyticsTabView) sessionBars(a *stats.TimeAnalytics) []barChartBar {
+	out := make([]barChartBar, 0, len(a.BySessionPhase))
+	for _, p := range stats.AllSessionPhases() {
+		out = append(out, v.bar(sessionPhaseLabel(p), a.BySessionPhase[p]))
+	}
+	return out
+}
+
+func (v *analyticsTabView) hourBars(a *stats.TimeAnalytics) []barChartBar {
+	out := make([]barChartBar, 0, len(a.ByHour))
+	for h, s := range a.ByHour {
+		out = append(out, v.bar(fmt.Sprintf("%d", h), s))
+	}
+	return out
+}
+
+func (v *analyticsTabView) weekdayBars(a *stats.TimeAnalytics) []barChartBar {
+	out := make([]barChartBar, 0, len(a.ByWeekday))
+	// Weeks start on Monday.
+	for i := 1; i <= 7; i++ {
+		d := time.Weekday(i % 7)
+		out = append(out, v.bar(weekdayLabel(d), a.ByWeekday[d]))
+	}
+	return out
+}
+
+// buildDriftSummary compares the first 50 hands of sessions with the hands
+// played after 200, where fatigue and tilt usually show.
+func buildDriftSummary(a *stats.TimeAnalytics) fyne.CanvasObject {
+	early := a.BySessionPhase[stats.SessionPhaseEarly]
+	late := a.BySessionPhase[stats.SessionPhaseExtended]
+	items := []fyne.CanvasObject{widget.NewLabelWithStyle(lang.X("analytics.drift.title", "Session drift"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})}
+	if late.TotalHands == 0 {
+		items = append(items, newSubtleText(lang.X("analytics.drift.no_long_sessions", "No session has reached 200 hands yet.")))
+		return container.NewVBox(items...)
+	}
+	for _, metric := range analyticsDriftMetrics {
+		e, okE := early.Metric(metric.ID)
+		l, okL := late.Metric(metric.ID)
+		if !okE || !okL || e.Opportunity == 0 || l.Opportunity == 0 {
+			continue
+		}
+		delta := fmt.Sprintf("%+.1f", l.Rate-e.Rate)
+		if e.Format == stats.MetricFormatRatio {
+			delta = fmt.Sprintf("%+.2f", l.Rate-e.Rate)
+		}
+		text := lang.X("analytics.drift.line", "{{.Metric}}: {{.Early}} in the first 50 hands, {{.Late}} after 200 hands ({{.Delta}})", map[string]any{
+			"Metric": metric.Label,
+			"Early":  statsMetricValue(early, metric.ID).Display,
+			"Late":   statsMetricValue(late, metric.ID).Display,
+			"Delta":  delta,
+		})
+		line := widget.NewLabel(text)
+		if stats.CompareMetric(l, e).Significant {
+			line.SetText(lang.X("analytics.drift.significant", "{{.Line}} — significant", map[string]any{"Line": text}))
+			line.Importance = widget.WarningImportance
+		} else {
+			line.Importance = widget.LowImportance
+		}
+		items = append(items, line)
+	}
+	return container.NewVBox(items...)
+}
+
+func sessionPhaseLabel(p stats.SessionPhase) string {
+	switch p {
+	case stats.SessionPhaseEarly:
+		return lang.X("analytics.session.phase.early", "1-50")
+	case stats.SessionPhaseMid:
+		return lang.X("analytics.session.phase.mid", "51-100")
+	case stats.SessionPhaseLate:
+		return lang.X("analytics.session.phase.late", "101-200")
+	default:
+		return lang.X("analytics.session.phase.extended", "201+")
+	}
+}
+
+func weekdayLabel(d time.Weekday) string {
+	switch d {
+	case time.Monday:
+		return lang.X("weekday.mon", "Mon")
+	case time.Tuesday:
+		return lang.X("weekday.tue", "Tue")
+	case time.Wednesday:
+		return lang.X("weekday.wed", "Wed")
+	case time.Thursday:
+		return lang.X("weekday.thu", "Thu")
+	case time.Friday:
+		return lang.X("weekday.fri", "Fri")
+	case time.Saturday:
+		return lang.X("weekday.sat", "Sat")
+	default:
+		return lang.X("weekday.sun", "Sun")
+	}
+}
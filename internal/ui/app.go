@@ -2,9 +2,14 @@ package ui
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"image/color"
 	"log/slog"
+	"maps"
 	"os"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -13,11 +18,13 @@ import (
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/lang"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
 	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/application"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/handquery"
 	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/persistence"
 	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
 	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/watcher"
@@ -28,8 +35,12 @@ type appTab int
 const (
 	tabOverview appTab = iota
 	tabPositionStats
+	tabBreakdown
+	tabAnalytics
+	tabCompare
 	tabHandRange
 	tabHandHistory
+	tabDataQuality
 	tabSettings
 )
 
@@ -42,43 +53,62 @@ type AppMetadata struct {
 
 // App is the main application controller
 type App struct {
-	ctx             context.Context
-	cancel          context.CancelFunc
-	fyneApp         fyne.App
-	win             fyne.Window
-	logPath         string
-	dbPath          string
-	service         application.AppService
-	watcher         *watcher.LogWatcher
-	watcherGen      uint64
-	changeReqCh     chan string
-	workerStopCh    chan struct{}
-	workerWG        sync.WaitGroup
-	closeOnce       sync.Once
-	isShuttingDown  atomic.Bool
-	mu              sync.Mutex
-	updateMu        sync.Mutex
-	debounceTimer   *time.Timer
-	debounceMu      sync.Mutex
-	lastStats       *stats.Stats
+	ctx            context.Context
+	cancel         context.CancelFunc
+	fyneApp        fyne.App
+	win            fyne.Window
+	logPath        string
+	dbPath         string
+	service        application.AppService
+	watcher        *watcher.LogWatcher
+	watcherGen     uint64
+	changeReqCh    chan string
+	workerStopCh   chan struct{}
+	workerWG       sync.WaitGroup
+	closeOnce      sync.Once
+	isShuttingDown atomic.Bool
+	mu             sync.Mutex
+	updateMu       sync.Mutex
+	debounceTimer  *time.Timer
+	debounceMu     sync.Mutex
+	lastStats      *stats.Stats
+	// baselineStats are the all-hands stats shown for comparison while a
+	// preset is active, nil otherwise.
+	baselineStats   *stats.Stats
 	lastLocalSeat   int
 	rangeState      *HandRangeViewState
 	historyState    *HandHistoryViewState
 	metricState     *MetricVisibilityState
 	settingsTab     fyne.CanvasObject
+	appSettings     application.AppSettings
 	settingsPath    string
 	overviewView    *overviewTabView
 	positionView    *positionStatsTabView
+	breakdownView   *breakdownTabView
+	analyticsView   *analyticsTabView
+	compareView     *compareTabView
 	handRangeView   *handRangeTabView
 	handHistoryView *handHistoryTabView
+	dataQualityView *dataQualityTabView
 	currentTab      appTab
 	navExpanded     bool
+	// tiltWarnedAt is the start of the loss event last warned about, so each
+	// event is reported once. Only used on the Fyne main thread.
+	tiltWarnedAt time.Time
+	// followers watch the live logs other than the current one, such as the
+	// logs of other VRChat installs, keyed by path. Guarded by mu.
+	followers map[string]*logFollower
+	// presets are the saved filter presets; activePreset names the one
+	// applied to every tab ("" = none). Both are guarded by mu.
+	presets      []application.FilterPreset
+	activePreset string
 	// historyPageRunning is 1 while loadHandHistoryPage is executing.
 	// pendingHistoryPage holds the next page to load (-1 = none).
 	historyPageRunning int32
 	pendingHistoryPage int32
 
 	mainContent *fyne.Container
+	presetBar   *fyne.Container
 	railPanel   *fyne.Container
 	overlayNav  *fyne.Container
 
@@ -115,6 +145,17 @@ func Run(service application.AppService, metadata AppMetadata, dbPath string) {
 		metadata:           metadata,
 		pendingHistoryPage: -1,
 	}
+	if settings, err := service.Settings(ctx); err == nil {
+		appCtrl.appSettings = settings
+	} else {
+		slog.Warn("load settings failed", "error", err)
+		appCtrl.appSettings = application.DefaultAppSettings()
+	}
+	if presets, err := service.FilterPresets(ctx); err == nil {
+		appCtrl.presets = presets
+	} else {
+		slog.Warn("load filter presets failed", "error", err)
+	}
 	appCtrl.startLogChangeWorker()
 	win.SetCloseIntercept(func() {
 		appCtrl.shutdown()
@@ -134,6 +175,7 @@ func (a *App) buildUI() fyne.CanvasObject {
 	statusBar := newSectionCard(statusRow)
 
 	a.mainContent = container.NewMax()
+	a.presetBar = container.NewMax()
 	a.railPanel = container.NewMax()
 	a.overlayNav = container.NewMax()
 	a.rebuildNavigation()
@@ -143,7 +185,8 @@ func (a *App) buildUI() fyne.CanvasObject {
 	go a.initLogFile()
 
 	content := container.NewStack(
-		container.NewBorder(nil, nil, a.railPanel, nil, a.mainContent),
+		container.NewBorder(nil, nil, a.railPanel, nil,
+			container.NewBorder(a.presetBar, nil, nil, nil, a.mainContent)),
 		a.overlayNav,
 	)
 
@@ -203,8 +246,12 @@ func (a *App) rebuildNavigation() {
 	}{
 		{tab: tabOverview, key: "app.tab.overview", fallback: "Overview", icon: theme.HomeIcon()},
 		{tab: tabPositionStats, key: "app.tab.position_stats", fallback: "Position Stats", icon: theme.GridIcon()},
+		{tab: tabBreakdown, key: "app.tab.breakdown", fallback: "Breakdowns", icon: theme.ListIcon()},
+		{tab: tabAnalytics, key: "app.tab.analytics", fallback: "Analytics", icon: theme.MediaFastForwardIcon()},
+		{tab: tabCompare, key: "app.tab.compare", fallback: "Compare", icon: theme.ContentCopyIcon()},
 		{tab: tabHandRange, key: "app.tab.hand_range", fallback: "Hand Range", icon: theme.ColorPaletteIcon()},
 		{tab: tabHandHistory, key: "app.tab.hand_history", fallback: "Hand History", icon: theme.HistoryIcon()},
+		{tab: tabDataQuality, key: "app.tab.data_quality", fallback: "Data Quality", icon: theme.WarningIcon()},
 		{tab: tabSettings, key: "app.tab.settings", fallback: "Settings", icon: theme.SettingsIcon()},
 	}
 
@@ -306,7 +353,15 @@ func (a *App) initLogFile() {
 
 	a.doSetStatus(lang.X("app.status.importing", "Importing VRChat logs..."))
 
+	ctx, cancel := context.WithCancel(a.ctx)
+	defer cancel()
+	control := &application.ImportControl{}
+	progressDialog := newImportProgressDialog(a.win, control, cancel)
+	defer progressDialog.close()
+
+	lastCurrent := 0
 	onProgress := func(p application.BootstrapProgress) {
+		progressDialog.update(p)
 		msg := lang.X("app.status.importing_progress",
 			"Importing logs… ({{.Current}}/{{.Total}}) {{.File}}",
 			map[string]any{
@@ -316,10 +371,19 @@ func (a *App) initLogFile() {
 			})
 		a.doSetStatus(msg)
 		// Update UI with data available so far so the user sees something.
-		a.doUpdateStats()
+		if p.Current != lastCurrent {
+			lastCurrent = p.Current
+			a.doUpdateStats()
+		}
 	}
 
-	logPath, err := a.service.BootstrapImportAllLogsWithProgress(a.ctx, onProgress)
+	logPath, err := a.service.BootstrapImport(ctx, application.BootstrapOptions{OnProgress: onProgress, Control: control})
+	if errors.Is(err, context.Canceled) && a.ctx.Err() == nil {
+		slog.Info("bootstrap import cancelled")
+		a.doUpdateStats()
+		a.doSetStatus(lang.X("app.status.import_cancelled", "Import cancelled. Logs imported so far are kept; the rest are imported at the next start."))
+		return
+	}
 	if err != nil {
 		slog.Error("no log file found during bootstrap", "error", err)
 		a.doSetStatus(lang.X("app.error.no_log_file", "No log file found: {{.Error}} — configure in Settings.", map[string]any{"Error": err}))
@@ -328,7 +392,12 @@ func (a *App) initLogFile() {
 
 	slog.Info("bootstrap complete", "logPath", logPath)
 	a.doUpdateStats()
+	if logPath == "" {
+		a.doSetStatus(lang.X("app.status.no_live_log", "Imported archived logs. No live VRChat log file was found."))
+		return
+	}
 	a.requestLogFileChange(logPath)
+	a.followLogs(logPath)
 }
 
 func (a *App) startLogChangeWorker() {
@@ -384,6 +453,9 @@ func (a *App) requestLogFileChange(path string) {
 }
 
 func (a *App) changeLogFile(path string) {
+	// A followed log that becomes the current one is watched as such.
+	a.unfollowLog(path)
+
 	// Stop existing watcher and invalidate stale callbacks.
 	a.mu.Lock()
 	a.watcherGen++
@@ -415,9 +487,47 @@ func (a *App) changeLogFile(path string) {
 	a.doSetStatus(lang.X("app.status.loaded", "Loaded: {{.Path}} — watching for changes…", map[string]any{"Path": shortPath(path)}))
 
 	// Start tail watcher from current end-of-file
+	w, err := a.newLogWatcher(path, func() bool { return a.isCurrentWatcherGeneration(gen) }, func(nextPath string) {
+		// Mark the current (now-old) log file as fully imported so future
+		// bootstrap runs skip it entirely.
+		a.service.MarkLogFullyImported(a.ctx, path)
+		a.requestLogFileChange(nextPath)
+	})
+	if err != nil {
+		slog.Error("watcher creation failed", "path", path, "error", err)
+		a.doSetStatus(lang.X("app.error.watcher", "Watcher error: {{.Error}}", map[string]any{"Error": err}))
+		return
+	}
+
+	if err := w.Start(); err != nil {
+		slog.Error("watcher start failed", "path", path, "error", err)
+		a.doSetStatus(lang.X("app.error.watcher_start", "Failed to start watcher: {{.Error}}", map[string]any{"Error": err}))
+		return
+	}
+	slog.Info("watcher started", "path", path)
+
+	a.mu.Lock()
+	if a.watcherGen == gen && !a.isShuttingDown.Load() {
+		a.watcher = w
+	} else {
+		w.Stop()
+	}
+	a.mu.Unlock()
+}
+
+// newLogWatcher creates a watcher that imports the lines appended to path
+// while isCurrent reports true, starting after the imported part of the log.
+// onRotate is called with the path of a new session log in the same
+// directory.
+func (a *App) newLogWatcher(path string, isCurrent func() bool, onRotate func(nextPath string)) (*watcher.LogWatcher, error) {
+	settings, err := a.service.Settings(a.ctx)
+	if err != nil {
+		settings = application.DefaultAppSettings()
+	}
 	w, err := watcher.NewLogWatcher(path, watcher.WatcherConfig{
+		Backend: watcher.Backend(settings.WatcherBackend),
 		OnNewData: func(lines []string, startOffset int64, endOffset int64) {
-			if !a.isCurrentWatcherGeneration(gen) {
+			if !isCurrent() {
 				return
 			}
 			if err := a.service.ImportLines(a.ctx, path, lines, startOffset, endOffset); err != nil {
@@ -425,7 +535,7 @@ func (a *App) changeLogFile(path string) {
 				a.doSetStatus(lang.X("app.error.import", "Import error: {{.Error}}", map[string]any{"Error": err}))
 				return
 			}
-			if !a.isCurrentWatcherGeneration(gen) {
+			if !isCurrent() {
 				return
 			}
 			// Debounce: collapse rapid-fire OnNewData events into a single update
@@ -434,24 +544,21 @@ func (a *App) changeLogFile(path string) {
 				a.debounceTimer.Stop()
 			}
 			a.debounceTimer = time.AfterFunc(time.Second, func() {
-				if a.isCurrentWatcherGeneration(gen) {
+				if isCurrent() {
 					a.doUpdateStats()
 				}
 			})
 			a.debounceMu.Unlock()
 		},
 		OnNewLogFile: func(nextPath string) {
-			if !a.isCurrentWatcherGeneration(gen) {
+			if !isCurrent() {
 				return
 			}
 			slog.Info("new log file detected", "path", nextPath)
-			// Mark the current (now-old) log file as fully imported so future
-			// bootstrap runs skip it entirely.
-			a.service.MarkLogFullyImported(a.ctx, path)
-			a.requestLogFileChange(nextPath)
+			onRotate(nextPath)
 		},
 		OnError: func(err error) {
-			if !a.isCurrentWatcherGeneration(gen) {
+			if !isCurrent() {
 				return
 			}
 			slog.Error("watcher error", "path", path, "error", err)
@@ -459,32 +566,130 @@ func (a *App) changeLogFile(path string) {
 		},
 	})
 	if err != nil {
-		slog.Error("watcher creation failed", "path", path, "error", err)
-		a.doSetStatus(lang.X("app.error.watcher", "Watcher error: {{.Error}}", map[string]any{"Error": err}))
-		return
+		return nil, err
 	}
 
-	// Start from end of file (we already parsed the full history above)
-	if offset, err := a.service.NextOffset(a.ctx, path); err == nil && offset > 0 {
+	// Resume where the import stopped: the log was imported before it is
+	// watched, and a half-written last line was left for the watcher.
+	if offset, err := a.service.NextOffset(a.ctx, path); err == nil {
 		w.SetOffset(offset)
 	} else if info, err := os.Stat(path); err == nil {
 		w.SetOffset(info.Size())
 	}
+	return w, nil
+}
+
+// restartWatcher reloads the current log and restarts the followers so
+// their watchers pick up changed watcher settings.
+func (a *App) restartWatcher() {
+	a.mu.Lock()
+	path := a.logPath
+	followed := slices.Sorted(maps.Keys(a.followers))
+	a.mu.Unlock()
+	a.requestLogFileChange(path)
+	for _, p := range followed {
+		a.followLog(p)
+	}
+}
+
+// logFollower is the watcher of a followed log. Its callbacks stop importing
+// once the follower is replaced or removed.
+type logFollower struct {
+	w *watcher.LogWatcher
+}
+
+// followLogs watches every live log other than current.
+func (a *App) followLogs(current string) {
+	for _, p := range a.service.LiveLogFiles() {
+		if p != current {
+			a.followLog(p)
+		}
+	}
+}
+
+// followLog watches the live log path next to the current log, replacing a
+// previous watcher of path. When a new session log appears in its
+// directory, the new log is followed instead.
+func (a *App) followLog(path string) {
+	f := &logFollower{}
+	isCurrent := func() bool {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		return !a.isShuttingDown.Load() && a.followers[path] == f
+	}
+	w, err := a.newLogWatcher(path, isCurrent, func(nextPath string) {
+		a.mu.Lock()
+		if a.isShuttingDown.Load() {
+			a.mu.Unlock()
+			return
+		}
+		a.workerWG.Add(1)
+		a.mu.Unlock()
+		go func() {
+			defer a.workerWG.Done()
+			a.rotateFollowedLog(path, nextPath)
+		}()
+	})
+	if err != nil {
+		slog.Error("watcher creation failed", "path", path, "error", err)
+		a.doSetStatus(lang.X("app.error.watcher", "Watcher error: {{.Error}}", map[string]any{"Error": err}))
+		return
+	}
+	f.w = w
+
+	a.mu.Lock()
+	if a.isShuttingDown.Load() {
+		a.mu.Unlock()
+		return
+	}
+	if a.followers == nil {
+		a.followers = make(map[string]*logFollower)
+	}
+	prev := a.followers[path]
+	a.followers[path] = f
+	a.mu.Unlock()
+	if prev != nil {
+		prev.w.Stop()
+	}
 
 	if err := w.Start(); err != nil {
 		slog.Error("watcher start failed", "path", path, "error", err)
 		a.doSetStatus(lang.X("app.error.watcher_start", "Failed to start watcher: {{.Error}}", map[string]any{"Error": err}))
+		a.mu.Lock()
+		if a.followers[path] == f {
+			delete(a.followers, path)
+		}
+		a.mu.Unlock()
 		return
 	}
-	slog.Info("watcher started", "path", path)
+	slog.Info("following log", "path", path)
+}
 
+// unfollowLog stops watching a followed log and drops its live state.
+func (a *App) unfollowLog(path string) {
 	a.mu.Lock()
-	if a.watcherGen == gen && !a.isShuttingDown.Load() {
-		a.watcher = w
-	} else {
-		w.Stop()
-	}
+	f := a.followers[path]
+	delete(a.followers, path)
 	a.mu.Unlock()
+	if f == nil {
+		return
+	}
+	f.w.Stop()
+	a.service.UnfollowLogFile(path)
+}
+
+// rotateFollowedLog replaces a followed log with the new session log of the
+// same install.
+func (a *App) rotateFollowedLog(path, nextPath string) {
+	a.unfollowLog(path)
+	a.service.MarkLogFullyImported(a.ctx, path)
+	if err := a.service.FollowLogFile(a.ctx, nextPath); err != nil {
+		slog.Error("failed to read log file", "path", nextPath, "error", err)
+		a.doSetStatus(lang.X("app.error.read_log", "Error reading log: {{.Error}}", map[string]any{"Error": err}))
+		return
+	}
+	a.followLog(nextPath)
+	a.doUpdateStats()
 }
 
 func (a *App) isCurrentWatcherGeneration(gen uint64) bool {
@@ -505,11 +710,16 @@ func (a *App) shutdown() {
 		stopCh := a.workerStopCh
 		prevWatcher := a.watcher
 		a.watcher = nil
+		followers := a.followers
+		a.followers = nil
 		a.mu.Unlock()
 
 		if prevWatcher != nil {
 			prevWatcher.Stop()
 		}
+		for _, f := range followers {
+			f.w.Stop()
+		}
 		if stopCh != nil {
 			close(stopCh)
 		}
@@ -536,12 +746,41 @@ func (a *App) doUpdateStats() {
 	}
 	defer a.updateMu.Unlock()
 
-	s, localSeat, err := a.service.Stats(a.ctx, persistence.HandFilter{})
+	filter, err := a.activePresetFilter()
+	if err != nil {
+		slog.Error("apply filter preset failed", "error", err)
+		a.doSetStatus(lang.X("app.error.stats", "Stats error: {{.Error}}", map[string]any{"Error": err}))
+		return
+	}
+	s, localSeat, err := a.service.Stats(a.ctx, filter)
 	if err != nil {
 		slog.Error("stats failed", "error", err)
 		a.doSetStatus(lang.X("app.error.stats", "Stats error: {{.Error}}", map[string]any{"Error": err}))
 		return
 	}
+	// Stats summed from daily rollups carry no tilt report; the insights
+	// and the live warning scan the period for it.
+	if s != nil && s.Tilt == nil {
+		if tilt, err := a.service.TiltReport(a.ctx, filter); err != nil {
+			slog.Warn("tilt report failed", "error", err)
+		} else {
+			withTilt := *s
+			withTilt.Tilt = tilt
+			s = &withTilt
+		}
+	}
+	// With a preset active, the Overview compares against all hands.
+	var baseline *stats.Stats
+	a.mu.Lock()
+	presetActive := a.activePreset != ""
+	a.mu.Unlock()
+	if presetActive {
+		baseline, _, err = a.service.Stats(a.ctx, persistence.HandFilter{})
+		if err != nil {
+			slog.Warn("all-hands stats failed", "error", err)
+			baseline = nil
+		}
+	}
 
 	handCount := 0
 	if s != nil {
@@ -551,6 +790,7 @@ func (a *App) doUpdateStats() {
 
 	a.mu.Lock()
 	a.lastStats = s
+	a.baselineStats = baseline
 	a.lastLocalSeat = localSeat
 	a.mu.Unlock()
 
@@ -559,9 +799,31 @@ func (a *App) doUpdateStats() {
 		if a.currentTab != tabSettings {
 			a.doRefreshCurrentTab()
 		}
+		a.maybeWarnTilt(s)
 	})
 }
 
+// maybeWarnTilt shows the live tilt warning when the latest loss event of
+// the current session is followed by significant drift. MUST be called from
+// the Fyne main thread.
+func (a *App) maybeWarnTilt(s *stats.Stats) {
+	if !a.appSettings.TiltWarning || s == nil || s.Tilt == nil {
+		return
+	}
+	ev := s.Tilt.CurrentEvent
+	if ev == nil || !ev.StartTime.After(a.tiltWarnedAt) || !stats.Drifting(s.Tilt.Current) {
+		return
+	}
+	a.tiltWarnedAt = ev.StartTime
+	msg := lang.X("tilt.warning.text", "Your play has changed since losing {{.Loss}} bb at {{.Time}}:\n\n{{.Changes}}\n\nConsider taking a short break.", map[string]any{
+		"Loss":    fmt.Sprintf("%.0f", ev.LossBB),
+		"Time":    ev.StartTime.Format("15:04"),
+		"Changes": strings.Join(tiltChangeLines(s.Tilt.Current, true), "\n"),
+	})
+	a.doSetStatus(lang.X("tilt.warning.status", "Possible tilt detected"))
+	dialog.ShowInformation(lang.X("tilt.warning.title", "Possible tilt"), msg, a.win)
+}
+
 // doRefreshCurrentTab rebuilds the content for the currently selected tab.
 // MUST be called from the Fyne main thread (or wrapped in fyne.Do).
 func (a *App) doRefreshCurrentTab() {
@@ -572,6 +834,7 @@ func (a *App) doRefreshCurrentTab() {
 	a.mu.Lock()
 	localSeat := a.lastLocalSeat
 	lastStats := a.lastStats
+	baselineStats := a.baselineStats
 	path := a.logPath
 	a.mu.Unlock()
 
@@ -581,7 +844,7 @@ func (a *App) doRefreshCurrentTab() {
 		if a.overviewView == nil {
 			a.overviewView = newOverviewTabView(a.win, a.metricState)
 		}
-		a.overviewView.Update(lastStats, localSeat)
+		a.overviewView.Update(lastStats, baselineStats, localSeat)
 		obj = a.overviewView.CanvasObject()
 	case tabPositionStats:
 		if a.positionView == nil {
@@ -589,6 +852,41 @@ func (a *App) doRefreshCurrentTab() {
 		}
 		a.positionView.Update(lastStats, localSeat)
 		obj = a.positionView.CanvasObject()
+	case tabBreakdown:
+		if a.breakdownView == nil {
+			a.breakdownView = newBreakdownTabView(a.metricState, func(b stats.Breakdown) {
+				go a.loadBreakdown(b)
+			})
+			a.breakdownView.rebuild()
+		}
+		obj = a.breakdownView.CanvasObject()
+		go a.loadBreakdown(a.breakdownView.breakdown)
+	case tabAnalytics:
+		if a.analyticsView == nil {
+			a.analyticsView = newAnalyticsTabView(func(id stats.MetricID, window int) {
+				go a.loadTrend(id, window)
+			})
+			a.analyticsView.rebuild()
+		}
+		obj = a.analyticsView.CanvasObject()
+		go a.loadAnalytics()
+		go a.loadTrend(a.analyticsView.trendMetric, a.analyticsView.trendWindow)
+	case tabCompare:
+		if a.compareView == nil {
+			a.compareView = newCompareTabView(a.metricState, func(presetA, presetB string) {
+				go a.loadComparison(presetA, presetB)
+			})
+		}
+		a.mu.Lock()
+		names := make([]string, 0, len(a.presets))
+		for _, p := range a.presets {
+			names = append(names, p.Name)
+		}
+		a.mu.Unlock()
+		a.compareView.SetPresets(names)
+		a.compareView.rebuild()
+		obj = a.compareView.CanvasObject()
+		go a.loadComparison(a.compareView.a, a.compareView.b)
 	case tabHandRange:
 		if a.handRangeView == nil {
 			a.handRangeView = newHandRangeTabView(a.win, a.rangeState)
@@ -602,7 +900,7 @@ func (a *App) doRefreshCurrentTab() {
 				go a.loadHandHistoryPage(page)
 			}, func(uid string) {
 				go a.loadHandDetail(uid)
-			})
+			}, a.exportHands)
 		}
 		// Show current (possibly stale) state immediately.
 		obj = a.handHistoryView.CanvasObject()
@@ -614,6 +912,13 @@ func (a *App) doRefreshCurrentTab() {
 		} else {
 			go a.loadHandHistoryPage(a.handHistoryView.page)
 		}
+	case tabDataQuality:
+		if a.dataQualityView == nil {
+			a.dataQualityView = newDataQualityTabView(func(uid string) { go a.showHandRawLog(uid) })
+			a.dataQualityView.rebuild()
+		}
+		obj = a.dataQualityView.CanvasObject()
+		go a.loadDataQuality()
 	case tabSettings:
 		if a.settingsTab == nil || a.settingsPath != path {
 			dbPath := a.dbPath
@@ -631,8 +936,23 @@ func (a *App) doRefreshCurrentTab() {
 						a.doRefreshCurrentTab()
 					}
 				},
-				DBPath:  dbPath,
-				OnReset: func() { a.doResetDB() },
+				DBPath:            dbPath,
+				OnReset:           func() { a.doResetDB() },
+				OnShowDiagnostics: func() { go a.showParserDiagnostics() },
+				OnReprocess:       func() { go a.reprocessHands(true) },
+				OnCheckDatabase:   func() { go a.checkDatabase() },
+				OnImportArchives:  func() { go a.importArchivedLogs() },
+				AppSettings:       a.appSettings,
+				OnAppSettingsChange: func(settings application.AppSettings) {
+					restartWatcher := settings.WatcherBackend != a.appSettings.WatcherBackend
+					a.appSettings = settings
+					go func() {
+						a.saveAppSettings(settings)
+						if restartWatcher {
+							a.restartWatcher()
+						}
+					}()
+				},
 			})
 			a.settingsPath = path
 		}
@@ -641,12 +961,13 @@ func (a *App) doRefreshCurrentTab() {
 		if a.overviewView == nil {
 			a.overviewView = newOverviewTabView(a.win, a.metricState)
 		}
-		a.overviewView.Update(lastStats, localSeat)
+		a.overviewView.Update(lastStats, baselineStats, localSeat)
 		obj = a.overviewView.CanvasObject()
 	}
 
 	a.mainContent.Objects = []fyne.CanvasObject{obj}
 	a.mainContent.Refresh()
+	a.rebuildPresetBar()
 }
 
 func (a *App) buildHandHistoryFilter() persistence.HandFilter {
@@ -676,6 +997,35 @@ func (a *App) buildHandHistoryFilter() persistence.HandFilter {
 		filter.FinalClassIDs = ids
 	}
 
+	filter.OnlyStarred = a.handHistoryView.handFilter.OnlyStarred
+	if tag := a.handHistoryView.handFilter.Tag; tag != "" {
+		filter.Tags = []string{tag}
+	}
+	filter.Query = a.handHistoryView.handFilter.Query
+
+	preset, err := a.activePresetFilter()
+	if err != nil {
+		slog.Warn("apply filter preset failed", "error", err)
+		preset = persistence.HandFilter{}
+	}
+	filter.FromTime, filter.ToTime = preset.FromTime, preset.ToTime
+	filter.TableSizes = preset.TableSizes
+	if size := a.handHistoryView.handFilter.TableSize; size != stats.TableSizeAny {
+		// Narrow the preset's sizes to the selected one. A size the preset
+		// excludes leaves TableSizeAny, which matches no hand.
+		if len(preset.TableSizes) == 0 || slices.Contains(preset.TableSizes, size) {
+			filter.TableSizes = []stats.TableSize{size}
+		} else {
+			filter.TableSizes = []stats.TableSize{stats.TableSizeAny}
+		}
+	}
+	switch {
+	case preset.Query != nil && filter.Query != nil:
+		filter.Query = handquery.And{Terms: []handquery.Expr{preset.Query, filter.Query}}
+	case preset.Query != nil:
+		filter.Query = preset.Query
+	}
+
 	return filter
 }
 
@@ -717,6 +1067,10 @@ func (a *App) loadHandHistoryPage(page int) {
 			// Do not update UI; loop to try any further pending request.
 			continue
 		}
+		tags, err := a.service.ListHandTags(a.ctx)
+		if err != nil {
+			slog.Warn("list hand tags failed", "error", err)
+		}
 
 		capturedPage := wantPage
 		capturedTotal := totalCount
@@ -725,7 +1079,7 @@ func (a *App) loadHandHistoryPage(page int) {
 			if a.handHistoryView == nil {
 				return
 			}
-			a.handHistoryView.UpdatePage(capturedSummaries, capturedPage, capturedTotal)
+			a.handHistoryView.UpdatePage(capturedSummaries, tags, capturedPage, capturedTotal)
 			if a.mainContent != nil {
 				a.mainContent.Refresh()
 			}
@@ -762,15 +1116,486 @@ func (a *App) loadHandDetail(uid string) {
 		return
 	}
 
-	detail := buildDetailPanel(h, localSeat)
+	annotation, err := a.service.HandAnnotation(a.ctx, uid)
+	if err != nil {
+		slog.Warn("get hand annotation failed", "uid", uid, "error", err)
+	}
+
 	fyne.Do(func() {
 		if a.handHistoryView == nil {
 			return
 		}
+		detail := buildDetailPanel(h, localSeat, func() { go a.showHandRawLog(uid) })
+		if h != nil {
+			editor := buildAnnotationEditor(uid, annotation, func(ann persistence.HandAnnotation) {
+				go a.saveHandAnnotation(ann)
+			})
+			detail = container.NewBorder(nil, editor, nil, nil, detail)
+		}
 		a.handHistoryView.UpdateDetail(detail)
 	})
 }
 
+// saveHandAnnotation stores a hand annotation in the background and reloads
+// the current hand history page so list markers and the tag filter update.
+func (a *App) saveHandAnnotation(ann persistence.HandAnnotation) {
+	if err := a.service.SaveHandAnnotation(a.ctx, ann); err != nil {
+		slog.Error("save hand annotation failed", "uid", ann.HandUID, "error", err)
+		fyne.Do(func() { dialog.ShowError(err, a.win) })
+		return
+	}
+	fyne.Do(func() {
+		if a.handHistoryView != nil {
+			go a.loadHandHistoryPage(a.handHistoryView.page)
+		}
+	})
+}
+
+// exportHands asks for a file and writes the hands matching the current hand
+// history filter as CSV. Must be called from the Fyne main thread.
+func (a *App) exportHands() {
+	filter := a.buildHandHistoryFilter()
+	save := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, a.win)
+			return
+		}
+		if w == nil {
+			return
+		}
+		go func() {
+			defer w.Close()
+			n, err := a.service.ExportHandsCSV(a.ctx, filter, w)
+			if err != nil {
+				slog.Error("export hands failed", "error", err)
+				fyne.Do(func() { dialog.ShowError(err, a.win) })
+				return
+			}
+			a.doSetStatus(lang.X("app.status.export_done", "Exported {{.Count}} hands.", map[string]any{"Count": n}))
+		}()
+	}, a.win)
+	save.SetFileName("hands.csv")
+	save.Show()
+}
+
+// loadDataQuality fetches the hands excluded from stats in a background
+// goroutine and then updates the dataQualityView on the Fyne main thread.
+func (a *App) loadDataQuality() {
+	a.mu.Lock()
+	localSeat := a.lastLocalSeat
+	a.mu.Unlock()
+
+	hands, err := a.service.ListStatsExcludedHands(a.ctx, persistence.HandFilter{Limit: dataQualityMaxHands})
+	if err != nil {
+		slog.Error("list stats-excluded hands failed", "error", err)
+		a.doSetStatus(lang.X("app.error.snapshot", "Snapshot error: {{.Error}}", map[string]any{"Error": err}))
+		return
+	}
+	fyne.Do(func() {
+		if a.dataQualityView == nil {
+			return
+		}
+		a.dataQualityView.Update(hands, localSeat)
+	})
+}
+
+// loadBreakdown computes the stats breakdown for the active preset in a
+// background goroutine and then updates the breakdownView on the Fyne main
+// thread.
+func (a *App) loadBreakdown(b stats.Breakdown) {
+	filter, err := a.activePresetFilter()
+	if err != nil {
+		slog.Warn("apply filter preset failed", "error", err)
+		filter = persistence.HandFilter{}
+	}
+	groups, names, err := a.service.StatsBreakdown(a.ctx, filter, b)
+	if err != nil {
+		slog.Error("stats breakdown failed", "error", err)
+		a.doSetStatus(lang.X("app.error.stats", "Stats error: {{.Error}}", map[string]any{"Error": err}))
+		return
+	}
+	fyne.Do(func() {
+		if a.breakdownView == nil {
+			return
+		}
+		a.breakdownView.Update(b, groups, names)
+	})
+}
+
+// loadAnalytics computes the time analytics for the active preset in a
+// background goroutine and then updates the analyticsView on the Fyne main
+// thread.
+func (a *App) loadAnalytics() {
+	filter, err := a.activePresetFilter()
+	if err != nil {
+		slog.Warn("apply filter preset failed", "error", err)
+		filter = persistence.HandFilter{}
+	}
+	analytics, err := a.service.TimeAnalytics(a.ctx, filter)
+	if err != nil {
+		slog.Error("time analytics failed", "error", err)
+		a.doSetStatus(lang.X("app.error.stats", "Stats error: {{.Error}}", map[string]any{"Error": err}))
+		return
+	}
+	fyne.Do(func() {
+		if a.analyticsView == nil {
+			return
+		}
+		a.analyticsView.Update(analytics)
+	})
+}
+
+// loadTrend computes the rolling trend of a metric for the active preset in
+// a background goroutine and then updates the analyticsView on the Fyne main
+// thread.
+func (a *App) loadTrend(id stats.MetricID, window int) {
+	filter, err := a.activePresetFilter()
+	if err != nil {
+		slog.Warn("apply filter preset failed", "error", err)
+		filter = persistence.HandFilter{}
+	}
+	points, err := a.service.MetricTrend(a.ctx, filter, id, window, trendMaxPoints)
+	if err != nil {
+		slog.Error("metric trend failed", "error", err)
+		a.doSetStatus(lang.X("app.error.stats", "Stats error: {{.Error}}", map[string]any{"Error": err}))
+		return
+	}
+	fyne.Do(func() {
+		if a.analyticsView == nil {
+			return
+		}
+		a.analyticsView.UpdateTrend(id, window, points)
+	})
+}
+
+// loadComparison compares the hands of the presets named presetA and
+// presetB ("" = all hands) in a background goroutine and then updates the
+// compareView on the Fyne main thread.
+func (a *App) loadComparison(presetA, presetB string) {
+	filterA, err := a.presetFilter(presetA)
+	if err != nil {
+		slog.Warn("apply filter preset failed", "error", err)
+		filterA = persistence.HandFilter{}
+	}
+	filterB, err := a.presetFilter(presetB)
+	if err != nil {
+		slog.Warn("apply filter preset failed", "error", err)
+		filterB = persistence.HandFilter{}
+	}
+	cmp, err := a.service.CompareFilters(a.ctx, filterA, filterB)
+	if err != nil {
+		slog.Error("compare filters failed", "error", err)
+		a.doSetStatus(lang.X("app.error.stats", "Stats error: {{.Error}}", map[string]any{"Error": err}))
+		return
+	}
+	fyne.Do(func() {
+		if a.compareView == nil {
+			return
+		}
+		a.compareView.Update(presetA, presetB, cmp)
+	})
+}
+
+// reprocessHands runs the parser reprocess job in the background, reporting
+// progress in the status bar. A dry run opens the preview dialog, from which
+// the user can apply the changes.
+func (a *App) reprocessHands(dryRun bool) {
+	onProgress := func(p application.ReprocessProgress) {
+		a.doSetStatus(lang.X("app.status.reprocessing",
+			"Reprocessing hands… ({{.Processed}}/{{.Total}}) {{.File}}",
+			map[string]any{"Processed": p.Processed, "Total": p.Total, "File": shortPath(p.Path)}))
+	}
+	res, err := a.service.ReprocessHands(a.ctx, application.ReprocessOptions{DryRun: dryRun}, onProgress)
+	if err != nil {
+		slog.Error("reprocess hands failed", "dryRun", dryRun, "error", err)
+		a.doSetStatus(lang.X("app.status.reprocess_failed", "Reprocess failed: {{.Error}}", map[string]any{"Error": err}))
+		fyne.Do(func() { dialog.ShowError(err, a.win) })
+		return
+	}
+	slog.Info("reprocess hands complete", "dryRun", dryRun, "outdated", res.Outdated, "changed", res.Changed, "updated", res.Updated)
+	a.doSetStatus(lang.X("app.status.reprocess_done", "Reprocess finished: {{.Changed}} of {{.Outdated}} outdated hands changed.",
+		map[string]any{"Changed": res.Changed, "Outdated": res.Outdated}))
+
+	if dryRun {
+		fyne.Do(func() {
+			showReprocessPreviewDialog(a.win, res, func() { go a.reprocessHands(false) })
+		})
+		return
+	}
+	a.doUpdateStats()
+	fyne.Do(func() {
+		dialog.ShowInformation(lang.X("reprocess.title", "Reprocess Hands"), reprocessSummaryText(res), a.win)
+	})
+}
+
+// checkDatabase runs the database integrity check in the background and
+// shows its findings, from which the user can start a repair.
+func (a *App) checkDatabase() {
+	a.doSetStatus(lang.X("app.status.checking_db", "Checking the database…"))
+	check, err := a.service.CheckDatabase(a.ctx)
+	if err != nil {
+		slog.Error("check database failed", "error", err)
+		a.doSetStatus(lang.X("app.status.check_db_failed", "Database check failed: {{.Error}}", map[string]any{"Error": err}))
+		fyne.Do(func() { dialog.ShowError(err, a.win) })
+		return
+	}
+	slog.Info("database check complete", "issues", len(check.Issues))
+	a.doSetStatus(lang.X("app.status.check_db_done", "Database check finished: {{.N}} problems found.", map[string]any{"N": len(check.Issues)}))
+	fyne.Do(func() {
+		showDatabaseCheckDialog(a.win, check, func() { go a.repairDatabase() })
+	})
+}
+
+// repairDatabase repairs what the database check finds and reloads the
+// statistics.
+func (a *App) repairDatabase() {
+	a.doSetStatus(lang.X("app.status.repairing_db", "Repairing the database…"))
+	res, err := a.service.RepairDatabase(a.ctx)
+	if err != nil {
+		slog.Error("repair database failed", "error", err)
+		a.doSetStatus(lang.X("app.status.repair_db_failed", "Database repair failed: {{.Error}}", map[string]any{"Error": err}))
+		fyne.Do(func() { dialog.ShowError(err, a.win) })
+		return
+	}
+	slog.Info("database repair complete", "repaired", len(res.Repaired), "remaining", len(res.Remaining))
+	a.doSetStatus(lang.X("app.status.repair_db_done", "Database repair finished: {{.N}} problems repaired.", map[string]any{"N": len(res.Repaired)}))
+	a.doUpdateStats()
+	fyne.Do(func() { showDatabaseRepairDialog(a.win, res) })
+}
+
+// importArchivedLogs imports logs from archives found in the log directories
+// that were not imported yet, reporting progress in the status bar.
+func (a *App) importArchivedLogs() {
+	onProgress := func(p application.BootstrapProgress) {
+		a.doSetStatus(lang.X("app.status.importing_progress",
+			"Importing logs… ({{.Current}}/{{.Total}}) {{.File}}",
+			map[string]any{"Current": p.Current, "Total": p.Total, "File": shortPath(p.Path)}))
+	}
+	n, err := a.service.ImportArchivedLogs(a.ctx, onProgress)
+	if err != nil {
+		slog.Error("import archived logs failed", "error", err)
+		a.doSetStatus(lang.X("app.status.archive_import_failed", "Archive import failed: {{.Error}}", map[string]any{"Error": err}))
+		return
+	}
+	slog.Info("archived logs imported", "files", n)
+	a.doSetStatus(lang.X("app.status.archive_import_done", "Imported {{.Count}} archived log files.", map[string]any{"Count": n}))
+	a.doUpdateStats()
+}
+
+// saveAppSettings persists settings in the background.
+func (a *App) saveAppSettings(settings application.AppSettings) {
+	if err := a.service.SaveSettings(a.ctx, settings); err != nil {
+		slog.Error("save settings failed", "error", err)
+		a.doSetStatus(lang.X("app.status.settings_save_failed", "Failed to save settings: {{.Error}}", map[string]any{"Error": err}))
+	}
+}
+
+// activePresetFilter returns the hand filter of the active preset, or an
+// empty filter when no preset is active.
+func (a *App) activePresetFilter() (persistence.HandFilter, error) {
+	a.mu.Lock()
+	name := a.activePreset
+	a.mu.Unlock()
+	return a.presetFilter(name)
+}
+
+// presetFilter returns the hand filter of the named preset, or an empty
+// filter when no preset has that name.
+func (a *App) presetFilter(name string) (persistence.HandFilter, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, p := range a.presets {
+		if p.Name == name {
+			return p.HandFilter(time.Now())
+		}
+	}
+	return persistence.HandFilter{}, nil
+}
+
+// rebuildPresetBar redraws the preset bar. It is hidden on tabs that do not
+// show filtered hands. MUST be called from the Fyne main thread.
+func (a *App) rebuildPresetBar() {
+	if a.presetBar == nil {
+		return
+	}
+	// The compare tab picks its own presets.
+	if a.currentTab == tabSettings || a.currentTab == tabDataQuality || a.currentTab == tabCompare {
+		a.presetBar.Hide()
+		return
+	}
+	a.mu.Lock()
+	presets := append([]application.FilterPreset(nil), a.presets...)
+	active := a.activePreset
+	a.mu.Unlock()
+
+	bar := buildPresetBar(presets, active, presetBarActions{
+		OnSelect: a.applyPreset,
+		OnNew: func() {
+			showFilterPresetDialog(a.win, application.FilterPreset{}, func(p application.FilterPreset) {
+				go a.storePreset("", p)
+			})
+		},
+		OnEdit: func() {
+			for _, p := range presets {
+				if p.Name == active {
+					showFilterPresetDialog(a.win, p, func(next application.FilterPreset) {
+						go a.storePreset(active, next)
+					})
+				}
+			}
+		},
+		OnDelete: func() {
+			dialog.ShowConfirm(
+				lang.X("filter_preset.delete_confirm.title", "Delete preset"),
+				lang.X("filter_preset.delete_confirm.message", "Delete the preset \"{{.Name}}\"?", map[string]any{"Name": active}),
+				func(ok bool) {
+					if ok {
+						go a.storePreset(active, application.FilterPreset{})
+					}
+				}, a.win)
+		},
+		OnImport: a.importPresets,
+		OnExport: a.exportPresets,
+	})
+	a.presetBar.Objects = []fyne.CanvasObject{container.NewPadded(bar)}
+	a.presetBar.Show()
+	a.presetBar.Refresh()
+}
+
+// applyPreset makes the named preset active ("" clears it) and recomputes
+// the stats and hand history with it.
+func (a *App) applyPreset(name string) {
+	a.mu.Lock()
+	a.activePreset = name
+	a.mu.Unlock()
+	if a.handHistoryView != nil {
+		a.handHistoryView.page = 0
+	}
+	a.rebuildPresetBar()
+	if name == "" {
+		a.doSetStatus(lang.X("app.status.preset_cleared", "Showing all hands."))
+	} else {
+		a.doSetStatus(lang.X("app.status.preset_applied", "Applied preset {{.Name}}.", map[string]any{"Name": name}))
+	}
+	go a.doUpdateStats()
+}
+
+// storePreset replaces the preset named old with p (adding p when old is
+// empty, deleting old when p has no name), saves the presets and applies p.
+func (a *App) storePreset(old string, p application.FilterPreset) {
+	a.mu.Lock()
+	next := make([]application.FilterPreset, 0, len(a.presets)+1)
+	for _, existing := range a.presets {
+		if existing.Name != old && existing.Name != p.Name {
+			next = append(next, existing)
+		}
+	}
+	a.mu.Unlock()
+	if p.Name != "" {
+		next = append(next, p)
+	}
+	if err := a.service.SaveFilterPresets(a.ctx, next); err != nil {
+		slog.Error("save filter presets failed", "error", err)
+		fyne.Do(func() { dialog.ShowError(err, a.win) })
+		return
+	}
+	a.reloadPresets(p.Name)
+}
+
+// reloadPresets reads the saved presets back from the service and applies
+// the preset named active.
+func (a *App) reloadPresets(active string) {
+	presets, err := a.service.FilterPresets(a.ctx)
+	if err != nil {
+		slog.Error("load filter presets failed", "error", err)
+		fyne.Do(func() { dialog.ShowError(err, a.win) })
+		return
+	}
+	a.mu.Lock()
+	a.presets = presets
+	a.mu.Unlock()
+	fyne.Do(func() { a.applyPreset(active) })
+}
+
+// importPresets asks for a preset file and merges it into the saved presets.
+// Must be called from the Fyne main thread.
+func (a *App) importPresets() {
+	open := dialog.NewFileOpen(func(r fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, a.win)
+			return
+		}
+		if r == nil {
+			return
+		}
+		a.mu.Lock()
+		active := a.activePreset
+		a.mu.Unlock()
+		go func() {
+			defer r.Close()
+			n, err := a.service.ImportFilterPresets(a.ctx, r)
+			if err != nil {
+				slog.Error("import filter presets failed", "error", err)
+				fyne.Do(func() { dialog.ShowError(err, a.win) })
+				return
+			}
+			a.reloadPresets(active)
+			a.doSetStatus(lang.X("app.status.preset_import_done", "Imported {{.Count}} filter presets.", map[string]any{"Count": n}))
+		}()
+	}, a.win)
+	open.Show()
+}
+
+// exportPresets asks for a file and writes the saved presets to it as JSON.
+// Must be called from the Fyne main thread.
+func (a *App) exportPresets() {
+	save := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, a.win)
+			return
+		}
+		if w == nil {
+			return
+		}
+		go func() {
+			defer w.Close()
+			n, err := a.service.ExportFilterPresets(a.ctx, w)
+			if err != nil {
+				slog.Error("export filter presets failed", "error", err)
+				fyne.Do(func() { dialog.ShowError(err, a.win) })
+				return
+			}
+			a.doSetStatus(lang.X("app.status.preset_export_done", "Exported {{.Count}} filter presets.", map[string]any{"Count": n}))
+		}()
+	}, a.win)
+	save.SetFileName("filter-presets.json")
+	save.Show()
+}
+
+// showHandRawLog loads the stored raw log lines of a hand in the background
+// and shows them in a dialog on the Fyne main thread.
+func (a *App) showHandRawLog(uid string) {
+	raw, err := a.service.HandRawLog(a.ctx, uid)
+	if err != nil {
+		slog.Error("get hand raw log failed", "uid", uid, "error", err)
+		fyne.Do(func() { dialog.ShowError(err, a.win) })
+		return
+	}
+	fyne.Do(func() { showRawLogDialog(a.win, a.fyneApp.Clipboard(), raw) })
+}
+
+// showParserDiagnostics loads the diagnostics report in the background and
+// opens the report dialog on the Fyne main thread.
+func (a *App) showParserDiagnostics() {
+	report, err := a.service.DiagnosticsReport(a.ctx)
+	if err != nil {
+		slog.Error("diagnostics report failed", "error", err)
+		fyne.Do(func() { dialog.ShowError(err, a.win) })
+		return
+	}
+	fyne.Do(func() { showDiagnosticsDialog(a.win, report) })
+}
+
 func shortPath(path string) string {
 	if len(path) > 60 {
 		return "..." + path[len(path)-57:]
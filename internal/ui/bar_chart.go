@@ -0,0 +1,178 @@
+package ui
+
+import (
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// barChartBar is one bar of a barChart. Bars without samples are drawn as a
+// gap; bars marked LowSample are drawn faded. With HasRange, an error bar
+// from Low to High is drawn over the bar.
+type barChartBar struct {
+	Label     string
+	Value     float64
+	Display   string
+	Samples   int
+	LowSample bool
+	HasRange  bool
+	Low       float64
+	High      float64
+}
+
+// barChart is a minimal vertical bar chart with a zero baseline, used by the
+// analytics tab. Negative values are drawn below the baseline.
+type barChart struct {
+	widget.BaseWidget
+	bars []barChartBar
+}
+
+func newBarChart(bars []barChartBar) *barChart {
+	c := &barChart{bars: bars}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+func (c *barChart) CreateRenderer() fyne.WidgetRenderer {
+	r := &barChartRenderer{chart: c, baseline: canvas.NewRectangle(dividerBaseColor)}
+	for _, b := range c.bars {
+		fill := toNRGBA(uiInfoAccent)
+		if b.Value < 0 {
+			fill = toNRGBA(uiDangerAccent)
+		}
+		if b.LowSample {
+			fill.A = 0x60
+		}
+		rect := canvas.NewRectangle(fill)
+		rect.CornerRadius = 2
+		if b.Samples == 0 {
+			rect.Hide()
+		}
+		value := canvas.NewText(b.Display, theme.ForegroundColor())
+		value.TextSize = theme.TextSize() * 0.72
+		value.Alignment = fyne.TextAlignCenter
+		label := canvas.NewText(b.Label, uiMutedTextColor)
+		label.TextSize = theme.TextSize() * 0.78
+		label.Alignment = fyne.TextAlignCenter
+		errBar := canvas.NewLine(theme.ForegroundColor())
+		errBar.StrokeWidth = 1
+		if b.Samples == 0 || !b.HasRange {
+			errBar.Hide()
+		}
+		r.errBars = append(r.errBars, errBar)
+		r.rects = append(r.rects, rect)
+		r.values = append(r.values, value)
+		r.labels = append(r.labels, label)
+	}
+	return r
+}
+
+type barChartRenderer struct {
+	chart    *barChart
+	baseline *canvas.Rectangle
+	rects    []*canvas.Rectangle
+	errBars  []*canvas.Line
+	values   []*canvas.Text
+	labels   []*canvas.Text
+}
+
+const (
+	barChartMinBarWidth = 26
+	barChartHeight      = 180
+)
+
+func (r *barChartRenderer) Layout(size fyne.Size) {
+	n := len(r.rects)
+	if n == 0 {
+		return
+	}
+	labelH := theme.TextSize() * 1.2
+	valueH := theme.TextSize()
+	top := valueH
+	plotH := size.Height - labelH - 2*valueH
+	if plotH < 1 {
+		plotH = 1
+	}
+
+	maxV, minV := 0.0, 0.0
+	for _, b := range r.chart.bars {
+		if b.Samples == 0 {
+			continue
+		}
+		if b.Value > maxV {
+			maxV = b.Value
+		}
+		if b.Value < minV {
+			minV = b.Value
+		}
+		if b.HasRange {
+			if b.High > maxV && !math.IsInf(b.High, 1) {
+				maxV = b.High
+			}
+			if b.Low < minV {
+				minV = b.Low
+			}
+		}
+	}
+	span := maxV - minV
+	if span == 0 {
+		span = 1
+	}
+	zeroY := top + float32(maxV/span)*plotH
+
+	slot := size.Width / float32(n)
+	barW := slot * 0.7
+	for i, b := range r.chart.bars {
+		x := float32(i) * slot
+		h := float32(math.Abs(b.Value)/span) * plotH
+		y := zeroY - h
+		valueY := y - valueH
+		if b.Value < 0 {
+			y = zeroY
+			valueY = zeroY + h
+		}
+		r.rects[i].Move(fyne.NewPos(x+(slot-barW)/2, y))
+		r.rects[i].Resize(fyne.NewSize(barW, h))
+		if b.HasRange {
+			hi := b.High
+			if math.IsInf(hi, 1) {
+				hi = maxV
+			}
+			cx := x + slot/2
+			r.errBars[i].Position1 = fyne.NewPos(cx, zeroY-float32(hi/span)*plotH)
+			r.errBars[i].Position2 = fyne.NewPos(cx, zeroY-float32(b.Low/span)*plotH)
+			if b.Value >= 0 && r.errBars[i].Position1.Y-valueH < valueY {
+				valueY = r.errBars[i].Position1.Y - valueH
+			}
+		}
+		r.values[i].Move(fyne.NewPos(x, valueY))
+		r.values[i].Resize(fyne.NewSize(slot, valueH))
+		r.labels[i].Move(fyne.NewPos(x, size.Height-labelH))
+		r.labels[i].Resize(fyne.NewSize(slot, labelH))
+	}
+	r.baseline.Move(fyne.NewPos(0, zeroY))
+	r.baseline.Resize(fyne.NewSize(size.Width, 1))
+}
+
+func (r *barChartRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(float32(len(r.rects)*barChartMinBarWidth), barChartHeight)
+}
+
+func (r *barChartRenderer) Refresh() {
+	r.Layout(r.chart.Size())
+	canvas.Refresh(r.chart)
+}
+
+func (r *barChartRenderer) Objects() []fyne.CanvasObject {
+	objs := make([]fyne.CanvasObject, 0, 1+4*len(r.rects))
+	objs = append(objs, r.baseline)
+	for i := range r.rects {
+		objs = append(objs, r.rects[i], r.errBars[i], r.values[i], r.labels[i])
+	}
+	return objs
+}
+
+func (r *barChartRenderer) Destroy() {}
@@ -0,0 +1,183 @@
+package ui
+
+import (
+	"image/color"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
+)
+
+// breakdownTabView shows the overview metrics side by side for each instance
+// type, region or instance owner. Groups are loaded in the background by the
+// app and passed to Update.
+type breakdownTabView struct {
+	tabRoot
+	visibility *MetricVisibilityState
+	breakdown  stats.Breakdown
+	groups     []stats.StatsGroup
+	ownerNames map[string]string
+	loaded     bool
+
+	// onBreakdownChanged is called when the user picks another dimension.
+	onBreakdownChanged func(stats.Breakdown)
+}
+
+func newBreakdownTabView(visibility *MetricVisibilityState, onBreakdownChanged func(stats.Breakdown)) *breakdownTabView {
+	return &breakdownTabView{
+		tabRoot:            newTabRoot(),
+		visibility:         visibility,
+		breakdown:          stats.BreakdownInstanceType,
+		onBreakdownChanged: onBreakdownChanged,
+	}
+}
+
+// Update replaces the shown groups. Must be called from the Fyne main thread.
+func (v *breakdownTabView) Update(breakdown stats.Breakdown, groups []stats.StatsGroup, ownerNames map[string]string) {
+	if breakdown != v.breakdown {
+		return
+	}
+	v.groups = groups
+	v.ownerNames = ownerNames
+	v.loaded = true
+	v.rebuild()
+}
+
+func (v *breakdownTabView) rebuild() {
+	title := widget.NewLabelWithStyle(lang.X("breakdown.title", "Breakdowns"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	subtitle := widget.NewLabel(lang.X("breakdown.subtitle", "Compare your play across instance types, regions and the users or groups hosting the instance."))
+	subtitle.Wrapping = fyne.TextWrapWord
+
+	dimensions := []stats.Breakdown{stats.BreakdownInstanceType, stats.BreakdownRegion, stats.BreakdownOwner}
+	labels := make([]string, 0, len(dimensions))
+	for _, b := range dimensions {
+		labels = append(labels, breakdownLabel(b))
+	}
+	sel := widget.NewSelect(labels, nil)
+	sel.SetSelected(breakdownLabel(v.breakdown))
+	sel.OnChanged = func(s string) {
+		for i, l := range labels {
+			if l == s && dimensions[i] != v.breakdown {
+				v.breakdown = dimensions[i]
+				v.loaded = false
+				v.groups = nil
+				v.rebuild()
+				if v.onBreakdownChanged != nil {
+					v.onBreakdownChanged(v.breakdown)
+				}
+			}
+		}
+	}
+	selector := container.NewHBox(
+		widget.NewLabel(lang.X("breakdown.group_by", "Group by")),
+		container.NewGridWrap(fyne.NewSize(200, sel.MinSize().Height), sel),
+	)
+
+	var content fyne.CanvasObject
+	switch {
+	case !v.loaded:
+		loadingLabel := widget.NewLabel(lang.X("app.status.loading_stats", "Loading stats…"))
+		loadingLabel.Alignment = fyne.TextAlignCenter
+		content = container.NewCenter(loadingLabel)
+	case len(v.groups) == 0:
+		content = newCenteredEmptyState(lang.X("breakdown.no_data", "No hands to break down yet."))
+	default:
+		content = v.buildTable()
+	}
+
+	header := container.NewVBox(title, subtitle, selector, newSectionDivider())
+	replaceViewContentPreservingLayout(v.root, withFixedLowSampleLegend(container.NewPadded(container.NewBorder(header, nil, nil, nil, content))))
+}
+
+func (v *breakdownTabView) buildTable() fyne.CanvasObject {
+	metricDefs := metricsForOverview(v.visibility)
+	if len(metricDefs) == 0 {
+		return newCenteredEmptyState(lang.X("position_stats.no_metrics", "No metrics selected. Enable metrics in Settings."))
+	}
+
+	headerBG := color.NRGBA{R: 0x7C, G: 0x8E, B: 0xA1, A: 0x24}
+	headers := []positionCellData{{Main: breakdownLabel(v.breakdown), IsHead: true, BG: headerBG}}
+	for _, metric := range metricDefs {
+		headers = append(headers, positionCellData{Main: metric.Label, IsHead: true, BG: headerBG})
+	}
+	rows := [][]positionCellData{headers}
+	for _, g := range v.groups {
+		row := []positionCellData{{Main: breakdownGroupLabel(v.breakdown, g.Key, v.ownerNames)}}
+		for _, metric := range metricDefs {
+			value := metric.OverviewValue(g.Stats)
+			row = append(row, positionCellData{
+				Main:     value.Display,
+				Note:     metricFootnoteText(value.Opportunities, metric.MinSamples),
+				Color:    value.Color,
+				BG:       metricCellTint(metric.ID, value),
+				ShowWarn: metric.MinSamples > 0 && value.Opportunities < metric.MinSamples,
+			})
+		}
+		rows = append(rows, row)
+	}
+
+	numCols := len(headers)
+	numRows := len(rows)
+	t := widget.NewTable(
+		func() (int, int) { return numRows, numCols },
+		func() fyne.CanvasObject { return newPositionTableCell() },
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			cell := obj.(*positionTableCell)
+			if id.Row >= numRows || id.Col >= numCols {
+				cell.Set(positionCellData{})
+				return
+			}
+			cell.Set(rows[id.Row][id.Col])
+		},
+	)
+	t.SetColumnWidth(0, 180)
+	for i, m := range metricDefs {
+		t.SetColumnWidth(i+1, positionColumnWidth(m))
+	}
+	for row := 0; row < numRows; row++ {
+		t.SetRowHeight(row, 46)
+	}
+
+	minTableHeight := float32(numRows*46 + 16)
+	if minTableHeight < 320 {
+		minTableHeight = 320
+	}
+	minSlot := canvas.NewRectangle(color.Transparent)
+	minSlot.SetMinSize(fyne.NewSize(0, minTableHeight))
+	return newSectionCard(container.NewStack(minSlot, container.NewScroll(t)))
+}
+
+func breakdownLabel(b stats.Breakdown) string {
+	switch b {
+	case stats.BreakdownRegion:
+		return lang.X("breakdown.dimension.region", "Region")
+	case stats.BreakdownOwner:
+		return lang.X("breakdown.dimension.owner", "Instance owner")
+	default:
+		return lang.X("breakdown.dimension.instance_type", "Instance type")
+	}
+}
+
+// breakdownGroupLabel returns the row label of a group key. Owners are shown
+// by display name when one is known; group owners keep their group ID.
+func breakdownGroupLabel(b stats.Breakdown, key string, ownerNames map[string]string) string {
+	if key == "" {
+		return lang.X("breakdown.unknown", "Unknown")
+	}
+	switch b {
+	case stats.BreakdownInstanceType:
+		return lang.X("instance_type."+key, key) //i18n:ignore instance type labels are translated via key
+	case stats.BreakdownRegion:
+		return strings.ToUpper(key)
+	case stats.BreakdownOwner:
+		if name := ownerNames[key]; name != "" {
+			return name
+		}
+	}
+	return key
+}
@@ -0,0 +1,406 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
+)
+
+// compareVerdict classifies a difference between the two compared samples.
+type compareVerdict int
+
+const (
+	compareSame compareVerdict = iota
+	// compareChanged is a significant difference without a better direction.
+	compareChanged
+	compareImproved
+	compareRegressed
+)
+
+// compareTabView shows the stats of two filter presets side by side: every
+// metric, every position row and every hand range cell, with the difference
+// of B from A. Comparisons are loaded in the background by the app and passed
+// to Update.
+type compareTabView struct {
+	tabRoot
+	visibility *MetricVisibilityState
+	presets    []string
+	// a and b name the compared presets; "" means all hands.
+	a, b   string
+	cmp    *stats.Comparison
+	loaded bool
+
+	// onChanged is called when the user picks another preset on either side.
+	onChanged func(a, b string)
+}
+
+func newCompareTabView(visibility *MetricVisibilityState, onChanged func(a, b string)) *compareTabView {
+	return &compareTabView{tabRoot: newTabRoot(), visibility: visibility, onChanged: onChanged}
+}
+
+// SetPresets replaces the preset names offered on both sides. A side whose
+// preset no longer exists falls back to all hands.
+func (v *compareTabView) SetPresets(names []string) {
+	v.presets = names
+	known := func(name string) bool {
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+	if !known(v.a) {
+		v.a = ""
+	}
+	if !known(v.b) {
+		v.b = ""
+	}
+}
+
+// Update replaces the shown comparison. Comparisons of presets that are no
+// longer selected are ignored. Must be called from the Fyne main thread.
+func (v *compareTabView) Update(a, b string, cmp *stats.Comparison) {
+	if a != v.a || b != v.b {
+		return
+	}
+	v.cmp = cmp
+	v.loaded = true
+	v.rebuild()
+}
+
+func (v *compareTabView) rebuild() {
+	title := widget.NewLabelWithStyle(lang.X("compare.title", "Compare"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	subtitle := widget.NewLabel(lang.X("compare.subtitle", "Compare two filter presets side by side. Differences are B minus A; only differences unlikely to be chance are highlighted."))
+	subtitle.Wrapping = fyne.TextWrapWord
+
+	selectors := container.NewHBox(
+		widget.NewLabel(lang.X("compare.side_a", "A")),
+		v.presetSelect(v.a, func(name string) { v.a = name }),
+		widget.NewLabel(lang.X("compare.versus", "vs")),
+		widget.NewLabel(lang.X("compare.side_b", "B")),
+		v.presetSelect(v.b, func(name string) { v.b = name }),
+	)
+	header := container.NewVBox(title, subtitle, selectors, buildCompareLegend(), newSectionDivider())
+
+	var content fyne.CanvasObject
+	switch {
+	case !v.loaded:
+		loadingLabel := widget.NewLabel(lang.X("app.status.loading_stats", "Loading stats…"))
+		loadingLabel.Alignment = fyne.TextAlignCenter
+		content = container.NewCenter(loadingLabel)
+	case v.cmp == nil || (v.cmp.A.TotalHands == 0 && v.cmp.B.TotalHands == 0):
+		content = newCenteredEmptyState(lang.X("compare.no_data", "Neither side has hands yet."))
+	default:
+		summary := widget.NewLabel(lang.X("compare.summary", "A: {{.A}} hands · B: {{.B}} hands", map[string]any{
+			"A": v.cmp.A.TotalHands,
+			"B": v.cmp.B.TotalHands,
+		}))
+		content = container.NewVScroll(container.NewVBox(
+			summary,
+			v.section(lang.X("compare.metrics.title", "Metrics"), v.buildMetricTable()),
+			v.section(lang.X("compare.positions.title", "Positions"), v.buildPositionTable()),
+			v.section(lang.X("compare.range.title", "Hand range"), v.buildRangeGrid()),
+		))
+	}
+	replaceViewContentPreservingLayout(v.root, container.NewPadded(container.NewBorder(header, nil, nil, nil, content)))
+}
+
+// presetSelect builds the preset picker of one side; set stores the choice.
+func (v *compareTabView) presetSelect(selected string, set func(string)) fyne.CanvasObject {
+	allLabel := lang.X("compare.all_hands", "All hands")
+	options := append([]string{allLabel}, v.presets...)
+	sel := widget.NewSelect(options, nil)
+	if selected == "" {
+		sel.SetSelected(allLabel)
+	} else {
+		sel.SetSelected(selected)
+	}
+	sel.OnChanged = func(s string) {
+		name := s
+		if s == allLabel {
+			name = ""
+		}
+		if name == selected {
+			return
+		}
+		set(name)
+		v.loaded = false
+		v.cmp = nil
+		v.rebuild()
+		if v.onChanged != nil {
+			v.onChanged(v.a, v.b)
+		}
+	}
+	return container.NewGridWrap(fyne.NewSize(200, sel.MinSize().Height), sel)
+}
+
+func (v *compareTabView) section(title string, body fyne.CanvasObject) fyne.CanvasObject {
+	return newSectionCard(container.NewVBox(
+		widget.NewLabelWithStyle(title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		body,
+	))
+}
+
+func (v *compareTabView) buildMetricTable() fyne.CanvasObject {
+	headerBG := color.NRGBA{R: 0x7C, G: 0x8E, B: 0xA1, A: 0x24}
+	rows := [][]positionCellData{{
+		{Main: lang.X("compare.metric_header", "Metric"), IsHead: true, BG: headerBG},
+		{Main: lang.X("compare.side_a", "A"), IsHead: true, BG: headerBG},
+		{Main: lang.X("compare.side_b", "B"), IsHead: true, BG: headerBG},
+		{Main: lang.X("compare.diff_header", "B − A"), IsHead: true, BG: headerBG},
+	}}
+	comparisons := make(map[stats.MetricID]stats.MetricComparison, len(v.cmp.Metrics))
+	for _, c := range v.cmp.Metrics {
+		comparisons[c.ID] = c
+	}
+	for _, metric := range metricsForOverview(v.visibility) {
+		if metric.StatsMetricID == nil {
+			continue
+		}
+		id := *metric.StatsMetricID
+		c, ok := comparisons[id]
+		if !ok {
+			continue
+		}
+		a, b := metric.OverviewValue(v.cmp.A), metric.OverviewValue(v.cmp.B)
+		verdict := metricVerdict(c, a.Stat, b.Stat)
+		diff := positionCellData{Main: "-"}
+		if a.Stat != nil && b.Stat != nil && a.Opportunities > 0 && b.Opportunities > 0 {
+			diff = positionCellData{
+				Main: formatMetricDiff(a.Stat.Format, c.Diff),
+				Note: compareZText(c.Z, c.Significant),
+				BG:   compareVerdictTint(verdict),
+			}
+		}
+		rows = append(rows, []positionCellData{
+			{Main: metric.Label},
+			{Main: a.Display, Note: metricFootnoteText(a.Opportunities, metric.MinSamples)},
+			{Main: b.Display, Note: metricFootnoteText(b.Opportunities, metric.MinSamples)},
+			diff,
+		})
+	}
+	return compareTable(rows, []float32{180, 120, 120, 120})
+}
+
+func (v *compareTabView) buildPositionTable() fyne.CanvasObject {
+	headerBG := color.NRGBA{R: 0x7C, G: 0x8E, B: 0xA1, A: 0x24}
+	rows := [][]positionCellData{{
+		{Main: lang.X("position_stats.position_header", "Position"), IsHead: true, BG: headerBG},
+		{Main: "VPIP", IsHead: true, BG: headerBG},
+		{Main: "PFR", IsHead: true, BG: headerBG},
+		{Main: "3Bet", IsHead: true, BG: headerBG},
+	}}
+	for _, pos := range positionDisplayOrder {
+		pc, ok := v.cmp.Positions[pos]
+		if !ok {
+			continue
+		}
+		rows = append(rows, []positionCellData{
+			{Main: pos.String(), BG: positionRowTint(pos)},
+			rateComparisonCell(pc.VPIP),
+			rateComparisonCell(pc.PFR),
+			rateComparisonCell(pc.ThreeBet),
+		})
+	}
+	if len(rows) == 1 {
+		return newSubtleText(lang.X("position_stats.no_data", "No position data yet."))
+	}
+	return compareTable(rows, []float32{90, 170, 170, 170})
+}
+
+// buildRangeGrid shows, for each starting hand, how much more or less often
+// B played it than A.
+func (v *compareTabView) buildRangeGrid() fyne.CanvasObject {
+	items := make([]fyne.CanvasObject, 0, 14*14)
+	headerBG := color.NRGBA{R: 0x22, G: 0x22, B: 0x22, A: 0xFF}
+	rankCell := func(rank string, w, h float32) fyne.CanvasObject {
+		bg := canvas.NewRectangle(headerBG)
+		bg.SetMinSize(fyne.NewSize(w, h))
+		tx := canvas.NewText(rankDisplayName(rank), color.White)
+		tx.TextStyle = fyne.TextStyle{Bold: true}
+		tx.TextSize = 11
+		return container.NewStack(bg, container.NewCenter(tx))
+	}
+
+	corner := canvas.NewRectangle(headerBG)
+	corner.SetMinSize(fyne.NewSize(rangeHeaderW, rangeHeaderH))
+	items = append(items, corner)
+	for col := 0; col < 13; col++ {
+		items = append(items, rankCell(stats.RankOrder[col], rangeCellW, rangeHeaderH))
+	}
+	for row := 0; row < 13; row++ {
+		items = append(items, rankCell(stats.RankOrder[row], rangeHeaderW, rangeCellH))
+		for col := 0; col < 13; col++ {
+			items = append(items, v.rangeCell(row, col))
+		}
+	}
+	note := newSubtleText(lang.X("compare.range.note", "Each cell shows how many percentage points more (or less) often B played the hand instead of folding it preflop."))
+	return container.NewVBox(note, container.NewHBox(container.NewGridWithColumns(14, items...)))
+}
+
+func (v *compareTabView) rangeCell(row, col int) fyne.CanvasObject {
+	rc := v.cmp.HandRange[row][col]
+	bg := canvas.NewRectangle(color.NRGBA{R: 0x30, G: 0x30, B: 0x30, A: 0xFF})
+	bg.SetMinSize(fyne.NewSize(rangeCellW, rangeCellH))
+	if rc.Significant {
+		bg.FillColor = compareVerdictTint(compareChanged)
+	}
+
+	var combo string
+	if cell := v.cmp.B.HandRange.Cells[row][col]; cell != nil {
+		combo = comboDisplayLabel(cell)
+	}
+	label := canvas.NewText(combo, uiMutedTextColor)
+	label.TextSize = 10
+	label.Alignment = fyne.TextAlignCenter
+	diffText := "-"
+	if rc.NA > 0 && rc.NB > 0 {
+		diffText = fmt.Sprintf("%+.0f", rc.Diff)
+	}
+	diff := canvas.NewText(diffText, color.White)
+	diff.TextSize = 11
+	diff.TextStyle = fyne.TextStyle{Bold: rc.Significant}
+	diff.Alignment = fyne.TextAlignCenter
+	return container.NewStack(bg, container.NewCenter(container.NewVBox(label, diff)))
+}
+
+func compareTable(rows [][]positionCellData, widths []float32) fyne.CanvasObject {
+	numRows, numCols := len(rows), len(widths)
+	t := widget.NewTable(
+		func() (int, int) { return numRows, numCols },
+		func() fyne.CanvasObject { return newPositionTableCell() },
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			cell := obj.(*positionTableCell)
+			if id.Row >= numRows || id.Col >= numCols {
+				cell.Set(positionCellData{})
+				return
+			}
+			cell.Set(rows[id.Row][id.Col])
+		},
+	)
+	var width float32
+	for i, w := range widths {
+		t.SetColumnWidth(i, w)
+		width += w
+	}
+	for row := 0; row < numRows; row++ {
+		t.SetRowHeight(row, 46)
+	}
+	slot := canvas.NewRectangle(color.Transparent)
+	slot.SetMinSize(fyne.NewSize(width, float32(numRows*46+8)))
+	return container.NewStack(slot, t)
+}
+
+func rateComparisonCell(rc stats.RateComparison) positionCellData {
+	if rc.NA == 0 && rc.NB == 0 {
+		return positionCellData{Main: "-"}
+	}
+	main := fmt.Sprintf("%.1f%% → %.1f%%", rc.A, rc.B)
+	if rc.NA == 0 || rc.NB == 0 {
+		return positionCellData{Main: main, Note: lang.X("compare.one_side_only", "only one side has hands")}
+	}
+	verdict := compareSame
+	if rc.Significant {
+		verdict = compareChanged
+	}
+	return positionCellData{
+		Main: main,
+		Note: fmt.Sprintf("%s · %s", formatMetricDiff(stats.MetricFormatPercent, rc.Diff), compareZText(rc.Z, rc.Significant)),
+		BG:   compareVerdictTint(verdict),
+	}
+}
+
+// metricVerdict tells whether a significant difference moved B towards the
+// typical range of the metric. bb/100 is better when higher; metrics without
+// a typical range only report that they changed.
+func metricVerdict(c stats.MetricComparison, a, b *stats.MetricValue) compareVerdict {
+	if !c.Significant || a == nil || b == nil {
+		return compareSame
+	}
+	if c.ID == stats.MetricBBPer100 {
+		if c.Diff > 0 {
+			return compareImproved
+		}
+		return compareRegressed
+	}
+	base := insightBaselineFor(stats.TableSizeAny, c.ID)
+	if base.Lo == 0 && base.Hi == 0 {
+		return compareChanged
+	}
+	da, db := baselineDistance(base, a.Rate), baselineDistance(base, b.Rate)
+	switch {
+	case db < da:
+		return compareImproved
+	case db > da:
+		return compareRegressed
+	default:
+		return compareChanged
+	}
+}
+
+// baselineDistance is how far v lies outside the typical range of b.
+func baselineDistance(b insightBaseline, v float64) float64 {
+	switch {
+	case v < b.Lo:
+		return b.Lo - v
+	case v > b.Hi:
+		return v - b.Hi
+	default:
+		return 0
+	}
+}
+
+func formatMetricDiff(format stats.MetricFormat, diff float64) string {
+	switch format {
+	case stats.MetricFormatRatio:
+		return fmt.Sprintf("%+.2f", diff)
+	case stats.MetricFormatBBPer100:
+		return fmt.Sprintf("%+.1f", diff)
+	default:
+		return lang.X("compare.diff.points", "{{.Diff}} pt", map[string]any{"Diff": fmt.Sprintf("%+.1f", diff)})
+	}
+}
+
+func compareZText(z float64, significant bool) string {
+	if !significant {
+		return lang.X("compare.not_significant", "not significant")
+	}
+	return fmt.Sprintf("z = %.1f", z)
+}
+
+func compareVerdictTint(v compareVerdict) color.Color {
+	var c color.NRGBA
+	switch v {
+	case compareImproved:
+		c = uiSuccessAccent
+	case compareRegressed:
+		c = uiDangerAccent
+	case compareChanged:
+		c = uiInfoAccent
+	default:
+		return color.Transparent
+	}
+	c.A = 0x40
+	return c
+}
+
+func buildCompareLegend() fyne.CanvasObject {
+	chip := func(v compareVerdict, text string) fyne.CanvasObject {
+		swatch := canvas.NewRectangle(compareVerdictTint(v))
+		swatch.SetMinSize(fyne.NewSize(14, 14))
+		return container.NewHBox(container.NewCenter(swatch), newSubtleText(text))
+	}
+	return container.NewHBox(
+		chip(compareImproved, lang.X("compare.legend.improved", "Improved")),
+		chip(compareRegressed, lang.X("compare.legend.regressed", "Regressed")),
+		chip(compareChanged, lang.X("compare.legend.changed", "Changed")),
+	)
+}
@@ -0,0 +1,242 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
+)
+
+// dataQualityMaxHands caps the number of excluded hands listed in the data
+// quality tab. Excluded hands should be rare; a larger number points at a log
+// format problem that the parser diagnostics report explains better.
+const dataQualityMaxHands = 500
+
+func anomalySeverityText(severity string) string {
+	switch severity {
+	case parser.AnomalySeverityError:
+		return lang.X("data_quality.severity.error", "Error")
+	case parser.AnomalySeverityWarn:
+		return lang.X("data_quality.severity.warn", "Warning")
+	default:
+		return lang.X("data_quality.severity.info", "Info")
+	}
+}
+
+// anomalyReasonText returns a human-readable explanation of an anomaly code.
+func anomalyReasonText(code string) string {
+	switch code {
+	case parser.AnomalyBoardParseError:
+		return lang.X("data_quality.reason.board_parse_error", "A community card could not be parsed.")
+	case parser.AnomalyBoardOverflow:
+		return lang.X("data_quality.reason.board_overflow", "More than five community cards were dealt.")
+	case parser.AnomalyBoardDuplicateCard:
+		return lang.X("data_quality.reason.board_duplicate_card", "The same card appears twice on the board.")
+	case parser.AnomalyBoardCountInvalid:
+		return lang.X("data_quality.reason.board_count_invalid", "The board ended with an impossible number of cards.")
+	case parser.AnomalyChipConservation:
+		return lang.X("data_quality.reason.chip_conservation", "Chips put into the pot do not match the amount paid out.")
+	case parser.AnomalyActionOrder:
+		return lang.X("data_quality.reason.action_order", "A player acted after folding or out of street order.")
+	case parser.AnomalyHoleCardOnBoard:
+		return lang.X("data_quality.reason.hole_card_on_board", "A hole card is also on the board.")
+	case parser.AnomalyFoldedWinner:
+		return lang.X("data_quality.reason.folded_winner", "A player won chips after folding.")
+	case parser.AnomalyMissingBlinds:
+		return lang.X("data_quality.reason.missing_blinds", "The blinds were not posted.")
+	case parser.AnomalyLocalSeatUnknown:
+		return lang.X("data_quality.reason.local_seat_unknown", "Your seat could not be determined.")
+	default:
+		return lang.X("data_quality.reason.unknown", "Unclassified data problem.")
+	}
+}
+
+// anomalySuggestionText returns a repair suggestion for an anomaly code.
+func anomalySuggestionText(code string) string {
+	switch code {
+	case parser.AnomalyBoardParseError, parser.AnomalyBoardOverflow,
+		parser.AnomalyBoardDuplicateCard, parser.AnomalyBoardCountInvalid:
+		return lang.X("data_quality.suggestion.board", "Usually a truncated or interleaved log. Check the parser diagnostics for unrecognized board lines.")
+	case parser.AnomalyChipConservation:
+		return lang.X("data_quality.suggestion.chip_conservation", "A pot or bet line was probably lost. If it happens often, export the parser diagnostics and report it.")
+	case parser.AnomalyActionOrder, parser.AnomalyFoldedWinner:
+		return lang.X("data_quality.suggestion.action_order", "Two hands may have been merged, for example after rejoining the table mid-hand.")
+	case parser.AnomalyHoleCardOnBoard:
+		return lang.X("data_quality.suggestion.hole_card_on_board", "The hole cards probably belong to a different hand. The hand is excluded so it does not skew your ranges.")
+	case parser.AnomalyMissingBlinds:
+		return lang.X("data_quality.suggestion.missing_blinds", "Logging probably started mid-hand. No action is needed.")
+	case parser.AnomalyLocalSeatUnknown:
+		return lang.X("data_quality.suggestion.local_seat_unknown", "Sit at the table before the hand starts so your seat is recorded.")
+	default:
+		return lang.X("data_quality.suggestion.unknown", "Export the parser diagnostics and report the problem.")
+	}
+}
+
+// buildAnomalyRows lists each anomaly with its severity, reason, detail and
+// repair suggestion.
+func buildAnomalyRows(anomalies []parser.HandAnomaly, textSize float32) []fyne.CanvasObject {
+	rows := make([]fyne.CanvasObject, 0, len(anomalies)*3)
+	for _, anom := range anomalies {
+		headline := lang.X("data_quality.anomaly_row", "[{{.Severity}}] {{.Reason}}",
+			map[string]any{"Severity": anomalySeverityText(anom.Severity), "Reason": anomalyReasonText(anom.Code)})
+		c := uiWarningColor
+		if !anom.ExcludesFromStats() {
+			c = uiMutedTextColor
+		}
+		headText := canvas.NewText(headline, c)
+		headText.TextSize = textSize
+		headText.TextStyle = fyne.TextStyle{Bold: true}
+		rows = append(rows, headText)
+
+		detail := anom.Code
+		if anom.Detail != "" {
+			detail += " · " + anom.Detail
+		}
+		rows = append(rows, newSubtleText(detail))
+		suggestion := widget.NewLabel(anomalySuggestionText(anom.Code))
+		suggestion.Wrapping = fyne.TextWrapWord
+		rows = append(rows, suggestion)
+	}
+	return rows
+}
+
+// excludedHandReasons summarizes the anomalies that exclude h from stats.
+func excludedHandReasons(h *parser.Hand) string {
+	out := ""
+	for _, anom := range h.Anomalies {
+		if !anom.ExcludesFromStats() {
+			continue
+		}
+		if out != "" {
+			out += ", "
+		}
+		out += anomalyReasonText(anom.Code)
+	}
+	if out == "" {
+		out = lang.X("hand_history.anomaly.flagged", "Potentially anomalous hand data detected.")
+	}
+	return out
+}
+
+type dataQualityTabView struct {
+	tabRoot
+	hands     []*parser.Hand
+	localSeat int
+	loaded    bool
+	selected  string
+
+	// onShowRawLog opens the stored raw log of the hand with the given UID.
+	onShowRawLog func(uid string)
+
+	detailContent *fyne.Container
+	list          *widget.List
+	split         *container.Split
+}
+
+func newDataQualityTabView(onShowRawLog func(uid string)) *dataQualityTabView {
+	return &dataQualityTabView{tabRoot: newTabRoot(), onShowRawLog: onShowRawLog}
+}
+
+// Update replaces the listed hands. Must be called from the Fyne main thread.
+func (v *dataQualityTabView) Update(hands []*parser.Hand, localSeat int) {
+	v.hands = hands
+	v.localSeat = localSeat
+	v.loaded = true
+	v.ensureInitialized()
+	v.list.Refresh()
+	v.restoreSelection()
+	v.rebuild()
+}
+
+func (v *dataQualityTabView) rebuild() {
+	v.ensureInitialized()
+
+	title := widget.NewLabelWithStyle(lang.X("data_quality.title", "Data Quality"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	subtitle := widget.NewLabel(lang.X("data_quality.subtitle", "Hands listed here are excluded from stats because the log data is inconsistent. Informational issues are shown in the hand details but do not exclude a hand."))
+	subtitle.Wrapping = fyne.TextWrapWord
+
+	var content fyne.CanvasObject
+	switch {
+	case !v.loaded:
+		loadingLabel := widget.NewLabel(lang.X("data_quality.loading", "Loading excluded hands…"))
+		loadingLabel.Alignment = fyne.TextAlignCenter
+		content = container.NewCenter(loadingLabel)
+	case len(v.hands) == 0:
+		content = newCenteredEmptyState(lang.X("data_quality.empty", "No hands are excluded from stats."))
+	default:
+		content = v.split
+	}
+
+	header := []fyne.CanvasObject{title, subtitle}
+	if v.loaded && len(v.hands) > 0 {
+		count := lang.X("data_quality.count", "{{.Count}} excluded hands", map[string]any{"Count": len(v.hands)})
+		if len(v.hands) >= dataQualityMaxHands {
+			count = lang.X("data_quality.count_capped", "Showing the latest {{.Count}} excluded hands", map[string]any{"Count": len(v.hands)})
+		}
+		header = append(header, newSubtleText(count))
+	}
+	header = append(header, newSectionDivider())
+	replaceViewContentPreservingLayout(v.root, container.NewBorder(container.NewVBox(header...), nil, nil, nil, content))
+}
+
+func (v *dataQualityTabView) ensureInitialized() {
+	if v.detailContent == nil {
+		v.detailContent = container.NewStack(buildDetailPanelEmpty(lang.X("hand_history.select_hand", "Select a hand to see details.")))
+	}
+	if v.list != nil {
+		return
+	}
+	v.list = widget.NewList(
+		func() int { return len(v.hands) },
+		func() fyne.CanvasObject {
+			when := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+			reason := widget.NewLabel("")
+			reason.Truncation = fyne.TextTruncateEllipsis
+			return container.NewVBox(when, reason)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id < 0 || id >= len(v.hands) {
+				return
+			}
+			h := v.hands[id]
+			box, ok := obj.(*fyne.Container)
+			if !ok || len(box.Objects) < 2 {
+				return
+			}
+			box.Objects[0].(*widget.Label).SetText(h.StartTime.Format("2006-01-02 15:04:05"))
+			box.Objects[1].(*widget.Label).SetText(excludedHandReasons(h))
+		},
+	)
+	v.list.OnSelected = func(id widget.ListItemID) {
+		if id < 0 || id >= len(v.hands) {
+			return
+		}
+		h := v.hands[id]
+		v.selected = h.HandUID
+		var onShowRawLog func()
+		if v.onShowRawLog != nil {
+			uid := h.HandUID
+			onShowRawLog = func() { v.onShowRawLog(uid) }
+		}
+		v.detailContent.Objects = []fyne.CanvasObject{buildDetailPanel(h, v.localSeat, onShowRawLog)}
+		v.detailContent.Refresh()
+	}
+	v.split = container.NewHSplit(v.list, v.detailContent)
+	v.split.Offset = 0.42
+}
+
+func (v *dataQualityTabView) restoreSelection() {
+	for i, h := range v.hands {
+		if v.selected != "" && h.HandUID == v.selected {
+			v.list.Select(i)
+			return
+		}
+	}
+	v.selected = ""
+	v.list.UnselectAll()
+	v.detailContent.Objects = []fyne.CanvasObject{buildDetailPanelEmpty(lang.X("hand_history.select_hand", "Select a hand to see details."))}
+	v.detailContent.Refresh()
+}
@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/application"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/persistence"
+)
+
+func integrityIssueText(issue persistence.IntegrityIssue) string {
+	switch issue.Problem {
+	case persistence.ProblemCorruption:
+		return lang.X("db_check.problem.corruption", "Database corruption: {{.Detail}}", map[string]any{"Detail": issue.Detail})
+	case persistence.ProblemOrphanRows:
+		return lang.X("db_check.problem.orphan_rows", "{{.Count}} leftover rows of removed hands in {{.Table}}",
+			map[string]any{"Count": issue.Count, "Table": issue.Subject})
+	case persistence.ProblemHandWithoutSource:
+		return lang.X("db_check.problem.hand_without_source", "{{.Count}} hands without a source log", map[string]any{"Count": issue.Count})
+	case persistence.ProblemStaleFilterIDs:
+		return lang.X("db_check.problem.stale_filter_ids", "{{.Count}} hands with outdated hand category filters", map[string]any{"Count": issue.Count})
+	case persistence.ProblemCursorBeyondFile:
+		return lang.X("db_check.problem.cursor_beyond_file", "Import position past the end of {{.Path}}", map[string]any{"Path": shortPath(issue.Subject)})
+	default:
+		return string(issue.Problem) + ": " + issue.Subject
+	}
+}
+
+func integrityIssueRows(issues []persistence.IntegrityIssue) []fyne.CanvasObject {
+	rows := make([]fyne.CanvasObject, 0, len(issues))
+	for _, issue := range issues {
+		label := widget.NewLabel("• " + integrityIssueText(issue))
+		label.Wrapping = fyne.TextWrapWord
+		rows = append(rows, label)
+	}
+	return rows
+}
+
+func hasCorruption(issues []persistence.IntegrityIssue) bool {
+	for _, issue := range issues {
+		if !issue.Repairable() {
+			return true
+		}
+	}
+	return false
+}
+
+func newDatabaseCheckContent(intro string, issues []persistence.IntegrityIssue) fyne.CanvasObject {
+	introLabel := widget.NewLabel(intro)
+	introLabel.Wrapping = fyne.TextWrapWord
+	rows := []fyne.CanvasObject{introLabel, newSectionCard(container.NewVBox(integrityIssueRows(issues)...))}
+	if hasCorruption(issues) {
+		rows = append(rows, newSubtleText(lang.X("db_check.corruption_hint", "SQLite reported corruption, which cannot be repaired here. Restore a backup of the database, or reset it and import your logs again.")))
+	}
+	return container.NewVScroll(container.NewVBox(rows...))
+}
+
+// showDatabaseCheckDialog shows the problems found by a database check and
+// calls onRepair when the user confirms repairing them.
+func showDatabaseCheckDialog(win fyne.Window, check application.DatabaseCheck, onRepair func()) {
+	title := lang.X("db_check.title", "Check Database")
+	if len(check.Issues) == 0 {
+		dialog.ShowInformation(title, lang.X("db_check.ok", "No problems were found."), win)
+		return
+	}
+	content := newDatabaseCheckContent(lang.X("db_check.intro", "The check found these problems. Repairing removes leftover rows, restores or removes hands without a source log, recomputes hand category filters and re-imports logs whose import position is past their end."), check.Issues)
+	var d dialog.Dialog
+	if check.Repairable() {
+		d = dialog.NewCustomConfirm(title,
+			lang.X("db_check.repair", "Repair"),
+			lang.X("db_check.close", "Close"),
+			content,
+			func(ok bool) {
+				if ok && onRepair != nil {
+					onRepair()
+				}
+			},
+			win,
+		)
+	} else {
+		d = dialog.NewCustom(title, lang.X("db_check.close", "Close"), content, win)
+	}
+	d.Resize(fyne.NewSize(640, 420))
+	d.Show()
+}
+
+// showDatabaseRepairDialog shows what a repair fixed and what is left.
+func showDatabaseRepairDialog(win fyne.Window, res application.DatabaseRepair) {
+	rows := []fyne.CanvasObject{}
+	if len(res.Repaired) == 0 {
+		rows = append(rows, widget.NewLabel(lang.X("db_check.nothing_repaired", "Nothing needed repairing.")))
+	} else {
+		rows = append(rows, newDatabaseCheckContent(lang.X("db_check.repaired", "Repaired:"), res.Repaired))
+	}
+	if len(res.Remaining) > 0 {
+		rows = append(rows, newDatabaseCheckContent(lang.X("db_check.remaining", "Still found after the repair:"), res.Remaining))
+	}
+	d := dialog.NewCustom(lang.X("db_check.title", "Check Database"), lang.X("db_check.close", "Close"), container.NewVBox(rows...), win)
+	d.Resize(fyne.NewSize(640, 420))
+	d.Show()
+}
@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/application"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
+)
+
+// diagnosticsTopPatterns is the number of unrecognized patterns listed per file.
+// The JSON export always contains every pattern.
+const diagnosticsTopPatterns = 10
+
+func diagnosticsSummaryText(snap parser.DiagnosticsSnapshot) string {
+	return lang.X("diagnostics.summary",
+		"Poker lines: {{.Tagged}} / unrecognized: {{.Unrecognized}} ({{.UnrecognizedRate}})\nHands: {{.Hands}} / anomalous: {{.Anomalous}} ({{.AnomalyRate}})",
+		map[string]any{
+			"Tagged":           snap.TaggedLines,
+			"Unrecognized":     snap.UnrecognizedLines,
+			"UnrecognizedRate": fmt.Sprintf("%.2f%%", snap.UnrecognizedRate*100),
+			"Hands":            snap.Hands,
+			"Anomalous":        snap.AnomalousHands,
+			"AnomalyRate":      fmt.Sprintf("%.2f%%", snap.AnomalyRate*100),
+		})
+}
+
+func buildDiagnosticsFileCard(file application.LogFileDiagnostics) fyne.CanvasObject {
+	pathLabel := widget.NewLabelWithStyle(shortPath(file.SourcePath), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	summary := widget.NewLabel(diagnosticsSummaryText(file.DiagnosticsSnapshot))
+	summary.Wrapping = fyne.TextWrapWord
+
+	rows := []fyne.CanvasObject{pathLabel, summary}
+	if len(file.Patterns) == 0 {
+		rows = append(rows, newSubtleText(lang.X("diagnostics.no_unrecognized", "No unrecognized poker lines.")))
+		return newSectionCard(container.NewVBox(rows...))
+	}
+
+	for i, p := range file.Patterns {
+		if i >= diagnosticsTopPatterns {
+			rows = append(rows, newSubtleText(lang.X("diagnostics.more_patterns", "…and {{.N}} more patterns (see export)",
+				map[string]any{"N": len(file.Patterns) - diagnosticsTopPatterns})))
+			break
+		}
+		lbl := widget.NewLabel(lang.X("diagnostics.pattern_row", "{{.Count}}× {{.Pattern}} (first at line {{.Line}})",
+			map[string]any{"Count": p.Count, "Pattern": p.Pattern, "Line": p.FirstLine}))
+		lbl.Wrapping = fyne.TextWrapBreak
+		rows = append(rows, lbl)
+	}
+	return newSectionCard(container.NewVBox(rows...))
+}
+
+// showDiagnosticsDialog displays the parser diagnostics report with an export button.
+func showDiagnosticsDialog(win fyne.Window, report application.DiagnosticsReport) {
+	intro := widget.NewLabel(lang.X("diagnostics.intro", "Unrecognized [Table]/[Seat]/[Pot]/[Manager] lines usually mean the VR Poker log format has changed. Only files parsed since the app started are listed."))
+	intro.Wrapping = fyne.TextWrapWord
+
+	totalsHeader := widget.NewLabelWithStyle(lang.X("diagnostics.totals", "All files"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	totals := widget.NewLabel(diagnosticsSummaryText(report.Totals))
+	totals.Wrapping = fyne.TextWrapWord
+
+	rows := []fyne.CanvasObject{intro, newSectionCard(container.NewVBox(totalsHeader, totals)), newSectionDivider()}
+	if len(report.Files) == 0 {
+		rows = append(rows, widget.NewLabel(lang.X("diagnostics.no_files", "No log files have been parsed in this session.")))
+	}
+	for _, file := range report.Files {
+		rows = append(rows, buildDiagnosticsFileCard(file))
+	}
+
+	exportBtn := widget.NewButton(lang.X("diagnostics.export", "Export JSON..."), func() {
+		exportDiagnosticsReport(win, report)
+	})
+	exportBtn.Importance = widget.HighImportance
+
+	scroll := container.NewVScroll(container.NewVBox(rows...))
+	content := container.NewBorder(nil, exportBtn, nil, nil, scroll)
+	d := dialog.NewCustom(lang.X("diagnostics.title", "Parser Diagnostics"), lang.X("diagnostics.close", "Close"), content, win)
+	d.Resize(fyne.NewSize(760, 560))
+	d.Show()
+}
+
+func exportDiagnosticsReport(win fyne.Window, report application.DiagnosticsReport) {
+	save := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		if w == nil {
+			return
+		}
+		defer w.Close()
+		if err := report.WriteJSON(w); err != nil {
+			dialog.ShowError(err, win)
+		}
+	}, win)
+	save.SetFileName("parser-diagnostics-" + report.GeneratedAt.Format("20060102-150405") + ".json")
+	save.Show()
+}
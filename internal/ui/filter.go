@@ -35,6 +35,8 @@ type TabFilterState struct {
 	NHands  int       // for FilterModeLastNHands
 	From    time.Time // for Custom
 	To      time.Time // for Custom
+	// TableSize restricts the tab to hands of one table size.
+	TableSize stats.TableSize
 }
 
 // commitEntry is a widget.Entry that fires onCommit only when the user
@@ -376,6 +378,11 @@ func buildFilterBar(state *TabFilterState, trendN int, onChange func()) fyne.Can
 	items = append(items, periodLabel, modeSelect)
 	items = append(items, conditionalWidgets...)
 
+	items = append(items, newTableSizeSelector(state.TableSize, func(size stats.TableSize) {
+		state.TableSize = size
+		onChange()
+	}))
+
 	hbox := container.NewHBox(items...)
 
 	// Wire up mode select after hbox is created so we can refresh it.
@@ -392,6 +399,43 @@ func buildFilterBar(state *TabFilterState, trendN int, onChange func()) fyne.Can
 	return hbox
 }
 
+// newTableSizeSelector returns a labelled select for a table size, where the
+// first option stands for every size.
+func newTableSizeSelector(current stats.TableSize, onChange func(stats.TableSize)) fyne.CanvasObject {
+	sizes := append([]stats.TableSize{stats.TableSizeAny}, stats.AllTableSizes()...)
+	options := make([]string, len(sizes))
+	for i, size := range sizes {
+		options[i] = tableSizeLabel(size)
+	}
+	sel := widget.NewSelect(options, nil)
+	for i, size := range sizes {
+		if size == current {
+			sel.Selected = options[i]
+		}
+	}
+	sel.OnChanged = func(selected string) {
+		for i, opt := range options {
+			if opt == selected {
+				onChange(sizes[i])
+				return
+			}
+		}
+	}
+	return container.NewHBox(widget.NewLabel(lang.X("filter.table_size.label", "Table")), sel)
+}
+
+// tableSizeLabel returns the display label of a table size.
+func tableSizeLabel(size stats.TableSize) string {
+	if size == stats.TableSizeHeadsUp {
+		return lang.X("table_size.hu", "Heads-up")
+	}
+	if size == stats.TableSizeAny {
+		return lang.X("table_size.any", "All sizes")
+	}
+	minPlayers, maxPlayers := size.PlayerRange()
+	return lang.X("table_size.range", "{{.Min}}-{{.Max}} players", map[string]any{"Min": minPlayers, "Max": maxPlayers})
+}
+
 // newDateEntry creates a commitEntry for YYYY-MM-DD date input.
 // No modification is made during typing; onChange fires only on Enter or focus-lost.
 func newDateEntry(initial time.Time, onChange func(time.Time)) *commitEntry {
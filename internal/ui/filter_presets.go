@@ -0,0 +1,270 @@
+package ui
+
+import (
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/application"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
+)
+
+// presetBarActions are the callbacks of the filter preset bar. OnSelect
+// receives "" when the user clears the active preset.
+type presetBarActions struct {
+	OnSelect func(name string)
+	OnNew    func()
+	OnEdit   func()
+	OnDelete func()
+	OnImport func()
+	OnExport func()
+}
+
+// buildPresetBar builds the preset selector shown above every stats and hand
+// history tab.
+func buildPresetBar(presets []application.FilterPreset, active string, actions presetBarActions) fyne.CanvasObject {
+	none := lang.X("filter_preset.none", "No preset (all hands)")
+	options := []string{none}
+	for _, p := range presets {
+		options = append(options, p.Name)
+	}
+	sel := widget.NewSelect(options, nil)
+	if active != "" {
+		sel.SetSelected(active)
+	} else {
+		sel.SetSelected(none)
+	}
+	sel.OnChanged = func(v string) {
+		if v == none {
+			v = ""
+		}
+		if v != active && actions.OnSelect != nil {
+			actions.OnSelect(v)
+		}
+	}
+
+	newBtn := widget.NewButtonWithIcon(lang.X("filter_preset.new", "New"), theme.ContentAddIcon(), actions.OnNew)
+	editBtn := widget.NewButtonWithIcon(lang.X("filter_preset.edit", "Edit"), theme.DocumentCreateIcon(), actions.OnEdit)
+	deleteBtn := widget.NewButtonWithIcon(lang.X("filter_preset.delete", "Delete"), theme.DeleteIcon(), actions.OnDelete)
+	if active == "" {
+		editBtn.Disable()
+		deleteBtn.Disable()
+	}
+	importBtn := widget.NewButtonWithIcon(lang.X("filter_preset.import", "Import..."), theme.DownloadIcon(), actions.OnImport)
+	exportBtn := widget.NewButtonWithIcon(lang.X("filter_preset.export", "Export..."), theme.UploadIcon(), actions.OnExport)
+	if len(presets) == 0 {
+		exportBtn.Disable()
+	}
+	for _, b := range []*widget.Button{newBtn, editBtn, deleteBtn, importBtn, exportBtn} {
+		b.Importance = widget.LowImportance
+	}
+
+	label := widget.NewLabelWithStyle(lang.X("filter_preset.label", "Preset"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	return container.NewBorder(nil, nil, label,
+		container.NewHBox(newBtn, editBtn, deleteBtn, importBtn, exportBtn),
+		container.NewGridWrap(fyne.NewSize(260, sel.MinSize().Height), sel))
+}
+
+// presetCheckGroup builds check boxes for values, preselecting selected, and
+// returns a function reporting the checked values in display order.
+func presetCheckGroup(values, labels, selected []string, columns int) (fyne.CanvasObject, func() []string) {
+	on := make(map[string]bool, len(selected))
+	for _, v := range selected {
+		on[v] = true
+	}
+	checks := make([]fyne.CanvasObject, 0, len(values))
+	for i, v := range values {
+		v := v
+		check := widget.NewCheck(labels[i], func(checked bool) { on[v] = checked })
+		check.Checked = on[v]
+		checks = append(checks, check)
+	}
+	get := func() []string {
+		var out []string
+		for _, v := range values {
+			if on[v] {
+				out = append(out, v)
+			}
+		}
+		return out
+	}
+	return container.NewGridWithColumns(columns, checks...), get
+}
+
+// showFilterPresetDialog opens the preset editor. onSave is called with the
+// edited preset once it is valid; validation errors are shown in the dialog.
+func showFilterPresetDialog(win fyne.Window, initial application.FilterPreset, onSave func(application.FilterPreset)) {
+	name := widget.NewEntry()
+	name.SetText(initial.Name)
+	name.SetPlaceHolder(lang.X("filter_preset.name_placeholder", "e.g. Button, short-handed, last 30 days"))
+
+	periodModes := []string{
+		application.PresetPeriodAll,
+		application.PresetPeriodLastDays,
+		application.PresetPeriodLastMonths,
+		application.PresetPeriodCustom,
+	}
+	periodLabels := []string{
+		lang.X("filter.mode.all", "All Time"),
+		lang.X("filter.mode.last_n_days_select", "Last N Days"),
+		lang.X("filter.mode.last_n_months_select", "Last N Months"),
+		lang.X("filter.mode.custom", "Custom Range"),
+	}
+	periodN := widget.NewEntry()
+	if initial.Period.N > 0 {
+		periodN.SetText(strconv.Itoa(initial.Period.N))
+	} else {
+		periodN.SetText("30") //i18n:ignore default period length
+	}
+	from := widget.NewEntry()
+	from.SetPlaceHolder(lang.X("filter.custom.date_hint", "YYYY-MM-DD"))
+	from.SetText(initial.Period.From)
+	to := widget.NewEntry()
+	to.SetPlaceHolder(lang.X("filter.custom.date_hint", "YYYY-MM-DD"))
+	to.SetText(initial.Period.To)
+	periodMode := initial.Period.Mode
+	if periodMode == "" {
+		periodMode = application.PresetPeriodAll
+	}
+	updatePeriodFields := func() {
+		periodN.Hide()
+		from.Hide()
+		to.Hide()
+		switch periodMode {
+		case application.PresetPeriodLastDays, application.PresetPeriodLastMonths:
+			periodN.Show()
+		case application.PresetPeriodCustom:
+			from.Show()
+			to.Show()
+		}
+	}
+	periodSelect := widget.NewSelect(periodLabels, func(v string) {
+		for i, l := range periodLabels {
+			if l == v {
+				periodMode = periodModes[i]
+			}
+		}
+		updatePeriodFields()
+	})
+	for i, m := range periodModes {
+		if m == periodMode {
+			periodSelect.SetSelected(periodLabels[i])
+		}
+	}
+	updatePeriodFields()
+	periodRow := container.NewHBox(periodSelect,
+		container.NewGridWrap(fyne.NewSize(80, periodN.MinSize().Height), periodN),
+		container.NewGridWrap(fyne.NewSize(130, from.MinSize().Height), from),
+		container.NewGridWrap(fyne.NewSize(130, to.MinSize().Height), to))
+
+	positions := []string{"SB", "BB", "UTG", "UTG1", "MP", "HJ", "CO", "BTN"}
+	positionLabels := []string{"SB", "BB", "UTG", "UTG+1", "MP", "HJ", "CO", "BTN"}
+	positionGroup, getPositions := presetCheckGroup(positions, positionLabels, initial.Positions, 8)
+
+	pockets := stats.AllPocketCategories()
+	pocketCodes := make([]string, 0, len(pockets))
+	pocketLabels := make([]string, 0, len(pockets))
+	for _, cat := range pockets {
+		pocketCodes = append(pocketCodes, pocketI18nKey(cat))
+		pocketLabels = append(pocketLabels, lang.X("pocket."+pocketI18nKey(cat), stats.PocketCategoryLabel(cat))) //i18n:ignore pocket category labels are already translated via key
+	}
+	pocketGroup, getPockets := presetCheckGroup(pocketCodes, pocketLabels, initial.PocketCategories, 3)
+
+	classes := stats.AllMadeHandClasses()
+	classCodes := make([]string, 0, len(classes))
+	classLabels := make([]string, 0, len(classes))
+	for _, cls := range classes {
+		classCodes = append(classCodes, strings.ToLower(strings.ReplaceAll(cls, " ", "_")))
+		classLabels = append(classLabels, lang.X("final."+finalI18nKey(cls), cls)) //i18n:ignore final hand class labels are already translated via key
+	}
+	classGroup, getClasses := presetCheckGroup(classCodes, classLabels, initial.FinalClasses, 3)
+
+	sizes := stats.AllTableSizes()
+	sizeCodes := make([]string, 0, len(sizes))
+	sizeLabels := make([]string, 0, len(sizes))
+	for _, size := range sizes {
+		sizeCodes = append(sizeCodes, size.Code())
+		sizeLabels = append(sizeLabels, tableSizeLabel(size))
+	}
+	sizeGroup, getSizes := presetCheckGroup(sizeCodes, sizeLabels, initial.TableSizes, 4)
+
+	instanceTypes := []parser.InstanceType{
+		parser.InstanceTypePublic, parser.InstanceTypeFriends, parser.InstanceTypeFriendsPlus,
+		parser.InstanceTypeInvite, parser.InstanceTypeInvitePlus, parser.InstanceTypeGroup,
+		parser.InstanceTypeGroupPlus, parser.InstanceTypeGroupPublic,
+	}
+	instanceCodes := make([]string, 0, len(instanceTypes))
+	instanceLabels := make([]string, 0, len(instanceTypes))
+	for _, t := range instanceTypes {
+		instanceCodes = append(instanceCodes, string(t))
+		instanceLabels = append(instanceLabels, lang.X("instance_type."+string(t), string(t))) //i18n:ignore instance type labels are translated via key
+	}
+	instanceGroup, getInstances := presetCheckGroup(instanceCodes, instanceLabels, initial.InstanceTypes, 4)
+
+	query := widget.NewEntry()
+	query.SetText(initial.Query)
+	query.SetPlaceHolder(lang.X("filter_preset.query_placeholder", "Optional search, e.g. action:flop:checkraise"))
+
+	errLabel := widget.NewLabel("")
+	errLabel.Importance = widget.DangerImportance
+	errLabel.Wrapping = fyne.TextWrapWord
+	errLabel.Hide()
+
+	section := func(title string, obj fyne.CanvasObject) fyne.CanvasObject {
+		return container.NewVBox(widget.NewLabelWithStyle(title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}), obj)
+	}
+	form := container.NewVBox(
+		section(lang.X("filter_preset.field.name", "Name"), name),
+		section(lang.X("filter.mode.label", "Period"), periodRow),
+		section(lang.X("filter_preset.field.positions", "Positions"), positionGroup),
+		section(lang.X("hand_history.filter.pocket.title", "Pocket Hand"), pocketGroup),
+		section(lang.X("hand_history.filter.final.title", "Final Hand"), classGroup),
+		section(lang.X("filter_preset.field.table_sizes", "Table size"), sizeGroup),
+		section(lang.X("filter_preset.field.instance_types", "Instance type"), instanceGroup),
+		section(lang.X("filter_preset.field.query", "Search"), query),
+		errLabel,
+	)
+
+	var d dialog.Dialog
+	saveBtn := widget.NewButtonWithIcon(lang.X("filter_preset.save", "Save"), theme.DocumentSaveIcon(), func() {
+		p := application.FilterPreset{
+			Name:             strings.TrimSpace(name.Text),
+			Period:           application.PresetPeriod{Mode: periodMode},
+			Positions:        getPositions(),
+			PocketCategories: getPockets(),
+			FinalClasses:     getClasses(),
+			TableSizes:       getSizes(),
+			InstanceTypes:    getInstances(),
+			Query:            strings.TrimSpace(query.Text),
+		}
+		switch periodMode {
+		case application.PresetPeriodLastDays, application.PresetPeriodLastMonths:
+			p.Period.N, _ = strconv.Atoi(strings.TrimSpace(periodN.Text))
+		case application.PresetPeriodCustom:
+			p.Period.From = strings.TrimSpace(from.Text)
+			p.Period.To = strings.TrimSpace(to.Text)
+		}
+		if err := p.Validate(); err != nil {
+			errLabel.SetText(lang.X("filter_preset.invalid", "Cannot save preset: {{.Error}}", map[string]any{"Error": err.Error()}))
+			errLabel.Show()
+			return
+		}
+		d.Hide()
+		onSave(p)
+	})
+	saveBtn.Importance = widget.HighImportance
+	cancelBtn := widget.NewButton(lang.X("filter_preset.cancel", "Cancel"), func() { d.Hide() })
+
+	content := container.NewBorder(nil, container.NewHBox(layout.NewSpacer(), cancelBtn, saveBtn), nil, nil, container.NewVScroll(form))
+	d = dialog.NewCustomWithoutButtons(lang.X("filter_preset.dialog_title", "Filter Preset"), content, win)
+	d.Resize(fyne.NewSize(820, 640))
+	d.Show()
+}
@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/persistence"
+)
+
+// buildAnnotationEditor renders the star toggle, tag list and note of a hand.
+// onSave receives the edited annotation; it is called from the Fyne main
+// thread and should persist in the background.
+func buildAnnotationEditor(uid string, current *persistence.HandAnnotation, onSave func(persistence.HandAnnotation)) fyne.CanvasObject {
+	a := persistence.HandAnnotation{HandUID: uid}
+	if current != nil {
+		a = *current
+	}
+
+	header := widget.NewLabelWithStyle(lang.X("hand_history.annotation.title", "Notes & Tags"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+
+	starBtn := widget.NewButton("", nil)
+	updateStar := func() {
+		if a.Starred {
+			starBtn.SetText(lang.X("hand_history.annotation.unstar", "★ Starred"))
+			starBtn.Importance = widget.WarningImportance
+		} else {
+			starBtn.SetText(lang.X("hand_history.annotation.star", "☆ Star"))
+			starBtn.Importance = widget.MediumImportance
+		}
+		starBtn.Refresh()
+	}
+	updateStar()
+
+	tagsEntry := widget.NewEntry()
+	tagsEntry.SetPlaceHolder(lang.X("hand_history.annotation.tags_placeholder", "Tags, comma separated (e.g. hero call, review with coach)"))
+	tagsEntry.SetText(strings.Join(a.Tags, ", "))
+
+	noteEntry := widget.NewMultiLineEntry()
+	noteEntry.SetPlaceHolder(lang.X("hand_history.annotation.note_placeholder", "Note"))
+	noteEntry.Wrapping = fyne.TextWrapWord
+	noteEntry.SetMinRowsVisible(3)
+	noteEntry.SetText(a.Note)
+
+	saveBtn := widget.NewButtonWithIcon(lang.X("hand_history.annotation.save", "Save"), theme.DocumentSaveIcon(), nil)
+	saveBtn.Disable()
+	markDirty := func(string) { saveBtn.Enable() }
+	tagsEntry.OnChanged = markDirty
+	noteEntry.OnChanged = markDirty
+
+	save := func() {
+		a.Tags = persistence.ParseTagList(tagsEntry.Text)
+		a.Note = strings.TrimSpace(noteEntry.Text)
+		tagsEntry.SetText(strings.Join(a.Tags, ", "))
+		// SetText fires OnChanged, so clear the dirty state afterwards.
+		saveBtn.Disable()
+		if onSave != nil {
+			onSave(a)
+		}
+	}
+	saveBtn.OnTapped = save
+	tagsEntry.OnSubmitted = func(string) { save() }
+	starBtn.OnTapped = func() {
+		a.Starred = !a.Starred
+		updateStar()
+		save()
+	}
+
+	top := container.NewBorder(nil, nil, header, container.NewHBox(starBtn, saveBtn))
+	return newSectionCard(container.NewVBox(top, tagsEntry, noteEntry))
+}
+
+// annotationSummaryText is the short marker shown in the hand list for
+// starred, tagged or noted hands.
+func annotationSummaryText(s persistence.HandSummary) string {
+	parts := make([]string, 0, 3)
+	if s.Starred {
+		parts = append(parts, "★")
+	}
+	if len(s.Tags) > 0 {
+		parts = append(parts, strings.Join(s.Tags, ", "))
+	}
+	if s.HasNote {
+		parts = append(parts, lang.X("hand_history.annotation.has_note", "✎ note"))
+	}
+	return strings.Join(parts, "  ")
+}
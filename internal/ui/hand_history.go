@@ -14,6 +14,7 @@ import (
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/handquery"
 	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/parser"
 	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/persistence"
 	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
@@ -287,11 +288,19 @@ type HandHistoryViewState struct {
 	SelectedHandKey string
 }
 
-// HandHistoryFilterState holds preflop pocket-hand and final hand-class filters.
-// Empty slices mean "no filter" (show all).
+// HandHistoryFilterState holds preflop pocket-hand and final hand-class filters
+// and the annotation filters. Empty values mean "no filter" (show all).
 type HandHistoryFilterState struct {
 	PocketCategories []stats.PocketCategory
 	FinalClasses     []string
+	OnlyStarred      bool
+	Tag              string
+	// Search is the search box text and Query its last successfully parsed
+	// form; Query is nil when the box is empty.
+	Search string
+	Query  handquery.Expr
+	// TableSize restricts the list to hands of one table size.
+	TableSize stats.TableSize
 }
 
 type handOutcomeSummary struct {
@@ -322,11 +331,7 @@ func buildHandOutcomeSummary(h *parser.Hand, seat int) handOutcomeSummary {
 		return out
 	}
 
-	invested := 0
-	for _, act := range lp.Actions {
-		invested += act.Amount
-	}
-	out.NetValue = signedChips(lp.PotWon - invested)
+	out.NetValue = signedChips(lp.PotWon - lp.Invested())
 	out.Won = lp.Won
 	if lp.Won {
 		out.Result = lang.X("hand_history.result_won_simple", "Won")
@@ -486,8 +491,9 @@ func streetActionSection(h *parser.Hand, street parser.Street, localSeat, openRa
 	return newSectionCard(container.NewVBox(header, container.NewVBox(rows...)))
 }
 
-// buildDetailPanel creates the right-side detail view for a selected hand.
-func buildDetailPanel(h *parser.Hand, localSeat int) fyne.CanvasObject {
+// buildDetailPanel renders the full detail view of h. When onShowRawLog is
+// non-nil a button to open the hand's stored raw log lines is added.
+func buildDetailPanel(h *parser.Hand, localSeat int, onShowRawLog func()) fyne.CanvasObject {
 	if h == nil {
 		return newCenteredEmptyState(lang.X("hand_history.select_hand", "Select a hand to see details."))
 	}
@@ -614,20 +620,21 @@ func buildDetailPanel(h *parser.Hand, localSeat int) fyne.CanvasObject {
 	if h.HasDataAnomaly() {
 		warnHeader := widget.NewLabel(lang.X("hand_history.anomaly.title", "Data Quality Warning"))
 		warnHeader.TextStyle = fyne.TextStyle{Bold: true}
-		lines := make([]string, 0, len(h.Anomalies))
-		for _, anom := range h.Anomalies {
-			lines = append(lines, anom.Code)
-		}
-		detail := strings.Join(lines, ", ")
-		if detail == "" {
-			detail = lang.X("hand_history.anomaly.flagged", "Potentially anomalous hand data detected.")
+		rows := []fyne.CanvasObject{warnHeader}
+		if len(h.Anomalies) == 0 {
+			warnText := canvas.NewText(lang.X("hand_history.anomaly.flagged", "Potentially anomalous hand data detected."), uiWarningColor)
+			warnText.TextSize = normalSize
+			warnText.Alignment = fyne.TextAlignLeading
+			rows = append(rows, warnText)
 		}
-		warnText := canvas.NewText(detail, uiWarningColor)
-		warnText.TextSize = normalSize
-		warnText.Alignment = fyne.TextAlignLeading
-		sections = append(sections, newSectionCard(container.NewVBox(warnHeader, warnText)))
+		rows = append(rows, buildAnomalyRows(h.Anomalies, normalSize)...)
+		sections = append(sections, newSectionCard(container.NewVBox(rows...)))
 	}
 	sections = append(sections, actionsSection)
+	if onShowRawLog != nil {
+		rawBtn := widget.NewButton(lang.X("hand_history.raw_log.button", "Show Raw Log..."), onShowRawLog)
+		sections = append(sections, rawBtn)
+	}
 
 	content := container.NewVBox(sections...)
 
@@ -868,7 +875,7 @@ func NewHandHistoryTab(hands []*parser.Hand, localSeat int, state *HandHistoryVi
 
 	// Detail panel placeholder - replaced on selection.
 	detailContent := container.NewStack()
-	detailContent.Objects = []fyne.CanvasObject{buildDetailPanel(nil, localSeat)}
+	detailContent.Objects = []fyne.CanvasObject{buildDetailPanel(nil, localSeat, nil)}
 	rowRefs := make(map[fyne.CanvasObject]*handListEntryRefs)
 
 	list := widget.NewList(
@@ -890,7 +897,7 @@ func NewHandHistoryTab(hands []*parser.Hand, localSeat int, state *HandHistoryVi
 	list.OnSelected = func(id widget.ListItemID) {
 		h := reversed[id]
 		state.SelectedHandKey = handSelectionKey(h)
-		detail := buildDetailPanel(h, localSeat)
+		detail := buildDetailPanel(h, localSeat, nil)
 		detailContent.Objects = []fyne.CanvasObject{detail}
 		detailContent.Refresh()
 	}
@@ -925,14 +932,17 @@ func parseCardString(s string) parser.Card {
 
 // buildHandHistoryFilterPanelSummary builds the combined hand filter panel for the summary path.
 // page and totalPages are 0-based and 1-based respectively; onPageChange is called with the new 0-based page index.
+// tags lists the tags offered in the tag filter; onExport may be nil.
 func buildHandHistoryFilterPanelSummary(
 	handFilter *HandHistoryFilterState,
+	tags []persistence.TagCount,
 	page int,
 	totalPages int,
 	total int,
 	filtered int,
 	onChange func(),
 	onPageChange func(newPage int),
+	onExport func(),
 ) fyne.CanvasObject {
 	// Showing count label
 	showingLabel := widget.NewLabel(lang.X("hand_history.filter.showing",
@@ -967,11 +977,21 @@ func buildHandHistoryFilterPanelSummary(
 
 	pageNav := container.NewHBox(prevBtn, pageLabel, nextBtn)
 	topRow := container.NewHBox(showingLabel, pageNav)
+	if onExport != nil {
+		exportBtn := widget.NewButtonWithIcon(lang.X("hand_history.export", "Export CSV..."), theme.DocumentSaveIcon(), onExport)
+		topRow = container.NewBorder(nil, nil, nil, exportBtn, topRow)
+	}
 
 	if handFilter == nil {
 		return topRow
 	}
 
+	annotationRow := buildAnnotationFilterRow(handFilter, tags, onChange)
+	annotationRow = container.NewBorder(nil, nil, newTableSizeSelector(handFilter.TableSize, func(size stats.TableSize) {
+		handFilter.TableSize = size
+		onChange()
+	}), nil, annotationRow)
+
 	// -- Pocket Hand checkboxes --
 	pocketCats := stats.AllPocketCategories()
 	selectedPocket := make(map[stats.PocketCategory]bool, len(handFilter.PocketCategories))
@@ -1037,7 +1057,75 @@ func buildHandHistoryFilterPanelSummary(
 		widget.NewAccordionItem(lang.X("hand_history.filter.final.title", "Final Hand"), finalGrid),
 	)
 
-	return container.NewVBox(topRow, acc)
+	return container.NewVBox(topRow, annotationRow, acc)
+}
+
+// buildAnnotationFilterRow builds the starred and tag filters and the search
+// box. The search is parsed on Enter; invalid text keeps the previous query
+// and shows the parse error under the box.
+func buildAnnotationFilterRow(handFilter *HandHistoryFilterState, tags []persistence.TagCount, onChange func()) fyne.CanvasObject {
+	starredCheck := widget.NewCheck(lang.X("hand_history.filter.starred", "Starred only"), func(on bool) {
+		handFilter.OnlyStarred = on
+		onChange()
+	})
+	starredCheck.Checked = handFilter.OnlyStarred
+
+	anyTag := lang.X("hand_history.filter.any_tag", "Any tag")
+	options := []string{anyTag}
+	for _, tc := range tags {
+		options = append(options, tc.Tag)
+	}
+	tagSelect := widget.NewSelect(options, nil)
+	if handFilter.Tag != "" {
+		tagSelect.SetSelected(handFilter.Tag)
+	} else {
+		tagSelect.SetSelected(anyTag)
+	}
+	tagSelect.OnChanged = func(v string) {
+		if v == anyTag {
+			v = ""
+		}
+		if v == handFilter.Tag {
+			return
+		}
+		handFilter.Tag = v
+		onChange()
+	}
+
+	searchError := widget.NewLabel("")
+	searchError.Importance = widget.DangerImportance
+	searchError.Wrapping = fyne.TextWrapWord
+	searchError.Hide()
+	help := widget.NewLabel(lang.X("hand_history.filter.search_help",
+		"Fields: pos:BTN  hole:AKs / QQ / AhKd  board:flush / paired / monotone / rainbow / Ah  made:two_pair  pot>40bb  net<0  players<=4  action:[street:]fold / check / call / bet / raise / allin / checkraise  result:won / lost / showdown  is:starred  tag:name. Other words search notes and tags. Combine with spaces (and), OR, parentheses and - (not); separate alternatives with commas, e.g. pos:BTN,CO."))
+	help.Wrapping = fyne.TextWrapWord
+	help.Hide()
+	helpBtn := widget.NewButtonWithIcon("", theme.HelpIcon(), func() {
+		if help.Visible() {
+			help.Hide()
+		} else {
+			help.Show()
+		}
+	})
+
+	search := widget.NewEntry()
+	search.SetPlaceHolder(lang.X("hand_history.filter.search_placeholder", "Search, e.g. pos:BTN hole:AKs result:lost, then press Enter"))
+	search.SetText(handFilter.Search)
+	search.OnChanged = func(v string) { handFilter.Search = v }
+	search.OnSubmitted = func(v string) {
+		q, err := handquery.Parse(v)
+		if err != nil {
+			searchError.SetText(lang.X("hand_history.filter.search_invalid", "Invalid search: {{.Error}}", map[string]any{"Error": err.Error()}))
+			searchError.Show()
+			return
+		}
+		searchError.Hide()
+		handFilter.Query = q
+		onChange()
+	}
+
+	row := container.NewBorder(nil, nil, container.NewHBox(starredCheck, tagSelect), helpBtn, search)
+	return container.NewVBox(row, searchError, help)
 }
 
 // buildDetailPanelEmpty returns an empty state panel for the detail pane.
@@ -1068,6 +1156,7 @@ func handSummaryEntryFieldsFromSummary(s persistence.HandSummary) ([]string, []s
 	line1 := []string{
 		lang.X("hand_history.summary.time", "Time: {{.Time}}", map[string]any{"Time": timeStr}),
 		lang.X("hand_history.summary.cards", "Cards: {{.Cards}}", map[string]any{"Cards": holeStr}),
+		annotationSummaryText(s),
 	}
 	line2 := []string{
 		lang.X("hand_history.summary.result", "Result: {{.Value}}", map[string]any{"Value": resultStr}),
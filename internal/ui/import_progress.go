@@ -0,0 +1,212 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/application"
+)
+
+// importProgressDialog shows the progress of the startup import with its
+// per-file state and buttons to pause, cancel or hide it. It only appears
+// when past logs are imported, not for the live logs of a normal start.
+// update and close may be called from any goroutine.
+type importProgressDialog struct {
+	win     fyne.Window
+	control *application.ImportControl
+	cancel  context.CancelFunc
+
+	mu     sync.Mutex
+	files  []application.FileProgress
+	shown  bool
+	hidden bool
+	closed bool
+
+	d        dialog.Dialog
+	bar      *widget.ProgressBar
+	summary  *widget.Label
+	eta      *widget.Label
+	list     *widget.List
+	pauseBtn *widget.Button
+	stopBtn  *widget.Button
+}
+
+func newImportProgressDialog(win fyne.Window, control *application.ImportControl, cancel context.CancelFunc) *importProgressDialog {
+	return &importProgressDialog{win: win, control: control, cancel: cancel}
+}
+
+// update shows p, opening the dialog once a past log is being imported.
+func (d *importProgressDialog) update(p application.BootstrapProgress) {
+	d.mu.Lock()
+	if d.closed || d.hidden {
+		d.mu.Unlock()
+		return
+	}
+	d.files = p.Files
+	open := !d.shown && hasHistoricalLogs(p.Files)
+	if open {
+		d.shown = true
+	}
+	visible := d.shown
+	d.mu.Unlock()
+	if !visible {
+		return
+	}
+	fyne.Do(func() {
+		if open {
+			d.build()
+		}
+		d.render(p)
+	})
+}
+
+// close hides the dialog for good when the import has finished.
+func (d *importProgressDialog) close() {
+	d.mu.Lock()
+	d.closed = true
+	shown := d.shown
+	d.mu.Unlock()
+	if shown {
+		fyne.Do(func() { d.d.Hide() })
+	}
+}
+
+func hasHistoricalLogs(files []application.FileProgress) bool {
+	for _, f := range files {
+		if !f.Live {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *importProgressDialog) build() {
+	d.bar = widget.NewProgressBar()
+	d.summary = widget.NewLabel("")
+	d.eta = widget.NewLabel("")
+	d.list = widget.NewList(
+		func() int {
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			return len(d.files)
+		},
+		func() fyne.CanvasObject {
+			state := widget.NewLabel("")
+			state.TextStyle = fyne.TextStyle{Bold: true}
+			return container.NewBorder(nil, nil, state, widget.NewLabel(""), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			d.mu.Lock()
+			if id >= len(d.files) {
+				d.mu.Unlock()
+				return
+			}
+			f := d.files[id]
+			d.mu.Unlock()
+			row := obj.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(shortPath(f.Path))
+			row.Objects[1].(*widget.Label).SetText(fileStateText(f.State))
+			row.Objects[2].(*widget.Label).SetText(fileProgressText(f))
+		},
+	)
+
+	d.pauseBtn = widget.NewButton(lang.X("import_progress.pause", "Pause"), func() {
+		if d.control.Paused() {
+			d.control.Resume()
+		} else {
+			d.control.Pause()
+		}
+		d.renderPaused(d.control.Paused(), 0)
+	})
+	d.stopBtn = widget.NewButton(lang.X("import_progress.cancel", "Cancel Import"), func() {
+		d.cancel()
+		d.pauseBtn.Disable()
+		d.stopBtn.Disable()
+		d.eta.SetText(lang.X("import_progress.cancelling", "Cancelling…"))
+	})
+	d.stopBtn.Importance = widget.DangerImportance
+	hideBtn := widget.NewButton(lang.X("import_progress.hide", "Run in Background"), func() {
+		d.mu.Lock()
+		d.hidden = true
+		d.mu.Unlock()
+		d.d.Hide()
+	})
+
+	hint := newSubtleText(lang.X("import_progress.hint", "Past VRChat logs are being imported. Stats already include every log marked done. A cancelled import continues at the next start."))
+	top := container.NewVBox(hint, d.bar, d.summary, d.eta)
+	buttons := container.NewHBox(d.pauseBtn, d.stopBtn, hideBtn)
+	content := container.NewBorder(top, buttons, nil, nil, d.list)
+
+	d.d = dialog.NewCustomWithoutButtons(lang.X("import_progress.title", "Importing Logs"), content, d.win)
+	d.d.Resize(fyne.NewSize(720, 520))
+	d.d.Show()
+}
+
+func (d *importProgressDialog) render(p application.BootstrapProgress) {
+	if p.BytesTotal > 0 {
+		d.bar.SetValue(float64(p.BytesDone) / float64(p.BytesTotal))
+	}
+	d.summary.SetText(lang.X("import_progress.summary",
+		"{{.Current}} of {{.Total}} files · {{.Done}} of {{.Size}} · {{.Hands}} hands found",
+		map[string]any{
+			"Current": p.Current,
+			"Total":   p.Total,
+			"Done":    formatMiB(p.BytesDone),
+			"Size":    formatMiB(p.BytesTotal),
+			"Hands":   p.Hands,
+		}))
+	if d.stopBtn.Disabled() {
+		return
+	}
+	d.renderPaused(p.Paused, p.ETA)
+	d.list.Refresh()
+}
+
+func (d *importProgressDialog) renderPaused(paused bool, eta time.Duration) {
+	switch {
+	case paused:
+		d.pauseBtn.SetText(lang.X("import_progress.resume", "Resume"))
+		d.eta.SetText(lang.X("import_progress.paused", "Paused"))
+	case eta > 0:
+		d.pauseBtn.SetText(lang.X("import_progress.pause", "Pause"))
+		d.eta.SetText(lang.X("import_progress.eta", "About {{.ETA}} left", map[string]any{"ETA": eta.Round(time.Second).String()}))
+	default:
+		d.pauseBtn.SetText(lang.X("import_progress.pause", "Pause"))
+		d.eta.SetText("")
+	}
+}
+
+func fileStateText(s application.FileState) string {
+	switch s {
+	case application.FileParsing:
+		return lang.X("import_progress.state.parsing", "Parsing")
+	case application.FileSaving:
+		return lang.X("import_progress.state.saving", "Saving")
+	case application.FileDone:
+		return lang.X("import_progress.state.done", "Done")
+	case application.FileFailed:
+		return lang.X("import_progress.state.failed", "Failed")
+	default:
+		return lang.X("import_progress.state.queued", "Waiting")
+	}
+}
+
+func fileProgressText(f application.FileProgress) string {
+	if f.State == application.FileQueued || f.Size == 0 {
+		return formatMiB(f.Size)
+	}
+	return lang.X("import_progress.file", "{{.Percent}} · {{.Hands}} hands",
+		map[string]any{"Percent": fmt.Sprintf("%.0f%%", float64(f.BytesDone)*100/float64(f.Size)), "Hands": f.Hands})
+}
+
+func formatMiB(n int64) string {
+	return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+}
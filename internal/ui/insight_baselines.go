@@ -0,0 +1,93 @@
+package ui
+
+import "github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
+
+// insightBaseline is the typical range of a metric (Lo to Hi) and the rates
+// at or beyond which a leak insight fires (AlertLow, AlertHigh). Only the
+// alert bounds a rule actually checks are meaningful.
+type insightBaseline struct {
+	Lo, Hi              float64
+	AlertLow, AlertHigh float64
+}
+
+// fullRingBaselines are the leak baselines of 7-8 player tables. They are
+// also used when hands of every size are mixed.
+var fullRingBaselines = map[stats.MetricID]insightBaseline{
+	stats.MetricVPIP:            {Lo: 18, Hi: 28},
+	stats.MetricPFR:             {Lo: 12, Hi: 22},
+	stats.MetricGap:             {Lo: 0, Hi: 10, AlertHigh: 11},
+	stats.MetricThreeBet:        {Lo: 4, Hi: 9, AlertLow: 3.5},
+	stats.MetricThreeBetVsSteal: {Lo: 5, Hi: 12},
+	stats.MetricFoldToThreeBet:  {Lo: 40, Hi: 55, AlertHigh: 70},
+	stats.MetricFourBet:         {Lo: 1, Hi: 3},
+	stats.MetricFoldBBToSteal:   {Lo: 40, Hi: 55, AlertLow: 35, AlertHigh: 65},
+	stats.MetricFoldSBToSteal:   {Lo: 45, Hi: 60, AlertLow: 35, AlertHigh: 70},
+	stats.MetricRFI:             {Lo: 16, Hi: 55, AlertLow: 16},
+	stats.MetricSteal:           {Lo: 30, Hi: 50, AlertLow: 28},
+	stats.MetricFoldToFlopCBet:  {Lo: 35, Hi: 50, AlertHigh: 60},
+	stats.MetricFoldToTurnCBet:  {Lo: 40, Hi: 55, AlertHigh: 65},
+	stats.MetricFlopCBet:        {Lo: 50, Hi: 70, AlertHigh: 75},
+	stats.MetricTurnCBet:        {Lo: 30, Hi: 55, AlertLow: 30},
+	stats.MetricWTSD:            {Lo: 22, Hi: 30, AlertLow: 20, AlertHigh: 32},
+	stats.MetricWSD:             {Lo: 47, Hi: 55, AlertLow: 45},
+	stats.MetricWWSF:            {Lo: 42, Hi: 48, AlertLow: 40},
+	stats.MetricAFq:             {Lo: 40, Hi: 55},
+	stats.MetricWonWithoutSD:    {Lo: 45, Hi: 55, AlertHigh: 58},
+}
+
+// tableSizeBaselines override fullRingBaselines for smaller tables, where
+// ranges widen and blinds come around more often.
+var tableSizeBaselines = map[stats.TableSize]map[stats.MetricID]insightBaseline{
+	stats.TableSizeSixMax: {
+		stats.MetricVPIP:            {Lo: 22, Hi: 32},
+		stats.MetricPFR:             {Lo: 17, Hi: 26},
+		stats.MetricThreeBet:        {Lo: 6, Hi: 11, AlertLow: 4.5},
+		stats.MetricThreeBetVsSteal: {Lo: 7, Hi: 14},
+		stats.MetricFoldToThreeBet:  {Lo: 40, Hi: 55, AlertHigh: 68},
+		stats.MetricFoldBBToSteal:   {Lo: 35, Hi: 50, AlertLow: 30, AlertHigh: 60},
+		stats.MetricFoldSBToSteal:   {Lo: 40, Hi: 55, AlertLow: 30, AlertHigh: 65},
+		stats.MetricRFI:             {Lo: 20, Hi: 60, AlertLow: 20},
+		stats.MetricSteal:           {Lo: 35, Hi: 55, AlertLow: 32},
+	},
+	stats.TableSizeShort: {
+		stats.MetricVPIP:            {Lo: 30, Hi: 45},
+		stats.MetricPFR:             {Lo: 24, Hi: 36},
+		stats.MetricGap:             {Lo: 0, Hi: 12, AlertHigh: 14},
+		stats.MetricThreeBet:        {Lo: 8, Hi: 15, AlertLow: 6},
+		stats.MetricThreeBetVsSteal: {Lo: 9, Hi: 18},
+		stats.MetricFoldToThreeBet:  {Lo: 38, Hi: 52, AlertHigh: 65},
+		stats.MetricFourBet:         {Lo: 2, Hi: 5},
+		stats.MetricFoldBBToSteal:   {Lo: 30, Hi: 45, AlertLow: 25, AlertHigh: 55},
+		stats.MetricFoldSBToSteal:   {Lo: 35, Hi: 50, AlertLow: 25, AlertHigh: 60},
+		stats.MetricRFI:             {Lo: 30, Hi: 65, AlertLow: 28},
+		stats.MetricSteal:           {Lo: 40, Hi: 65, AlertLow: 36},
+		stats.MetricWTSD:            {Lo: 25, Hi: 33, AlertLow: 22, AlertHigh: 36},
+		stats.MetricFlopCBet:        {Lo: 55, Hi: 75, AlertHigh: 80},
+	},
+	stats.TableSizeHeadsUp: {
+		stats.MetricVPIP:            {Lo: 60, Hi: 85},
+		stats.MetricPFR:             {Lo: 45, Hi: 75},
+		stats.MetricGap:             {Lo: 0, Hi: 20, AlertHigh: 25},
+		stats.MetricThreeBet:        {Lo: 12, Hi: 25, AlertLow: 9},
+		stats.MetricThreeBetVsSteal: {Lo: 12, Hi: 25},
+		stats.MetricFoldToThreeBet:  {Lo: 35, Hi: 50, AlertHigh: 60},
+		stats.MetricFourBet:         {Lo: 3, Hi: 8},
+		stats.MetricFoldBBToSteal:   {Lo: 20, Hi: 40, AlertLow: 15, AlertHigh: 50},
+		stats.MetricFoldSBToSteal:   {Lo: 20, Hi: 40, AlertLow: 15, AlertHigh: 50},
+		stats.MetricRFI:             {Lo: 70, Hi: 95, AlertLow: 60},
+		stats.MetricSteal:           {Lo: 70, Hi: 95, AlertLow: 60},
+		stats.MetricFoldToFlopCBet:  {Lo: 30, Hi: 45, AlertHigh: 55},
+		stats.MetricFlopCBet:        {Lo: 55, Hi: 80, AlertHigh: 85},
+		stats.MetricWTSD:            {Lo: 28, Hi: 38, AlertLow: 24, AlertHigh: 42},
+		stats.MetricWSD:             {Lo: 45, Hi: 53, AlertLow: 42},
+		stats.MetricWWSF:            {Lo: 46, Hi: 55, AlertLow: 43},
+	},
+}
+
+// insightBaselineFor returns the leak baseline of a metric at a table size.
+func insightBaselineFor(size stats.TableSize, id stats.MetricID) insightBaseline {
+	if b, ok := tableSizeBaselines[size][id]; ok {
+		return b
+	}
+	return fullRingBaselines[id]
+}
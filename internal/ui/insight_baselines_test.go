@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
+)
+
+func TestTrendInsightsUseTableSizeBaselines(t *testing.T) {
+	s := &stats.Stats{Metrics: map[stats.MetricID]stats.MetricValue{
+		stats.MetricVPIP:     {ID: stats.MetricVPIP, Rate: 40, Opportunity: 500},
+		stats.MetricPFR:      {ID: stats.MetricPFR, Rate: 27, Opportunity: 500},
+		stats.MetricGap:      {ID: stats.MetricGap, Rate: 13, Opportunity: 500, Format: stats.MetricFormatDiff},
+		stats.MetricThreeBet: {ID: stats.MetricThreeBet, Rate: 5, Opportunity: 200},
+	}}
+
+	titles := func(size stats.TableSize) map[string]bool {
+		out := make(map[string]bool)
+		for _, in := range buildTrendInsights(s, size) {
+			out[in.Title] = true
+		}
+		return out
+	}
+
+	// A 13-point VPIP/PFR gap is passive at a full table but normal when
+	// short-handed; a 5% 3-bet is fine at a full table but too low short-handed.
+	full := titles(stats.TableSizeFullRing)
+	if !full["Passive preflop entries"] || full["Preflop exploit risk"] {
+		t.Fatalf("full ring insights = %v", full)
+	}
+	short := titles(stats.TableSizeShort)
+	if short["Passive preflop entries"] || !short["Preflop exploit risk"] {
+		t.Fatalf("short-handed insights = %v", short)
+	}
+	if got := titles(stats.TableSizeAny); len(got) != len(full) {
+		t.Fatalf("mixed sizes should use full ring baselines: %v vs %v", got, full)
+	}
+}
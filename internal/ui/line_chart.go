@@ -0,0 +1,185 @@
+package ui
+
+import (
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// lineChartPoint is one point of a lineChart. Points must be sorted by X.
+// With HasRange, the interval from Low to High is shaded behind the line.
+type lineChartPoint struct {
+	X        float64
+	Y        float64
+	HasRange bool
+	Low      float64
+	High     float64
+}
+
+// lineChart is a minimal line chart with a shaded interval band, used by
+// the analytics trend. The Y axis is labelled with its bounds and the X axis
+// with the labels of the first and last point.
+type lineChart struct {
+	widget.BaseWidget
+	points        []lineChartPoint
+	firstX, lastX string
+	formatY       func(float64) string
+}
+
+func newLineChart(points []lineChartPoint, firstX, lastX string, formatY func(float64) string) *lineChart {
+	c := &lineChart{points: points, firstX: firstX, lastX: lastX, formatY: formatY}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+func (c *lineChart) CreateRenderer() fyne.WidgetRenderer {
+	r := &lineChartRenderer{
+		chart:    c,
+		baseline: canvas.NewRectangle(dividerBaseColor),
+		maxLabel: canvas.NewText("", uiMutedTextColor),
+		minLabel: canvas.NewText("", uiMutedTextColor),
+		firstX:   canvas.NewText(c.firstX, uiMutedTextColor),
+		lastX:    canvas.NewText(c.lastX, uiMutedTextColor),
+	}
+	for _, t := range []*canvas.Text{r.maxLabel, r.minLabel, r.firstX, r.lastX} {
+		t.TextSize = theme.TextSize() * 0.72
+	}
+	r.lastX.Alignment = fyne.TextAlignTrailing
+	band := toNRGBA(uiInfoAccent)
+	band.A = 0x50
+	for i, p := range c.points {
+		bar := canvas.NewLine(band)
+		bar.StrokeWidth = 2
+		if !p.HasRange {
+			bar.Hide()
+		}
+		r.bands = append(r.bands, bar)
+		if i > 0 {
+			seg := canvas.NewLine(uiInfoAccent)
+			seg.StrokeWidth = 2
+			r.segments = append(r.segments, seg)
+		}
+	}
+	return r
+}
+
+type lineChartRenderer struct {
+	chart    *lineChart
+	baseline *canvas.Rectangle
+	bands    []*canvas.Line
+	segments []*canvas.Line
+	maxLabel *canvas.Text
+	minLabel *canvas.Text
+	firstX   *canvas.Text
+	lastX    *canvas.Text
+}
+
+const (
+	lineChartHeight     = 200
+	lineChartMinWidth   = 320
+	lineChartAxisLabelW = 56
+)
+
+func (r *lineChartRenderer) Layout(size fyne.Size) {
+	points := r.chart.points
+	if len(points) == 0 {
+		return
+	}
+	labelH := theme.TextSize()
+	left := float32(lineChartAxisLabelW)
+	plotW := size.Width - left
+	plotH := size.Height - labelH*2
+	if plotW < 1 {
+		plotW = 1
+	}
+	if plotH < 1 {
+		plotH = 1
+	}
+	top := labelH / 2
+
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, p := range points {
+		minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+		if p.HasRange {
+			if !math.IsInf(p.High, 1) {
+				maxY = math.Max(maxY, p.High)
+			}
+			minY = math.Min(minY, p.Low)
+		}
+	}
+	if maxY == minY {
+		maxY++
+		minY--
+	}
+	minX, maxX := points[0].X, points[len(points)-1].X
+	spanX := maxX - minX
+	if spanX == 0 {
+		spanX = 1
+	}
+	xPos := func(x float64) float32 {
+		if len(points) == 1 {
+			return left + plotW/2
+		}
+		return left + float32((x-minX)/spanX)*plotW
+	}
+	yPos := func(y float64) float32 {
+		y = math.Max(minY, math.Min(maxY, y))
+		return top + float32((maxY-y)/(maxY-minY))*plotH
+	}
+
+	for i, p := range points {
+		x := xPos(p.X)
+		if p.HasRange {
+			r.bands[i].Position1 = fyne.NewPos(x, yPos(p.High))
+			r.bands[i].Position2 = fyne.NewPos(x, yPos(p.Low))
+		}
+		if i > 0 {
+			prev := points[i-1]
+			r.segments[i-1].Position1 = fyne.NewPos(xPos(prev.X), yPos(prev.Y))
+			r.segments[i-1].Position2 = fyne.NewPos(x, yPos(p.Y))
+		}
+	}
+
+	if minY < 0 && maxY > 0 {
+		r.baseline.Show()
+		r.baseline.Move(fyne.NewPos(left, yPos(0)))
+		r.baseline.Resize(fyne.NewSize(plotW, 1))
+	} else {
+		r.baseline.Hide()
+	}
+
+	format := r.chart.formatY
+	r.maxLabel.Text = format(maxY)
+	r.minLabel.Text = format(minY)
+	r.maxLabel.Move(fyne.NewPos(0, top-labelH/2))
+	r.minLabel.Move(fyne.NewPos(0, top+plotH-labelH/2))
+	r.firstX.Move(fyne.NewPos(left, size.Height-labelH))
+	r.lastX.Move(fyne.NewPos(left, size.Height-labelH))
+	r.lastX.Resize(fyne.NewSize(plotW, labelH))
+}
+
+func (r *lineChartRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(lineChartMinWidth, lineChartHeight)
+}
+
+func (r *lineChartRenderer) Refresh() {
+	r.Layout(r.chart.Size())
+	canvas.Refresh(r.chart)
+}
+
+func (r *lineChartRenderer) Objects() []fyne.CanvasObject {
+	objs := make([]fyne.CanvasObject, 0, 5+len(r.bands)+len(r.segments))
+	objs = append(objs, r.baseline)
+	for _, b := range r.bands {
+		objs = append(objs, b)
+	}
+	for _, s := range r.segments {
+		objs = append(objs, s)
+	}
+	return append(objs, r.maxLabel, r.minLabel, r.firstX, r.lastX)
+}
+
+func (r *lineChartRenderer) Destroy() {}
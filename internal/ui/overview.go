@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"fmt"
 	"image/color"
 
 	"fyne.io/fyne/v2"
@@ -13,7 +14,9 @@ import (
 	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
 )
 
-func overviewMetricCard(metric MetricDefinition, value MetricValue, win fyne.Window, hero bool) fyne.CanvasObject {
+// overviewMetricCard renders one metric. When baseline is non-nil the card
+// also tells whether the value differs significantly from it.
+func overviewMetricCard(metric MetricDefinition, value MetricValue, baseline *MetricValue, win fyne.Window, hero bool) fyne.CanvasObject {
 	footnote := metricFootnoteText(value.Opportunities, metric.MinSamples)
 	showWarn := metric.MinSamples > 0 && value.Opportunities < metric.MinSamples
 
@@ -37,7 +40,15 @@ func overviewMetricCard(metric MetricDefinition, value MetricValue, win fyne.Win
 	valueText.Wrapping = fyne.TextWrapOff
 
 	foot := newSubtleText(footnote)
-	cardBody := container.NewVBox(header, valueText, container.NewHBox(layout.NewSpacer(), foot))
+	footRow := container.NewHBox(layout.NewSpacer(), foot)
+	if value.Range != "" {
+		footRow = container.NewHBox(newSubtleText(lang.X("metric.range", "95% CI {{.Range}}", map[string]any{"Range": value.Range})), layout.NewSpacer(), foot)
+	}
+	rows := []fyne.CanvasObject{header, valueText, footRow}
+	if baseline != nil && value.Stat != nil && baseline.Stat != nil {
+		rows = append(rows, metricComparisonChip(stats.CompareMetric(*value.Stat, *baseline.Stat)))
+	}
+	cardBody := container.NewVBox(rows...)
 
 	if hero {
 		return newHeroCard(cardBody)
@@ -45,6 +56,18 @@ func overviewMetricCard(metric MetricDefinition, value MetricValue, win fyne.Win
 	return newSectionCard(cardBody)
 }
 
+// metricComparisonChip labels a comparison against all hands.
+func metricComparisonChip(c stats.MetricComparison) fyne.CanvasObject {
+	switch {
+	case c.Significant && c.Diff > 0:
+		return newSubtleText(lang.X("metric.compare.higher", "Significantly higher than all hands (z = {{.Z}})", map[string]any{"Z": fmt.Sprintf("%+.2f", c.Z)}))
+	case c.Significant:
+		return newSubtleText(lang.X("metric.compare.lower", "Significantly lower than all hands (z = {{.Z}})", map[string]any{"Z": fmt.Sprintf("%+.2f", c.Z)}))
+	default:
+		return newSubtleText(lang.X("metric.compare.same", "No significant difference from all hands"))
+	}
+}
+
 func splitOverviewMetrics(metricDefs []MetricDefinition) ([]MetricDefinition, []MetricDefinition) {
 	priority := []string{"hands", "profit", "vpip", "pfr", "bb_per_100"}
 	hero := make([]MetricDefinition, 0, 3)
@@ -124,8 +147,10 @@ func overviewInsightCard(in trendInsight) fyne.CanvasObject {
 	return newSectionCard(container.NewVBox(rows...))
 }
 
-// NewOverviewTab returns the "Overview" tab canvas object.
-func NewOverviewTab(s *stats.Stats, visibility *MetricVisibilityState, win fyne.Window) fyne.CanvasObject {
+// NewOverviewTab returns the "Overview" tab canvas object. size selects the
+// baselines of the leak insights. When compareTo is non-nil every metric
+// card tests its value against the same metric of compareTo.
+func NewOverviewTab(s, compareTo *stats.Stats, size stats.TableSize, visibility *MetricVisibilityState, win fyne.Window) fyne.CanvasObject {
 	if s == nil || s.TotalHands == 0 {
 		return newCenteredEmptyState(lang.X("overview.no_hands", "No hands recorded yet.\nStart playing in the VR Poker world!"))
 	}
@@ -135,18 +160,26 @@ func NewOverviewTab(s *stats.Stats, visibility *MetricVisibilityState, win fyne.
 		return newCenteredEmptyState(lang.X("overview.no_metrics", "No metrics selected. Enable metrics in Settings."))
 	}
 
+	card := func(metric MetricDefinition, hero bool) fyne.CanvasObject {
+		var baseline *MetricValue
+		if compareTo != nil {
+			v := metric.OverviewValue(compareTo)
+			baseline = &v
+		}
+		return overviewMetricCard(metric, metric.OverviewValue(s), baseline, win, hero)
+	}
 	heroDefs, otherDefs := splitOverviewMetrics(metricDefs)
 	heroCards := make([]fyne.CanvasObject, 0, len(heroDefs))
 	for _, metric := range heroDefs {
-		heroCards = append(heroCards, overviewMetricCard(metric, metric.OverviewValue(s), win, true))
+		heroCards = append(heroCards, card(metric, true))
 	}
 
 	otherCards := make([]fyne.CanvasObject, 0, len(otherDefs))
 	for _, metric := range otherDefs {
-		otherCards = append(otherCards, overviewMetricCard(metric, metric.OverviewValue(s), win, false))
+		otherCards = append(otherCards, card(metric, false))
 	}
 
-	insights := buildTrendInsights(s)
+	insights := buildTrendInsights(s, size)
 	insightRows := make([]fyne.CanvasObject, 0, len(insights)+1)
 	if len(insights) == 0 {
 		none := widget.NewLabel(lang.X("overview.no_insight_signal", "No strong leak signal is detected right now."))
@@ -0,0 +1,40 @@
+package ui
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/persistence"
+)
+
+// showRawLogDialog shows the raw log lines a hand was parsed from. raw may be
+// nil when no snippet was stored for the hand.
+func showRawLogDialog(win fyne.Window, clipboard fyne.Clipboard, raw *persistence.RawLogSnippet) {
+	title := lang.X("hand_history.raw_log.title", "Raw Log")
+	if raw == nil || len(raw.Data) == 0 {
+		dialog.ShowInformation(title,
+			lang.X("hand_history.raw_log.none", "No raw log was stored for this hand. Raw logs are kept for hands imported while \"Keep raw log lines\" is enabled in Settings."),
+			win)
+		return
+	}
+
+	text := strings.TrimRight(string(raw.Data), "\n")
+	source := newSubtleText(lang.X("hand_history.raw_log.source", "{{.Path}} bytes {{.Start}}-{{.End}}",
+		map[string]any{"Path": shortPath(raw.SourcePath), "Start": raw.StartByte, "End": raw.EndByte}))
+	grid := widget.NewTextGridFromString(text)
+	copyBtn := widget.NewButton(lang.X("hand_history.raw_log.copy", "Copy"), func() {
+		if clipboard != nil {
+			clipboard.SetContent(text)
+		}
+	})
+
+	content := container.NewBorder(source, copyBtn, nil, nil, container.NewScroll(grid))
+	d := dialog.NewCustom(title, lang.X("hand_history.raw_log.close", "Close"), content, win)
+	d.Resize(fyne.NewSize(900, 560))
+	d.Show()
+}
@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/application"
+)
+
+// reprocessPreviewDiffs is the number of changed hands shown in the preview.
+// The exported diff always contains every change.
+const reprocessPreviewDiffs = 50
+
+func reprocessSummaryText(res application.ReprocessResult) string {
+	return lang.X("reprocess.summary",
+		"Outdated hands: {{.Outdated}}\nRe-parsed: {{.Reparsed}} / changed: {{.Changed}} / updated: {{.Updated}}\nNot found in log: {{.Unmatched}} / missing log files: {{.Missing}} (recovered from raw logs: {{.FromRawLogs}})",
+		map[string]any{
+			"Outdated":    res.Outdated,
+			"Reparsed":    res.Reparsed,
+			"Changed":     res.Changed,
+			"Updated":     res.Updated,
+			"Unmatched":   res.Unmatched,
+			"Missing":     len(res.MissingFiles),
+			"FromRawLogs": res.FromRawLogs,
+		})
+}
+
+// showReprocessPreviewDialog shows the result of a dry run and calls onApply
+// when the user confirms writing the re-parsed hands.
+func showReprocessPreviewDialog(win fyne.Window, res application.ReprocessResult, onApply func()) {
+	if res.Outdated == 0 {
+		dialog.ShowInformation(
+			lang.X("reprocess.title", "Reprocess Hands"),
+			lang.X("reprocess.up_to_date", "All stored hands were produced by the current parser. Nothing to reprocess."),
+			win,
+		)
+		return
+	}
+
+	intro := widget.NewLabel(lang.X("reprocess.preview_intro", "The following changes will be written when you apply. Hands whose log file is missing are left unchanged."))
+	intro.Wrapping = fyne.TextWrapWord
+	summary := widget.NewLabel(reprocessSummaryText(res))
+	summary.Wrapping = fyne.TextWrapWord
+
+	rows := []fyne.CanvasObject{intro, newSectionCard(summary)}
+	for _, path := range res.MissingFiles {
+		rows = append(rows, newSubtleText(lang.X("reprocess.missing_file", "Missing: {{.Path}}", map[string]any{"Path": shortPath(path)})))
+	}
+	if len(res.Diffs) == 0 {
+		rows = append(rows, widget.NewLabel(lang.X("reprocess.no_changes", "Re-parsing does not change any hand data; only the parser version will be updated.")))
+	}
+	for i, d := range res.Diffs {
+		if i >= reprocessPreviewDiffs {
+			rows = append(rows, newSubtleText(lang.X("reprocess.more_diffs", "…and {{.N}} more changed hands (see export)",
+				map[string]any{"N": len(res.Diffs) - reprocessPreviewDiffs})))
+			break
+		}
+		header := widget.NewLabelWithStyle(
+			lang.X("reprocess.diff_header", "{{.Path}} bytes {{.Start}}-{{.End}}",
+				map[string]any{"Path": shortPath(d.SourcePath), "Start": d.StartByte, "End": d.EndByte}),
+			fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+		changes := widget.NewLabel(strings.Join(d.Changes, "\n"))
+		changes.Wrapping = fyne.TextWrapBreak
+		rows = append(rows, newSectionCard(container.NewVBox(header, changes)))
+	}
+
+	exportBtn := widget.NewButton(lang.X("reprocess.export", "Export Diff..."), func() {
+		exportReprocessDiff(win, res)
+	})
+	content := container.NewBorder(nil, exportBtn, nil, nil, container.NewVScroll(container.NewVBox(rows...)))
+	d := dialog.NewCustomConfirm(
+		lang.X("reprocess.title", "Reprocess Hands"),
+		lang.X("reprocess.apply", "Apply"),
+		lang.X("reprocess.cancel", "Cancel"),
+		content,
+		func(ok bool) {
+			if ok && onApply != nil {
+				onApply()
+			}
+		},
+		win,
+	)
+	d.Resize(fyne.NewSize(760, 560))
+	d.Show()
+}
+
+func exportReprocessDiff(win fyne.Window, res application.ReprocessResult) {
+	save := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		if w == nil {
+			return
+		}
+		defer w.Close()
+		if err := res.WriteDiff(w); err != nil {
+			dialog.ShowError(err, win)
+		}
+	}, win)
+	save.SetFileName("reprocess-diff.txt")
+	save.Show()
+}
@@ -2,13 +2,18 @@ package ui
 
 import (
 	"net/url"
+	"slices"
 	"sort"
+	"strconv"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/lang"
 	"fyne.io/fyne/v2/widget"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/application"
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/watcher"
 )
 
 type SettingsTab struct {
@@ -17,6 +22,12 @@ type SettingsTab struct {
 	onPathChange    func(string)
 	onMetricsChange func()
 	onReset         func()
+	onDiagnostics   func()
+	onReprocess     func()
+	onCheckDB       func()
+	onImportArchive func()
+	appSettings     application.AppSettings
+	onAppSettings   func(application.AppSettings)
 	metricState     *MetricVisibilityState
 	metadata        AppMetadata
 	win             fyne.Window
@@ -31,6 +42,18 @@ type SettingsTabConfig struct {
 	OnMetricsChange func()
 	DBPath          string
 	OnReset         func()
+	// OnShowDiagnostics opens the parser diagnostics report.
+	OnShowDiagnostics func()
+	// OnReprocess starts a dry-run reprocess of hands stored by an older parser.
+	OnReprocess func()
+	// OnCheckDatabase checks the database for damage and offers to repair it.
+	OnCheckDatabase func()
+	// OnImportArchives imports archived logs found in the log directories.
+	OnImportArchives func()
+	// AppSettings are the stored settings shown in the form; edits are passed
+	// to OnAppSettingsChange.
+	AppSettings         application.AppSettings
+	OnAppSettingsChange func(application.AppSettings)
 }
 
 func NewSettingsTab(cfg SettingsTabConfig) fyne.CanvasObject {
@@ -44,6 +67,12 @@ func NewSettingsTab(cfg SettingsTabConfig) fyne.CanvasObject {
 		onPathChange:    cfg.OnPathChange,
 		onMetricsChange: cfg.OnMetricsChange,
 		onReset:         cfg.OnReset,
+		onDiagnostics:   cfg.OnShowDiagnostics,
+		onReprocess:     cfg.OnReprocess,
+		onCheckDB:       cfg.OnCheckDatabase,
+		onImportArchive: cfg.OnImportArchives,
+		appSettings:     cfg.AppSettings,
+		onAppSettings:   cfg.OnAppSettingsChange,
 		metricState:     metricState,
 		metadata:        cfg.Metadata,
 		win:             cfg.Window,
@@ -85,7 +114,137 @@ func (st *SettingsTab) buildLogSourceSection() fyne.CanvasObject {
 	pathInfoLabel := widget.NewLabel(lang.X("settings.log_path_info", "The application monitors your VRChat log file in real-time.\nLog files are typically found at:\n\n  Linux (Steam Proton):\n  ~/.local/share/Steam/steamapps/compatdata/438100/pfx/\n  drive_c/users/steamuser/AppData/LocalLow/VRChat/VRChat/\n\n  Windows:\n  %APPDATA%\\..\\LocalLow\\VRChat\\VRChat\\\n\nStatistics are calculated for VR Poker world sessions only.\nHistorical logs (from before the app was started) are also analyzed."))
 	pathInfoLabel.Wrapping = fyne.TextWrapWord
 
-	return newSectionCard(container.NewVBox(pathLabel, pathRow, pathInfoLabel))
+	return newSectionCard(container.NewVBox(pathLabel, pathRow, pathInfoLabel, newSectionDivider(), st.buildWatcherBackend(), newSectionDivider(), st.buildExtraLogDirs(), newSectionDivider(), st.buildImportWorkers()))
+}
+
+// buildWatcherBackend selects how the live log is watched. Changes are saved
+// through onAppSettings and restart the watcher.
+func (st *SettingsTab) buildWatcherBackend() fyne.CanvasObject {
+	backends := []watcher.Backend{watcher.BackendHybrid, watcher.BackendFSNotify, watcher.BackendPolling}
+	labels := []string{
+		lang.X("settings.watcher.hybrid", "Automatic (file events with polling fallback)"),
+		lang.X("settings.watcher.fsnotify", "File events only"),
+		lang.X("settings.watcher.polling", "Polling only"),
+	}
+	sel := widget.NewSelect(labels, nil)
+	sel.SetSelected(labels[0])
+	for i, b := range backends {
+		if string(b) == st.appSettings.WatcherBackend {
+			sel.SetSelected(labels[i])
+		}
+	}
+	sel.OnChanged = func(s string) {
+		for i, l := range labels {
+			if l == s && string(backends[i]) != st.appSettings.WatcherBackend {
+				st.appSettings.WatcherBackend = string(backends[i])
+				if st.onAppSettings != nil {
+					st.onAppSettings(st.appSettings)
+				}
+			}
+		}
+	}
+	hint := widget.NewLabel(lang.X("settings.watcher.hint", "Use polling when new hands show up late or not at all, which can happen with network drives, Windows shares and some Proton setups. Polling checks the log more slowly while it is idle."))
+	hint.Wrapping = fyne.TextWrapWord
+
+	label := widget.NewLabel(lang.X("settings.watcher.label", "Log Watching:"))
+	return container.NewVBox(container.NewBorder(nil, nil, label, nil, sel), hint)
+}
+
+// importWorkerChoices are the worker counts offered; 0 is automatic.
+var importWorkerChoices = []int{0, 1, 2, 4, 8}
+
+// buildImportWorkers selects how many past logs are parsed at once. Changes
+// apply to the next import.
+func (st *SettingsTab) buildImportWorkers() fyne.CanvasObject {
+	labels := make([]string, len(importWorkerChoices))
+	for i, n := range importWorkerChoices {
+		if n == 0 {
+			labels[i] = lang.X("settings.import_workers.auto", "Automatic")
+		} else {
+			labels[i] = strconv.Itoa(n)
+		}
+	}
+	sel := widget.NewSelect(labels, nil)
+	sel.SetSelected(labels[0])
+	for i, n := range importWorkerChoices {
+		if n == st.appSettings.ImportWorkers {
+			sel.SetSelected(labels[i])
+		}
+	}
+	sel.OnChanged = func(s string) {
+		for i, l := range labels {
+			if l == s && importWorkerChoices[i] != st.appSettings.ImportWorkers {
+				st.appSettings.ImportWorkers = importWorkerChoices[i]
+				if st.onAppSettings != nil {
+					st.onAppSettings(st.appSettings)
+				}
+			}
+		}
+	}
+	hint := widget.NewLabel(lang.X("settings.import_workers.hint", "Past logs are parsed in parallel when they are imported. Fewer workers keep the PC responsive while VRChat is running; more workers finish large imports sooner. Takes effect on the next import."))
+	hint.Wrapping = fyne.TextWrapWord
+
+	label := widget.NewLabel(lang.X("settings.import_workers.label", "Import Workers:"))
+	return container.NewVBox(container.NewBorder(nil, nil, label, nil, sel), hint)
+}
+
+// buildExtraLogDirs lists the extra directories scanned for logs and log
+// archives. Every edit is saved through onAppSettings.
+func (st *SettingsTab) buildExtraLogDirs() fyne.CanvasObject {
+	label := widget.NewLabel(lang.X("settings.extra_dirs.label", "Extra Log Directories:"))
+	hint := widget.NewLabel(lang.X("settings.extra_dirs.hint", "These directories are scanned at startup for VRChat logs and for .gz, .zip and .tar.gz archives containing them. Each archived log is imported once. A directory can also be a Wine prefix (Bottles, Lutris), a Proton prefix or a Steam library; the VRChat logs inside it are found automatically. The newest log in every log directory is watched live, so several VRChat installs or a folder synced from another PC are tracked at once."))
+	hint.Wrapping = fyne.TextWrapWord
+
+	list := container.NewVBox()
+	save := func(dirs []string) {
+		st.appSettings.ExtraLogDirs = dirs
+		if st.onAppSettings != nil {
+			st.onAppSettings(st.appSettings)
+		}
+	}
+	var refresh func()
+	refresh = func() {
+		rows := make([]fyne.CanvasObject, 0, len(st.appSettings.ExtraLogDirs)+1)
+		if len(st.appSettings.ExtraLogDirs) == 0 {
+			rows = append(rows, newSubtleText(lang.X("settings.extra_dirs.none", "No extra directories.")))
+		}
+		for i, dir := range st.appSettings.ExtraLogDirs {
+			i := i
+			removeBtn := widget.NewButton(lang.X("settings.extra_dirs.remove", "Remove"), func() {
+				dirs := slices.Delete(slices.Clone(st.appSettings.ExtraLogDirs), i, i+1)
+				save(dirs)
+				refresh()
+			})
+			removeBtn.Importance = widget.LowImportance
+			pathLabel := widget.NewLabel(dir)
+			pathLabel.Wrapping = fyne.TextWrapBreak
+			rows = append(rows, container.NewBorder(nil, nil, nil, removeBtn, pathLabel))
+		}
+		list.Objects = rows
+		list.Refresh()
+	}
+	refresh()
+
+	addBtn := widget.NewButton(lang.X("settings.extra_dirs.add", "Add Directory..."), func() {
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err != nil || uri == nil {
+				return
+			}
+			dir := uri.Path()
+			if slices.Contains(st.appSettings.ExtraLogDirs, dir) {
+				return
+			}
+			save(append(slices.Clone(st.appSettings.ExtraLogDirs), dir))
+			refresh()
+		}, st.win)
+	})
+	importBtn := widget.NewButton(lang.X("settings.extra_dirs.import", "Import Archived Logs Now"), func() {
+		if st.onImportArchive != nil {
+			st.onImportArchive()
+		}
+	})
+
+	return container.NewVBox(label, hint, list, container.NewHBox(addBtn, importBtn))
 }
 
 func categoryLabel(category metricCategoryID) string {
@@ -179,7 +338,17 @@ func (st *SettingsTab) buildMetricsSection() fyne.CanvasObject {
 		item.Open = false
 	}
 
-	return newSectionCard(container.NewVBox(metricsHint, presetRow, groups))
+	tiltCheck := widget.NewCheck(lang.X("settings.metrics.tilt_warning", "Warn about tilt during a session"), func(on bool) {
+		st.appSettings.TiltWarning = on
+		if st.onAppSettings != nil {
+			st.onAppSettings(st.appSettings)
+		}
+	})
+	tiltCheck.SetChecked(st.appSettings.TiltWarning)
+	tiltHint := widget.NewLabel(lang.X("settings.metrics.tilt_warning_hint", "Shows a warning when your VPIP, PFR, 3Bet or aggression change significantly after a big loss or downswing in the current session."))
+	tiltHint.Wrapping = fyne.TextWrapWord
+
+	return newSectionCard(container.NewVBox(metricsHint, presetRow, groups, newSectionDivider(), tiltHint, tiltCheck))
 }
 
 func (st *SettingsTab) buildDataManagementSection() fyne.CanvasObject {
@@ -204,7 +373,47 @@ func (st *SettingsTab) buildDataManagementSection() fyne.CanvasObject {
 	})
 	resetBtn.Importance = widget.DangerImportance
 
-	return newSectionCard(container.NewVBox(dbPathHint, dbPathValue, resetBtn))
+	diagHint := widget.NewLabel(lang.X("settings.data.diagnostics_hint", "Inspect log lines the parser did not recognize and the anomaly rate of each log file."))
+	diagHint.Wrapping = fyne.TextWrapWord
+	diagBtn := widget.NewButton(lang.X("settings.data.diagnostics_button", "Parser Diagnostics..."), func() {
+		if st.onDiagnostics != nil {
+			st.onDiagnostics()
+		}
+	})
+
+	rawLogCheck := widget.NewCheck(lang.X("settings.data.keep_raw_logs", "Keep raw log lines for each hand"), func(on bool) {
+		st.appSettings.KeepRawLogs = on
+		if st.onAppSettings != nil {
+			st.onAppSettings(st.appSettings)
+		}
+	})
+	rawLogCheck.SetChecked(st.appSettings.KeepRawLogs)
+	rawLogHint := widget.NewLabel(lang.X("settings.data.keep_raw_logs_hint", "Stores a compressed copy of the log lines of each newly imported hand in the database, so hands can be inspected and reprocessed after VRChat deletes old logs."))
+	rawLogHint.Wrapping = fyne.TextWrapWord
+
+	reprocessHint := widget.NewLabel(lang.X("settings.data.reprocess_hint", "Re-parse hands stored by an older version of the parser from their original log files. A preview of the changes is shown before anything is written."))
+	reprocessHint.Wrapping = fyne.TextWrapWord
+	reprocessBtn := widget.NewButton(lang.X("settings.data.reprocess_button", "Reprocess Hands..."), func() {
+		if st.onReprocess != nil {
+			st.onReprocess()
+		}
+	})
+
+	checkDBHint := widget.NewLabel(lang.X("settings.data.check_db_hint", "Look for damage left by crashes or older versions, such as leftover rows of removed hands or import positions past the end of a log, and repair it."))
+	checkDBHint.Wrapping = fyne.TextWrapWord
+	checkDBBtn := widget.NewButton(lang.X("settings.data.check_db_button", "Check Database..."), func() {
+		if st.onCheckDB != nil {
+			st.onCheckDB()
+		}
+	})
+
+	return newSectionCard(container.NewVBox(
+		dbPathHint, dbPathValue, resetBtn,
+		newSectionDivider(), diagHint, diagBtn,
+		newSectionDivider(), rawLogHint, rawLogCheck,
+		newSectionDivider(), reprocessHint, reprocessBtn,
+		newSectionDivider(), checkDBHint, checkDBBtn,
+	))
 }
 
 func (st *SettingsTab) buildAboutSection() fyne.CanvasObject {
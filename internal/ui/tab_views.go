@@ -147,7 +147,10 @@ type overviewTabView struct {
 	visibility *MetricVisibilityState
 	filter     TabFilterState
 	lastStats  *stats.Stats
-	localSeat  int
+	// baseline is the all-hands stats the preset-filtered lastStats are
+	// compared with; nil when no preset is active.
+	baseline  *stats.Stats
+	localSeat int
 }
 
 func applyFilterLayout(root *fyne.Container, filter *TabFilterState, rebuild func(), buildContent func() fyne.CanvasObject) {
@@ -171,8 +174,9 @@ func newOverviewTabView(win fyne.Window, visibility *MetricVisibilityState) *ove
 	}
 }
 
-func (v *overviewTabView) Update(s *stats.Stats, localSeat int) {
+func (v *overviewTabView) Update(s, baseline *stats.Stats, localSeat int) {
 	v.lastStats = s
+	v.baseline = baseline
 	v.localSeat = localSeat
 	v.rebuild()
 }
@@ -186,7 +190,11 @@ func (v *overviewTabView) rebuild() {
 		return
 	}
 	applyFilterLayout(v.root, &v.filter, v.rebuild, func() fyne.CanvasObject {
-		return NewOverviewTab(s, v.visibility, v.win)
+		var compareTo *stats.Stats
+		if v.baseline != nil {
+			compareTo = v.baseline.ForTableSize(v.filter.TableSize)
+		}
+		return NewOverviewTab(s.ForTableSize(v.filter.TableSize), compareTo, v.filter.TableSize, v.visibility, v.win)
 	})
 }
 
@@ -221,7 +229,7 @@ func (v *positionStatsTabView) rebuild() {
 		return
 	}
 	applyFilterLayout(v.root, &v.filter, v.rebuild, func() fyne.CanvasObject {
-		return NewPositionStatsTab(s, v.visibility)
+		return NewPositionStatsTab(s.ForTableSize(v.filter.TableSize), v.visibility)
 	})
 }
 
@@ -258,7 +266,7 @@ func (v *handRangeTabView) rebuild() {
 		return
 	}
 	applyFilterLayout(v.root, &v.filter, v.rebuild, func() fyne.CanvasObject {
-		return NewHandRangeTab(s, v.win, v.state)
+		return NewHandRangeTab(s.ForTableSize(v.filter.TableSize), v.win, v.state)
 	})
 }
 
@@ -266,6 +274,8 @@ type handHistoryTabView struct {
 	tabRoot
 	state      *HandHistoryViewState
 	handFilter HandHistoryFilterState
+	// tags are the tags offered in the tag filter.
+	tags []persistence.TagCount
 
 	// Pagination state
 	page          int                       // 0-based current page
@@ -281,6 +291,9 @@ type handHistoryTabView struct {
 	// The UID is passed; the controller fetches the full hand and calls UpdateDetail.
 	onFetchHand func(uid string)
 
+	// onExport exports the hands matching the current filter.
+	onExport func()
+
 	// detailContent holds the right-side detail panel; kept as a typed ref so
 	// UpdateDetail can replace its content while reusing the same container.
 	detailContent  *fyne.Container
@@ -289,19 +302,21 @@ type handHistoryTabView struct {
 	suppressSelect bool
 }
 
-func newHandHistoryTabView(state *HandHistoryViewState, onLoadPage func(page int), onFetchHand func(uid string)) *handHistoryTabView {
+func newHandHistoryTabView(state *HandHistoryViewState, onLoadPage func(page int), onFetchHand func(uid string), onExport func()) *handHistoryTabView {
 	return &handHistoryTabView{
 		tabRoot:     newTabRoot(),
 		state:       state,
 		onLoadPage:  onLoadPage,
 		onFetchHand: onFetchHand,
+		onExport:    onExport,
 	}
 }
 
 // UpdatePage replaces the current page of summaries and total count, then rebuilds the view.
 // Must be called from the Fyne main thread.
-func (v *handHistoryTabView) UpdatePage(summaries []persistence.HandSummary, page, totalCount int) {
+func (v *handHistoryTabView) UpdatePage(summaries []persistence.HandSummary, tags []persistence.TagCount, page, totalCount int) {
 	v.summaries = summaries
+	v.tags = tags
 	v.page = page
 	v.totalCount = totalCount
 	v.filteredCount = len(summaries)
@@ -334,6 +349,7 @@ func (v *handHistoryTabView) rebuild() {
 
 	panel := buildHandHistoryFilterPanelSummary(
 		&v.handFilter,
+		v.tags,
 		v.page,
 		totalPages,
 		v.totalCount,
@@ -348,6 +364,7 @@ func (v *handHistoryTabView) rebuild() {
 				v.onLoadPage(newPage)
 			}
 		},
+		v.onExport,
 	)
 	var content fyne.CanvasObject
 	if len(v.summaries) == 0 {
@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2/lang"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/stats"
+)
+
+// tiltMetricLabel returns the display label of a metric compared by tilt
+// detection.
+func tiltMetricLabel(id stats.MetricID) string {
+	switch id {
+	case stats.MetricVPIP:
+		return "VPIP"
+	case stats.MetricPFR:
+		return "PFR"
+	case stats.MetricThreeBet:
+		return "3Bet"
+	case stats.MetricAF:
+		return "AF"
+	default:
+		return string(id)
+	}
+}
+
+// tiltChangeLines describes each comparison as "baseline -> after". With
+// onlySignificant, comparisons without significant drift are left out.
+func tiltChangeLines(cmp []stats.TiltComparison, onlySignificant bool) []string {
+	out := make([]string, 0, len(cmp))
+	for _, c := range cmp {
+		if onlySignificant && !c.Significant {
+			continue
+		}
+		format := "%.1f%%"
+		if c.After.Format == stats.MetricFormatRatio {
+			format = "%.2f"
+		}
+		out = append(out, lang.X("tilt.change_line", "{{.Metric}}: {{.Baseline}} → {{.After}} (z = {{.Z}})", map[string]any{
+			"Metric":   tiltMetricLabel(c.Metric),
+			"Baseline": fmt.Sprintf(format, c.Baseline.Rate),
+			"After":    fmt.Sprintf(format, c.After.Rate),
+			"Z":        fmt.Sprintf("%+.2f", c.Z),
+		}))
+	}
+	return out
+}
+
+// tiltInsight returns the Overview insight for play after big losses, or
+// false when no loss event was followed by significant drift.
+func tiltInsight(r *stats.TiltReport) (trendInsight, bool) {
+	if r == nil || len(r.Events) == 0 || !stats.Drifting(r.Comparisons) {
+		return trendInsight{}, false
+	}
+	evidence := []evidenceItem{{
+		Text: lang.X("insight.tilt.events", "{{.Events}} big losses or downswings, {{.Hands}} hands played right after them.",
+			map[string]any{"Events": len(r.Events), "Hands": r.AfterHands}),
+	}}
+	for i, line := range tiltChangeLines(r.Comparisons, false) {
+		evidence = append(evidence, evidenceItem{Text: line, IsGood: !r.Comparisons[i].Significant})
+	}
+	return trendInsight{
+		Priority:  "P0",
+		Severity:  insightSeverityLabel("P0"),
+		Title:     lang.X("insight.tilt.title", "Play changes after big losses"),
+		Text:      lang.X("insight.tilt.text", "Your tendencies shift significantly in the hands right after a big loss or downswing, which is a common sign of tilt."),
+		Evidence:  evidence,
+		LowSample: r.AfterHands < 3*stats.TiltWindowHands,
+	}, true
+}
@@ -0,0 +1,67 @@
+package watcher
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// fingerprintHeadSize is how many leading bytes of the log a fingerprint
+// keeps. VRChat logs start with a timestamped header, so a rewritten file
+// almost always differs within it.
+const fingerprintHeadSize = 1024
+
+// fileFingerprint identifies a log file between reads, so a watcher can tell
+// appended content from a truncated or replaced file.
+type fileFingerprint struct {
+	info    os.FileInfo
+	size    int64
+	modTime time.Time
+	head    []byte
+}
+
+func takeFingerprint(f *os.File, info os.FileInfo) (*fileFingerprint, error) {
+	head := make([]byte, min(info.Size(), fingerprintHeadSize))
+	n, err := f.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return &fileFingerprint{
+		info:    info,
+		size:    info.Size(),
+		modTime: info.ModTime(),
+		head:    head[:n],
+	}, nil
+}
+
+// unchanged reports whether info describes the same file with the same size
+// and modification time as fp, in which case there is nothing to read.
+func (fp *fileFingerprint) unchanged(info os.FileInfo) bool {
+	return fp != nil &&
+		os.SameFile(fp.info, info) &&
+		fp.size == info.Size() &&
+		fp.modTime.Equal(info.ModTime())
+}
+
+// rewritten returns why the content read up to offset, last seen as prev, is
+// no longer a prefix of the file fp describes, or "" when the file only grew.
+// prev is nil before the first read, when only truncation can be detected.
+func (fp *fileFingerprint) rewritten(prev *fileFingerprint, offset int64) string {
+	switch {
+	case fp.size < offset:
+		return "truncated"
+	case prev == nil:
+		return ""
+	case !os.SameFile(prev.info, fp.info):
+		return "replaced"
+	case fp.modTime.Before(prev.modTime):
+		return "modification time went backwards"
+	}
+	n := min(len(prev.head), len(fp.head))
+	if !bytes.Equal(prev.head[:n], fp.head[:n]) {
+		return "content changed"
+	}
+	return ""
+}
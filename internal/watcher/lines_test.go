@@ -0,0 +1,299 @@
+package watcher
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// simulatedWriter appends content to a log the way a game flushes it: in
+// fragments of random size that often end in the middle of a line.
+type simulatedWriter struct {
+	t    *testing.T
+	f    *os.File
+	rand *rand.Rand
+}
+
+func newSimulatedWriter(t *testing.T, path string, seed int64) *simulatedWriter {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatalf("open log: %v", err)
+	}
+	t.Cleanup(func() { _ = f.Close() })
+	return &simulatedWriter{t: t, f: f, rand: rand.New(rand.NewSource(seed))}
+}
+
+// write appends content in fragments of 1 to maxFragment bytes and calls
+// afterFragment after each one.
+func (w *simulatedWriter) write(content string, maxFragment int, afterFragment func()) {
+	for content != "" {
+		n := min(1+w.rand.Intn(maxFragment), len(content))
+		if _, err := w.f.WriteString(content[:n]); err != nil {
+			w.t.Errorf("write log: %v", err)
+			return
+		}
+		content = content[n:]
+		if afterFragment != nil {
+			afterFragment()
+		}
+	}
+}
+
+type delivery struct {
+	lines      []string
+	start, end int64
+}
+
+// checkDeliveries verifies that deliveries cover [0, end) without gaps and
+// returns all delivered lines.
+func checkDeliveries(t *testing.T, got []delivery, end int64) []string {
+	t.Helper()
+	var lines []string
+	pos := int64(0)
+	for i, d := range got {
+		if d.start != pos {
+			t.Fatalf("delivery %d starts at %d, want %d", i, d.start, pos)
+		}
+		pos = d.end
+		lines = append(lines, d.lines...)
+	}
+	if pos != end {
+		t.Fatalf("deliveries end at %d, want %d", pos, end)
+	}
+	return lines
+}
+
+func TestLogWatcherHoldsBackPartialLines(t *testing.T) {
+	t.Parallel()
+
+	logPath := filepath.Join(t.TempDir(), "output_log_2026-02-21_00-00-00.txt")
+	var got []delivery
+	lw, err := NewLogWatcher(logPath, WatcherConfig{
+		Backend: BackendPolling,
+		OnNewData: func(lines []string, start, end int64) {
+			for _, l := range lines {
+				if strings.ContainsAny(l, "\r\n") {
+					t.Errorf("line %q still has a line ending", l)
+				}
+			}
+			got = append(got, delivery{lines: lines, start: start, end: end})
+		},
+	})
+	if err != nil {
+		t.Fatalf("new watcher: %v", err)
+	}
+	defer lw.Stop()
+
+	content := "2026.02.21 00:00:01 Log - alpha\r\n\r\n2026.02.21 00:00:02 Log - beta\n2026.02.21 00:00:03 Log - gamma\n"
+	newSimulatedWriter(t, logPath, 1).write(content, 4, func() {
+		if _, err := lw.readNewContent(); err != nil {
+			t.Fatalf("read: %v", err)
+		}
+	})
+
+	lines := checkDeliveries(t, got, int64(len(content)))
+	want := []string{"2026.02.21 00:00:01 Log - alpha", "", "2026.02.21 00:00:02 Log - beta", "2026.02.21 00:00:03 Log - gamma"}
+	if !reflect.DeepEqual(lines, want) {
+		t.Fatalf("lines = %q, want %q", lines, want)
+	}
+}
+
+func TestLogWatcherDeliversLargeBurstsInChunks(t *testing.T) {
+	t.Parallel()
+
+	logPath := filepath.Join(t.TempDir(), "output_log_2026-02-21_00-00-00.txt")
+	var got []delivery
+	var lw *LogWatcher
+	lw, err := NewLogWatcher(logPath, WatcherConfig{
+		Backend:    BackendPolling,
+		ChunkLines: 3,
+		OnNewData: func(lines []string, start, end int64) {
+			// The next chunk is read only after this one was handled.
+			if lw.offset != start {
+				t.Errorf("offset = %d while delivering chunk at %d", lw.offset, start)
+			}
+			got = append(got, delivery{lines: lines, start: start, end: end})
+		},
+	})
+	if err != nil {
+		t.Fatalf("new watcher: %v", err)
+	}
+	defer lw.Stop()
+
+	var b strings.Builder
+	for i := range 10 {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	if err := os.WriteFile(logPath, []byte(b.String()), 0o600); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+	if _, err := lw.readNewContent(); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	checkDeliveries(t, got, int64(b.Len()))
+	var sizes []int
+	for _, d := range got {
+		sizes = append(sizes, len(d.lines))
+	}
+	if !reflect.DeepEqual(sizes, []int{3, 3, 3, 1}) {
+		t.Fatalf("chunk sizes = %v, want [3 3 3 1]", sizes)
+	}
+}
+
+func TestLogWatcherTruncatesOverlongLines(t *testing.T) {
+	t.Parallel()
+
+	logPath := filepath.Join(t.TempDir(), "output_log_2026-02-21_00-00-00.txt")
+	var got []delivery
+	lw, err := NewLogWatcher(logPath, WatcherConfig{
+		Backend:      BackendPolling,
+		MaxLineBytes: 16,
+		OnNewData: func(lines []string, start, end int64) {
+			got = append(got, delivery{lines: lines, start: start, end: end})
+		},
+	})
+	if err != nil {
+		t.Fatalf("new watcher: %v", err)
+	}
+	defer lw.Stop()
+
+	// Longer than the read buffer, which made bufio.Scanner fail with
+	// "token too long".
+	content := "before\n" + strings.Repeat("x", 3*readBufferSize) + "\nafter\n"
+	if err := os.WriteFile(logPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+	if _, err := lw.readNewContent(); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	lines := checkDeliveries(t, got, int64(len(content)))
+	want := []string{"before", strings.Repeat("x", 16), "after"}
+	if !reflect.DeepEqual(lines, want) {
+		t.Fatalf("lines = %q, want %q", lines, want)
+	}
+}
+
+func TestPollingLogWatcherFollowsSimulatedWriter(t *testing.T) {
+	t.Parallel()
+
+	logPath := filepath.Join(t.TempDir(), "output_log_2026-02-21_00-00-00.txt")
+	if err := os.WriteFile(logPath, nil, 0o600); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+
+	var b strings.Builder
+	var want []string
+	for i := range 500 {
+		line := fmt.Sprintf("2026.02.21 00:%02d:%02d Log - event %d", i/60, i%60, i)
+		want = append(want, line)
+		b.WriteString(line + "\n")
+	}
+	content := b.String()
+
+	var mu sync.Mutex
+	var got []delivery
+	done := make(chan struct{})
+	lw, err := NewLogWatcher(logPath, WatcherConfig{
+		Backend:         BackendPolling,
+		PollInterval:    time.Millisecond,
+		MaxPollInterval: 5 * time.Millisecond,
+		ChunkLines:      32,
+		OnNewData: func(lines []string, start, end int64) {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, delivery{lines: lines, start: start, end: end})
+			if end == int64(len(content)) {
+				close(done)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("new watcher: %v", err)
+	}
+	defer lw.Stop()
+	if err := lw.Start(); err != nil {
+		t.Fatalf("start watcher: %v", err)
+	}
+
+	newSimulatedWriter(t, logPath, 2).write(content, 200, func() {
+		time.Sleep(100 * time.Microsecond)
+	})
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for the written lines")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if lines := checkDeliveries(t, got, int64(len(content))); !reflect.DeepEqual(lines, want) {
+		t.Fatalf("delivered %d lines, want %d in order", len(lines), len(want))
+	}
+}
+
+func TestLogWatcherCallbackMaySetOffset(t *testing.T) {
+	t.Parallel()
+
+	logPath := filepath.Join(t.TempDir(), "output_log_2026-02-21_00-00-00.txt")
+	content := "line 0\nline 1\nline 2\nline 3\n"
+	if err := os.WriteFile(logPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+	var got []delivery
+	var lw *LogWatcher
+	lw, err := NewLogWatcher(logPath, WatcherConfig{
+		Backend:    BackendPolling,
+		ChunkLines: 1,
+		OnNewData: func(lines []string, start, end int64) {
+			got = append(got, delivery{lines: lines, start: start, end: end})
+			// The callback runs without the watcher's lock held, so it
+			// can move the offset: here past the next line.
+			if start == 0 {
+				lw.SetOffset(int64(len("line 0\nline 1\n")))
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("new watcher: %v", err)
+	}
+	defer lw.Stop()
+
+	read := func() {
+		t.Helper()
+		errc := make(chan error, 1)
+		go func() {
+			_, err := lw.readNewContent()
+			errc <- err
+		}()
+		select {
+		case err := <-errc:
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("read blocked on a callback that set the offset")
+		}
+	}
+	read()
+	// The offset set by the callback wins over the chunk it interrupted.
+	if len(got) != 1 || lw.offset != int64(len("line 0\nline 1\n")) {
+		t.Fatalf("deliveries = %+v, offset = %d", got, lw.offset)
+	}
+	read()
+	want := []delivery{
+		{lines: []string{"line 2"}, start: 14, end: 21},
+		{lines: []string{"line 3"}, start: 21, end: 28},
+	}
+	if !reflect.DeepEqual(got[1:], want) {
+		t.Fatalf("deliveries after the new offset = %+v, want %+v", got[1:], want)
+	}
+}
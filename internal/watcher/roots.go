@@ -0,0 +1,71 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// vrchatLogSubdir is where VRChat writes its logs inside a Windows user
+// profile, including the profiles of Wine and Proton prefixes.
+var vrchatLogSubdir = filepath.Join("AppData", "LocalLow", "VRChat", "VRChat")
+
+// expandLogRoot returns the log directories under a configured log root. A
+// root is a VRChat log directory itself (a portable install or a synced
+// folder), a Wine prefix (Bottles, Lutris), a Proton compatdata prefix, or a
+// Steam library whose prefixes are all searched.
+func expandLogRoot(root string) []string {
+	dirs := []string{root}
+	for _, pattern := range []string{
+		filepath.Join(root, "drive_c", "users", "*", vrchatLogSubdir),
+		filepath.Join(root, "pfx", "drive_c", "users", "*", vrchatLogSubdir),
+	} {
+		matches, _ := filepath.Glob(pattern)
+		dirs = append(dirs, matches...)
+	}
+	return append(dirs, compatdataLogDirs(root)...)
+}
+
+// compatdataLogDirs returns the VRChat log directories in the Proton prefixes
+// of a Steam library. Every prefix is searched, since VRChat added as a
+// non-Steam game runs in a prefix named after a generated app ID.
+func compatdataLogDirs(library string) []string {
+	matches, _ := filepath.Glob(filepath.Join(library, "steamapps", "compatdata", "*", "pfx", "drive_c", "users", "*", vrchatLogSubdir))
+	return matches
+}
+
+// steamLibraries returns the Steam installs under home and the library
+// folders listed in their libraryfolders.vdf. It may list a library twice
+// through symlinks.
+func steamLibraries(home string) []string {
+	installs := []string{
+		filepath.Join(home, ".local", "share", "Steam"),
+		filepath.Join(home, ".steam", "steam"),
+		// Flatpak Steam
+		filepath.Join(home, ".var", "app", "com.valvesoftware.Steam", "data", "Steam"),
+		// Snap Steam
+		filepath.Join(home, "snap", "steam", "common", ".local", "share", "Steam"),
+	}
+	libs := slices.Clone(installs)
+	for _, install := range installs {
+		data, err := os.ReadFile(filepath.Join(install, "steamapps", "libraryfolders.vdf"))
+		if err != nil {
+			continue
+		}
+		libs = append(libs, parseLibraryFolders(data)...)
+	}
+	return libs
+}
+
+var libraryPathRe = regexp.MustCompile(`"path"\s+"((?:[^"\\]|\\.)*)"`)
+
+// parseLibraryFolders returns the library paths of a libraryfolders.vdf file.
+func parseLibraryFolders(data []byte) []string {
+	var paths []string
+	for _, m := range libraryPathRe.FindAllSubmatch(data, -1) {
+		paths = append(paths, strings.ReplaceAll(string(m[1]), `\\`, `\`))
+	}
+	return paths
+}
@@ -0,0 +1,80 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestLogRootsDiscoverPrefixesAndSteamLibraries(t *testing.T) {
+	t.Parallel()
+
+	home := t.TempDir()
+	writeLog := func(dir, name string) string {
+		t.Helper()
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatalf("write log: %v", err)
+		}
+		return path
+	}
+	profile := func(prefix, user string) string {
+		return filepath.Join(prefix, "drive_c", "users", user, vrchatLogSubdir)
+	}
+
+	// A Steam install with VRChat in its own library and, as a non-Steam
+	// game, in a second library listed in libraryfolders.vdf.
+	steam := filepath.Join(home, ".local", "share", "Steam")
+	library := filepath.Join(home, "games", "SteamLibrary")
+	vdf := "\"libraryfolders\"\n{\n\t\"0\"\n\t{\n\t\t\"path\"\t\t\"" + steam + "\"\n\t}\n\t\"1\"\n\t{\n\t\t\"path\"\t\t\"" + library + "\"\n\t}\n}\n"
+	if err := os.MkdirAll(filepath.Join(steam, "steamapps"), 0o700); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(steam, "steamapps", "libraryfolders.vdf"), []byte(vdf), 0o600); err != nil {
+		t.Fatalf("write vdf: %v", err)
+	}
+	steamLog := writeLog(profile(filepath.Join(steam, "steamapps", "compatdata", "438100", "pfx"), "steamuser"), "output_log_2026-02-21_00-00-00.txt")
+	shortcutLog := writeLog(profile(filepath.Join(library, "steamapps", "compatdata", "3012345678", "pfx"), "steamuser"), "output_log_2026-02-21_01-00-00.txt")
+	// ~/.steam/steam usually links to the same install.
+	if err := os.MkdirAll(filepath.Join(home, ".steam"), 0o700); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.Symlink(steam, filepath.Join(home, ".steam", "steam")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	// Configured roots: a Bottles prefix and a synced log folder.
+	bottle := filepath.Join(home, "Bottles", "vrchat")
+	bottleLog := writeLog(profile(bottle, "alice"), "output_log_2026-02-21_02-00-00.txt")
+	synced := filepath.Join(home, "Sync", "pc2")
+	syncedLog := writeLog(synced, "output_log_2026-02-21_03-00-00.txt")
+
+	var dirs []string
+	for _, lib := range steamLibraries(home) {
+		dirs = append(dirs, compatdataLogDirs(lib)...)
+	}
+	for _, root := range []string{bottle, synced} {
+		dirs = append(dirs, expandLogRoot(root)...)
+	}
+	got := collectLogFilesIn(dirs)
+	slices.Sort(got)
+	want := []string{steamLog, shortcutLog, bottleLog, syncedLog}
+	slices.Sort(want)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("logs = %q, want %q", got, want)
+	}
+}
+
+func TestParseLibraryFoldersUnescapesWindowsPaths(t *testing.T) {
+	t.Parallel()
+
+	vdf := []byte("\"libraryfolders\"\n{\n\t\"0\"\n\t{\n\t\t\"path\"\t\t\"D:\\\\SteamLibrary\"\n\t\t\"label\"\t\t\"\"\n\t}\n}\n")
+	if got := parseLibraryFolders(vdf); !reflect.DeepEqual(got, []string{`D:\SteamLibrary`}) {
+		t.Fatalf("libraries = %q", got)
+	}
+}
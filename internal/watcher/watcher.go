@@ -2,7 +2,9 @@ package watcher
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -13,6 +15,41 @@ import (
 	"time"
 
 	"github.com/fsnotify/fsnotify"
+
+	"github.com/AkatukiSora/vrc-vrpoker-ststs/internal/logsource"
+)
+
+// Backend selects how a LogWatcher notices changes to the log directory.
+type Backend string
+
+const (
+	// BackendHybrid uses fsnotify events and polls at PollInterval as a
+	// fallback. When fsnotify cannot watch the directory it polls like
+	// BackendPolling.
+	BackendHybrid Backend = "hybrid"
+	// BackendFSNotify relies on fsnotify events only.
+	BackendFSNotify Backend = "fsnotify"
+	// BackendPolling only polls, backing off while the log is idle. It suits
+	// filesystems that do not deliver change events, such as network mounts,
+	// Windows shares and some Proton prefixes.
+	BackendPolling Backend = "polling"
+)
+
+// Default poll intervals. An adaptive poller starts at the minimum, doubles
+// the interval every idle poll up to the maximum and drops back to the
+// minimum as soon as the log changes.
+const (
+	DefaultPollInterval    = 500 * time.Millisecond
+	DefaultMaxPollInterval = 5 * time.Second
+)
+
+// Read limits. onNewData receives at most ChunkLines lines, or roughly
+// maxChunkBytes of text, per call; lines longer than MaxLineBytes are cut.
+const (
+	DefaultChunkLines   = 2000
+	DefaultMaxLineBytes = 1 << 20
+	maxChunkBytes       = 4 << 20
+	readBufferSize      = 64 << 10
 )
 
 // LogWatcher monitors VRChat log files for new content
@@ -25,6 +62,23 @@ type LogWatcher struct {
 	readMu   sync.Mutex
 	stopOnce sync.Once
 
+	backend         Backend
+	chunkLines      int
+	maxLineBytes    int
+	pollInterval    time.Duration
+	maxPollInterval time.Duration
+	// adaptive is set when the watcher polls without fsnotify events.
+	adaptive bool
+	// fingerprint identifies the file content read up to offset; nil until
+	// the first read. Guarded by mu.
+	fingerprint *fileFingerprint
+	// gen counts SetOffset calls, so a read in progress does not overwrite
+	// an offset set while its callback ran. Guarded by mu.
+	gen uint64
+	// knownLogs are the VRChat logs seen in the directory, so polling
+	// reports each new session log once. Only used by watchLoop.
+	knownLogs map[string]bool
+
 	cleanLogPath string
 	onNewData    func(lines []string, startOffset int64, endOffset int64)
 	onNewLogFile func(path string)
@@ -35,39 +89,97 @@ type WatcherConfig struct {
 	OnNewData    func(lines []string, startOffset int64, endOffset int64)
 	OnNewLogFile func(path string)
 	OnError      func(err error)
+
+	// Backend defaults to BackendHybrid. Unknown backends fall back to it.
+	Backend Backend
+	// PollInterval and MaxPollInterval default to DefaultPollInterval and
+	// DefaultMaxPollInterval.
+	PollInterval    time.Duration
+	MaxPollInterval time.Duration
+	// ChunkLines and MaxLineBytes default to DefaultChunkLines and
+	// DefaultMaxLineBytes.
+	ChunkLines   int
+	MaxLineBytes int
 }
 
 // NewLogWatcher creates a watcher for the given log file path
 func NewLogWatcher(logPath string, cfg WatcherConfig) (*LogWatcher, error) {
+	lw := &LogWatcher{
+		LogPath:         logPath,
+		done:            make(chan struct{}),
+		backend:         cfg.Backend,
+		chunkLines:      cfg.ChunkLines,
+		maxLineBytes:    cfg.MaxLineBytes,
+		pollInterval:    cfg.PollInterval,
+		maxPollInterval: cfg.MaxPollInterval,
+		cleanLogPath:    filepath.Clean(logPath),
+		onNewData:       cfg.OnNewData,
+		onNewLogFile:    cfg.OnNewLogFile,
+		onError:         cfg.OnError,
+	}
+	switch lw.backend {
+	case BackendHybrid, BackendFSNotify, BackendPolling:
+	case "":
+		lw.backend = BackendHybrid
+	default:
+		slog.Warn("unknown watcher backend, using hybrid", "backend", lw.backend)
+		lw.backend = BackendHybrid
+	}
+	if lw.chunkLines <= 0 {
+		lw.chunkLines = DefaultChunkLines
+	}
+	if lw.maxLineBytes <= 0 {
+		lw.maxLineBytes = DefaultMaxLineBytes
+	}
+	if lw.pollInterval <= 0 {
+		lw.pollInterval = DefaultPollInterval
+	}
+	if lw.maxPollInterval < lw.pollInterval {
+		lw.maxPollInterval = max(DefaultMaxPollInterval, lw.pollInterval)
+	}
+
+	if lw.backend == BackendPolling {
+		lw.adaptive = true
+		return lw, nil
+	}
 	w, err := fsnotify.NewWatcher()
 	if err != nil {
-		return nil, fmt.Errorf("create fsnotify watcher: %w", err)
-	}
-
-	return &LogWatcher{
-		LogPath:      logPath,
-		watcher:      w,
-		done:         make(chan struct{}),
-		cleanLogPath: filepath.Clean(logPath),
-		onNewData:    cfg.OnNewData,
-		onNewLogFile: cfg.OnNewLogFile,
-		onError:      cfg.OnError,
-	}, nil
+		if lw.backend == BackendFSNotify {
+			return nil, fmt.Errorf("create fsnotify watcher: %w", err)
+		}
+		slog.Warn("fsnotify unavailable, polling instead", "path", logPath, "error", err)
+		lw.adaptive = true
+		return lw, nil
+	}
+	lw.watcher = w
+	return lw, nil
 }
 
 // Start begins watching for file changes
 func (lw *LogWatcher) Start() error {
-	slog.Info("watcher starting", "path", lw.LogPath)
+	slog.Info("watcher starting", "path", lw.LogPath, "backend", lw.backend)
 	// Watch the directory (more reliable than watching file directly)
 	dir := filepath.Dir(lw.LogPath)
-	if err := lw.watcher.Add(dir); err != nil {
-		return fmt.Errorf("watch directory %s: %w", dir, err)
+	if lw.watcher != nil {
+		if err := lw.watcher.Add(dir); err != nil {
+			if lw.backend == BackendFSNotify {
+				return fmt.Errorf("watch directory %s: %w", dir, err)
+			}
+			slog.Warn("cannot watch log directory, polling instead", "dir", dir, "error", err)
+			_ = lw.watcher.Close()
+			lw.watcher = nil
+			lw.adaptive = true
+		}
+	}
+	if lw.backend != BackendFSNotify {
+		lw.knownLogs = make(map[string]bool)
+		lw.scanNewLogFiles()
 	}
 
 	// Read existing content first only when no explicit offset is set.
 	// This keeps caller-specified offsets (e.g. EOF after initial import).
 	if lw.offset == 0 {
-		if err := lw.readNewContent(); err != nil {
+		if _, err := lw.readNewContent(); err != nil {
 			_ = err // non-fatal
 		}
 	}
@@ -81,7 +193,9 @@ func (lw *LogWatcher) Stop() {
 	lw.stopOnce.Do(func() {
 		slog.Info("watcher stopped", "path", lw.LogPath)
 		close(lw.done)
-		_ = lw.watcher.Close()
+		if lw.watcher != nil {
+			_ = lw.watcher.Close()
+		}
 	})
 }
 
@@ -90,119 +204,299 @@ func (lw *LogWatcher) SetOffset(offset int64) {
 	lw.mu.Lock()
 	defer lw.mu.Unlock()
 	lw.offset = offset
+	lw.gen++
 }
 
 func (lw *LogWatcher) watchLoop() {
-	ticker := time.NewTicker(500 * time.Millisecond)
-	defer ticker.Stop()
+	// A nil channel never delivers, which disables the fsnotify cases when
+	// polling.
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	if lw.watcher != nil {
+		events, errs = lw.watcher.Events, lw.watcher.Errors
+	}
+	var poll <-chan time.Time
+	var timer *time.Timer
+	interval := lw.pollInterval
+	if lw.backend != BackendFSNotify {
+		timer = time.NewTimer(interval)
+		defer timer.Stop()
+		poll = timer.C
+	}
 
 	for {
 		select {
 		case <-lw.done:
 			return
-		case event, ok := <-lw.watcher.Events:
+		case event, ok := <-events:
 			if !ok {
 				return
 			}
 			if event.Has(fsnotify.Create) && isVRChatLogFile(event.Name) {
-				if filepath.Clean(event.Name) != lw.cleanLogPath && lw.onNewLogFile != nil {
-					lw.onNewLogFile(event.Name)
-				}
+				lw.reportNewLogFile(event.Name)
 			}
 			if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) {
 				if filepath.Clean(event.Name) == lw.cleanLogPath {
-					if err := lw.readNewContent(); err != nil && lw.onError != nil {
+					if _, err := lw.readNewContent(); err != nil && lw.onError != nil {
 						lw.onError(err)
 					}
 				}
 			}
-		case err, ok := <-lw.watcher.Errors:
+		case err, ok := <-errs:
 			if !ok {
 				return
 			}
 			if lw.onError != nil {
 				lw.onError(err)
 			}
-		case <-ticker.C:
-			// Periodic poll as fallback
-			if err := lw.readNewContent(); err != nil && lw.onError != nil {
+		case <-poll:
+			changed := lw.scanNewLogFiles()
+			read, err := lw.readNewContent()
+			if err != nil && lw.onError != nil {
 				lw.onError(err)
 			}
+			if lw.adaptive {
+				interval = nextPollInterval(interval, changed || read, lw.pollInterval, lw.maxPollInterval)
+			}
+			timer.Reset(interval)
+		}
+	}
+}
+
+// nextPollInterval returns the interval before the next poll: the minimum
+// after a change, otherwise twice the current interval up to the maximum.
+func nextPollInterval(cur time.Duration, changed bool, minInterval, maxInterval time.Duration) time.Duration {
+	if changed {
+		return minInterval
+	}
+	return min(cur*2, maxInterval)
+}
+
+// scanNewLogFiles reports VRChat logs that appeared in the log directory
+// since the last scan, for backends that cannot rely on fsnotify create
+// events. It reports whether a new log was found.
+func (lw *LogWatcher) scanNewLogFiles() bool {
+	entries, err := os.ReadDir(filepath.Dir(lw.cleanLogPath))
+	if err != nil {
+		return false
+	}
+	// The first scan only records the logs that already exist.
+	initial := len(lw.knownLogs) == 0
+	found := false
+	for _, e := range entries {
+		if e.IsDir() || !isVRChatLogFile(e.Name()) {
+			continue
+		}
+		path := filepath.Join(filepath.Dir(lw.cleanLogPath), e.Name())
+		if initial {
+			lw.knownLogs[path] = true
+			continue
+		}
+		if lw.reportNewLogFile(path) {
+			found = true
 		}
 	}
+	lw.knownLogs[lw.cleanLogPath] = true
+	return found
 }
 
-func (lw *LogWatcher) readNewContent() error {
+// reportNewLogFile calls onNewLogFile for a log other than the watched one
+// that was not reported before. It reports whether the callback ran.
+func (lw *LogWatcher) reportNewLogFile(path string) bool {
+	path = filepath.Clean(path)
+	if path == lw.cleanLogPath {
+		return false
+	}
+	if lw.knownLogs != nil {
+		if lw.knownLogs[path] {
+			return false
+		}
+		lw.knownLogs[path] = true
+	}
+	if lw.onNewLogFile != nil {
+		lw.onNewLogFile(path)
+	}
+	return true
+}
+
+// readNewContent reads the lines appended since the last read. It reports
+// whether the log changed, including being truncated or replaced.
+func (lw *LogWatcher) readNewContent() (bool, error) {
 	lw.readMu.Lock()
 	defer lw.readMu.Unlock()
 
 	f, err := os.Open(lw.LogPath)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer f.Close()
 
 	// Check file size
 	info, err := f.Stat()
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	lw.mu.Lock()
-	defer lw.mu.Unlock()
-	if info.Size() < lw.offset {
-		lw.offset = 0
+	prev := lw.fingerprint
+	if prev.unchanged(info) {
+		lw.mu.Unlock()
+		return false, nil // No new content
 	}
-	if info.Size() <= lw.offset {
-		return nil // No new content
+	fp, err := takeFingerprint(f, info)
+	if err != nil {
+		lw.mu.Unlock()
+		return false, err
 	}
-	startOffset := lw.offset
-
-	if _, err := f.Seek(startOffset, 0 /* io.SeekStart */); err != nil {
-		return err
+	reset := false
+	if reason := fp.rewritten(prev, lw.offset); reason != "" {
+		slog.Info("log file rewritten, reading from the start", "path", lw.LogPath, "reason", reason, "offset", lw.offset)
+		lw.offset = 0
+		reset = true
 	}
+	startOffset, gen := lw.offset, lw.gen
+	lw.mu.Unlock()
 
-	endOffset := info.Size()
-	lw.offset = endOffset
-
-	// Stream lines without loading the entire new content into memory at once.
-	lines := make([]string, 0, 512)
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+	endOffset, err := lw.deliverLines(f, startOffset, info.Size(), gen)
+	changed := reset || endOffset > startOffset
+	if err != nil {
+		return changed, err
 	}
-	if err := scanner.Err(); err != nil {
-		return err
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	if lw.gen == gen {
+		lw.fingerprint = fp
 	}
+	return changed, nil
+}
 
-	if len(lines) > 0 && lw.onNewData != nil {
-		slog.Debug("new data detected", "path", lw.LogPath, "lines", len(lines))
-		lw.onNewData(lines, startOffset, endOffset)
+// advance moves the offset to end unless SetOffset was called since the read
+// of generation gen started. It reports whether the offset moved.
+func (lw *LogWatcher) advance(gen uint64, end int64) bool {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	if lw.gen != gen {
+		return false
 	}
+	lw.offset = end
+	return true
+}
 
-	return nil
+// deliverLines passes the complete lines between start and size to onNewData
+// in chunks and advances the offset past each delivered chunk. It returns the
+// offset reached. The callback runs synchronously, so a slow importer holds
+// back further reads instead of letting them pile up in memory, but without
+// mu held, so it may call SetOffset or Stop. A trailing line without a
+// newline is left for a later read, when its writer has finished it.
+func (lw *LogWatcher) deliverLines(f *os.File, start, size int64, gen uint64) (int64, error) {
+	if size <= start {
+		return start, nil
+	}
+	r := bufio.NewReaderSize(io.NewSectionReader(f, start, size-start), readBufferSize)
+	chunkStart, pos := start, start
+	var lines []string
+	chunkBytes := 0
+	// flush reports false when the offset was set elsewhere meanwhile and
+	// the rest of this read is stale.
+	flush := func() bool {
+		if len(lines) > 0 && lw.onNewData != nil {
+			slog.Debug("new data detected", "path", lw.LogPath, "lines", len(lines))
+			lw.onNewData(lines, chunkStart, pos)
+		}
+		lines, chunkBytes = nil, 0
+		if !lw.advance(gen, pos) {
+			return false
+		}
+		chunkStart = pos
+		return true
+	}
+	for {
+		line, n, truncated, err := logsource.ReadLine(r, lw.maxLineBytes)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			flush()
+			return chunkStart, err
+		}
+		if truncated {
+			slog.Warn("log line too long, truncated", "path", lw.LogPath, "offset", pos, "bytes", n, "kept", len(line))
+		}
+		pos += n
+		lines = append(lines, line)
+		chunkBytes += len(line)
+		// A truncated line ends its chunk, so the chunk's end offset
+		// accounts for the dropped bytes.
+		if truncated || len(lines) >= lw.chunkLines || chunkBytes >= maxChunkBytes {
+			if !flush() {
+				return chunkStart, nil
+			}
+			select {
+			case <-lw.done:
+				return chunkStart, nil
+			default:
+			}
+		}
+	}
+	flush()
+	return chunkStart, nil
 }
 
 // collectLogFiles builds the list of all VRChat log files found in known
-// platform-specific directories. It does not sort the results.
-func collectLogFiles() []string {
+// platform-specific directories and under the log roots in extraDirs.
+// Archives (.gz, .zip, .tar.gz) found in those directories are included as
+// archive paths; callers expand them with logsource.ListMembers. It does not
+// sort the results.
+func collectLogFiles(extraDirs []string) []string {
 	dirs := logDirectories()
+	for _, root := range extraDirs {
+		if root == "" {
+			continue
+		}
+		dirs = append(dirs, expandLogRoot(filepath.Clean(expandHome(root)))...)
+	}
+	return collectLogFilesIn(dirs)
+}
 
+func collectLogFilesIn(dirs []string) []string {
+	seen := make(map[string]bool, len(dirs))
 	var files []string
 	for _, dir := range dirs {
-		expanded := expandHome(dir)
-		matches, err := filepath.Glob(filepath.Join(expanded, "output_log_*.txt"))
+		expanded := filepath.Clean(expandHome(dir))
+		// Steam installs are often reachable through symlinks such as
+		// ~/.steam/steam, so directories are told apart by their real path.
+		key := expanded
+		if real, err := filepath.EvalSymlinks(expanded); err == nil {
+			key = real
+		}
+		if dir == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		entries, err := os.ReadDir(expanded)
 		if err != nil {
 			continue
 		}
-		files = append(files, matches...)
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			if isVRChatLogFile(e.Name()) || logsource.IsArchive(e.Name()) {
+				files = append(files, filepath.Join(expanded, e.Name()))
+			}
+		}
 	}
 	return files
 }
 
 // DetectLatestLogFile finds the most recent VRChat log file
 func DetectLatestLogFile() (string, error) {
-	candidates := collectLogFiles()
+	var candidates []string
+	for _, p := range collectLogFiles(nil) {
+		if isVRChatLogFile(p) {
+			candidates = append(candidates, p)
+		}
+	}
 
 	if len(candidates) == 0 {
 		return "", fmt.Errorf("no VRChat log files found in known locations")
@@ -212,9 +506,10 @@ func DetectLatestLogFile() (string, error) {
 	return candidates[0], nil
 }
 
-// DetectAllLogFiles finds all VRChat log files sorted newest first
-func DetectAllLogFiles() ([]string, error) {
-	candidates := collectLogFiles()
+// DetectAllLogFiles finds all VRChat log files and log archives in the known
+// locations and extraDirs, sorted newest first
+func DetectAllLogFiles(extraDirs []string) ([]string, error) {
+	candidates := collectLogFiles(extraDirs)
 
 	if len(candidates) == 0 {
 		return nil, fmt.Errorf("no VRChat log files found in known locations")
@@ -252,12 +547,13 @@ func logDirectories() []string {
 			filepath.Join(os.Getenv("USERPROFILE"), "AppData", "LocalLow", "VRChat", "VRChat"),
 		}
 	case "linux":
-		return []string{
-			// Steam Proton (most common for Linux VRChat)
-			filepath.Join(home, ".local", "share", "Steam", "steamapps", "compatdata", "438100", "pfx", "drive_c", "users", "steamuser", "AppData", "LocalLow", "VRChat", "VRChat"),
-			// Flatpak Steam
-			filepath.Join(home, ".var", "app", "com.valvesoftware.Steam", "data", "Steam", "steamapps", "compatdata", "438100", "pfx", "drive_c", "users", "steamuser", "AppData", "LocalLow", "VRChat", "VRChat"),
+		// Steam Proton (most common for Linux VRChat), in every library of
+		// every Steam install.
+		var dirs []string
+		for _, lib := range steamLibraries(home) {
+			dirs = append(dirs, compatdataLogDirs(lib)...)
 		}
+		return dirs
 	case "darwin":
 		return []string{
 			filepath.Join(home, "Library", "Application Support", "com.vrchat.VRChat"),
@@ -3,6 +3,7 @@ package watcher
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -100,3 +101,193 @@ func TestLogWatcherIgnoresNonVRChatCreatedFiles(t *testing.T) {
 	case <-time.After(500 * time.Millisecond):
 	}
 }
+
+func TestPollingLogWatcherReadsAppendsAndNewSessionLogs(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "output_log_2026-02-21_00-00-00.txt")
+	if err := os.WriteFile(logPath, []byte("first\n"), 0o600); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+
+	linesCh := make(chan []string, 4)
+	newLogCh := make(chan string, 4)
+	lw, err := NewLogWatcher(logPath, WatcherConfig{
+		Backend:         BackendPolling,
+		PollInterval:    10 * time.Millisecond,
+		MaxPollInterval: 40 * time.Millisecond,
+		OnNewData: func(lines []string, _, _ int64) {
+			linesCh <- lines
+		},
+		OnNewLogFile: func(path string) {
+			newLogCh <- path
+		},
+	})
+	if err != nil {
+		t.Fatalf("new watcher: %v", err)
+	}
+	defer lw.Stop()
+	if lw.watcher != nil {
+		t.Fatalf("polling watcher created an fsnotify watcher")
+	}
+	lw.SetOffset(int64(len("first\n")))
+	if err := lw.Start(); err != nil {
+		t.Fatalf("start watcher: %v", err)
+	}
+
+	// Let the poller back off before the log changes.
+	time.Sleep(100 * time.Millisecond)
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatalf("open log: %v", err)
+	}
+	if _, err := f.WriteString("second\n"); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	_ = f.Close()
+
+	select {
+	case got := <-linesCh:
+		if !reflect.DeepEqual(got, []string{"second"}) {
+			t.Fatalf("lines = %q, want [second]", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for appended lines")
+	}
+
+	newLogPath := filepath.Join(dir, "output_log_2026-02-21_00-10-00.txt")
+	if err := os.WriteFile(newLogPath, []byte("new session"), 0o600); err != nil {
+		t.Fatalf("write new log: %v", err)
+	}
+	select {
+	case got := <-newLogCh:
+		if got != newLogPath {
+			t.Fatalf("detected path = %q, want %q", got, newLogPath)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for new log file detection")
+	}
+	select {
+	case got := <-newLogCh:
+		t.Fatalf("new log reported twice: %q", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestLogWatcherRereadsTruncatedAndReplacedLogs(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "output_log_2026-02-21_00-00-00.txt")
+	type read struct {
+		lines []string
+		start int64
+	}
+	var reads []read
+	lw, err := NewLogWatcher(logPath, WatcherConfig{
+		Backend: BackendPolling,
+		OnNewData: func(lines []string, start, _ int64) {
+			reads = append(reads, read{lines: lines, start: start})
+		},
+	})
+	if err != nil {
+		t.Fatalf("new watcher: %v", err)
+	}
+	defer lw.Stop()
+
+	step := func(name string, want read) {
+		t.Helper()
+		reads = nil
+		if _, err := lw.readNewContent(); err != nil {
+			t.Fatalf("%s: read: %v", name, err)
+		}
+		if len(reads) != 1 || !reflect.DeepEqual(reads[0], want) {
+			t.Fatalf("%s: reads = %+v, want %+v", name, reads, want)
+		}
+	}
+
+	if err := os.WriteFile(logPath, []byte("a1\na2\n"), 0o600); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+	step("initial", read{lines: []string{"a1", "a2"}, start: 0})
+
+	// Truncated below the offset.
+	if err := os.WriteFile(logPath, []byte("b\n"), 0o600); err != nil {
+		t.Fatalf("truncate log: %v", err)
+	}
+	step("truncated", read{lines: []string{"b"}, start: 0})
+
+	// Replaced by a different, longer file: the size alone looks like an append.
+	replacement := filepath.Join(dir, "replacement.tmp")
+	if err := os.WriteFile(replacement, []byte("c1\nc2\nc3\n"), 0o600); err != nil {
+		t.Fatalf("write replacement: %v", err)
+	}
+	if err := os.Rename(replacement, logPath); err != nil {
+		t.Fatalf("replace log: %v", err)
+	}
+	step("replaced", read{lines: []string{"c1", "c2", "c3"}, start: 0})
+
+	// Rewritten in place with a different head.
+	if err := os.WriteFile(logPath, []byte("d1\nd2\nd3\nd4\n"), 0o600); err != nil {
+		t.Fatalf("rewrite log: %v", err)
+	}
+	step("rewritten", read{lines: []string{"d1", "d2", "d3", "d4"}, start: 0})
+
+	// A plain append continues from the offset.
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatalf("open log: %v", err)
+	}
+	if _, err := f.WriteString("d5\n"); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	_ = f.Close()
+	step("appended", read{lines: []string{"d5"}, start: int64(len("d1\nd2\nd3\nd4\n"))})
+}
+
+func TestNextPollIntervalBacksOffWhileIdle(t *testing.T) {
+	t.Parallel()
+
+	minInterval, maxInterval := 100*time.Millisecond, 350*time.Millisecond
+	cur := minInterval
+	var got []time.Duration
+	for _, changed := range []bool{false, false, false, true, false} {
+		cur = nextPollInterval(cur, changed, minInterval, maxInterval)
+		got = append(got, cur)
+	}
+	want := []time.Duration{200 * time.Millisecond, maxInterval, maxInterval, minInterval, 200 * time.Millisecond}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("intervals = %v, want %v", got, want)
+	}
+}
+
+func TestCollectLogFilesIncludesArchivesAndSkipsDuplicateDirs(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, name := range []string{
+		"output_log_2026-02-21_00-00-00.txt",
+		"old-logs.zip",
+		"output_log_2026-01-01_00-00-00.txt.gz",
+		"old-logs.tar.gz",
+		"notes.txt",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "nested.zip"), 0o700); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	got := collectLogFilesIn([]string{dir, dir + string(filepath.Separator), "", filepath.Join(dir, "missing")})
+	if len(got) != 4 {
+		t.Fatalf("collected %d files, want 4: %v", len(got), got)
+	}
+	for _, p := range got {
+		if filepath.Base(p) == "notes.txt" || filepath.Base(p) == "nested.zip" {
+			t.Fatalf("unexpected file collected: %s", p)
+		}
+	}
+}
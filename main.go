@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -22,6 +24,8 @@ var (
 
 func main() {
 	debugFlag := flag.Bool("debug", false, "Enable debug logging")
+	checkDBFlag := flag.Bool("check-db", false, "Check the database for damage, print the problems found and exit")
+	repairDBFlag := flag.Bool("repair-db", false, "Repair the problems -check-db finds and exit")
 	flag.Parse()
 
 	debug := *debugFlag || os.Getenv("VRC_VRPOKER_DEBUG") == "1"
@@ -37,6 +41,10 @@ func main() {
 	dbPath := resolveDBPath()
 	slog.Info("database", "path", dbPath)
 
+	if *checkDBFlag || *repairDBFlag {
+		os.Exit(checkDatabase(dbPath, *repairDBFlag))
+	}
+
 	repo, err := persistence.NewSQLiteRepository(dbPath)
 	if err != nil {
 		slog.Warn("sqlite init failed, falling back to memory", "error", err)
@@ -57,6 +65,55 @@ func main() {
 	ui.Run(application.NewService(persistence.NewMemoryRepository(), watcher.DetectAllLogFiles), meta, "")
 }
 
+// checkDatabase checks the database at dbPath, repairing it when repair is
+// set, and prints the problems to stdout. It returns the exit code: 0 when no
+// problem is left, 1 when some are and 2 when the check failed.
+func checkDatabase(dbPath string, repair bool) int {
+	// A check must not change the database, and neither may create one
+	// where there was none.
+	var repo *persistence.SQLiteRepository
+	var err error
+	if repair {
+		if _, err = os.Stat(dbPath); err == nil {
+			repo, err = persistence.NewSQLiteRepository(dbPath)
+		}
+	} else {
+		repo, err = persistence.OpenSQLiteRepositoryReadOnly(dbPath)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "open database: %v\n", err)
+		return 2
+	}
+	svc := application.NewService(repo, watcher.DetectAllLogFiles)
+	defer svc.Close()
+
+	ctx := context.Background()
+	var remaining []persistence.IntegrityIssue
+	if repair {
+		res, err := svc.RepairDatabase(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "repair database: %v\n", err)
+			return 2
+		}
+		fmt.Println("repaired:")
+		_ = application.WriteIntegrityIssues(os.Stdout, res.Repaired)
+		fmt.Println("remaining:")
+		remaining = res.Remaining
+	} else {
+		check, err := svc.CheckDatabase(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "check database: %v\n", err)
+			return 2
+		}
+		remaining = check.Issues
+	}
+	_ = application.WriteIntegrityIssues(os.Stdout, remaining)
+	if len(remaining) > 0 {
+		return 1
+	}
+	return 0
+}
+
 // resolveDBPath returns the OS-appropriate path for the SQLite database:
 //
 //	Linux:   $XDG_DATA_HOME/vrc-vrpoker-ststs/vrpoker-stats.db